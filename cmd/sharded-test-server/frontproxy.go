@@ -73,6 +73,13 @@ func startFrontProxy(
 	}
 
 	mappings := []mappingEntry{
+		{
+			Path:            "/services/syncer-tunnels/",
+			Backend:         "https://localhost:6444",
+			BackendServerCA: ".kcp/serving-ca.crt",
+			ProxyClientCert: ".kcp-front-proxy/requestheader.crt",
+			ProxyClientKey:  ".kcp-front-proxy/requestheader.key",
+		},
 		{
 			Path: "/services/",
 			// TODO: support multiple virtual workspace backend servers
@@ -18,23 +18,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/spf13/cobra"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/component-base/version"
 	"k8s.io/klog/v2"
 
 	synceroptions "github.com/kcp-dev/kcp/cmd/syncer/options"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/syncer"
 )
 
-const numThreads = 2
-
 func NewSyncerCommand() *cobra.Command {
 	options := synceroptions.NewOptions()
 	syncerCommand := &cobra.Command{
@@ -56,6 +61,9 @@ func NewSyncerCommand() *cobra.Command {
 			if err := Run(options, ctx); err != nil {
 				return err
 			}
+			if options.DryRun {
+				return nil
+			}
 
 			<-ctx.Done()
 
@@ -75,7 +83,9 @@ func NewSyncerCommand() *cobra.Command {
 }
 
 func Run(options *synceroptions.Options, ctx context.Context) error {
-	klog.Infof("Syncing the following resource types: %s", options.SyncedResourceTypes)
+	if !options.DryRun {
+		klog.Infof("Syncing the following resource types: %s", options.SyncedResourceTypes)
+	}
 
 	kcpConfigOverrides := &clientcmd.ConfigOverrides{
 		CurrentContext: options.FromContext,
@@ -102,17 +112,43 @@ func Run(options *synceroptions.Options, ctx context.Context) error {
 	downstreamConfig.QPS = options.QPS
 	downstreamConfig.Burst = options.Burst
 
+	statusAggregation := map[schema.GroupResource]workloadv1alpha1.StatusAggregationStrategy{}
+	for resource, strategy := range options.StatusAggregation {
+		statusAggregation[schema.ParseGroupResource(resource)] = workloadv1alpha1.StatusAggregationStrategy(strategy)
+	}
+	statusTransformers := make([]schema.GroupResource, 0, len(options.StatusTransformers))
+	for _, resource := range options.StatusTransformers {
+		statusTransformers = append(statusTransformers, schema.ParseGroupResource(resource))
+	}
+
+	syncerConfig := &syncer.SyncerConfig{
+		UpstreamConfig:      upstreamConfig,
+		DownstreamConfig:    downstreamConfig,
+		ResourcesToSync:     sets.NewString(options.SyncedResourceTypes...),
+		SyncTargetWorkspace: logicalcluster.New(options.FromClusterName),
+		SyncTargetName:      options.SyncTargetName,
+		SyncTargetUID:       options.SyncTargetUID,
+		ResourcesToUpsync:   sets.NewString(options.UpsyncedResourceTypes...),
+
+		StatusAggregation:      statusAggregation,
+		StatusTransformers:     statusTransformers,
+		CapacityReportInterval: options.CapacityReportInterval,
+		WorkspaceConcurrency:   options.WorkspaceConcurrency,
+		LeaderElection:         &options.LeaderElection,
+	}
+
+	if options.DryRun {
+		return syncer.DiffSyncer(ctx, syncerConfig, os.Stdout)
+	}
+
+	if options.MetricsBindAddress != "" {
+		go serveMetrics(ctx, options.MetricsBindAddress)
+	}
+
 	if err := syncer.StartSyncer(
 		ctx,
-		&syncer.SyncerConfig{
-			UpstreamConfig:      upstreamConfig,
-			DownstreamConfig:    downstreamConfig,
-			ResourcesToSync:     sets.NewString(options.SyncedResourceTypes...),
-			SyncTargetWorkspace: logicalcluster.New(options.FromClusterName),
-			SyncTargetName:      options.SyncTargetName,
-			SyncTargetUID:       options.SyncTargetUID,
-		},
-		numThreads,
+		syncerConfig,
+		options.Workers,
 		options.APIImportPollInterval,
 	); err != nil {
 		return err
@@ -120,3 +156,23 @@ func Run(options *synceroptions.Options, ctx context.Context) error {
 
 	return nil
 }
+
+// serveMetrics serves the metrics of the syncer on the given address until the context is done.
+func serveMetrics(ctx context.Context, address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", legacyregistry.Handler())
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	klog.Infof("Serving metrics on %s", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.Errorf("Failed to serve metrics on %s: %v", address, err)
+	}
+}
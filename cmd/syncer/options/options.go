@@ -24,27 +24,44 @@ import (
 
 	"github.com/spf13/pflag"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/component-base/config"
+	componentbaseconfigoptions "k8s.io/component-base/config/options"
 	"k8s.io/component-base/logs"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+	"github.com/kcp-dev/kcp/pkg/syncer/status/transformers"
 )
 
 type Options struct {
-	QPS                 float32
-	Burst               int
-	FromKubeconfig      string
-	FromContext         string
-	FromClusterName     string
-	ToKubeconfig        string
-	ToContext           string
-	SyncTargetName      string
-	SyncTargetUID       string
-	Logs                *logs.Options
-	SyncedResourceTypes []string
-
-	APIImportPollInterval time.Duration
+	QPS                   float32
+	Burst                 int
+	FromKubeconfig        string
+	FromContext           string
+	FromClusterName       string
+	ToKubeconfig          string
+	ToContext             string
+	SyncTargetName        string
+	SyncTargetUID         string
+	Logs                  *logs.Options
+	SyncedResourceTypes   []string
+	UpsyncedResourceTypes []string
+	DryRun                bool
+	StatusAggregation     map[string]string
+	StatusTransformers    []string
+
+	APIImportPollInterval  time.Duration
+	CapacityReportInterval time.Duration
+
+	Workers              int
+	WorkspaceConcurrency int
+	MetricsBindAddress   string
+
+	LeaderElection config.LeaderElectionConfiguration
 }
 
 func NewOptions() *Options {
@@ -53,11 +70,23 @@ func NewOptions() *Options {
 	logs.Config.Verbosity = config.VerbosityLevel(2)
 
 	return &Options{
-		QPS:                   30,
-		Burst:                 20,
-		SyncedResourceTypes:   []string{},
-		Logs:                  logs,
-		APIImportPollInterval: 1 * time.Minute,
+		QPS:                    30,
+		Burst:                  20,
+		SyncedResourceTypes:    []string{},
+		UpsyncedResourceTypes:  []string{},
+		StatusAggregation:      map[string]string{},
+		StatusTransformers:     []string{},
+		Logs:                   logs,
+		APIImportPollInterval:  1 * time.Minute,
+		CapacityReportInterval: 1 * time.Minute,
+		Workers:                2,
+		LeaderElection: config.LeaderElectionConfiguration{
+			LeaderElect:   false,
+			LeaseDuration: metav1.Duration{Duration: 15 * time.Second},
+			RenewDeadline: metav1.Duration{Duration: 10 * time.Second},
+			RetryPeriod:   metav1.Duration{Duration: 2 * time.Second},
+			ResourceLock:  resourcelock.LeasesResourceLock,
+		},
 	}
 }
 
@@ -73,11 +102,21 @@ func (options *Options) AddFlags(fs *pflag.FlagSet) {
 		fmt.Sprintf("ID of the -to cluster. Resources with this ID set in the '%s' label will be synced.", workloadv1alpha1.ClusterResourceStateLabelPrefix+"<ClusterID>"))
 	fs.StringVar(&options.SyncTargetUID, "sync-target-uid", options.SyncTargetUID, "The UID from the SyncTarget resource in KCP.")
 	fs.StringArrayVarP(&options.SyncedResourceTypes, "resources", "r", options.SyncedResourceTypes, "Resources to be synchronized in kcp.")
+	fs.StringArrayVar(&options.UpsyncedResourceTypes, "upsync-resources", options.UpsyncedResourceTypes, "Namespaced resources created in the -to cluster, e.g. by controllers, to be mirrored read-only to kcp. They must be synchronized resources too.")
+	fs.StringToStringVar(&options.StatusAggregation, "status-aggregation", options.StatusAggregation, "Strategy to aggregate the statuses of resources synced to multiple sync targets per resource, e.g. deployments.apps=Primary. One of Aggregate (default), Primary and None. The workload.kcp.dev/status-aggregation annotation of a resource takes precedence.")
+	fs.StringSliceVar(&options.StatusTransformers, "status-transformers", options.StatusTransformers,
+		fmt.Sprintf("Resources whose downstream status is transformed before it is synced to kcp, e.g. to remove cluster-internal addresses. The status is synced as is by default. Possible values: %s.", strings.Join(transformers.Resources(), ", ")))
+	fs.BoolVar(&options.DryRun, "dry-run", options.DryRun, "Print the difference between the objects the syncer would apply and the objects in the -to cluster, and exit without writing anything.")
 	fs.DurationVar(&options.APIImportPollInterval, "api-import-poll-interval", options.APIImportPollInterval, "Polling interval for API import.")
+	fs.DurationVar(&options.CapacityReportInterval, "capacity-report-interval", options.CapacityReportInterval, "Minimal interval between two updates of the capacity of the -to cluster in the SyncTarget status. Set to 0 to disable capacity reporting.")
+	fs.IntVar(&options.Workers, "workers", options.Workers, "Number of workers of each of the spec and status syncers.")
+	fs.IntVar(&options.WorkspaceConcurrency, "workspace-concurrency", options.WorkspaceConcurrency, "Maximal number of workers of each of the spec and status syncers processing resources of the same workspace at the same time. Set to 0 for no limit. The workspaces are served in turn in any case.")
+	fs.StringVar(&options.MetricsBindAddress, "metrics-bind-address", options.MetricsBindAddress, "The address to serve the metrics on, e.g. :8090. The metrics are not served if empty.")
 	fs.Var(kcpfeatures.NewFlagValue(), "feature-gates", ""+
 		"A set of key=value pairs that describe feature gates for alpha/experimental features. "+
 		"Options are:\n"+strings.Join(kcpfeatures.KnownFeatures(), "\n")) // hide kube-only gates
 
+	componentbaseconfigoptions.BindLeaderElectionFlags(&options.LeaderElection, fs)
 	options.Logs.AddFlags(fs)
 }
 
@@ -95,5 +134,38 @@ func (options *Options) Validate() error {
 	if options.SyncTargetUID == "" {
 		return errors.New("--sync-target-uid is required")
 	}
+	if options.CapacityReportInterval < 0 {
+		return errors.New("--capacity-report-interval must not be negative")
+	}
+	for resource, strategy := range options.StatusAggregation {
+		if !shared.IsValidStatusAggregationStrategy(workloadv1alpha1.StatusAggregationStrategy(strategy)) {
+			return fmt.Errorf("--status-aggregation: unknown strategy %q for %s", strategy, resource)
+		}
+	}
+	if _, err := transformers.ForResources(options.statusTransformerResources()); err != nil {
+		return fmt.Errorf("--status-transformers: %w", err)
+	}
+	if options.Workers < 1 {
+		return errors.New("--workers must be at least 1")
+	}
+	if options.WorkspaceConcurrency < 0 {
+		return errors.New("--workspace-concurrency must not be negative")
+	}
+	if options.LeaderElection.LeaderElect {
+		if options.LeaderElection.ResourceNamespace == "" {
+			return errors.New("--leader-elect-resource-namespace is required with --leader-elect")
+		}
+		if options.LeaderElection.RenewDeadline.Duration >= options.LeaderElection.LeaseDuration.Duration {
+			return errors.New("--leader-elect-renew-deadline must be less than --leader-elect-lease-duration")
+		}
+	}
 	return nil
 }
+
+func (options *Options) statusTransformerResources() []schema.GroupResource {
+	resources := make([]schema.GroupResource, 0, len(options.StatusTransformers))
+	for _, resource := range options.StatusTransformers {
+		resources = append(resources, schema.ParseGroupResource(resource))
+	}
+	return resources
+}
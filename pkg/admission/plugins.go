@@ -56,6 +56,10 @@ import (
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdgroups"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedmetadata"
 	"github.com/kcp-dev/kcp/pkg/admission/reservednames"
+	"github.com/kcp-dev/kcp/pkg/admission/specoverrides"
+	"github.com/kcp-dev/kcp/pkg/admission/statusaggregation"
+	"github.com/kcp-dev/kcp/pkg/admission/syncselection"
+	"github.com/kcp-dev/kcp/pkg/admission/upsync"
 	kcpvalidatingwebhook "github.com/kcp-dev/kcp/pkg/admission/validatingwebhook"
 )
 
@@ -79,6 +83,10 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	reservedmetadata.PluginName,
 	permissionclaims.PluginName,
 	kubequota.PluginName,
+	specoverrides.PluginName,
+	statusaggregation.PluginName,
+	syncselection.PluginName,
+	upsync.PluginName,
 )
 
 func beforeWebhooks(recommended []string, plugins ...string) []string {
@@ -114,6 +122,10 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	reservedmetadata.Register(plugins)
 	permissionclaims.Register(plugins)
 	kubequota.Register(plugins)
+	specoverrides.Register(plugins)
+	statusaggregation.Register(plugins)
+	syncselection.Register(plugins)
+	upsync.Register(plugins)
 }
 
 var defaultOnPluginsInKcp = sets.NewString(
@@ -139,6 +151,10 @@ var defaultOnPluginsInKcp = sets.NewString(
 	reservednames.PluginName,
 	permissionclaims.PluginName,
 	kubequota.PluginName,
+	specoverrides.PluginName,
+	statusaggregation.PluginName,
+	syncselection.PluginName,
+	upsync.PluginName,
 )
 
 // defaultOnKubePluginsInKube is a copy of kubeapiserveroptions.defaultOnKubePlugins.
@@ -47,6 +47,10 @@ var (
 	labelAllowList = []string{
 		apisv1alpha1.APIExportPermissionClaimLabelPrefix + "*", // protected by the permissionclaim admission plugin
 	}
+	// annotationReservedList holds annotations only kcp may write, in addition to those with keys ending in kcp.dev.
+	annotationReservedList = []string{
+		workloadv1alpha1.InternalTunnelShardAnnotationKey, // kcp forwards syncer tunnel requests to the shard named here
+	}
 )
 
 // Register registers the reserved metadata plugin for creation and updates.
@@ -92,20 +96,20 @@ func (o *reservedMetadata) Validate(ctx context.Context, a admission.Attributes,
 		return nil
 	}
 
-	if k, ok := hasPrivilegedModification(newMeta.GetAnnotations(), oldMeta.GetAnnotations(), annotationAllowList); ok {
+	if k, ok := hasPrivilegedModification(newMeta.GetAnnotations(), oldMeta.GetAnnotations(), annotationAllowList, annotationReservedList); ok {
 		return admission.NewForbidden(a, fmt.Errorf("modification of reserved annotation: %q", k))
 	}
 
-	if k, ok := hasPrivilegedModification(newMeta.GetLabels(), oldMeta.GetLabels(), labelAllowList); ok {
+	if k, ok := hasPrivilegedModification(newMeta.GetLabels(), oldMeta.GetLabels(), labelAllowList, nil); ok {
 		return admission.NewForbidden(a, fmt.Errorf("modification of reserved label: %q", k))
 	}
 
 	return nil
 }
 
-func hasPrivilegedModification(new, old map[string]string, allowList []string) (key string, modified bool) {
+func hasPrivilegedModification(new, old map[string]string, allowList, reservedList []string) (key string, modified bool) {
 	hasChanged := func(k, v1, v2 string, v2present bool) bool {
-		return (!v2present || v1 != v2) && isPrivileged(k, allowList)
+		return (!v2present || v1 != v2) && isPrivileged(k, allowList, reservedList)
 	}
 
 	for k, v1 := range old {
@@ -127,7 +131,11 @@ func hasPrivilegedModification(new, old map[string]string, allowList []string) (
 	return "", false
 }
 
-func isPrivileged(key string, allowList []string) bool {
+func isPrivileged(key string, allowList, reservedList []string) bool {
+	if slices.Contains(reservedList, key) {
+		return true
+	}
+
 	for i := range allowList {
 		if strings.HasSuffix(allowList[i], "*") && strings.HasPrefix(key, allowList[i][:len(allowList[i])-1]) {
 			return false
@@ -26,6 +26,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/authentication/user"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 func newAttr(obj, oldObject runtime.Object, op admission.Operation, user user.Info) admission.Attributes {
@@ -604,6 +606,48 @@ func TestAdmission(t *testing.T) {
 				},
 			),
 		},
+		{
+			testName: "added reserved annotation",
+			attr: newAttr(
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo",
+						Annotations: map[string]string{
+							workloadv1alpha1.InternalTunnelShardAnnotationKey: "evil",
+						},
+					},
+				},
+				nil,
+				admission.Create,
+				&user.DefaultInfo{},
+			),
+			wantErr: "forbidden: modification of reserved annotation: \"internal.workload.kcp.dev/tunnel-shard\"",
+		},
+		{
+			testName: "changed reserved annotation by privileged user",
+			attr: newAttr(
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo",
+						Annotations: map[string]string{
+							workloadv1alpha1.InternalTunnelShardAnnotationKey: "shard-b",
+						},
+					},
+				},
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo",
+						Annotations: map[string]string{
+							workloadv1alpha1.InternalTunnelShardAnnotationKey: "shard-a",
+						},
+					},
+				},
+				admission.Update,
+				&user.DefaultInfo{
+					Groups: []string{user.SystemPrivilegedGroup},
+				},
+			),
+		},
 	} {
 		t.Run(tc.testName, func(t *testing.T) {
 			plugin := &reservedMetadata{
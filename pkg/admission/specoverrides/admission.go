@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package specoverrides
+
+import (
+	"context"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+const (
+	PluginName = "workload.kcp.dev/SpecOverrides"
+)
+
+// Register registers the spec overrides plugin for creation and updates.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &specOverrides{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// specOverrides is a validating admission plugin validating the typed spec overrides
+// stored in the workload.kcp.dev/spec-overrides annotation of any object.
+type specOverrides struct {
+	*admission.Handler
+}
+
+var _ = admission.ValidationInterface(&specOverrides{})
+
+// Validate decodes and validates the spec overrides annotation.
+func (o *specOverrides) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	objMeta, err := meta.Accessor(a.GetObject())
+	//nolint:nilerr
+	if err != nil {
+		// The object we are dealing with doesn't have object metadata defined
+		// hence it doesn't have annotations to be checked.
+		return nil
+	}
+
+	value, found := objMeta.GetAnnotations()[workloadv1alpha1.SpecOverridesAnnotationKey]
+	if !found {
+		return nil
+	}
+
+	fldPath := field.NewPath("metadata", "annotations").Key(workloadv1alpha1.SpecOverridesAnnotationKey)
+	overrides, err := shared.ParseSpecOverrides(value)
+	if err != nil {
+		return admission.NewForbidden(a, field.Invalid(fldPath, value, err.Error()))
+	}
+	if errs := shared.ValidateSpecOverrides(overrides, fldPath); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package specoverrides
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func newAttr(obj runtime.Object) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		"default",
+		"test",
+		schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func deploymentWithOverrides(value *string) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+	}
+	if value != nil {
+		deployment.Annotations = map[string]string{"workload.kcp.dev/spec-overrides": *value}
+	}
+	return deployment
+}
+
+func TestValidate(t *testing.T) {
+	value := func(s string) *string { return &s }
+
+	for _, tc := range []struct {
+		name    string
+		value   *string
+		wantErr string
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:  "override by sync target key",
+			value: value(`[{"syncTargetKey":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5","replicas":3}]`),
+		},
+		{
+			name:  "override by sync target selector",
+			value: value(`[{"syncTargetSelector":{"matchLabels":{"region":"eu"}},"containers":[{"name":"app","image":"nginx","env":[{"name":"FOO","value":"bar"}],"resources":{"requests":{"cpu":"100m"}}}]}]`),
+		},
+		{
+			name:    "invalid json",
+			value:   value(`{`),
+			wantErr: "unexpected EOF",
+		},
+		{
+			name:    "unknown field",
+			value:   value(`[{"syncTargetKey":"abc","replica":3}]`),
+			wantErr: `unknown field "replica"`,
+		},
+		{
+			name:    "no sync target",
+			value:   value(`[{"replicas":3}]`),
+			wantErr: "one of syncTargetKey or syncTargetSelector is required",
+		},
+		{
+			name:    "sync target key and selector",
+			value:   value(`[{"syncTargetKey":"abc","syncTargetSelector":{},"replicas":3}]`),
+			wantErr: "only one of syncTargetKey or syncTargetSelector may be set",
+		},
+		{
+			name:    "invalid sync target selector",
+			value:   value(`[{"syncTargetSelector":{"matchLabels":{"-":"eu"}},"replicas":3}]`),
+			wantErr: `syncTargetSelector.matchLabels: Invalid value: "-"`,
+		},
+		{
+			name:    "nothing to override",
+			value:   value(`[{"syncTargetKey":"abc"}]`),
+			wantErr: "one of replicas or containers is required",
+		},
+		{
+			name:    "negative replicas",
+			value:   value(`[{"syncTargetKey":"abc","replicas":-1}]`),
+			wantErr: "replicas: Invalid value: -1",
+		},
+		{
+			name:    "duplicate container",
+			value:   value(`[{"syncTargetKey":"abc","containers":[{"name":"app","image":"a"},{"name":"app","image":"b"}]}]`),
+			wantErr: `containers[1].name: Duplicate value: "app"`,
+		},
+		{
+			name:    "env without name",
+			value:   value(`[{"syncTargetKey":"abc","containers":[{"name":"app","env":[{"value":"bar"}]}]}]`),
+			wantErr: "containers[0].env[0].name: Required value",
+		},
+		{
+			name:    "negative resources",
+			value:   value(`[{"syncTargetKey":"abc","containers":[{"name":"app","resources":{"limits":{"cpu":"-1"}}}]}]`),
+			wantErr: "containers[0].resources.limits[cpu]: Invalid value",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &specOverrides{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			err := o.Validate(context.Background(), newAttr(deploymentWithOverrides(tc.value)), nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
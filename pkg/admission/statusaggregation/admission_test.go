@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func newAttr(obj runtime.Object) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		"default",
+		"test",
+		schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	value := func(s string) *string { return &s }
+
+	for _, tc := range []struct {
+		name    string
+		value   *string
+		wantErr string
+	}{
+		{name: "no annotation"},
+		{name: "Aggregate", value: value("Aggregate")},
+		{name: "Primary", value: value("Primary")},
+		{name: "None", value: value("None")},
+		{
+			name:    "unknown strategy",
+			value:   value("Sum"),
+			wantErr: `metadata.annotations[workload.kcp.dev/status-aggregation]: Unsupported value: "Sum": supported values: "Aggregate", "Primary", "None"`,
+		},
+		{
+			name:    "empty strategy",
+			value:   value(""),
+			wantErr: `Unsupported value: ""`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+			if tc.value != nil {
+				deployment.Annotations = map[string]string{"workload.kcp.dev/status-aggregation": *tc.value}
+			}
+			o := &statusAggregation{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			err := o.Validate(context.Background(), newAttr(deployment), nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncselection
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
+)
+
+const (
+	PluginName = "workload.kcp.dev/SyncSelection"
+)
+
+// Register registers the sync selection plugin for creation and updates.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &syncSelection{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// syncSelection is a validating admission plugin validating the workload.kcp.dev/sync-exclude
+// and workload.kcp.dev/sync-targets annotations of any object. Objects can only be pinned to
+// the sync targets of the locations selected by the placements of the workspace.
+type syncSelection struct {
+	*admission.Handler
+
+	placementIndexer  cache.Indexer
+	locationLister    schedulinglisters.LocationLister
+	syncTargetIndexer cache.Indexer
+}
+
+var _ = admission.ValidationInterface(&syncSelection{})
+var _ = admission.InitializationValidator(&syncSelection{})
+
+// Validate validates the sync selection annotations.
+func (o *syncSelection) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	objMeta, err := meta.Accessor(a.GetObject())
+	//nolint:nilerr
+	if err != nil {
+		// The object we are dealing with doesn't have object metadata defined
+		// hence it doesn't have annotations to be checked.
+		return nil
+	}
+
+	annotations := objMeta.GetAnnotations()
+	fldPath := field.NewPath("metadata", "annotations")
+	var errs field.ErrorList
+
+	exclude, excludeFound := annotations[workloadv1alpha1.SyncExcludeAnnotationKey]
+	if excludeFound && exclude != "true" && exclude != "false" {
+		errs = append(errs, field.NotSupported(fldPath.Key(workloadv1alpha1.SyncExcludeAnnotationKey), exclude, []string{"true", "false"}))
+	}
+
+	if value, found := annotations[workloadv1alpha1.SyncTargetsAnnotationKey]; found {
+		syncTargetsPath := fldPath.Key(workloadv1alpha1.SyncTargetsAnnotationKey)
+		if exclude == "true" {
+			errs = append(errs, field.Forbidden(syncTargetsPath, fmt.Sprintf("must not be set together with %s", workloadv1alpha1.SyncExcludeAnnotationKey)))
+		}
+		keyErrs := validateSyncTargetKeys(value, syncTargetsPath)
+		errs = append(errs, keyErrs...)
+		if len(keyErrs) == 0 {
+			placeableErrs, err := o.validatePlaceable(ctx, a, value, syncTargetsPath)
+			if err != nil {
+				return apierrors.NewInternalError(err)
+			}
+			errs = append(errs, placeableErrs...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+	return nil
+}
+
+// validatePlaceable validates that the sync targets an object is newly pinned to are selected by the
+// placements of the workspace. Sync targets the object is already pinned to are not validated again,
+// so that objects stay updatable after a placement is removed.
+func (o *syncSelection) validatePlaceable(ctx context.Context, a admission.Attributes, value string, fldPath *field.Path) (field.ErrorList, error) {
+	keys := sets.NewString(splitList(value)...)
+	if a.GetOperation() == admission.Update && a.GetOldObject() != nil {
+		if oldMeta, err := meta.Accessor(a.GetOldObject()); err == nil {
+			keys.Delete(splitList(oldMeta.GetAnnotations()[workloadv1alpha1.SyncTargetsAnnotationKey])...)
+		}
+	}
+	if keys.Len() == 0 {
+		return nil, nil
+	}
+
+	clusterName, err := request.ClusterNameFrom(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve cluster from context: %w", err)
+	}
+	placeable, err := o.placeableSyncTargets(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	for _, key := range keys.List() {
+		if !placeable.Has(key) {
+			errs = append(errs, field.Forbidden(fldPath, fmt.Sprintf("sync target %q is not in a location selected by a placement of the workspace", key)))
+		}
+	}
+	return errs, nil
+}
+
+// placeableSyncTargets returns the keys of the sync targets of the locations selected by the placements
+// of the given workspace.
+func (o *syncSelection) placeableSyncTargets(clusterName logicalcluster.Name) (sets.String, error) {
+	placements, err := indexers.ByIndex[*schedulingv1alpha1.Placement](o.placementIndexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := sets.NewString()
+	for _, placement := range placements {
+		if placement.Status.SelectedLocation == nil {
+			continue
+		}
+		locationWorkspace := logicalcluster.New(placement.Status.SelectedLocation.Path)
+		location, err := o.locationLister.Get(clusters.ToClusterAwareKey(locationWorkspace, placement.Status.SelectedLocation.LocationName))
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		syncTargets, err := indexers.ByIndex[*workloadv1alpha1.SyncTarget](o.syncTargetIndexer, indexers.ByLogicalCluster, locationWorkspace.String())
+		if err != nil {
+			return nil, err
+		}
+		locationSyncTargets, err := locationreconciler.LocationSyncTargets(syncTargets, location)
+		if err != nil {
+			return nil, err
+		}
+		for _, syncTarget := range locationSyncTargets {
+			keys.Insert(workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name))
+		}
+	}
+	return keys, nil
+}
+
+// SetKcpInformers implements the WantsExternalKcpInformerFactory interface.
+func (o *syncSelection) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	placementsReady := informers.Scheduling().V1alpha1().Placements().Informer().HasSynced
+	locationsReady := informers.Scheduling().V1alpha1().Locations().Informer().HasSynced
+	syncTargetsReady := informers.Workload().V1alpha1().SyncTargets().Informer().HasSynced
+	o.SetReadyFunc(func() bool {
+		return placementsReady() && locationsReady() && syncTargetsReady()
+	})
+	o.placementIndexer = informers.Scheduling().V1alpha1().Placements().Informer().GetIndexer()
+	o.locationLister = informers.Scheduling().V1alpha1().Locations().Lister()
+	o.syncTargetIndexer = informers.Workload().V1alpha1().SyncTargets().Informer().GetIndexer()
+}
+
+// ValidateInitialization implements the InitializationValidator interface.
+func (o *syncSelection) ValidateInitialization() error {
+	if o.placementIndexer == nil {
+		return fmt.Errorf(PluginName + " plugin needs a Placement indexer")
+	}
+	if o.locationLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a Location lister")
+	}
+	if o.syncTargetIndexer == nil {
+		return fmt.Errorf(PluginName + " plugin needs a SyncTarget indexer")
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validateSyncTargetKeys validates a comma-separated, non-empty list of sync target keys.
+func validateSyncTargetKeys(value string, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	var keys int
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		keys++
+		if msgs := validation.IsQualifiedName(workloadv1alpha1.ClusterResourceStateLabelPrefix + key); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(fldPath, key, "invalid sync target key: "+strings.Join(msgs, ", ")))
+		}
+	}
+	if keys == 0 {
+		errs = append(errs, field.Required(fldPath, "at least one sync target key is required"))
+	}
+	return errs
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncselection
+
+import (
+	"context"
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+var (
+	usWest1 = workloadv1alpha1.ToSyncTargetKey(logicalcluster.New("root:org:ws"), "us-west1")
+	usEast1 = workloadv1alpha1.ToSyncTargetKey(logicalcluster.New("root:org:ws"), "us-east1")
+	euWest1 = workloadv1alpha1.ToSyncTargetKey(logicalcluster.New("root:org:ws"), "eu-west1")
+)
+
+func newAttr(obj, old runtime.Object) admission.Attributes {
+	op := admission.Create
+	if old != nil {
+		op = admission.Update
+	}
+	return admission.NewAttributesRecord(
+		obj,
+		old,
+		schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		"default",
+		"test",
+		schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		"",
+		op,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func newIndexer(objs ...runtime.Object) cache.Indexer {
+	indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, indexers.ClusterScoped())
+	for _, obj := range objs {
+		if err := indexer.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+	return indexer
+}
+
+func syncTarget(name string, labels map[string]string) *workloadv1alpha1.SyncTarget {
+	return &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		annotations    map[string]string
+		oldAnnotations map[string]string
+		wantErr        string
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name:        "excluded",
+			annotations: map[string]string{"workload.kcp.dev/sync-exclude": "true"},
+		},
+		{
+			name:        "not excluded",
+			annotations: map[string]string{"workload.kcp.dev/sync-exclude": "false"},
+		},
+		{
+			name:        "invalid exclude value",
+			annotations: map[string]string{"workload.kcp.dev/sync-exclude": "yes"},
+			wantErr:     `Unsupported value: "yes"`,
+		},
+		{
+			name:        "pinned to sync targets",
+			annotations: map[string]string{"workload.kcp.dev/sync-targets": usWest1 + ", " + usEast1},
+		},
+		{
+			name:        "pinned to a sync target not selected by a placement",
+			annotations: map[string]string{"workload.kcp.dev/sync-targets": usWest1 + "," + euWest1},
+			wantErr:     `sync target "` + euWest1 + `" is not in a location selected by a placement of the workspace`,
+		},
+		{
+			name:        "pinned to an unknown sync target",
+			annotations: map[string]string{"workload.kcp.dev/sync-targets": "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa"},
+			wantErr:     "is not in a location selected by a placement of the workspace",
+		},
+		{
+			name:           "already pinned to a sync target not selected by a placement anymore",
+			annotations:    map[string]string{"workload.kcp.dev/sync-targets": usWest1 + "," + euWest1},
+			oldAnnotations: map[string]string{"workload.kcp.dev/sync-targets": euWest1},
+		},
+		{
+			name: "pinned and not excluded",
+			annotations: map[string]string{
+				"workload.kcp.dev/sync-targets": usWest1,
+				"workload.kcp.dev/sync-exclude": "false",
+			},
+		},
+		{
+			name:        "no sync target",
+			annotations: map[string]string{"workload.kcp.dev/sync-targets": " , "},
+			wantErr:     "at least one sync target key is required",
+		},
+		{
+			name:        "invalid sync target key",
+			annotations: map[string]string{"workload.kcp.dev/sync-targets": "us-west1/cluster"},
+			wantErr:     `Invalid value: "us-west1/cluster": invalid sync target key`,
+		},
+		{
+			name: "pinned and excluded",
+			annotations: map[string]string{
+				"workload.kcp.dev/sync-targets": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				"workload.kcp.dev/sync-exclude": "true",
+			},
+			wantErr: "must not be set together with workload.kcp.dev/sync-exclude",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &syncSelection{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+
+				placementIndexer: newIndexer(&schedulingv1alpha1.Placement{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "us",
+						Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+					},
+					Status: schedulingv1alpha1.PlacementStatus{
+						SelectedLocation: &schedulingv1alpha1.LocationReference{Path: "root:org:ws", LocationName: "us"},
+					},
+				}),
+				locationLister: schedulinglisters.NewLocationLister(newIndexer(&schedulingv1alpha1.Location{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "us",
+						Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+					},
+					Spec: schedulingv1alpha1.LocationSpec{
+						InstanceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us"}},
+					},
+				})),
+				syncTargetIndexer: newIndexer(
+					syncTarget("us-west1", map[string]string{"region": "us"}),
+					syncTarget("us-east1", map[string]string{"region": "us"}),
+					syncTarget("eu-west1", map[string]string{"region": "eu"}),
+				),
+			}
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: tc.annotations,
+				},
+			}
+			var old runtime.Object
+			if tc.oldAnnotations != nil {
+				old = &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test",
+						Annotations: tc.oldAnnotations,
+					},
+				}
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Validate(ctx, newAttr(cm, old), nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/utils/strings/slices"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+const (
+	PluginName = "workload.kcp.dev/Upsync"
+)
+
+// Register registers the upsync plugin for creation, updates and deletion.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &upsync{
+				Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+			}, nil
+		})
+}
+
+// upsync is a validating admission plugin making the resources upsynced from sync targets read-only.
+// They are created, updated and deleted by the syncers through the syncer virtual workspace, which
+// forwards the requests as a member of the "system:masters" group.
+type upsync struct {
+	*admission.Handler
+}
+
+var _ = admission.ValidationInterface(&upsync{})
+
+// Validate rejects the creation, update and deletion of upsynced resources, and turning a resource into an
+// upsynced resource, unless the user is member of the "system:masters" group.
+func (o *upsync) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if slices.Contains(a.GetUserInfo().GetGroups(), user.SystemPrivilegedGroup) {
+		return nil
+	}
+
+	for _, obj := range []runtime.Object{a.GetObject(), a.GetOldObject()} {
+		if obj == nil {
+			continue
+		}
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			// The object we are dealing with doesn't have object metadata defined
+			// hence it doesn't have labels to be checked.
+			continue
+		}
+		if shared.IsUpsynced(objMeta.GetLabels()) {
+			return admission.NewForbidden(a, fmt.Errorf("%s is upsynced from a sync target and is read-only", a.GetResource().GroupResource()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func newAttr(obj, old runtime.Object, op admission.Operation, userInfo user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		old,
+		schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"},
+		"default",
+		"test",
+		schema.GroupVersionResource{Version: "v1", Resource: "endpoints"},
+		"",
+		op,
+		nil,
+		false,
+		userInfo,
+	)
+}
+
+func endpoints(labels map[string]string) *corev1.Endpoints {
+	return &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Labels: labels}}
+}
+
+func TestValidate(t *testing.T) {
+	upsynced := map[string]string{"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Upsync"}
+	synced := map[string]string{"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync"}
+	tenant := &user.DefaultInfo{Name: "tenant"}
+	privileged := &user.DefaultInfo{Name: "system:admin", Groups: []string{user.SystemPrivilegedGroup}}
+
+	for _, tc := range []struct {
+		name     string
+		obj, old runtime.Object
+		op       admission.Operation
+		userInfo user.Info
+		wantErr  bool
+	}{
+		{name: "create synced resource", obj: endpoints(synced), op: admission.Create, userInfo: tenant},
+		{name: "update synced resource", obj: endpoints(synced), old: endpoints(synced), op: admission.Update, userInfo: tenant},
+		{name: "delete synced resource", old: endpoints(synced), op: admission.Delete, userInfo: tenant},
+		{name: "create upsynced resource", obj: endpoints(upsynced), op: admission.Create, userInfo: tenant, wantErr: true},
+		{name: "update upsynced resource", obj: endpoints(upsynced), old: endpoints(upsynced), op: admission.Update, userInfo: tenant, wantErr: true},
+		{name: "turn into upsynced resource", obj: endpoints(upsynced), old: endpoints(synced), op: admission.Update, userInfo: tenant, wantErr: true},
+		{name: "remove upsync label", obj: endpoints(nil), old: endpoints(upsynced), op: admission.Update, userInfo: tenant, wantErr: true},
+		{name: "delete upsynced resource", old: endpoints(upsynced), op: admission.Delete, userInfo: tenant, wantErr: true},
+		{name: "syncer virtual workspace creates upsynced resource", obj: endpoints(upsynced), op: admission.Create, userInfo: privileged},
+		{name: "syncer virtual workspace deletes upsynced resource", old: endpoints(upsynced), op: admission.Delete, userInfo: privileged},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &upsync{Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete)}
+			err := o.Validate(context.Background(), newAttr(tc.obj, tc.old, tc.op, tc.userInfo), nil)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "is upsynced from a sync target and is read-only")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
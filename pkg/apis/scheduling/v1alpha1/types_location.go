@@ -85,6 +85,86 @@ type LocationSpec struct {
 	//
 	// +optional
 	InstanceSelector *metav1.LabelSelector `json:"instanceSelector,omitempty"`
+
+	// schedulingPolicy configures how the instances of this location are filtered and
+	// scored when one of them is picked for a placement. If it is not set, the
+	// LeastAllocated and Spread score plugins are used with equal weight.
+	//
+	// +optional
+	SchedulingPolicy *SchedulingPolicy `json:"schedulingPolicy,omitempty"`
+}
+
+// SchedulingPolicy configures the filter and score plugins used to pick an instance of a location.
+type SchedulingPolicy struct {
+	// scorePlugins is the list of score plugins with their weights. Every plugin scores
+	// an instance between 0 and 100, and the instance with the highest weighted sum wins.
+	// Ties are broken by instance name.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ScorePlugins []ScorePluginConfig `json:"scorePlugins,omitempty"`
+
+	// preferredInstances is a list of weighted label selectors used by the LabelAffinity
+	// score plugin. An instance matching a selector gains the weight of that selector.
+	//
+	// +optional
+	PreferredInstances []WeightedInstanceSelector `json:"preferredInstances,omitempty"`
+
+	// excludedInstances is a list of label selectors. Instances matching any of them are
+	// never selected, similar to a NoSchedule taint on a node. Placements already scheduled
+	// onto an excluded instance are rescheduled.
+	//
+	// +optional
+	ExcludedInstances []metav1.LabelSelector `json:"excludedInstances,omitempty"`
+}
+
+// ScorePluginName is the name of a score plugin.
+//
+// +kubebuilder:validation:Enum=LeastAllocated;Spread;LabelAffinity
+type ScorePluginName string
+
+const (
+	// LeastAllocatedScorePlugin favors instances with the highest ratio of allocatable to capacity resources.
+	LeastAllocatedScorePlugin ScorePluginName = "LeastAllocated"
+	// SpreadScorePlugin favors instances with the fewest placements scheduled onto them.
+	SpreadScorePlugin ScorePluginName = "Spread"
+	// LabelAffinityScorePlugin favors instances matching the preferredInstances selectors.
+	LabelAffinityScorePlugin ScorePluginName = "LabelAffinity"
+)
+
+// ScorePluginConfig enables a score plugin with a weight.
+type ScorePluginConfig struct {
+	// name is the name of the score plugin.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Name ScorePluginName `json:"name"`
+
+	// weight is multiplied with the score of the plugin.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// WeightedInstanceSelector is a label selector with a weight.
+type WeightedInstanceSelector struct {
+	// selector selects the preferred instances.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// weight is added to the LabelAffinity score of a matching instance.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
 }
 
 // GroupVersionResource unambiguously identifies a resource.
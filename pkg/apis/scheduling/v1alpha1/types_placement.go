@@ -24,6 +24,8 @@ import (
 )
 
 // Placement defines a selection rule to choose ONE location for MULTIPLE namespaces in a workspace.
+// Within the location, the namespaces are scheduled to one or, according to instanceSelection,
+// multiple instances.
 //
 // placement is in Pending state initially. When a location is selected by the placement, the placement
 // turns to Unbound state. In Pending or Unbound state, the selection rule can be updated to select another location.
@@ -82,6 +84,42 @@ type PlacementSpec struct {
 	// +optional
 	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
 	LocationWorkspace string `json:"locationWorkspace,omitempty"`
+
+	// instanceSelection controls how many instances of the selected location the namespaces
+	// bound to this placement are scheduled to. By default, they are scheduled to one instance.
+	//
+	// +optional
+	InstanceSelection *InstanceSelection `json:"instanceSelection,omitempty"`
+}
+
+// InstanceSelectionType is the type of an instance selection.
+//
+// +kubebuilder:validation:Enum=Count;All
+type InstanceSelectionType string
+
+const (
+	// InstanceSelectionCount schedules a fixed number of instances of the location.
+	InstanceSelectionCount InstanceSelectionType = "Count"
+	// InstanceSelectionAll schedules all feasible instances of the location.
+	InstanceSelectionAll InstanceSelectionType = "All"
+)
+
+// InstanceSelection controls how many instances of a location are scheduled.
+type InstanceSelection struct {
+	// type is either Count to schedule a fixed number of instances, or All to schedule
+	// every feasible instance of the location.
+	//
+	// +optional
+	// +kubebuilder:default=Count
+	Type InstanceSelectionType `json:"type,omitempty"`
+
+	// count is the number of instances to schedule if type is Count. If fewer instances are
+	// feasible, all of them are scheduled.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Count int32 `json:"count,omitempty"`
 }
 
 type PlacementStatus struct {
@@ -150,6 +188,18 @@ const (
 	// LocationNotMatchReason is a reason for PlacementReady condition that no matched location for
 	// this placement can be found.
 	LocationNotMatchReason = "LocationNoMatch"
+
+	// PlacementScheduled is a condition type for placement representing that the instances of the
+	// selected location have been scheduled for the placement. The message of the condition carries
+	// the score breakdown of the scheduling decision.
+	PlacementScheduled conditionsv1alpha1.ConditionType = "Scheduled"
+
+	// InstanceScheduledReason is a reason for PlacementScheduled condition that the instances have been
+	// picked for this placement.
+	InstanceScheduledReason = "InstanceScheduled"
+	// NoInstanceAvailableReason is a reason for PlacementScheduled condition that no instance of the
+	// selected location passed the filters of the scheduler.
+	NoInstanceAvailableReason = "NoInstanceAvailable"
 )
 
 // PlacementList is a list of locations.
@@ -65,6 +65,22 @@ func (in *GroupVersionResource) DeepCopy() *GroupVersionResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceSelection) DeepCopyInto(out *InstanceSelection) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceSelection.
+func (in *InstanceSelection) DeepCopy() *InstanceSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Location) DeepCopyInto(out *Location) {
 	*out = *in
@@ -158,6 +174,11 @@ func (in *LocationSpec) DeepCopyInto(out *LocationSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SchedulingPolicy != nil {
+		in, out := &in.SchedulingPolicy, &out.SchedulingPolicy
+		*out = new(SchedulingPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -274,6 +295,11 @@ func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.InstanceSelection != nil {
+		in, out := &in.InstanceSelection, &out.InstanceSelection
+		*out = new(InstanceSelection)
+		**out = **in
+	}
 	return
 }
 
@@ -314,3 +340,71 @@ func (in *PlacementStatus) DeepCopy() *PlacementStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulingPolicy) DeepCopyInto(out *SchedulingPolicy) {
+	*out = *in
+	if in.ScorePlugins != nil {
+		in, out := &in.ScorePlugins, &out.ScorePlugins
+		*out = make([]ScorePluginConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreferredInstances != nil {
+		in, out := &in.PreferredInstances, &out.PreferredInstances
+		*out = make([]WeightedInstanceSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExcludedInstances != nil {
+		in, out := &in.ExcludedInstances, &out.ExcludedInstances
+		*out = make([]v1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulingPolicy.
+func (in *SchedulingPolicy) DeepCopy() *SchedulingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScorePluginConfig) DeepCopyInto(out *ScorePluginConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScorePluginConfig.
+func (in *ScorePluginConfig) DeepCopy() *ScorePluginConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ScorePluginConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedInstanceSelector) DeepCopyInto(out *WeightedInstanceSelector) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedInstanceSelector.
+func (in *WeightedInstanceSelector) DeepCopy() *WeightedInstanceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedInstanceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
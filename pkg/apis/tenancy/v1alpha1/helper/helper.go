@@ -25,6 +25,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
 
 // IsValidCluster indicates whether a cluster is valid based on whether it
@@ -53,3 +54,11 @@ func QualifiedObjectName(obj metav1.Object) string {
 func WorkspaceLabelSelector(name string) string {
 	return fmt.Sprintf("%s=%s", v1beta1.WorkspaceNameLabel, name)
 }
+
+// IsRetained returns true if the given deleted ClusterWorkspace or Workspace is retained, i.e. its content
+// is not purged yet. A retained workspace is hidden and inaccessible, but can be undeleted.
+func IsRetained(workspace conditions.Getter) bool {
+	return workspace.GetDeletionTimestamp() != nil &&
+		conditions.IsFalse(workspace, v1alpha1.WorkspaceContentDeleted) &&
+		conditions.GetReason(workspace, v1alpha1.WorkspaceContentDeleted) == v1alpha1.WorkspaceContentDeletedReasonRetained
+}
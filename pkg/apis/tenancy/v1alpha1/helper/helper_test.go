@@ -21,7 +21,11 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
 
 func TestIsValidCluster(t *testing.T) {
@@ -111,3 +115,40 @@ func TestWorkspaceLabelSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRetained(t *testing.T) {
+	now := metav1.Now()
+	retained := conditionsv1alpha1.Condition{
+		Type:   v1alpha1.WorkspaceContentDeleted,
+		Status: corev1.ConditionFalse,
+		Reason: v1alpha1.WorkspaceContentDeletedReasonRetained,
+	}
+	purging := conditionsv1alpha1.Condition{
+		Type:   v1alpha1.WorkspaceContentDeleted,
+		Status: corev1.ConditionFalse,
+		Reason: "SomeResourcesRemain",
+	}
+	tests := []struct {
+		name              string
+		deletionTimestamp *metav1.Time
+		conditions        conditionsv1alpha1.Conditions
+		retained          bool
+	}{
+		{"not deleted", nil, nil, false},
+		{"not deleted, but stale condition", nil, conditionsv1alpha1.Conditions{retained}, false},
+		{"deleted", &now, nil, false},
+		{"deleted and retained", &now, conditionsv1alpha1.Conditions{retained}, true},
+		{"deleted and purging", &now, conditionsv1alpha1.Conditions{purging}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := &v1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tt.deletionTimestamp},
+				Status:     v1alpha1.ClusterWorkspaceStatus{Conditions: tt.conditions},
+			}
+			if got := IsRetained(ws); got != tt.retained {
+				t.Errorf("IsRetained() = %v, want %v", got, tt.retained)
+			}
+		})
+	}
+}
@@ -86,6 +86,11 @@ var _ conditions.Setter = &ClusterWorkspace{}
 
 // ClusterWorkspaceSpec holds the desired state of the ClusterWorkspace.
 type ClusterWorkspaceSpec struct {
+	// readOnly denies all writes to the content of the workspace, e.g. to freeze it during audits.
+	// Privileged subjects and the subjects exempted by the kcp server flags
+	// --read-only-workspace-exempt-users and --read-only-workspace-exempt-groups can still write.
+	// The workspace object itself, living in the parent workspace, can still be changed.
+	//
 	// +optional
 	ReadOnly bool `json:"readOnly,omitempty"`
 
@@ -252,6 +257,14 @@ type ClusterWorkspaceTypeSpec struct {
 	// +listMapKey=path
 	// +listMapKey=exportName
 	DefaultAPIBindings []APIExportReference `json:"defaultAPIBindings,omitempty"`
+
+	// deletionRetention is how long workspaces of this type are retained after deletion. During
+	// that time, a deleted workspace is hidden and inaccessible, but can be undeleted by setting
+	// the experimental.tenancy.kcp.dev/undelete annotation. Its content is purged after the
+	// retention period. If unset, the content of deleted workspaces is purged immediately.
+	//
+	// +optional
+	DeletionRetention *metav1.Duration `json:"deletionRetention,omitempty"`
 }
 
 // APIExportReference provides the fields necessary to resolve an APIExport.
@@ -386,6 +399,15 @@ const (
 
 const ExperimentalClusterWorkspaceOwnerAnnotationKey string = "experimental.tenancy.kcp.dev/owner"
 
+// ExperimentalClusterWorkspaceUndeleteAnnotationKey is the annotation to set on a deleted ClusterWorkspace
+// to restore it while it is retained, i.e. before its content is purged.
+const ExperimentalClusterWorkspaceUndeleteAnnotationKey string = "experimental.tenancy.kcp.dev/undelete"
+
+// ExperimentalClusterWorkspaceMigrateWithNewUIDsAnnotationKey is the annotation to set to "true" on a
+// ClusterWorkspace to confirm its migration to another shard although it contains objects whose UIDs are
+// referenced outside of kcp, like SyncTargets and ServiceAccounts. The copies get new UIDs on the target shard.
+const ExperimentalClusterWorkspaceMigrateWithNewUIDsAnnotationKey string = "experimental.tenancy.kcp.dev/migrate-with-new-uids"
+
 // ClusterWorkspaceStatus communicates the observed state of the ClusterWorkspace.
 type ClusterWorkspaceStatus struct {
 	// Phase of the workspace  (Scheduling / Initializing / Ready)
@@ -417,6 +439,81 @@ type ClusterWorkspaceStatus struct {
 	//
 	// +optional
 	Initializers []ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// migration describes the movement of the workspace content from the current
+	// shard to the shard in location.target. It is set by the system while the
+	// workspace is migrated, and removed when the migration has finished or has
+	// been rolled back.
+	//
+	// +optional
+	Migration *ClusterWorkspaceMigration `json:"migration,omitempty"`
+}
+
+// ClusterWorkspaceMigrationPhaseType is the type of the phase of a workspace migration.
+type ClusterWorkspaceMigrationPhaseType string
+
+const (
+	// ClusterWorkspaceMigrationPhaseFreezing means that writes to the workspace are being frozen on all shards
+	// before the content is copied.
+	ClusterWorkspaceMigrationPhaseFreezing ClusterWorkspaceMigrationPhaseType = "Freezing"
+	// ClusterWorkspaceMigrationPhaseCopying means that the content of the workspace is copied from the source
+	// to the target shard. When the copy is complete, the workspace is switched to the target shard in one step.
+	ClusterWorkspaceMigrationPhaseCopying ClusterWorkspaceMigrationPhaseType = "Copying"
+	// ClusterWorkspaceMigrationPhaseCleaningUp means that the workspace has been switched to the target shard,
+	// and the stale content is removed from the source shard.
+	ClusterWorkspaceMigrationPhaseCleaningUp ClusterWorkspaceMigrationPhaseType = "CleaningUp"
+	// ClusterWorkspaceMigrationPhaseRollingBack means that the migration has been aborted before the switch by
+	// clearing or changing location.target, and the copied content is removed from the target shard.
+	ClusterWorkspaceMigrationPhaseRollingBack ClusterWorkspaceMigrationPhaseType = "RollingBack"
+)
+
+// ClusterWorkspaceMigration describes the movement of the workspace content between shards.
+type ClusterWorkspaceMigration struct {
+	// phase of the migration (Freezing / Copying / CleaningUp / RollingBack).
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Freezing;Copying;CleaningUp;RollingBack
+	Phase ClusterWorkspaceMigrationPhaseType `json:"phase"`
+
+	// source is the name of the ClusterWorkspaceShard the content is moved from.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Source string `json:"source"`
+
+	// target is the name of the ClusterWorkspaceShard the content is moved to.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Target string `json:"target"`
+
+	// startTime is the time the migration was started, i.e. when writes were frozen.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	StartTime metav1.Time `json:"startTime"`
+
+	// copiedObjects is the number of objects copied to the target shard by the last copy pass.
+	//
+	// +optional
+	CopiedObjects int64 `json:"copiedObjects,omitempty"`
+}
+
+// IsFrozenOn returns true if writes to the workspace content are frozen on the given shard
+// because of the migration.
+func (in *ClusterWorkspaceMigration) IsFrozenOn(shard string) bool {
+	if in == nil {
+		return false
+	}
+	switch in.Phase {
+	case ClusterWorkspaceMigrationPhaseFreezing, ClusterWorkspaceMigrationPhaseCopying:
+		return true
+	case ClusterWorkspaceMigrationPhaseCleaningUp:
+		// the content on the source shard is stale after the switch
+		return shard == in.Source
+	}
+	return false
 }
 
 // These are valid conditions of workspace.
@@ -424,7 +521,7 @@ const (
 	// WorkspaceScheduled represents status of the scheduling process for this workspace.
 	WorkspaceScheduled conditionsv1alpha1.ConditionType = "WorkspaceScheduled"
 	// WorkspaceReasonUnschedulable reason in WorkspaceScheduled WorkspaceCondition means that the scheduler
-	// can't schedule the workspace right now, for example due to insufficient resources in the cluster.
+	// can't schedule the workspace right now, for example because all eligible shards are cordoned or full.
 	WorkspaceReasonUnschedulable = "Unschedulable"
 	// WorkspaceReasonReasonUnknown reason in WorkspaceScheduled means that scheduler has failed for
 	// some unexpected reason.
@@ -433,6 +530,37 @@ const (
 	// can't reschedule the workspace right now, for example because it not in Scheduling phase anymore and
 	// movement is not possible.
 	WorkspaceReasonUnreschedulable = "Unreschedulable"
+	// WorkspaceReasonRescheduling reason in WorkspaceScheduled WorkspaceCondition means that the workspace
+	// does not match its shard constraints anymore and is being moved to another shard.
+	WorkspaceReasonRescheduling = "Rescheduling"
+
+	// WorkspaceMigrated represents the status of the last movement of the workspace between shards.
+	WorkspaceMigrated conditionsv1alpha1.ConditionType = "WorkspaceMigrated"
+	// WorkspaceMigratedReasonInProgress reason in WorkspaceMigrated condition means that the workspace is
+	// being moved. The migration status tells the current phase.
+	WorkspaceMigratedReasonInProgress = "MigrationInProgress"
+	// WorkspaceMigratedReasonFailed reason in WorkspaceMigrated condition means that a step of the migration
+	// failed. It is retried, and can be rolled back by clearing location.target before the switch.
+	WorkspaceMigratedReasonFailed = "MigrationFailed"
+	// WorkspaceMigratedReasonRolledBack reason in WorkspaceMigrated condition means that the migration was
+	// aborted, and the workspace stays on its current shard.
+	WorkspaceMigratedReasonRolledBack = "MigrationRolledBack"
+	// WorkspaceMigratedReasonTargetShardInvalid reason in WorkspaceMigrated condition means that the
+	// shard in location.target does not exist.
+	WorkspaceMigratedReasonTargetShardInvalid = "TargetShardInvalid"
+	// WorkspaceMigratedReasonConfirmationRequired reason in WorkspaceMigrated condition means that the
+	// workspace contains objects whose UIDs are referenced outside of kcp, and the migration waits for the
+	// experimental.tenancy.kcp.dev/migrate-with-new-uids annotation.
+	WorkspaceMigratedReasonConfirmationRequired = "ConfirmationRequired"
+
+	// WorkspaceWritable represents whether the content of the workspace can be written to.
+	WorkspaceWritable conditionsv1alpha1.ConditionType = "WorkspaceWritable"
+	// WorkspaceWritableReasonReadOnly reason in WorkspaceWritable condition means that spec.readOnly is set,
+	// and writes are denied for all but exempted subjects.
+	WorkspaceWritableReasonReadOnly = "ReadOnly"
+	// WorkspaceWritableReasonMigrating reason in WorkspaceWritable condition means that writes are denied
+	// while the content of the workspace is moved to another shard.
+	WorkspaceWritableReasonMigrating = "Migrating"
 
 	// WorkspaceShardValid represents status of the connection process for this cluster workspace.
 	WorkspaceShardValid conditionsv1alpha1.ConditionType = "WorkspaceShardValid"
@@ -445,6 +573,9 @@ const (
 
 	// WorkspaceContentDeleted represents the status that all resources in the workspace is deleted.
 	WorkspaceContentDeleted conditionsv1alpha1.ConditionType = "WorkspaceContentDeleted"
+	// WorkspaceContentDeletedReasonRetained reason in WorkspaceContentDeleted condition means that the workspace
+	// is deleted, but its content is retained until the deletion retention period of its type expires.
+	WorkspaceContentDeletedReasonRetained = "Retained"
 
 	// WorkspaceInitialized represents the status that initialization has finished.
 	WorkspaceInitialized conditionsv1alpha1.ConditionType = "WorkspaceInitialized"
@@ -474,10 +605,12 @@ type ClusterWorkspaceLocation struct {
 	// +optional
 	Current string `json:"current,omitempty"`
 
-	// Target workspace placement (shard).
+	// Target workspace placement (shard). Setting a target different from the
+	// current shard starts a migration of the workspace content to that shard.
+	// Clearing it before the content has been switched to the target shard rolls
+	// the migration back.
 	//
 	// +optional
-	// +kubebuilder:validation:Enum=""
 	Target string `json:"target,omitempty"`
 }
 
@@ -569,19 +702,46 @@ type ClusterWorkspaceShardSpec struct {
 	// +kubebuilder:validation:MinLength=1
 	// +optional
 	VirtualWorkspaceURL string `json:"virtualWorkspaceURL,omitempty"`
+
+	// unschedulable cordons the shard, i.e. no new workspaces are scheduled onto it.
+	// Workspaces already on the shard are not affected.
+	//
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
 }
 
 // ClusterWorkspaceShardStatus communicates the observed state of the ClusterWorkspaceShard.
 type ClusterWorkspaceShardStatus struct {
-	// Set of integer resources that workspaces can be scheduled into
+	// Set of integer resources that workspaces can be scheduled into. No new workspaces
+	// are scheduled onto the shard when the usage of one of these resources reaches the capacity.
 	// +optional
 	Capacity corev1.ResourceList `json:"capacity,omitempty"`
 
+	// usage is the observed usage of the resources of the shard, reported by the shard controller.
+	//
+	// +optional
+	Usage corev1.ResourceList `json:"usage,omitempty"`
+
 	// Current processing state of the ClusterWorkspaceShard.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
 }
 
+// These are the resources reported in the usage of ClusterWorkspaceShards.
+const (
+	// ClusterWorkspaceShardResourceWorkspaces is the number of workspaces scheduled onto the shard.
+	ClusterWorkspaceShardResourceWorkspaces corev1.ResourceName = "workspaces"
+	// ClusterWorkspaceShardResourceWorkspacesReportedByPrefix is the prefix of the number of workspaces scheduled
+	// onto the shard whose ClusterWorkspace objects are stored on the shard named by the suffix. ClusterWorkspaces
+	// are stored on the shard of their parent workspace, hence every shard reports those it stores, and
+	// ClusterWorkspaceShardResourceWorkspaces is the sum of the reports.
+	ClusterWorkspaceShardResourceWorkspacesReportedByPrefix corev1.ResourceName = "workspaces.tenancy.kcp.dev/"
+	// ClusterWorkspaceShardResourceEtcdDatabaseSize is the size of the etcd database of the shard in bytes.
+	ClusterWorkspaceShardResourceEtcdDatabaseSize corev1.ResourceName = "etcd-database-size"
+	// ClusterWorkspaceShardResourceObjects is the number of objects the shard stores in etcd.
+	ClusterWorkspaceShardResourceObjects corev1.ResourceName = "objects"
+)
+
 // ClusterWorkspaceShardList is a list of workspace shards
 //
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -122,6 +122,23 @@ func (in *ClusterWorkspaceLocation) DeepCopy() *ClusterWorkspaceLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceMigration) DeepCopyInto(out *ClusterWorkspaceMigration) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceMigration.
+func (in *ClusterWorkspaceMigration) DeepCopy() *ClusterWorkspaceMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterWorkspaceShard) DeepCopyInto(out *ClusterWorkspaceShard) {
 	*out = *in
@@ -209,6 +226,13 @@ func (in *ClusterWorkspaceShardStatus) DeepCopyInto(out *ClusterWorkspaceShardSt
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(conditionsv1alpha1.Conditions, len(*in))
@@ -267,6 +291,11 @@ func (in *ClusterWorkspaceStatus) DeepCopyInto(out *ClusterWorkspaceStatus) {
 		*out = make([]ClusterWorkspaceInitializer, len(*in))
 		copy(*out, *in)
 	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(ClusterWorkspaceMigration)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -430,6 +459,11 @@ func (in *ClusterWorkspaceTypeSpec) DeepCopyInto(out *ClusterWorkspaceTypeSpec)
 		*out = make([]APIExportReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.DeletionRetention != nil {
+		in, out := &in.DeletionRetention, &out.DeletionRetention
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
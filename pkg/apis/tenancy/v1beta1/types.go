@@ -21,6 +21,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
 
 // WorkspaceNameLabel is a label indicating the workspace in which the given
@@ -56,6 +57,12 @@ type Workspace struct {
 	Status WorkspaceStatus `json:"status,omitempty"`
 }
 
+func (in *Workspace) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+var _ conditions.Getter = &Workspace{}
+
 // WorkspaceSpec holds the desired state of the ClusterWorkspace.
 type WorkspaceSpec struct {
 	// type defines properties of the workspace both on creation (e.g. initial
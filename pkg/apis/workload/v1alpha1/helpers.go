@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"crypto/sha256"
 	"math/big"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 )
@@ -36,3 +37,18 @@ func toBase62(hash [28]byte) string {
 	i.SetBytes(hash[:])
 	return i.Text(62)
 }
+
+// SyncTargetKeysFromPlacementAnnotation returns the SyncTarget keys stored in the value of the
+// InternalSyncTargetPlacementAnnotationKey annotation.
+func SyncTargetKeysFromPlacementAnnotation(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// PlacementAnnotationFromSyncTargetKeys returns the value of the InternalSyncTargetPlacementAnnotationKey
+// annotation for the given SyncTarget keys.
+func PlacementAnnotationFromSyncTargetKeys(syncTargetKeys []string) string {
+	return strings.Join(syncTargetKeys, ",")
+}
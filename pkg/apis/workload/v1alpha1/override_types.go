@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+const (
+	// SpecOverridesAnnotationKey is the annotation
+	//
+	//   workload.kcp.dev/spec-overrides
+	//
+	// on upstream resources storing the overrides to be applied to the resource when syncing
+	// down to a sync target. The value is a JSON list of SpecOverride. The overrides are applied
+	// in order, i.e. later overrides win over earlier ones. The value is validated on admission.
+	SpecOverridesAnnotationKey = "workload.kcp.dev/spec-overrides"
+
+	// SpecOverridesApplied is a condition type set by the syncer on upstream resources with status
+	// and spec overrides, representing that the overrides have been rendered into the downstream
+	// resource.
+	SpecOverridesApplied conditionsv1alpha1.ConditionType = "SpecOverridesApplied"
+
+	// SpecOverridesFailedReason is a reason for the SpecOverridesApplied condition that the overrides
+	// could not be applied to the resource, e.g. because a referenced container does not exist.
+	SpecOverridesFailedReason = "SpecOverridesFailed"
+)
+
+// SpecOverride overrides parts of the spec of a resource on the sync targets it applies to.
+// Exactly one of syncTargetKey and syncTargetSelector must be set.
+type SpecOverride struct {
+	// syncTargetKey selects a single sync target by its key, as shown in the Key column
+	// of the SyncTarget resource.
+	//
+	// +optional
+	SyncTargetKey string `json:"syncTargetKey,omitempty"`
+
+	// syncTargetSelector selects the sync targets by their labels, e.g. the labels the
+	// instance selector of a Location matches.
+	//
+	// +optional
+	SyncTargetSelector *metav1.LabelSelector `json:"syncTargetSelector,omitempty"`
+
+	// replicas overrides spec.replicas of the resource.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// containers overrides the containers of the pod template of the resource, or of the
+	// pod itself.
+	//
+	// +optional
+	Containers []ContainerOverride `json:"containers,omitempty"`
+}
+
+// ContainerOverride overrides a container of a pod template.
+type ContainerOverride struct {
+	// name is the name of the container to override.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// image overrides the image of the container.
+	//
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// env is merged into the environment of the container. Variables with the same name
+	// are replaced.
+	//
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// resources overrides the resource requests and limits of the container. Only the
+	// given resource names are replaced.
+	//
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
@@ -37,6 +37,8 @@ import (
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`,priority=2
 // +kubebuilder:printcolumn:name="Synced API resources",type="string",JSONPath=`.status.syncedResources`,priority=3
 // +kubebuilder:printcolumn:name="Key",type="string",JSONPath=`.metadata.labels['internal\.workload\.kcp\.dev/key']`,priority=4
+// +kubebuilder:printcolumn:name="Allocatable CPU",type="string",JSONPath=`.status.allocatable.cpu`
+// +kubebuilder:printcolumn:name="Allocatable Memory",type="string",JSONPath=`.status.allocatable.memory`
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type SyncTarget struct {
 	metav1.TypeMeta `json:",inline"`
@@ -80,6 +82,13 @@ type SyncTargetSpec struct {
 	// they are in the same physical cluster. Each key/value pair in the cells should be added and updated by service providers
 	// (i.e. a network provider updates one key/value, while the storage provider updates another.)
 	Cells map[string]string `json:"cells,omitempty"`
+
+	// ClusterScopedResources is the allow-list of cluster-scoped resources the syncer syncs to the
+	// SyncTarget, e.g. priorityclasses in the scheduling.k8s.io group. Cluster-scoped resources not in
+	// the list are never synced. The synced objects are renamed on the SyncTarget with a prefix unique
+	// to their workspace, and references to them, e.g. priorityClassName, are rewritten accordingly.
+	// +optional
+	ClusterScopedResources []apisv1alpha1.GroupResource `json:"clusterScopedResources,omitempty"`
 }
 
 // SyncTargetStatus communicates the observed state of the SyncTarget (from the controller).
@@ -109,6 +118,107 @@ type SyncTargetStatus struct {
 	// VirtualWorkspaces contains all syncer virtual workspace URLs.
 	// +optional
 	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+
+	// SyncerHealth is the health of the syncer and of the physical cluster, as reported by the
+	// syncer with its heartbeats.
+	// +optional
+	SyncerHealth *SyncerHealth `json:"syncerHealth,omitempty"`
+
+	// SyncerVersion is the build version of the syncer, as reported by the syncer with its heartbeats.
+	// +optional
+	SyncerVersion string `json:"syncerVersion,omitempty"`
+
+	// SupportedSyncerVersions is the range of syncer versions supported by kcp. It is set by kcp,
+	// unless kcp is a development build.
+	// +optional
+	SupportedSyncerVersions *SyncerVersionRange `json:"supportedSyncerVersions,omitempty"`
+}
+
+// SyncerVersionRange is a range of minor versions of the syncer, e.g. v0.7 to v0.8.
+type SyncerVersionRange struct {
+	// Min is the oldest supported minor version, e.g. v0.7.
+	// +required
+	// +kubebuilder:validation:Required
+	Min string `json:"min"`
+
+	// Max is the newest supported minor version, i.e. the one of kcp, e.g. v0.8.
+	// +required
+	// +kubebuilder:validation:Required
+	Max string `json:"max"`
+}
+
+// SyncerHealth is the health of a syncer and of the physical cluster it syncs to.
+type SyncerHealth struct {
+	// downstream is the health of the API server of the physical cluster.
+	// +optional
+	Downstream DownstreamHealth `json:"downstream,omitempty"`
+
+	// resources are the synced resources with the state of their informers.
+	// +optional
+	Resources []ResourceHealth `json:"resources,omitempty"`
+
+	// controllers are the controllers of the syncer with the state of their work queues.
+	// +optional
+	Controllers []ControllerHealth `json:"controllers,omitempty"`
+}
+
+// DownstreamHealth is the health of the API server of a physical cluster.
+type DownstreamHealth struct {
+	// reachable is true if the syncer reached the API server with the last heartbeat.
+	Reachable bool `json:"reachable"`
+
+	// version is the version of the API server, e.g. v1.24.3.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// error is the error reaching the API server if it is not reachable.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ResourceHealth is the state of the informers of a synced resource.
+type ResourceHealth struct {
+	// group is the API group of the resource, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// version is the API version of the resource.
+	// +required
+	// +kubebuilder:validation:Required
+	Version string `json:"version"`
+
+	// resource is the name of the resource.
+	// +required
+	// +kubebuilder:validation:Required
+	Resource string `json:"resource"`
+
+	// upstreamSynced is true if the informer of the resource in kcp has synced.
+	UpstreamSynced bool `json:"upstreamSynced"`
+
+	// downstreamSynced is true if the informer of the resource in the physical cluster has synced.
+	DownstreamSynced bool `json:"downstreamSynced"`
+}
+
+// ControllerHealth is the state of the work queue of a controller of the syncer.
+type ControllerHealth struct {
+	// name is the name of the controller.
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// queueDepth is the number of objects waiting to be reconciled.
+	QueueDepth int64 `json:"queueDepth"`
+
+	// errors is the number of failed reconciliations since the syncer started.
+	Errors int64 `json:"errors"`
+
+	// consecutiveErrors is the number of failed reconciliations since the last successful one.
+	ConsecutiveErrors int64 `json:"consecutiveErrors"`
+
+	// lastSuccessTime is the time of the last successful reconciliation, e.g. the last object
+	// applied to the physical cluster by the spec controller.
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
 }
 
 type ResourceToSync struct {
@@ -181,8 +291,47 @@ const (
 	// SyncerAuthorized means the syncer is authorized to sync resources to downstream cluster.
 	SyncerAuthorized conditionsv1alpha1.ConditionType = "SyncerAuthorized"
 
+	// DownstreamReachable means the syncer reached the API server of the physical cluster with its last heartbeat.
+	DownstreamReachable conditionsv1alpha1.ConditionType = "DownstreamReachable"
+
+	// InformersSynced means the informers of all the synced resources have synced, upstream and downstream.
+	InformersSynced conditionsv1alpha1.ConditionType = "InformersSynced"
+
+	// SyncControllersHealthy means none of the controllers of the syncer keeps failing to reconcile.
+	SyncControllersHealthy conditionsv1alpha1.ConditionType = "SyncControllersHealthy"
+
+	// SyncerHealthy summarizes the DownstreamReachable, InformersSynced and SyncControllersHealthy conditions.
+	// A SyncTarget with a false SyncerHealthy condition is degraded: it stays Ready, and workloads scheduled
+	// to it are not evicted, but no new workload is scheduled to it.
+	SyncerHealthy conditionsv1alpha1.ConditionType = "SyncerHealthy"
+
+	// SyncerVersionSupported means the version of the syncer is in the range of supported versions of kcp.
+	// It is not part of the Ready summary.
+	SyncerVersionSupported conditionsv1alpha1.ConditionType = "SyncerVersionSupported"
+
 	// ErrorHeartbeatMissedReason indicates that a heartbeat update was not received within the configured threshold.
 	ErrorHeartbeatMissedReason = "ErrorHeartbeat"
+
+	// DownstreamUnreachableReason indicates that the syncer cannot reach the API server of the physical cluster.
+	DownstreamUnreachableReason = "DownstreamUnreachable"
+
+	// InformersNotSyncedReason indicates that the informers of some synced resources have not synced.
+	InformersNotSyncedReason = "InformersNotSynced"
+
+	// ControllersFailingReason indicates that some controllers of the syncer keep failing to reconcile.
+	ControllersFailingReason = "ControllersFailing"
+
+	// DegradedReason indicates that the SyncTarget is degraded, see SyncerHealthy.
+	DegradedReason = "Degraded"
+
+	// SyncerTooOldReason indicates that the syncer is older than the oldest version supported by kcp.
+	SyncerTooOldReason = "SyncerTooOld"
+
+	// SyncerTooNewReason indicates that the syncer is newer than kcp.
+	SyncerTooNewReason = "SyncerTooNew"
+
+	// SyncerVersionInvalidReason indicates that the version reported by the syncer cannot be parsed.
+	SyncerVersionInvalidReason = "SyncerVersionInvalid"
 )
 
 func (in *SyncTarget) SetConditions(conditions conditionsv1alpha1.Conditions) {
@@ -27,6 +27,27 @@ const (
 	// This includes the deletion process until the resource is deleted downstream and the
 	// syncer removes the state.workload.kcp.dev/<sync-target-name> label.
 	ResourceStateSync ResourceState = "Sync"
+	// ResourceStateUpsync is the state of a resource which has been created on the sync target, e.g. by
+	// a controller or a provisioner, and that the syncer mirrors upstream. Upsynced resources are owned
+	// by the syncer and read-only for everybody else.
+	ResourceStateUpsync ResourceState = "Upsync"
+)
+
+// StatusAggregationStrategy is the strategy to combine the statuses of a resource synced to
+// multiple sync targets.
+type StatusAggregationStrategy string
+
+const (
+	// StatusAggregationAggregate combines the statuses with the built-in aggregator of the resource,
+	// e.g. summing up the replicas of deployments. Resources without aggregator fall back to
+	// StatusAggregationPrimary.
+	StatusAggregationAggregate StatusAggregationStrategy = "Aggregate"
+	// StatusAggregationPrimary uses the status of the sync target with the lowest key.
+	StatusAggregationPrimary StatusAggregationStrategy = "Primary"
+	// StatusAggregationNone leaves the status of the upstream resource untouched. The statuses are
+	// only available per sync target in the experimental.status.workload.kcp.dev/<sync-target-key>
+	// annotations.
+	StatusAggregationNone StatusAggregationStrategy = "None"
 )
 
 const (
@@ -55,6 +76,31 @@ const (
 	// TODO(sttts): use sync-target-uid instead of sync-target-name
 	ClusterFinalizerAnnotationPrefix = "finalizers.workload.kcp.dev/"
 
+	// MigrationAnnotationPrefix is the prefix of the annotation
+	//
+	//   migration.workload.kcp.dev/<sync-target-key>
+	//
+	// on namespaces and stateful upstream resources marking that the namespace or resource is
+	// migrated away from that sync target. The value is the comma-separated list of the keys of
+	// the sync targets the resource is migrated to. On namespaces, it holds the removal of the
+	// sync target until all stateful resources in the namespace are migrated.
+	MigrationAnnotationPrefix = "migration.workload.kcp.dev/"
+
+	// MigrationFinalizerName is the finalizer name set in the finalizers.workload.kcp.dev/<sync-target-key>
+	// annotation of stateful resources migrated away from that sync target, until the sync targets
+	// the resource is migrated to report the resource as ready.
+	MigrationFinalizerName = "workload.kcp.dev/migration"
+
+	// MigrationHooksAnnotationKey is the annotation
+	//
+	//   workload.kcp.dev/migration-hooks
+	//
+	// on stateful upstream resources storing a comma-separated list of finalizer names that are
+	// added to the finalizers.workload.kcp.dev/<sync-target-key> annotation when the resource
+	// starts being migrated away from that sync target. Data-mover controllers use them to hold
+	// the deletion on the old sync target until the data is moved, and then remove their name.
+	MigrationHooksAnnotationKey = "workload.kcp.dev/migration-hooks"
+
 	// ClusterResourceStateLabelPrefix is the prefix of the label
 	//
 	//   state.workload.kcp.dev/<sync-target-name>
@@ -68,6 +114,9 @@ const (
 	//       controller will have to set the value to "Sync" after initializion in order to
 	//       start the sync process.
 	// - "Sync": the object is assigned and the syncer will start the sync process.
+	// - "Upsync": the object has been created downstream and is mirrored upstream by the syncer.
+	//             It is not scheduled by the workload controllers, and is deleted by the syncer
+	//             when it is deleted downstream.
 	//
 	// While being in "Sync" state, a deletion timestamp in deletion.internal.workload.kcp.dev/<sync-target-name>
 	// will signal the start of the deletion process of the object. During the deletion process
@@ -91,19 +140,88 @@ const (
 	// The format is JSON.
 	InternalClusterStatusAnnotationPrefix = "experimental.status.workload.kcp.dev/"
 
-	// ClusterSpecDiffAnnotationPrefix is the prefix of the annotation
+	// StatusAggregationAnnotationKey is the annotation
+	//
+	//   workload.kcp.dev/status-aggregation
+	//
+	// on upstream resources selecting how the statuses of the downstream resources are combined into
+	// the status of the upstream resource when the resource is synced to multiple sync targets. The
+	// value is one of the StatusAggregationStrategy values, "Aggregate" by default.
+	StatusAggregationAnnotationKey = "workload.kcp.dev/status-aggregation"
+
+	// InternalStatusWriterAnnotationKey is the annotation
+	//
+	//   internal.workload.kcp.dev/status-writer
+	//
+	// on upstream resources synced to multiple sync targets holding the key of the sync target whose
+	// syncer writes the aggregated status. kcp chooses the ready sync target with the lowest key, or the
+	// sync target with the lowest key if none is ready.
+	InternalStatusWriterAnnotationKey = "internal.workload.kcp.dev/status-writer"
+
+	// GlobalEndpointLabel is the label
 	//
-	//   experimental.spec-diff.workload.kcp.dev/<sync-target-name>
+	//   workload.kcp.dev/global-endpoint
 	//
-	// on upstream resources storing the desired spec diffs to be applied to the resource when syncing
-	// down to the <sync-target-name>. This feature requires the "Advanced Scheduling" feature gate
-	// to be enabled.
+	// on the ConfigMaps generated for Services and Ingresses synced to multiple sync targets, holding
+	// the load balancer endpoints of all sync targets. The value is the resource of the owner, e.g.
+	// "services" or "ingresses.networking.k8s.io", the owner itself is referenced in the owner references.
+	// Objects with this label are never synced to sync targets.
+	GlobalEndpointLabel = "workload.kcp.dev/global-endpoint"
+
+	// ClusterScopedConflictAnnotationPrefix is the prefix of the annotation
+	//
+	//   conflict.workload.kcp.dev/<sync-target-key>
+	//
+	// on cluster-scoped upstream resources, set by the syncer when the resource cannot be synced to
+	// that sync target because an object with the same name, not synced from the same workspace,
+	// already exists downstream. The value is a human-readable message. The annotation is removed
+	// once the conflict is resolved.
+	ClusterScopedConflictAnnotationPrefix = "conflict.workload.kcp.dev/"
+
+	// SyncExcludeAnnotationKey is the annotation
+	//
+	//   workload.kcp.dev/sync-exclude
+	//
+	// on upstream resources excluding the resource from syncing, e.g. for configuration that is only
+	// consumed in kcp. The value is "true" or "false". A resource that is already synced is removed
+	// from its sync targets when it is excluded.
+	SyncExcludeAnnotationKey = "workload.kcp.dev/sync-exclude"
+
+	// SyncTargetsAnnotationKey is the annotation
+	//
+	//   workload.kcp.dev/sync-targets
+	//
+	// on upstream resources pinning the resource to the given sync targets, regardless of the sync
+	// targets the namespace is scheduled to. The value is a comma-separated list of sync target keys.
+	// The namespace is scheduled to pinned sync targets as well, but other resources of the namespace
+	// are not synced to them.
+	SyncTargetsAnnotationKey = "workload.kcp.dev/sync-targets"
+
+	// InternalPinnedSyncTargetsAnnotationKey is the annotation
+	//
+	//   internal.workload.kcp.dev/pinned-synctargets
+	//
+	// on namespaces holding the comma-separated list of the keys of the sync targets resources of the
+	// namespace are pinned to with the workload.kcp.dev/sync-targets annotation.
+	InternalPinnedSyncTargetsAnnotationKey = "internal.workload.kcp.dev/pinned-synctargets"
+
+	// InternalPinnedOnlySyncTargetsAnnotationKey is the annotation
+	//
+	//   internal.workload.kcp.dev/pinned-only-synctargets
+	//
+	// on namespaces holding the comma-separated list of the keys of the sync targets the namespace is
+	// only scheduled to because resources are pinned to them, i.e. not selected by a placement. Only the
+	// pinned resources are synced to these sync targets.
+	InternalPinnedOnlySyncTargetsAnnotationKey = "internal.workload.kcp.dev/pinned-only-synctargets"
+
+	// InternalTunnelShardAnnotationKey is the annotation
 	//
-	// The patch will be applied to the resource Spec field of the resource, so the JSON root path is the
-	// resource's Spec field.
+	//   internal.workload.kcp.dev/tunnel-shard
 	//
-	// The format for the value of this annotation is: JSON Patch (https://tools.ietf.org/html/rfc6902).
-	ClusterSpecDiffAnnotationPrefix = "experimental.spec-diff.workload.kcp.dev/"
+	// on sync targets holding the name of the ClusterWorkspaceShard the syncer tunnel of the sync target is
+	// connected to. Tunnel requests reaching another shard are forwarded to the base URL of that shard.
+	// It is only written by kcp.
+	InternalTunnelShardAnnotationKey = "internal.workload.kcp.dev/tunnel-shard"
 
 	// InternalDownstreamClusterLabel is a label with the upstream cluster name applied on the downstream cluster
 	// instead of state.workload.kcp.dev/<sync-target-name> which is used upstream.
@@ -113,8 +231,9 @@ const (
 	// has been created already. If the created default resource is deleted, it will not be recreated.
 	AnnotationSkipDefaultObjectCreation = "workload.kcp.dev/skip-default-object-creation"
 
-	// InternalSyncTargetPlacementAnnotationKey is a internal annotation key on placement API to mark the synctarget scheduled
-	// from this placement. The value is a hash of the SyncTarget workspace + SyncTarget name, generated with the ToSyncTargetKey(..) helper func.
+	// InternalSyncTargetPlacementAnnotationKey is a internal annotation key on placement API to mark the synctargets scheduled
+	// from this placement. The value is a comma-separated list of hashes of the SyncTarget workspace + SyncTarget name, generated
+	// with the ToSyncTargetKey(..) helper func. Use the SyncTargetKeysFromPlacementAnnotation(..) helper func to parse it.
 	InternalSyncTargetPlacementAnnotationKey = "internal.workload.kcp.dev/synctarget"
 
 	// InternalSyncTargetKeyLabel is an internal label set on a SyncTarget resource that contains the full hash of the SyncTargetKey, generated with the ToSyncTargetKey(..)
@@ -24,12 +24,93 @@ package v1alpha1
 import (
 	v1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerOverride) DeepCopyInto(out *ContainerOverride) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerOverride.
+func (in *ContainerOverride) DeepCopy() *ContainerOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerHealth) DeepCopyInto(out *ControllerHealth) {
+	*out = *in
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerHealth.
+func (in *ControllerHealth) DeepCopy() *ControllerHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownstreamHealth) DeepCopyInto(out *DownstreamHealth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownstreamHealth.
+func (in *DownstreamHealth) DeepCopy() *DownstreamHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(DownstreamHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceHealth) DeepCopyInto(out *ResourceHealth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceHealth.
+func (in *ResourceHealth) DeepCopy() *ResourceHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceToSync) DeepCopyInto(out *ResourceToSync) {
 	*out = *in
@@ -52,6 +133,39 @@ func (in *ResourceToSync) DeepCopy() *ResourceToSync {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpecOverride) DeepCopyInto(out *SpecOverride) {
+	*out = *in
+	if in.SyncTargetSelector != nil {
+		in, out := &in.SyncTargetSelector, &out.SyncTargetSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ContainerOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpecOverride.
+func (in *SpecOverride) DeepCopy() *SpecOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(SpecOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncTarget) DeepCopyInto(out *SyncTarget) {
 	*out = *in
@@ -134,6 +248,11 @@ func (in *SyncTargetSpec) DeepCopyInto(out *SyncTargetSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ClusterScopedResources != nil {
+		in, out := &in.ClusterScopedResources, &out.ClusterScopedResources
+		*out = make([]apisv1alpha1.GroupResource, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -195,6 +314,16 @@ func (in *SyncTargetStatus) DeepCopyInto(out *SyncTargetStatus) {
 		*out = make([]VirtualWorkspace, len(*in))
 		copy(*out, *in)
 	}
+	if in.SyncerHealth != nil {
+		in, out := &in.SyncerHealth, &out.SyncerHealth
+		*out = new(SyncerHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SupportedSyncerVersions != nil {
+		in, out := &in.SupportedSyncerVersions, &out.SupportedSyncerVersions
+		*out = new(SyncerVersionRange)
+		**out = **in
+	}
 	return
 }
 
@@ -208,6 +337,51 @@ func (in *SyncTargetStatus) DeepCopy() *SyncTargetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncerHealth) DeepCopyInto(out *SyncerHealth) {
+	*out = *in
+	out.Downstream = in.Downstream
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceHealth, len(*in))
+		copy(*out, *in)
+	}
+	if in.Controllers != nil {
+		in, out := &in.Controllers, &out.Controllers
+		*out = make([]ControllerHealth, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncerHealth.
+func (in *SyncerHealth) DeepCopy() *SyncerHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncerHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncerVersionRange) DeepCopyInto(out *SyncerVersionRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncerVersionRange.
+func (in *SyncerVersionRange) DeepCopy() *SyncerVersionRange {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncerVersionRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualWorkspace) DeepCopyInto(out *VirtualWorkspace) {
 	*out = *in
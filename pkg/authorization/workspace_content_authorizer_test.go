@@ -20,6 +20,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
@@ -37,6 +38,8 @@ import (
 	"k8s.io/kubernetes/pkg/controller"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	"github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 )
 
@@ -128,6 +131,14 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 			requestingUser:     newUser("user-admin"),
 			wantUser:           newUser("user-admin", "system:kcp:clusterworkspace:access", "system:kcp:clusterworkspace:admin"),
 		},
+		{
+			testName: "retained workspace is not accessible",
+
+			requestedWorkspace: "root:retained",
+			requestingUser:     newUser("user-admin"),
+			wantDecision:       authorizer.DecisionNoOpinion,
+			wantReason:         "workspace access not permitted",
+		},
 		{
 			testName: "permitted access user is granted access",
 
@@ -408,6 +419,15 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), "initializing")},
 				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing},
 			}))
+			retained := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              clusters.ToClusterAwareKey(logicalcluster.New("root"), "retained"),
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+			}
+			conditions.MarkFalse(retained, tenancyv1alpha1.WorkspaceContentDeleted, tenancyv1alpha1.WorkspaceContentDeletedReasonRetained, conditionsv1alpha1.ConditionSeverityInfo, "")
+			require.NoError(t, indexer.Add(retained))
 			lister := v1alpha1.NewClusterWorkspaceLister(indexer)
 
 			recordingAuthorizer := &recordingAuthorizer{}
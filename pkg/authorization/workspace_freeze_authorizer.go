@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+const (
+	WorkspaceFrozenReason   = "workspace is frozen"
+	WorkspaceReadOnlyReason = "workspace is read-only"
+
+	WorkspaceFreezeAuditPrefix   = "freeze.authorization.kcp.dev/"
+	WorkspaceFreezeAuditDecision = WorkspaceFreezeAuditPrefix + "decision"
+	WorkspaceFreezeAuditReason   = WorkspaceFreezeAuditPrefix + "reason"
+)
+
+// mutatingVerbs are the verbs denied in frozen and read-only workspaces.
+var mutatingVerbs = sets.NewString("create", "update", "patch", "delete", "deletecollection")
+
+// NewWorkspaceFreezeAuthorizer returns an authorizer that denies writes to the content of workspaces
+// which are being migrated between shards, i.e. while the content is copied, and on the source shard
+// after the switch to the target shard. Privileged subjects, like the migration controller itself,
+// are allowed by the privileged groups authorizer before this one is consulted.
+//
+// Writes to workspaces with spec.readOnly set are denied as well, unless the user name is in
+// readOnlyExemptUsers or the user is member of a group in readOnlyExemptGroups. User names
+// ending in * match by prefix.
+func NewWorkspaceFreezeAuthorizer(clusterWorkspaceLister tenancylisters.ClusterWorkspaceLister, shardName string, readOnlyExemptUsers, readOnlyExemptGroups []string, delegate authorizer.Authorizer) authorizer.Authorizer {
+	a := &workspaceFreezeAuthorizer{
+		clusterWorkspaceLister: clusterWorkspaceLister,
+		shardName:              shardName,
+		readOnlyExemptUsers:    sets.NewString(),
+		readOnlyExemptGroups:   sets.NewString(readOnlyExemptGroups...),
+		delegate:               delegate,
+	}
+	for _, u := range readOnlyExemptUsers {
+		if strings.HasSuffix(u, "*") {
+			a.readOnlyExemptUserPrefixes = append(a.readOnlyExemptUserPrefixes, strings.TrimSuffix(u, "*"))
+		} else {
+			a.readOnlyExemptUsers.Insert(u)
+		}
+	}
+	return a
+}
+
+type workspaceFreezeAuthorizer struct {
+	clusterWorkspaceLister tenancylisters.ClusterWorkspaceLister
+	shardName              string
+
+	readOnlyExemptUsers        sets.String
+	readOnlyExemptUserPrefixes []string
+	readOnlyExemptGroups       sets.String
+
+	delegate authorizer.Authorizer
+}
+
+func (a *workspaceFreezeAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	cluster := genericapirequest.ClusterFrom(ctx)
+	if cluster == nil || cluster.Name.Empty() || !attr.IsResourceRequest() || !mutatingVerbs.Has(attr.GetVerb()) {
+		return a.delegate.Authorize(ctx, attr)
+	}
+	parentClusterName, hasParent := cluster.Name.Parent()
+	if !hasParent {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	ws, err := a.clusterWorkspaceLister.Get(clusters.ToClusterAwareKey(parentClusterName, cluster.Name.Base()))
+	if errors.IsNotFound(err) {
+		// other authorizers decide about nonexistent workspaces
+		return a.delegate.Authorize(ctx, attr)
+	} else if err != nil {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			WorkspaceFreezeAuditDecision, DecisionNoOpinion,
+			WorkspaceFreezeAuditReason, fmt.Sprintf("error getting clusterworkspace: %v", err),
+		)
+		return authorizer.DecisionNoOpinion, "", err
+	}
+
+	if ws.Status.Migration.IsFrozenOn(a.shardName) {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			WorkspaceFreezeAuditDecision, DecisionDenied,
+			WorkspaceFreezeAuditReason, fmt.Sprintf("clusterworkspace is migrating to shard %q, phase %q", ws.Status.Migration.Target, ws.Status.Migration.Phase),
+		)
+		return authorizer.DecisionDeny, WorkspaceFrozenReason, nil
+	}
+
+	if ws.Spec.ReadOnly && !a.isReadOnlyExempt(attr.GetUser()) {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			WorkspaceFreezeAuditDecision, DecisionDenied,
+			WorkspaceFreezeAuditReason, "clusterworkspace is read-only",
+		)
+		return authorizer.DecisionDeny, WorkspaceReadOnlyReason, nil
+	}
+
+	return a.delegate.Authorize(ctx, attr)
+}
+
+func (a *workspaceFreezeAuthorizer) isReadOnlyExempt(u user.Info) bool {
+	if u == nil {
+		return false
+	}
+	if a.readOnlyExemptUsers.Has(u.GetName()) || a.readOnlyExemptGroups.HasAny(u.GetGroups()...) {
+		return true
+	}
+	for _, prefix := range a.readOnlyExemptUserPrefixes {
+		if strings.HasPrefix(u.GetName(), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestWorkspaceFreezeAuthorizer(t *testing.T) {
+	migrating := func(name string, phase tenancyv1alpha1.ClusterWorkspaceMigrationPhaseType) *tenancyv1alpha1.ClusterWorkspace {
+		ws := &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), name)},
+		}
+		if phase != "" {
+			ws.Status.Migration = &tenancyv1alpha1.ClusterWorkspaceMigration{
+				Phase:  phase,
+				Source: "source",
+				Target: "target",
+			}
+		}
+		return ws
+	}
+
+	for _, tt := range []struct {
+		testName           string
+		requestedWorkspace string
+		shard              string
+		verb               string
+		requestingUser     *user.DefaultInfo
+		nonResource        bool
+		wantDecision       authorizer.Decision
+		wantReason         string
+		wantDelegated      bool
+	}{
+		{
+			testName:           "write to workspace without migration",
+			requestedWorkspace: "root:idle",
+			shard:              "source",
+			verb:               "create",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to unknown workspace",
+			requestedWorkspace: "root:unknown",
+			shard:              "source",
+			verb:               "create",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to freezing workspace",
+			requestedWorkspace: "root:freezing",
+			shard:              "source",
+			verb:               "update",
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         WorkspaceFrozenReason,
+		},
+		{
+			testName:           "write to copying workspace",
+			requestedWorkspace: "root:copying",
+			shard:              "target",
+			verb:               "delete",
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         WorkspaceFrozenReason,
+		},
+		{
+			testName:           "read from copying workspace",
+			requestedWorkspace: "root:copying",
+			shard:              "source",
+			verb:               "list",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "non-resource request to copying workspace",
+			requestedWorkspace: "root:copying",
+			shard:              "source",
+			verb:               "create",
+			nonResource:        true,
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to cleaned up workspace on source shard",
+			requestedWorkspace: "root:cleaningup",
+			shard:              "source",
+			verb:               "patch",
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         WorkspaceFrozenReason,
+		},
+		{
+			testName:           "write to cleaned up workspace on target shard",
+			requestedWorkspace: "root:cleaningup",
+			shard:              "target",
+			verb:               "patch",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to rolled back workspace",
+			requestedWorkspace: "root:rollingback",
+			shard:              "source",
+			verb:               "create",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to read-only workspace",
+			requestedWorkspace: "root:readonly",
+			shard:              "source",
+			verb:               "create",
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         WorkspaceReadOnlyReason,
+		},
+		{
+			testName:           "read from read-only workspace",
+			requestedWorkspace: "root:readonly",
+			shard:              "source",
+			verb:               "get",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to read-only workspace by exempted user",
+			requestedWorkspace: "root:readonly",
+			shard:              "source",
+			verb:               "update",
+			requestingUser:     newUser("controller"),
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to read-only workspace by user exempted by prefix",
+			requestedWorkspace: "root:readonly",
+			shard:              "source",
+			verb:               "update",
+			requestingUser:     newServiceAccount("system:serviceaccount:default:kcp-syncer-east"),
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to read-only workspace by user with similar name",
+			requestedWorkspace: "root:readonly",
+			shard:              "source",
+			verb:               "update",
+			requestingUser:     newServiceAccount("system:serviceaccount:other:kcp-syncer-east"),
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         WorkspaceReadOnlyReason,
+		},
+		{
+			testName:           "write to read-only workspace by exempted group",
+			requestedWorkspace: "root:readonly",
+			shard:              "source",
+			verb:               "delete",
+			requestingUser:     newUser("user-1", "auditors"),
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+		{
+			testName:           "write to frozen read-only workspace by exempted user",
+			requestedWorkspace: "root:readonlycopying",
+			shard:              "source",
+			verb:               "update",
+			requestingUser:     newUser("controller"),
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         WorkspaceFrozenReason,
+		},
+		{
+			testName:           "write to root workspace",
+			requestedWorkspace: "root",
+			shard:              "source",
+			verb:               "create",
+			wantDecision:       authorizer.DecisionAllow,
+			wantDelegated:      true,
+		},
+	} {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx := context.Background()
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, ws := range []*tenancyv1alpha1.ClusterWorkspace{
+				migrating("idle", ""),
+				migrating("freezing", tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing),
+				migrating("copying", tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying),
+				migrating("cleaningup", tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp),
+				migrating("rollingback", tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack),
+			} {
+				require.NoError(t, indexer.Add(ws))
+			}
+			readOnly := migrating("readonly", "")
+			readOnly.Spec.ReadOnly = true
+			require.NoError(t, indexer.Add(readOnly))
+			readOnlyCopying := migrating("readonlycopying", tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying)
+			readOnlyCopying.Spec.ReadOnly = true
+			require.NoError(t, indexer.Add(readOnlyCopying))
+			lister := v1alpha1.NewClusterWorkspaceLister(indexer)
+
+			recordingAuthorizer := &recordingAuthorizer{decision: authorizer.DecisionAllow}
+			w := NewWorkspaceFreezeAuthorizer(lister, tt.shard,
+				[]string{"controller", "system:serviceaccount:default:kcp-syncer-*"}, []string{"auditors"},
+				recordingAuthorizer,
+			)
+
+			ctx = request.WithCluster(ctx, request.Cluster{Name: logicalcluster.New(tt.requestedWorkspace)})
+			requestingUser := tt.requestingUser
+			if requestingUser == nil {
+				requestingUser = newUser("user-1")
+			}
+			attr := authorizer.AttributesRecord{
+				User:            requestingUser,
+				Verb:            tt.verb,
+				ResourceRequest: !tt.nonResource,
+			}
+
+			gotDecision, gotReason, err := w.Authorize(ctx, attr)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDecision, gotDecision)
+			require.Equal(t, tt.wantReason, gotReason)
+			require.Equal(t, tt.wantDelegated, recordingAuthorizer.recordedAttributes != nil)
+		})
+	}
+}
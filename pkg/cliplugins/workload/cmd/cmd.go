@@ -34,6 +34,14 @@ var (
 
 	# Directly apply the manifest
 	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> -o - | KUBECONFIG=<pcluster-config> kubectl apply -f -
+`
+	upgradeExample = `
+	# Upgrade the syncer of a sync target to a new image, keeping its service account token.
+	%[1]s workload upgrade <sync-target-name> --syncer-image <kcp-syncer-image> -o syncer.yaml
+	KUBECONFIG=<pcluster-config> kubectl apply -f syncer.yaml
+
+	# Directly apply the upgraded manifest to the physical cluster
+	%[1]s workload upgrade <sync-target-name> --syncer-image <kcp-syncer-image> --to-kubeconfig <pcluster-config>
 `
 	cordonExample = `
 	# Mark a sync target as unschedulable.
@@ -46,6 +54,13 @@ var (
 	drainExample = `
 	# Start draining a sync target in preparation for maintenance.
 	%[1]s workload drain <sync-target-name>
+`
+	diffExample = `
+	# Show what the syncer would apply to the physical cluster of a sync target, without writing anything.
+	%[1]s workload diff <sync-target-name> --to-kubeconfig <pcluster-config>
+
+	# Only compare deployments.
+	%[1]s workload diff <sync-target-name> --to-kubeconfig <pcluster-config> --resources deployments.apps
 `
 )
 
@@ -90,6 +105,34 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	syncOptions.BindFlags(enableSyncerCmd)
 	cmd.AddCommand(enableSyncerCmd)
 
+	// Upgrade command
+	upgradeOptions := plugin.NewUpgradeOptions(streams)
+
+	upgradeCmd := &cobra.Command{
+		Use:          "upgrade <sync-target-name> --syncer-image <kcp-syncer-image> [-o <output-file>] [--to-kubeconfig <pcluster-config>]",
+		Short:        "Regenerate the manifest of the syncer of an existing sync target, e.g. with a new image, and write or apply it to the physical cluster.",
+		Example:      fmt.Sprintf(upgradeExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+
+			if err := upgradeOptions.Complete(args); err != nil {
+				return err
+			}
+
+			if err := upgradeOptions.Validate(); err != nil {
+				return err
+			}
+
+			return upgradeOptions.Run(c.Context())
+		},
+	}
+
+	upgradeOptions.BindFlags(upgradeCmd)
+	cmd.AddCommand(upgradeCmd)
+
 	// Cordon command
 	cordonOpts := plugin.NewCordonOptions(streams)
 	cordonOpts.Cordon = true
@@ -176,5 +219,33 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	drainOpts.BindFlags(drainCmd)
 	cmd.AddCommand(drainCmd)
 
+	// Diff command
+	diffOpts := plugin.NewDiffOptions(streams)
+
+	diffCmd := &cobra.Command{
+		Use:          "diff <sync-target-name> --to-kubeconfig <pcluster-config> [--resources=<resource1>,<resource2>..]",
+		Short:        "Show the difference between the objects the syncer would apply and the objects in the physical cluster",
+		Example:      fmt.Sprintf(diffExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+
+			if err := diffOpts.Complete(args); err != nil {
+				return err
+			}
+
+			if err := diffOpts.Validate(); err != nil {
+				return err
+			}
+
+			return diffOpts.Run(c.Context())
+		},
+	}
+
+	diffOpts.BindFlags(diffCmd)
+	cmd.AddCommand(diffCmd)
+
 	return cmd, nil
 }
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	"github.com/kcp-dev/kcp/pkg/syncer"
+)
+
+// DiffOptions contains options for showing what the syncer would apply to a physical cluster.
+type DiffOptions struct {
+	*base.Options
+
+	// SyncTargetName is the name of the SyncTarget to compute the difference for.
+	SyncTargetName string
+	// ToKubeconfig is the kubeconfig file of the physical cluster.
+	ToKubeconfig string
+	// ToContext is the context in ToKubeconfig to use, instead of the current context.
+	ToContext string
+	// Resources restricts the difference to the given resources, e.g. "deployments.apps".
+	Resources []string
+}
+
+// NewDiffOptions returns a new DiffOptions.
+func NewDiffOptions(streams genericclioptions.IOStreams) *DiffOptions {
+	return &DiffOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields DiffOptions as command line flags to cmd's flagset.
+func (o *DiffOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.ToKubeconfig, "to-kubeconfig", o.ToKubeconfig, "Kubeconfig file of the physical cluster.")
+	cmd.Flags().StringVar(&o.ToContext, "to-context", o.ToContext, "Context to use in the kubeconfig file of the physical cluster, instead of the current context.")
+	cmd.Flags().StringSliceVar(&o.Resources, "resources", o.Resources, "Resources to compare. By default all the resources synced to the sync target are compared.")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *DiffOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.SyncTargetName = args[0]
+	}
+
+	return nil
+}
+
+// Validate validates the DiffOptions are complete and usable.
+func (o *DiffOptions) Validate() error {
+	if o.SyncTargetName == "" {
+		return errors.New("sync target name is required")
+	}
+	if o.ToKubeconfig == "" {
+		return errors.New("--to-kubeconfig is required")
+	}
+
+	return o.Options.Validate()
+}
+
+// Run prints the difference between the objects the syncer of the sync target would apply and the objects in
+// the physical cluster. Nothing is written.
+func (o *DiffOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	serverURL, currentClusterName, err := helpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	upstreamConfig := rest.CopyConfig(config)
+	upstreamConfig.Host = serverURL.String()
+
+	downstreamConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: o.ToKubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: o.ToContext},
+	).ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	return syncer.DiffSyncer(ctx, &syncer.SyncerConfig{
+		UpstreamConfig:      upstreamConfig,
+		DownstreamConfig:    downstreamConfig,
+		ResourcesToSync:     sets.NewString(o.Resources...),
+		SyncTargetWorkspace: currentClusterName,
+		SyncTargetName:      o.SyncTargetName,
+	}, o.Out)
+}
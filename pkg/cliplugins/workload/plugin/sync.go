@@ -19,7 +19,6 @@ package plugin
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"embed"
 	"encoding/base64"
 	"encoding/json"
@@ -32,7 +31,6 @@ import (
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
-	"github.com/martinlindhe/base36"
 	"github.com/spf13/cobra"
 
 	corev1 "k8s.io/api/core/v1"
@@ -55,6 +53,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
 //go:embed *.yaml
@@ -62,16 +61,25 @@ var embeddedResources embed.FS
 
 const (
 	SyncerSecretConfigKey   = "kubeconfig"
-	SyncerIDPrefix          = "kcp-syncer-"
+	SyncerIDPrefix          = shared.SyncerIDPrefix
 	MaxSyncTargetNameLength = validation.DNS1123SubdomainMaxLength - (9 + len(SyncerIDPrefix))
 )
 
+// requiredResourcesToSync are synced in addition to any user-specified types to
+// ensure support for the use case of a synced deployment capable of talking to kcp.
+//
+// TODO(marun) Consider allowing a user-specified and exclusive set of types.
+var requiredResourcesToSync = sets.NewString("deployments.apps", "secrets", "configmaps", "serviceaccounts")
+
 // SyncOptions contains options for configuring a SyncTarget and its corresponding syncer.
 type SyncOptions struct {
 	*base.Options
 
 	// ResourcesToSync is a list of fully-qualified resource names that should be synced by the syncer.
 	ResourcesToSync []string
+	// ResourcesToUpsync is a list of fully-qualified names of namespaced resources created in the physical cluster
+	// that should be mirrored to kcp by the syncer. They are synced resources too.
+	ResourcesToUpsync []string
 	// SyncerImage is the container image that should be used for the syncer.
 	SyncerImage string
 	// Replicas is the number of replicas to configure in the syncer's deployment.
@@ -91,6 +99,8 @@ type SyncOptions struct {
 	SyncTargetName string
 	// APIImportPollInterval is the time interval to push apiimport.
 	APIImportPollInterval time.Duration
+	// CapacityReportInterval is the minimal interval between two updates of the capacity of the physical cluster.
+	CapacityReportInterval time.Duration
 	// FeatureGates is used to configure which feature gates are enabled.
 	FeatureGates string
 }
@@ -100,11 +110,12 @@ func NewSyncOptions(streams genericclioptions.IOStreams) *SyncOptions {
 	return &SyncOptions{
 		Options: base.NewOptions(streams),
 
-		Replicas:              1,
-		KCPNamespace:          "default",
-		QPS:                   20,
-		Burst:                 30,
-		APIImportPollInterval: 1 * time.Minute,
+		Replicas:               1,
+		KCPNamespace:           "default",
+		QPS:                    20,
+		Burst:                  30,
+		APIImportPollInterval:  1 * time.Minute,
+		CapacityReportInterval: 1 * time.Minute,
 	}
 }
 
@@ -113,8 +124,9 @@ func (o *SyncOptions) BindFlags(cmd *cobra.Command) {
 	o.Options.BindFlags(cmd)
 
 	cmd.Flags().StringSliceVar(&o.ResourcesToSync, "resources", o.ResourcesToSync, "Resources to synchronize with kcp.")
+	cmd.Flags().StringSliceVar(&o.ResourcesToUpsync, "upsync-resources", o.ResourcesToUpsync, "Namespaced resources created in the physical cluster, e.g. by controllers, to mirror read-only to kcp. They are synchronized resources too.")
 	cmd.Flags().StringVar(&o.SyncerImage, "syncer-image", o.SyncerImage, "The syncer image to use in the syncer's deployment YAML. Images are published at https://github.com/kcp-dev/kcp/pkgs/container/kcp%2Fsyncer.")
-	cmd.Flags().IntVar(&o.Replicas, "replicas", o.Replicas, "Number of replicas of the syncer deployment.")
+	cmd.Flags().IntVar(&o.Replicas, "replicas", o.Replicas, "Number of replicas of the syncer deployment. The replicas elect a leader, the others are hot standbys.")
 	cmd.Flags().StringVar(&o.KCPNamespace, "kcp-namespace", o.KCPNamespace, "The name of the kcp namespace to create a service account in.")
 	cmd.Flags().StringVarP(&o.OutputFile, "output-file", "o", o.OutputFile, "The manifest file to be created and applied to the physical cluster. Use - for stdout.")
 	cmd.Flags().StringVarP(&o.DownstreamNamespace, "namespace", "n", o.DownstreamNamespace, "The namespace to create the syncer in in the physical cluster. By default this is \"kcp-syncer-<synctarget-name>-<uid>\".")
@@ -124,6 +136,7 @@ func (o *SyncOptions) BindFlags(cmd *cobra.Command) {
 		"A set of key=value pairs that describe feature gates for alpha/experimental features. "+
 			"Options are:\n"+strings.Join(kcpfeatures.KnownFeatures(), "\n")) // hide kube-only gates
 	cmd.Flags().DurationVar(&o.APIImportPollInterval, "api-import-poll-interval", o.APIImportPollInterval, "Polling interval for API import.")
+	cmd.Flags().DurationVar(&o.CapacityReportInterval, "capacity-report-interval", o.CapacityReportInterval, "Minimal interval between two updates of the capacity of the physical cluster in the SyncTarget status. Set to 0 to disable capacity reporting.")
 }
 
 // Complete ensures all dynamically populated fields are initialized.
@@ -139,6 +152,17 @@ func (o *SyncOptions) Complete(args []string) error {
 
 // Validate validates the SyncOptions are complete and usable.
 func (o *SyncOptions) Validate() error {
+	errs := o.validateSyncer()
+
+	if o.OutputFile == "" {
+		errs = append(errs, errors.New("--output-file is required"))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateSyncer validates the options of the syncer deployment.
+func (o *SyncOptions) validateSyncer() []error {
 	var errs []error
 
 	if err := o.Options.Validate(); err != nil {
@@ -156,31 +180,21 @@ func (o *SyncOptions) Validate() error {
 	if o.Replicas < 0 {
 		errs = append(errs, errors.New("--replicas cannot be negative"))
 	}
-	if o.Replicas > 1 {
-		// TODO: relax when we have leader-election in the syncer
-		errs = append(errs, errors.New("only 0 and 1 are valid values for --replicas"))
-	}
 
-	if o.OutputFile == "" {
-		errs = append(errs, errors.New("--output-file is required"))
+	if o.CapacityReportInterval < 0 {
+		errs = append(errs, errors.New("--capacity-report-interval cannot be negative"))
 	}
 
 	if len(o.SyncTargetName)+len(SyncerIDPrefix)+8 > 254 {
 		errs = append(errs, fmt.Errorf("the maximum length of the sync-target-name is %d", MaxSyncTargetNameLength))
 	}
 
-	return utilerrors.NewAggregate(errs)
+	return errs
 }
 
 // Run prepares a kcp workspace for use with a syncer and outputs the
 // configuration required to deploy a syncer to the pcluster to stdout.
 func (o *SyncOptions) Run(ctx context.Context) error {
-	// Any user-specified types will be synced in addition to the required types
-	// to ensure support for the use case of a synced deployment capable of
-	// talking to kcp.
-	//
-	// TODO(marun) Consider allowing a user-specified and exclusive set of types.
-	requiredResourcesToSync := sets.NewString("deployments.apps", "secrets", "configmaps", "serviceaccounts")
 	resourcesToSync := sets.NewString(o.ResourcesToSync...).Union(requiredResourcesToSync).List()
 
 	config, err := o.ClientConfig.ClientConfig()
@@ -204,38 +218,9 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 		return err
 	}
 
-	configURL, currentClusterName, err := helpers.ParseClusterURL(config.Host)
+	input, err := o.newTemplateInput(config, token, syncerID, syncTargetUID, resourcesToSync)
 	if err != nil {
-		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
-	}
-
-	if o.DownstreamNamespace == "" {
-		o.DownstreamNamespace = syncerID
-	}
-
-	// Compose the syncer's upstream configuration server URL without any path. This is
-	// required so long as the API importer and syncer expect to require cluster clients.
-	//
-	// TODO(marun) It's probably preferable that the syncer and importer are provided a
-	// cluster configuration since they only operate against a single workspace.
-	serverURL := configURL.Scheme + "://" + configURL.Host
-
-	input := templateInput{
-		ServerURL:                   serverURL,
-		CAData:                      base64.StdEncoding.EncodeToString(config.CAData),
-		Token:                       token,
-		KCPNamespace:                o.KCPNamespace,
-		Namespace:                   o.DownstreamNamespace,
-		LogicalCluster:              currentClusterName.String(),
-		SyncTarget:                  o.SyncTargetName,
-		SyncTargetUID:               syncTargetUID,
-		Image:                       o.SyncerImage,
-		Replicas:                    o.Replicas,
-		ResourcesToSync:             resourcesToSync,
-		QPS:                         o.QPS,
-		Burst:                       o.Burst,
-		FeatureGatesString:          o.FeatureGates,
-		APIImportPollIntervalString: o.APIImportPollInterval.String(),
+		return err
 	}
 
 	resources, err := renderSyncerResources(input, syncerID)
@@ -251,12 +236,43 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 	return err
 }
 
-// getSyncerID returns a unique ID for a syncer derived from the name and its UID. It's
-// a valid DNS segment and can be used as namespace or object names.
-func getSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
-	syncerHash := sha256.Sum224([]byte(syncTarget.UID))
-	base36hash := strings.ToLower(base36.EncodeBytes(syncerHash[:]))
-	return fmt.Sprintf("kcp-syncer-%s-%s", syncTarget.Name, base36hash[:8])
+// newTemplateInput returns the input to render the resources of the syncer deployment.
+func (o *SyncOptions) newTemplateInput(config *rest.Config, token, syncerID, syncTargetUID string, resourcesToSync []string) (templateInput, error) {
+	configURL, currentClusterName, err := helpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return templateInput{}, fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	if o.DownstreamNamespace == "" {
+		o.DownstreamNamespace = syncerID
+	}
+
+	// Compose the syncer's upstream configuration server URL without any path. This is
+	// required so long as the API importer and syncer expect to require cluster clients.
+	//
+	// TODO(marun) It's probably preferable that the syncer and importer are provided a
+	// cluster configuration since they only operate against a single workspace.
+	serverURL := configURL.Scheme + "://" + configURL.Host
+
+	return templateInput{
+		ServerURL:                    serverURL,
+		CAData:                       base64.StdEncoding.EncodeToString(config.CAData),
+		Token:                        token,
+		KCPNamespace:                 o.KCPNamespace,
+		Namespace:                    o.DownstreamNamespace,
+		LogicalCluster:               currentClusterName.String(),
+		SyncTarget:                   o.SyncTargetName,
+		SyncTargetUID:                syncTargetUID,
+		Image:                        o.SyncerImage,
+		Replicas:                     o.Replicas,
+		ResourcesToSync:              sets.NewString(resourcesToSync...).Insert(o.ResourcesToUpsync...).List(),
+		ResourcesToUpsync:            sets.NewString(o.ResourcesToUpsync...).List(),
+		QPS:                          o.QPS,
+		Burst:                        o.Burst,
+		FeatureGatesString:           o.FeatureGates,
+		APIImportPollIntervalString:  o.APIImportPollInterval.String(),
+		CapacityReportIntervalString: o.CapacityReportInterval.String(),
+	}, nil
 }
 
 // enableSyncerForWorkspace creates a sync target with the given name and creates a service
@@ -299,7 +315,7 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 		return "", "", "", fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	syncerID = getSyncerID(syncTarget)
+	syncerID = shared.GetSyncerID(syncTarget)
 
 	syncTargetOwnerReferences := []metav1.OwnerReference{{
 		APIVersion: workloadv1alpha1.SchemeGroupVersion.String(),
@@ -375,6 +391,12 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 			ResourceNames: []string{syncTargetName},
 			Resources:     []string{"synctargets/status"},
 		},
+		{
+			Verbs:         []string{"connect"},
+			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			ResourceNames: []string{syncTargetName},
+			Resources:     []string{"synctargets/tunnel"},
+		},
 		{
 			Verbs:     []string{"get", "create", "update", "delete", "list", "watch"},
 			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
@@ -469,10 +491,20 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 		return "", "", "", err
 	}
 
+	saToken, err = serviceAccountToken(ctx, kubeClient, namespace, sa.Name)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return saToken, syncerID, string(syncTarget.UID), nil
+}
+
+// serviceAccountToken returns the token of the service account, waiting for its token secret to be created.
+func serviceAccountToken(ctx context.Context, kubeClient kubernetesclient.Interface, namespace, name string) (string, error) {
 	// Wait for the service account to be updated with the name of the token secret
 	tokenSecretName := ""
-	err = wait.PollImmediateWithContext(ctx, 100*time.Millisecond, 20*time.Second, func(ctx context.Context) (bool, error) {
-		serviceAccount, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, sa.Name, metav1.GetOptions{})
+	err := wait.PollImmediateWithContext(ctx, 100*time.Millisecond, 20*time.Second, func(ctx context.Context) (bool, error) {
+		serviceAccount, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			klog.V(5).Infof("failed to retrieve ServiceAccount: %v", err)
 			return false, nil
@@ -484,20 +516,19 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 		return true, nil
 	})
 	if err != nil {
-		return "", "", "", fmt.Errorf("timed out waiting for token secret name to be set on ServiceAccount %s/%s", namespace, sa.Name)
+		return "", fmt.Errorf("timed out waiting for token secret name to be set on ServiceAccount %s/%s", namespace, name)
 	}
 
 	// Retrieve the token that the syncer will use to authenticate to kcp
 	tokenSecret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, tokenSecretName, metav1.GetOptions{})
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to retrieve Secret: %w", err)
+		return "", fmt.Errorf("failed to retrieve Secret: %w", err)
 	}
 	saTokenBytes := tokenSecret.Data["token"]
 	if len(saTokenBytes) == 0 {
-		return "", "", "", fmt.Errorf("token secret %s/%s is missing a value for `token`", namespace, tokenSecretName)
+		return "", fmt.Errorf("token secret %s/%s is missing a value for `token`", namespace, tokenSecretName)
 	}
-
-	return string(saTokenBytes), syncerID, string(syncTarget.UID), nil
+	return string(saTokenBytes), nil
 }
 
 // mergeOwnerReference: merge a slice of ownerReference with a given ownerReferences
@@ -551,6 +582,9 @@ type templateInput struct {
 	// "deployments.apps.k8s.io") that the syncer will synchronize between the kcp
 	// workspace and the pcluster.
 	ResourcesToSync []string
+	// ResourcesToUpsync is the set of qualified names of the namespaced resources (eg. ["endpoints"]) that the
+	// syncer will mirror from the pcluster to the kcp workspace.
+	ResourcesToUpsync []string
 	// Image is the name of the container image that the syncer deployment will use
 	Image string
 	// Replicas is the number of syncer pods to run (should be 0 or 1).
@@ -563,6 +597,8 @@ type templateInput struct {
 	FeatureGatesString string
 	// APIImportPollIntervalString is the string of interval to poll APIImport.
 	APIImportPollIntervalString string
+	// CapacityReportIntervalString is the string of the minimal interval between two capacity reports.
+	CapacityReportIntervalString string
 }
 
 // templateArgs represents the full set of arguments required to render the resources
@@ -578,6 +614,12 @@ type templateArgs struct {
 	// ClusterRoleBinding is the name of the cluster role binding to create for the
 	// syncer on the pcluster.
 	ClusterRoleBinding string
+	// Role is the name of the role to create for the leader election of the syncer
+	// replicas on the pcluster.
+	Role string
+	// RoleBinding is the name of the role binding to create for the leader election
+	// of the syncer replicas on the pcluster.
+	RoleBinding string
 	// GroupMappings is the mapping of api group to resources that will be used to
 	// define the cluster role rules for the syncer in the pcluster. The syncer will be
 	// granted full permissions for the resources it will synchronize.
@@ -607,6 +649,8 @@ func renderSyncerResources(input templateInput, syncerID string) ([]byte, error)
 		ServiceAccount:     syncerID,
 		ClusterRole:        syncerID,
 		ClusterRoleBinding: syncerID,
+		Role:               syncerID,
+		RoleBinding:        syncerID,
 		GroupMappings:      getGroupMappings(input.ResourcesToSync),
 		Secret:             syncerID,
 		SecretConfigKey:    SyncerSecretConfigKey,
@@ -59,6 +59,14 @@ rules:
   - "list"
   - "watch"
   - "delete"
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  - pods
+  verbs:
+  - "list"
+  - "watch"
 - apiGroups:
   - "apiextensions.k8s.io"
   resources:
@@ -88,6 +96,35 @@ subjects:
   name: kcp-syncer-sync-target-name-34b23c4k
   namespace: kcp-syncer-sync-target-name-34b23c4k
 ---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+rules:
+- apiGroups:
+  - "coordination.k8s.io"
+  resources:
+  - leases
+  verbs:
+  - "get"
+  - "create"
+  - "update"
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: kcp-syncer-sync-target-name-34b23c4k
+subjects:
+- kind: ServiceAccount
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+---
 apiVersion: v1
 kind: Secret
 metadata:
@@ -122,7 +159,7 @@ metadata:
 spec:
   replicas: 1
   strategy:
-    type: Recreate
+    type: RollingUpdate
   selector:
     matchLabels:
       app: kcp-syncer-sync-target-name-34b23c4k
@@ -131,6 +168,15 @@ spec:
       labels:
         app: kcp-syncer-sync-target-name-34b23c4k
     spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+              labelSelector:
+                matchLabels:
+                  app: kcp-syncer-sync-target-name-34b23c4k
       containers:
       - name: kcp-syncer
         command:
@@ -141,6 +187,9 @@ spec:
         - --sync-target-uid=sync-target-uid
         - --from-cluster=root:default:foo
         - --api-import-poll-interval=1m
+        - --capacity-report-interval=1m
+        - --leader-elect
+        - --leader-elect-resource-namespace=kcp-syncer-sync-target-name-34b23c4k
         - --resources=resource1
         - --resources=resource2
         - --qps=123.4
@@ -160,20 +209,21 @@ spec:
             optional: false
 `
 	actualYAML, err := renderSyncerResources(templateInput{
-		ServerURL:                   "server-url",
-		Token:                       "token",
-		CAData:                      "ca-data",
-		KCPNamespace:                "kcp-namespace",
-		Namespace:                   "kcp-syncer-sync-target-name-34b23c4k",
-		LogicalCluster:              "root:default:foo",
-		SyncTarget:                  "sync-target-name",
-		SyncTargetUID:               "sync-target-uid",
-		Image:                       "image",
-		Replicas:                    1,
-		ResourcesToSync:             []string{"resource1", "resource2"},
-		APIImportPollIntervalString: "1m",
-		QPS:                         123.4,
-		Burst:                       456,
+		ServerURL:                    "server-url",
+		Token:                        "token",
+		CAData:                       "ca-data",
+		KCPNamespace:                 "kcp-namespace",
+		Namespace:                    "kcp-syncer-sync-target-name-34b23c4k",
+		LogicalCluster:               "root:default:foo",
+		SyncTarget:                   "sync-target-name",
+		SyncTargetUID:                "sync-target-uid",
+		Image:                        "image",
+		Replicas:                     1,
+		ResourcesToSync:              []string{"resource1", "resource2"},
+		APIImportPollIntervalString:  "1m",
+		CapacityReportIntervalString: "1m",
+		QPS:                          123.4,
+		Burst:                        456,
 	}, "kcp-syncer-sync-target-name-34b23c4k")
 	require.NoError(t, err)
 	require.Empty(t, cmp.Diff(expectedYAML, string(actualYAML)))
@@ -215,6 +265,14 @@ rules:
   - "list"
   - "watch"
   - "delete"
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  - pods
+  verbs:
+  - "list"
+  - "watch"
 - apiGroups:
   - "apiextensions.k8s.io"
   resources:
@@ -244,6 +302,35 @@ subjects:
   name: kcp-syncer-sync-target-name-34b23c4k
   namespace: kcp-syncer-sync-target-name-34b23c4k
 ---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+rules:
+- apiGroups:
+  - "coordination.k8s.io"
+  resources:
+  - leases
+  verbs:
+  - "get"
+  - "create"
+  - "update"
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: kcp-syncer-sync-target-name-34b23c4k
+subjects:
+- kind: ServiceAccount
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+---
 apiVersion: v1
 kind: Secret
 metadata:
@@ -278,7 +365,7 @@ metadata:
 spec:
   replicas: 1
   strategy:
-    type: Recreate
+    type: RollingUpdate
   selector:
     matchLabels:
       app: kcp-syncer-sync-target-name-34b23c4k
@@ -287,6 +374,15 @@ spec:
       labels:
         app: kcp-syncer-sync-target-name-34b23c4k
     spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+              labelSelector:
+                matchLabels:
+                  app: kcp-syncer-sync-target-name-34b23c4k
       containers:
       - name: kcp-syncer
         command:
@@ -297,6 +393,9 @@ spec:
         - --sync-target-uid=sync-target-uid
         - --from-cluster=root:default:foo
         - --api-import-poll-interval=1m
+        - --capacity-report-interval=1m
+        - --leader-elect
+        - --leader-elect-resource-namespace=kcp-syncer-sync-target-name-34b23c4k
         - --resources=resource1
         - --resources=resource2
         - --qps=123.4
@@ -317,21 +416,22 @@ spec:
             optional: false
 `
 	actualYAML, err := renderSyncerResources(templateInput{
-		ServerURL:                   "server-url",
-		Token:                       "token",
-		CAData:                      "ca-data",
-		KCPNamespace:                "kcp-namespace",
-		Namespace:                   "kcp-syncer-sync-target-name-34b23c4k",
-		LogicalCluster:              "root:default:foo",
-		SyncTarget:                  "sync-target-name",
-		SyncTargetUID:               "sync-target-uid",
-		Image:                       "image",
-		Replicas:                    1,
-		ResourcesToSync:             []string{"resource1", "resource2"},
-		QPS:                         123.4,
-		Burst:                       456,
-		APIImportPollIntervalString: "1m",
-		FeatureGatesString:          "myfeature=true",
+		ServerURL:                    "server-url",
+		Token:                        "token",
+		CAData:                       "ca-data",
+		KCPNamespace:                 "kcp-namespace",
+		Namespace:                    "kcp-syncer-sync-target-name-34b23c4k",
+		LogicalCluster:               "root:default:foo",
+		SyncTarget:                   "sync-target-name",
+		SyncTargetUID:                "sync-target-uid",
+		Image:                        "image",
+		Replicas:                     1,
+		ResourcesToSync:              []string{"resource1", "resource2"},
+		QPS:                          123.4,
+		Burst:                        456,
+		APIImportPollIntervalString:  "1m",
+		CapacityReportIntervalString: "1m",
+		FeatureGatesString:           "myfeature=true",
 	}, "kcp-syncer-sync-target-name-34b23c4k")
 	require.NoError(t, err)
 	require.Empty(t, cmp.Diff(expectedYAML, string(actualYAML)))
@@ -409,3 +509,30 @@ func TestGetGroupMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSyncerYAMLWithUpsyncedResources(t *testing.T) {
+	actualYAML, err := renderSyncerResources(templateInput{
+		ServerURL:                    "server-url",
+		Token:                        "token",
+		CAData:                       "ca-data",
+		KCPNamespace:                 "kcp-namespace",
+		Namespace:                    "kcp-syncer-sync-target-name-34b23c4k",
+		LogicalCluster:               "root:default:foo",
+		SyncTarget:                   "sync-target-name",
+		SyncTargetUID:                "sync-target-uid",
+		Image:                        "image",
+		Replicas:                     1,
+		ResourcesToSync:              []string{"endpoints", "resource1"},
+		ResourcesToUpsync:            []string{"endpoints"},
+		APIImportPollIntervalString:  "1m",
+		CapacityReportIntervalString: "1m",
+		QPS:                          123.4,
+		Burst:                        456,
+	}, "kcp-syncer-sync-target-name-34b23c4k")
+	require.NoError(t, err)
+	require.Contains(t, string(actualYAML), `
+        - --resources=endpoints
+        - --resources=resource1
+        - --upsync-resources=endpoints
+`)
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// upgradeFieldManager is the field manager of the resources applied to the physical cluster.
+const upgradeFieldManager = "kubectl-kcp"
+
+// UpgradeOptions contains options for upgrading the syncer of an existing SyncTarget.
+type UpgradeOptions struct {
+	*SyncOptions
+
+	// ToKubeconfig is the kubeconfig file of the physical cluster. If set, the manifest is applied to it.
+	ToKubeconfig string
+	// ToContext is the context in ToKubeconfig to use, instead of the current context.
+	ToContext string
+}
+
+// NewUpgradeOptions returns a new UpgradeOptions.
+func NewUpgradeOptions(streams genericclioptions.IOStreams) *UpgradeOptions {
+	return &UpgradeOptions{
+		SyncOptions: NewSyncOptions(streams),
+	}
+}
+
+// BindFlags binds fields UpgradeOptions as command line flags to cmd's flagset.
+func (o *UpgradeOptions) BindFlags(cmd *cobra.Command) {
+	o.SyncOptions.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.ToKubeconfig, "to-kubeconfig", o.ToKubeconfig, "Kubeconfig file of the physical cluster to apply the manifest to.")
+	cmd.Flags().StringVar(&o.ToContext, "to-context", o.ToContext, "Context to use in the kubeconfig file of the physical cluster, instead of the current context.")
+}
+
+// Validate validates the UpgradeOptions are complete and usable.
+func (o *UpgradeOptions) Validate() error {
+	errs := o.validateSyncer()
+
+	if o.OutputFile == "" && o.ToKubeconfig == "" {
+		errs = append(errs, errors.New("--output-file or --to-kubeconfig is required"))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run regenerates the manifest of the syncer of an existing SyncTarget with the given options, e.g. a new
+// image, and writes it to the output file or applies it to the physical cluster. The service account of the
+// syncer in kcp, and so its token, is kept.
+func (o *UpgradeOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	kcpClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kcp client: %w", err)
+	}
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, o.SyncTargetName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("synctarget %q not found, use \"kubectl kcp workload sync\" to create it", o.SyncTargetName)
+	} else if err != nil {
+		return fmt.Errorf("failed to get synctarget %q: %w", o.SyncTargetName, err)
+	}
+
+	kubeClient, err := kubernetesclient.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	syncerID := shared.GetSyncerID(syncTarget)
+	token, err := serviceAccountToken(ctx, kubeClient, o.KCPNamespace, syncerID)
+	if err != nil {
+		return fmt.Errorf("failed to get the token of the syncer of synctarget %q, use \"kubectl kcp workload sync\" to create it: %w", o.SyncTargetName, err)
+	}
+
+	resourcesToSync := o.ResourcesToSync
+	if len(resourcesToSync) == 0 {
+		resourcesToSync = syncedResources(syncTarget)
+	}
+	input, err := o.newTemplateInput(config, token, syncerID, string(syncTarget.UID), sets.NewString(resourcesToSync...).Union(requiredResourcesToSync).List())
+	if err != nil {
+		return err
+	}
+	resources, err := renderSyncerResources(input, syncerID)
+	if err != nil {
+		return err
+	}
+
+	if syncTarget.Status.SyncerVersion != "" {
+		fmt.Fprintf(o.ErrOut, "Upgrading syncer %q from version %s to image %s.\n", syncerID, syncTarget.Status.SyncerVersion, o.SyncerImage)
+	}
+	if supported := syncTarget.Status.SupportedSyncerVersions; supported != nil {
+		fmt.Fprintf(o.ErrOut, "kcp supports syncer versions %s to %s.\n", supported.Min, supported.Max)
+	}
+
+	if o.OutputFile != "" {
+		if err := writeOutputFile(o.OutputFile, resources); err != nil {
+			return err
+		}
+		if o.OutputFile != "-" {
+			fmt.Fprintf(o.ErrOut, "Wrote physical cluster manifest to %s for namespace %q.\n", o.OutputFile, o.DownstreamNamespace)
+		}
+	}
+
+	if o.ToKubeconfig != "" {
+		downstreamConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: o.ToKubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: o.ToContext},
+		).ClientConfig()
+		if err != nil {
+			return err
+		}
+		if err := applyManifest(ctx, downstreamConfig, resources, o.ErrOut); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "Applied the manifest to the physical cluster. Use\n\n  KUBECONFIG=%s kubectl rollout status deployment -n %q %s\n\nto wait for the upgraded syncer pod to be running.\n", o.ToKubeconfig, o.DownstreamNamespace, syncerID)
+	}
+
+	return nil
+}
+
+// syncedResources returns the resources synced to the SyncTarget, in the format of the --resources flag.
+func syncedResources(syncTarget *workloadv1alpha1.SyncTarget) []string {
+	var resources []string
+	for _, r := range syncTarget.Status.SyncedResources {
+		name := r.Resource
+		if r.Group != "" {
+			name += "." + r.Group
+		}
+		resources = append(resources, name)
+	}
+	return resources
+}
+
+func writeOutputFile(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// applyManifest applies the resources of the YAML manifest with server-side apply, in order.
+func applyManifest(ctx context.Context, config *rest.Config, manifest []byte, out io.Writer) error {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	for {
+		var obj unstructured.Unstructured
+		if err := decoder.Decode(&obj.Object); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to map %s: %w", gvk, err)
+		}
+		var client dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			client = dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+		}
+
+		data, err := json.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+		force := true
+		if _, err := client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: upgradeFieldManager, Force: &force}); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", mapping.Resource.Resource, obj.GetName(), err)
+		}
+		fmt.Fprintf(out, "Applied %s %q.\n", mapping.Resource.Resource, obj.GetName())
+	}
+}
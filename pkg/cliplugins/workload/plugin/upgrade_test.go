@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSyncedResources(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		Status: workloadv1alpha1.SyncTargetStatus{
+			SyncedResources: []workloadv1alpha1.ResourceToSync{
+				{GroupResource: apisv1alpha1.GroupResource{Resource: "services"}, Versions: []string{"v1"}},
+				{GroupResource: apisv1alpha1.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, Versions: []string{"v1"}},
+			},
+		},
+	}
+	require.Equal(t, []string{"services", "ingresses.networking.k8s.io"}, syncedResources(syncTarget))
+}
+
+func TestUpgradeOptionsValidate(t *testing.T) {
+	tests := map[string]struct {
+		outputFile   string
+		toKubeconfig string
+		wantErr      bool
+	}{
+		"output file":         {outputFile: "syncer.yaml"},
+		"physical cluster":    {toKubeconfig: "pcluster.kubeconfig"},
+		"no output file":      {wantErr: true},
+		"output and physical": {outputFile: "-", toKubeconfig: "pcluster.kubeconfig"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := NewUpgradeOptions(genericclioptions.IOStreams{})
+			o.SyncTargetName = "us-west1"
+			o.SyncerImage = "ghcr.io/kcp-dev/kcp/syncer:v0.8.0"
+			o.OutputFile = tc.outputFile
+			o.ToKubeconfig = tc.toKubeconfig
+			err := o.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
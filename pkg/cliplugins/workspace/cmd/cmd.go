@@ -61,6 +61,16 @@ var (
 
 	# create a context with the current workspace, named context-name
 	%[1]s workspace create-context context-name
+
+	# export the content of a child workspace and of its child workspaces into a directory
+	%[1]s workspace export my-workspace -o my-workspace/ --recursive
+
+	# import the exported content into a new workspace
+	%[1]s workspace create my-copy
+	%[1]s workspace import my-copy -f my-workspace/ --recursive
+
+	# undelete a deleted workspace while its content is retained
+	%[1]s workspace undelete my-workspace
 `
 )
 
@@ -75,7 +85,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 	cmd := &cobra.Command{
 		Aliases:          []string{"ws", "workspaces"},
-		Use:              "workspace [create|create-context|use|current|<workspace>|..|.|-|~|<root:absolute:workspace>]",
+		Use:              "workspace [create|create-context|use|current|export|import|undelete|<workspace>|..|.|-|~|<root:absolute:workspace>]",
 		Short:            "Manages KCP workspaces",
 		Example:          fmt.Sprintf(workspaceExample, cliName),
 		SilenceUsage:     true,
@@ -200,10 +210,70 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	treeCmdOpts.BindFlags(treeCmd)
 
+	exportWorkspaceOpts := plugin.NewExportWorkspaceOptions(streams)
+	exportCmd := &cobra.Command{
+		Use:          "export <workspace> -o <dir> [--recursive]",
+		Short:        "Export the content of a workspace into a directory",
+		Example:      "kcp workspace export my-workspace -o my-workspace/",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := exportWorkspaceOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := exportWorkspaceOpts.Validate(); err != nil {
+				return err
+			}
+			return exportWorkspaceOpts.Run(c.Context())
+		},
+	}
+	exportWorkspaceOpts.BindFlags(exportCmd)
+
+	importWorkspaceOpts := plugin.NewImportWorkspaceOptions(streams)
+	importCmd := &cobra.Command{
+		Use:          "import <workspace> -f <dir> [--recursive]",
+		Short:        "Import exported content into a new or empty workspace",
+		Example:      "kcp workspace import my-copy -f my-workspace/",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := importWorkspaceOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := importWorkspaceOpts.Validate(); err != nil {
+				return err
+			}
+			return importWorkspaceOpts.Run(c.Context())
+		},
+	}
+	importWorkspaceOpts.BindFlags(importCmd)
+
+	undeleteWorkspaceOpts := plugin.NewUndeleteWorkspaceOptions(streams)
+	undeleteCmd := &cobra.Command{
+		Use:          "undelete <workspace>",
+		Short:        "Undelete a deleted workspace while its content is retained",
+		Example:      "kcp workspace undelete my-workspace",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := undeleteWorkspaceOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := undeleteWorkspaceOpts.Validate(); err != nil {
+				return err
+			}
+			return undeleteWorkspaceOpts.Run(c.Context())
+		},
+	}
+	undeleteWorkspaceOpts.BindFlags(undeleteCmd)
+
 	cmd.AddCommand(useCmd)
 	cmd.AddCommand(treeCmd)
 	cmd.AddCommand(currentCmd)
 	cmd.AddCommand(createCmd)
 	cmd.AddCommand(createContextCmd)
+	cmd.AddCommand(exportCmd)
+	cmd.AddCommand(importCmd)
+	cmd.AddCommand(undeleteCmd)
 	return cmd, nil
 }
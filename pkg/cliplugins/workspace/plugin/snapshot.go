@@ -0,0 +1,460 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	"github.com/kcp-dev/kcp/pkg/content"
+)
+
+const (
+	// snapshotPath is the non-resource path kcp serves snapshots of a workspace under.
+	snapshotPath = "/snapshot"
+
+	// childWorkspacesDir is the directory of a snapshot holding the snapshots of child workspaces.
+	childWorkspacesDir = "workspaces"
+
+	// snapshotChunkBytes is the maximal encoded size of the objects imported in one request, well below
+	// the request body limit of the server.
+	snapshotChunkBytes = 1 << 20
+)
+
+// ExportWorkspaceOptions contains options for exporting the content of a workspace into a directory.
+type ExportWorkspaceOptions struct {
+	*base.Options
+
+	// Name is the workspace to export, relative to the current workspace, or absolute.
+	Name string
+	// Directory is the directory to write the snapshot to. It must not exist or be empty.
+	Directory string
+	// Recursive exports child workspaces too.
+	Recursive bool
+
+	config *rest.Config
+}
+
+// NewExportWorkspaceOptions returns a new ExportWorkspaceOptions.
+func NewExportWorkspaceOptions(streams genericclioptions.IOStreams) *ExportWorkspaceOptions {
+	return &ExportWorkspaceOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *ExportWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	cmd.Flags().StringVarP(&o.Directory, "output-dir", "o", o.Directory, "The directory to write the snapshot to. It must not exist or be empty.")
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "r", o.Recursive, "Export child workspaces too")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *ExportWorkspaceOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	var err error
+	o.config, err = o.ClientConfig.ClientConfig()
+	return err
+}
+
+// Validate validates the ExportWorkspaceOptions are complete and usable.
+func (o *ExportWorkspaceOptions) Validate() error {
+	if o.Directory == "" {
+		return errors.New("--output-dir is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run exports the workspace.
+func (o *ExportWorkspaceOptions) Run(ctx context.Context) error {
+	cluster, err := resolveWorkspace(o.config.Host, o.Name)
+	if err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(o.Directory); err == nil && len(entries) > 0 {
+		return fmt.Errorf("directory %q is not empty", o.Directory)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return o.export(ctx, cluster, o.Directory)
+}
+
+func (o *ExportWorkspaceOptions) export(ctx context.Context, cluster logicalcluster.Name, dir string) error {
+	client, err := snapshotClient(o.config, cluster)
+	if err != nil {
+		return err
+	}
+	data, err := client.Get().AbsPath(snapshotPath).DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to export workspace %q: %w", cluster, err)
+	}
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("failed to decode snapshot of workspace %q: %w", cluster, err)
+	}
+	if err := writeSnapshot(dir, list.Items); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(o.Out, "Exported %d objects of workspace %q to %q.\n", len(list.Items), cluster, dir); err != nil {
+		return err
+	}
+
+	if !o.Recursive {
+		return nil
+	}
+	for _, obj := range list.Items {
+		if !isClusterWorkspace(&obj) {
+			continue
+		}
+		if err := o.export(ctx, cluster.Join(obj.GetName()), filepath.Join(dir, childWorkspacesDir, obj.GetName())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportWorkspaceOptions contains options for importing a snapshot into a workspace.
+type ImportWorkspaceOptions struct {
+	*base.Options
+
+	// Name is the workspace to import into, relative to the current workspace, or absolute.
+	Name string
+	// Directory is the directory to read the snapshot from.
+	Directory string
+	// Recursive imports the snapshots of child workspaces too.
+	Recursive bool
+	// Timeout is how long to retry importing a workspace, and to wait for child workspaces to be ready.
+	Timeout time.Duration
+
+	config           *rest.Config
+	kcpClusterClient kcpclient.ClusterInterface
+}
+
+// NewImportWorkspaceOptions returns a new ImportWorkspaceOptions.
+func NewImportWorkspaceOptions(streams genericclioptions.IOStreams) *ImportWorkspaceOptions {
+	return &ImportWorkspaceOptions{
+		Options: base.NewOptions(streams),
+
+		Timeout: 2 * time.Minute,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *ImportWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	cmd.Flags().StringVarP(&o.Directory, "filename", "f", o.Directory, "The directory to read the snapshot from.")
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "r", o.Recursive, "Import the snapshots of child workspaces too")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "How long to retry the import of a workspace, e.g. while APIBindings are being bound")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *ImportWorkspaceOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	var err error
+	o.config, err = o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.kcpClusterClient, err = newKCPClusterClient(o.ClientConfig)
+	return err
+}
+
+// Validate validates the ImportWorkspaceOptions are complete and usable.
+func (o *ImportWorkspaceOptions) Validate() error {
+	if o.Directory == "" {
+		return errors.New("--filename is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run imports the snapshot.
+func (o *ImportWorkspaceOptions) Run(ctx context.Context) error {
+	cluster, err := resolveWorkspace(o.config.Host, o.Name)
+	if err != nil {
+		return err
+	}
+	return o.importSnapshot(ctx, cluster, o.Directory)
+}
+
+func (o *ImportWorkspaceOptions) importSnapshot(ctx context.Context, cluster logicalcluster.Name, dir string) error {
+	objects, err := readSnapshot(dir)
+	if err != nil {
+		return err
+	}
+	// the request body size of the server is limited. Large snapshots are imported in chunks, in the
+	// order the server restores them in, such that dependencies are imported first.
+	content.SortForRestore(objects)
+
+	client, err := snapshotClient(o.config, cluster)
+	if err != nil {
+		return err
+	}
+	chunks, err := chunkSnapshot(objects, snapshotChunkBytes)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err := o.postSnapshot(ctx, client, chunk); err != nil {
+			return fmt.Errorf("failed to import into workspace %q: %w", cluster, err)
+		}
+	}
+	if len(chunks) > 1 {
+		// owners imported in later chunks did not exist when their dependents were imported. Importing the
+		// dependents again sets their owner references.
+		var owned []unstructured.Unstructured
+		for _, obj := range objects {
+			if len(obj.GetOwnerReferences()) > 0 {
+				owned = append(owned, obj)
+			}
+		}
+		ownedChunks, err := chunkSnapshot(owned, snapshotChunkBytes)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range ownedChunks {
+			if err := o.postSnapshot(ctx, client, chunk); err != nil {
+				return fmt.Errorf("failed to set owner references in workspace %q: %w", cluster, err)
+			}
+		}
+	}
+	if _, err := fmt.Fprintf(o.Out, "Imported %d objects from %q into workspace %q.\n", len(objects), dir, cluster); err != nil {
+		return err
+	}
+
+	if !o.Recursive {
+		return nil
+	}
+	for _, obj := range objects {
+		if !isClusterWorkspace(&obj) {
+			continue
+		}
+		childDir := filepath.Join(dir, childWorkspacesDir, obj.GetName())
+		if _, err := os.Stat(childDir); errors.Is(err, fs.ErrNotExist) {
+			continue // exported non-recursively
+		}
+		if err := o.waitForReady(ctx, cluster, obj.GetName()); err != nil {
+			return err
+		}
+		if err := o.importSnapshot(ctx, cluster.Join(obj.GetName()), childDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postSnapshot restores the given objects through the snapshot endpoint.
+func (o *ImportWorkspaceOptions) postSnapshot(ctx context.Context, client rest.Interface, objects []unstructured.Unstructured) error {
+	list := &unstructured.UnstructuredList{Items: objects}
+	list.SetAPIVersion("v1")
+	list.SetKind("List")
+	data, err := list.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	// the resources of restored CRDs and APIBindings become available asynchronously. The server
+	// signals that with an internal error, and a retry restores the remaining objects.
+	var lastErr error
+	if err := wait.PollImmediateWithContext(ctx, time.Second, o.Timeout, func(ctx context.Context) (bool, error) {
+		lastErr = client.Post().AbsPath(snapshotPath).SetHeader("Content-Type", "application/json").Body(data).Do(ctx).Error()
+		if apierrors.IsInternalError(lastErr) {
+			return false, nil
+		}
+		return lastErr == nil, lastErr
+	}); err != nil {
+		if lastErr != nil {
+			return lastErr
+		}
+		return err
+	}
+	return nil
+}
+
+func (o *ImportWorkspaceOptions) waitForReady(ctx context.Context, parent logicalcluster.Name, name string) error {
+	if _, err := fmt.Fprintf(o.Out, "Waiting for workspace %q to be ready...\n", parent.Join(name)); err != nil {
+		return err
+	}
+	return wait.PollImmediateWithContext(ctx, time.Millisecond*500, o.Timeout, func(ctx context.Context) (bool, error) {
+		ws, err := o.kcpClusterClient.Cluster(parent).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return ws.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady, nil
+	})
+}
+
+// resolveWorkspace returns the logical cluster of the given workspace, which is either absolute, or relative to
+// the workspace of host. An empty name or "." means the workspace of host.
+func resolveWorkspace(host, name string) (logicalcluster.Name, error) {
+	_, current, err := pluginhelpers.ParseClusterURL(host)
+	if err != nil {
+		return logicalcluster.Name{}, fmt.Errorf("current URL %q does not point to workspace", host)
+	}
+	switch {
+	case name == "" || name == ".":
+		return current, nil
+	case logicalcluster.New(name).HasPrefix(tenancyv1alpha1.RootCluster):
+		return logicalcluster.New(name), nil
+	default:
+		return current.Join(name), nil
+	}
+}
+
+// snapshotClient returns a client for requests to the given workspace.
+func snapshotClient(config *rest.Config, cluster logicalcluster.Name) (rest.Interface, error) {
+	u, _, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("current URL %q does not point to workspace", config.Host)
+	}
+	config = rest.CopyConfig(config)
+	u.Path = cluster.Path()
+	config.Host = u.String()
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return client.RESTClient(), nil
+}
+
+func isClusterWorkspace(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind().GroupKind() == tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace").GroupKind()
+}
+
+// snapshotFile returns the path of the object relative to the snapshot directory, i.e.
+// cluster/<kind>[.<group>]/<name>.yaml or namespaces/<namespace>/<kind>[.<group>]/<name>.yaml.
+func snapshotFile(obj *unstructured.Unstructured) string {
+	kind := strings.ToLower(obj.GroupVersionKind().GroupKind().String())
+	if ns := obj.GetNamespace(); ns != "" {
+		return filepath.Join("namespaces", ns, kind, obj.GetName()+".yaml")
+	}
+	return filepath.Join("cluster", kind, obj.GetName()+".yaml")
+}
+
+// writeSnapshot writes every object into its own file below dir.
+func writeSnapshot(dir string, objects []unstructured.Unstructured) error {
+	for i := range objects {
+		obj := &objects[i]
+		file := filepath.Join(dir, snapshotFile(obj))
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkSnapshot splits the objects into chunks of at most maxBytes encoded, keeping their order. Objects
+// larger than maxBytes are put into a chunk of their own.
+func chunkSnapshot(objects []unstructured.Unstructured, maxBytes int) ([][]unstructured.Unstructured, error) {
+	var chunks [][]unstructured.Unstructured
+	var chunk []unstructured.Unstructured
+	size := 0
+	for _, obj := range objects {
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		if len(chunk) > 0 && size+len(data) > maxBytes {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, obj)
+		size += len(data)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// readSnapshot reads the objects written by writeSnapshot, excluding those of child workspaces.
+func readSnapshot(dir string) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+	if err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if file == filepath.Join(dir, childWorkspacesDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(file) != ".yaml" {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		obj := unstructured.Unstructured{}
+		if err := yaml.Unmarshal(data, &obj.Object); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		objects = append(objects, obj)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no snapshot found in %q", dir)
+	}
+	return objects, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResolveWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		want logicalcluster.Name
+	}{
+		"current":  {name: ".", want: logicalcluster.New("root:org")},
+		"empty":    {name: "", want: logicalcluster.New("root:org")},
+		"relative": {name: "team", want: logicalcluster.New("root:org:team")},
+		"absolute": {name: "root:other:team", want: logicalcluster.New("root:other:team")},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveWorkspace("https://test/clusters/root:org", tt.name)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := resolveWorkspace("https://test", "team")
+	require.Error(t, err, "the current URL must point to a workspace")
+}
+
+func TestWriteReadSnapshot(t *testing.T) {
+	object := func(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+	objects := []unstructured.Unstructured{
+		object("v1", "Namespace", "", "default"),
+		object("v1", "ConfigMap", "default", "cm"),
+		object("tenancy.kcp.dev/v1alpha1", "ClusterWorkspace", "", "child"),
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, writeSnapshot(dir, objects))
+	for _, file := range []string{
+		"cluster/namespace/default.yaml",
+		"namespaces/default/configmap/cm.yaml",
+		"cluster/clusterworkspace.tenancy.kcp.dev/child.yaml",
+	} {
+		require.FileExists(t, filepath.Join(dir, file))
+	}
+
+	// snapshots of child workspaces are not part of the parent snapshot
+	require.NoError(t, writeSnapshot(filepath.Join(dir, childWorkspacesDir, "child"), []unstructured.Unstructured{object("v1", "ConfigMap", "default", "nested")}))
+
+	read, err := readSnapshot(dir)
+	require.NoError(t, err)
+	require.ElementsMatch(t, objects, read)
+
+	_, err = readSnapshot(filepath.Join(dir, "cluster", "unknown"))
+	require.Error(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "empty"), 0755))
+	_, err = readSnapshot(filepath.Join(dir, "empty"))
+	require.ErrorContains(t, err, "no snapshot found")
+}
+
+func TestChunkSnapshot(t *testing.T) {
+	configMap := func(name string, size int) unstructured.Unstructured {
+		obj := unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetName(name)
+		require.NoError(t, unstructured.SetNestedField(obj.Object, strings.Repeat("x", size), "data", "value"))
+		return obj
+	}
+	names := func(chunks [][]unstructured.Unstructured) [][]string {
+		var ret [][]string
+		for _, chunk := range chunks {
+			var chunkNames []string
+			for _, obj := range chunk {
+				chunkNames = append(chunkNames, obj.GetName())
+			}
+			ret = append(ret, chunkNames)
+		}
+		return ret
+	}
+
+	chunks, err := chunkSnapshot(nil, 1000)
+	require.NoError(t, err)
+	require.Empty(t, chunks)
+
+	chunks, err = chunkSnapshot([]unstructured.Unstructured{configMap("a", 100), configMap("b", 100), configMap("c", 100)}, 1000)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"a", "b", "c"}}, names(chunks))
+
+	chunks, err = chunkSnapshot([]unstructured.Unstructured{configMap("a", 400), configMap("b", 400), configMap("c", 400), configMap("d", 2000), configMap("e", 100)}, 1000)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"a", "b"}, {"c"}, {"d"}, {"e"}}, names(chunks))
+}
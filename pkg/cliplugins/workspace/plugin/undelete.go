@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// UndeleteWorkspaceOptions contains options for undeleting a workspace whose content is retained.
+type UndeleteWorkspaceOptions struct {
+	*base.Options
+
+	// Name is the workspace to undelete, relative to the current workspace, or absolute.
+	Name string
+	// Timeout is how long to wait for the undeleted workspace to be ready.
+	Timeout time.Duration
+
+	config           *rest.Config
+	kcpClusterClient kcpclient.ClusterInterface
+}
+
+// NewUndeleteWorkspaceOptions returns a new UndeleteWorkspaceOptions.
+func NewUndeleteWorkspaceOptions(streams genericclioptions.IOStreams) *UndeleteWorkspaceOptions {
+	return &UndeleteWorkspaceOptions{
+		Options: base.NewOptions(streams),
+
+		Timeout: 2 * time.Minute,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *UndeleteWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "How long to wait for the undeleted workspace to be ready")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *UndeleteWorkspaceOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	var err error
+	o.config, err = o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.kcpClusterClient, err = newKCPClusterClient(o.ClientConfig)
+	return err
+}
+
+// Validate validates the UndeleteWorkspaceOptions are complete and usable.
+func (o *UndeleteWorkspaceOptions) Validate() error {
+	if o.Name == "" || o.Name == "." {
+		return fmt.Errorf("a workspace name is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run undeletes the workspace.
+func (o *UndeleteWorkspaceOptions) Run(ctx context.Context) error {
+	cluster, err := resolveWorkspace(o.config.Host, o.Name)
+	if err != nil {
+		return err
+	}
+	parent, hasParent := cluster.Parent()
+	if !hasParent {
+		return fmt.Errorf("workspace %q cannot be undeleted", cluster)
+	}
+	name := cluster.Base()
+	client := o.kcpClusterClient.Cluster(parent).TenancyV1alpha1().ClusterWorkspaces()
+
+	ws, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("workspace %q not found, its retention period might have expired", cluster)
+	} else if err != nil {
+		return err
+	}
+	if ws.DeletionTimestamp == nil {
+		return fmt.Errorf("workspace %q is not deleted", cluster)
+	}
+	if !helper.IsRetained(ws) {
+		return fmt.Errorf("the content of workspace %q is not retained", cluster)
+	}
+
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, tenancyv1alpha1.ExperimentalClusterWorkspaceUndeleteAnnotationKey)
+	if _, err := client.Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(o.Out, "Waiting for workspace %q to be undeleted...\n", cluster); err != nil {
+		return err
+	}
+
+	// the deleted ClusterWorkspace is replaced by a new one with the same name.
+	if err := wait.PollImmediateWithContext(ctx, time.Millisecond*500, o.Timeout, func(ctx context.Context) (bool, error) {
+		ws, err := client.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return ws.DeletionTimestamp == nil && ws.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady, nil
+	}); err != nil {
+		return fmt.Errorf("workspace %q was not undeleted: %w", cluster, err)
+	}
+
+	_, err = fmt.Fprintf(o.Out, "Workspace %q undeleted.\n", cluster)
+	return err
+}
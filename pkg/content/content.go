@@ -0,0 +1,402 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/projection"
+)
+
+// RestoringAnnotationKey is set on copied objects until their status is restored, because the status is ignored
+// on creation. Controllers acting on the status of new objects, like the scheduler of ClusterWorkspaces, must
+// leave objects with this annotation alone.
+const RestoringAnnotationKey = "internal.kcp.dev/restoring"
+
+// Client gives access to the content of one logical cluster on one shard.
+type Client struct {
+	// Dynamic is a client for the logical cluster.
+	Dynamic dynamic.Interface
+	// DiscoverResources returns the preferred resources served in the logical cluster.
+	DiscoverResources func() ([]*metav1.APIResourceList, error)
+}
+
+// resourcesFirst are the resources copied before all others, in this order, because other objects
+// depend on them: namespaces hold the namespaced objects, and CRDs and APIBindings make the custom
+// resources available.
+var resourcesFirst = []schema.GroupResource{
+	{Group: "", Resource: "namespaces"},
+	{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+	{Group: "apis.kcp.dev", Resource: "apibindings"},
+}
+
+type copiedObject struct {
+	gvr    schema.GroupVersionResource
+	source *unstructured.Unstructured
+}
+
+// Copy copies all objects of the source logical cluster to the target logical cluster, including their
+// status. Objects which already exist in the target are updated, i.e. Copy can be called repeatedly until
+// it succeeds. As objects get new UIDs in the target, owner references are remapped to the UIDs of the
+// copied owners in a second pass.
+//
+// It returns the number of copied objects.
+func Copy(ctx context.Context, source, target *Client) (int, error) {
+	logger := klog.FromContext(ctx).WithValues("operation", "copy")
+
+	gvrs, err := discoverResources(source, "list", "get", "create", "update")
+	if err != nil {
+		return 0, err
+	}
+
+	var copied []copiedObject
+	uids := map[types.UID]types.UID{}
+	var errs []error
+	for _, gvr := range gvrs {
+		list, err := source.Dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", gvr, err))
+			continue
+		}
+		logger.V(4).Info("copying objects", "gvr", gvr.String(), "count", len(list.Items))
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if obj.GetDeletionTimestamp() != nil {
+				// going away, and would be stuck on its finalizers in the target
+				continue
+			}
+			uid, err := copyObject(ctx, target.Dynamic.Resource(gvr).Namespace(obj.GetNamespace()), obj)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to copy %s %s: %w", gvr, key(obj), err))
+				continue
+			}
+			uids[obj.GetUID()] = uid
+			copied = append(copied, copiedObject{gvr: gvr, source: obj})
+		}
+	}
+
+	// owner references must only be set when the owners exist, otherwise the garbage collector
+	// of the target would delete the objects.
+	for _, c := range copied {
+		if len(c.source.GetOwnerReferences()) == 0 {
+			continue
+		}
+		if err := remapOwnerReferences(ctx, target.Dynamic.Resource(c.gvr).Namespace(c.source.GetNamespace()), c.source, ownersByUID(uids)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set owner references of %s %s: %w", c.gvr, key(c.source), err))
+		}
+	}
+
+	return len(copied), utilerrors.NewAggregate(errs)
+}
+
+// uidReferencedResources are the resources whose object UIDs are referenced outside of kcp: the UID of a
+// SyncTarget is configured in its syncer and part of the names of the downstream namespaces, and the UID of
+// a ServiceAccount is bound into its tokens.
+var uidReferencedResources = []schema.GroupVersionResource{
+	{Group: "workload.kcp.dev", Version: "v1alpha1", Resource: "synctargets"},
+	{Group: "", Version: "v1", Resource: "serviceaccounts"},
+}
+
+// UIDReferenced returns the objects of the logical cluster whose UIDs are referenced outside of kcp, as
+// "<resource> <namespace>/<name>". Their copies made by Copy get new UIDs, which breaks these references.
+func UIDReferenced(ctx context.Context, c *Client) ([]string, error) {
+	var referenced []string
+	for _, gvr := range uidReferencedResources {
+		list, err := c.Dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			// resource not served in the logical cluster
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvr, err)
+		}
+		for i := range list.Items {
+			referenced = append(referenced, gvr.GroupResource().String()+" "+key(&list.Items[i]))
+		}
+	}
+	return referenced, nil
+}
+
+// copyObject creates or updates the given object in the target, and returns the UID of the target object.
+// Owner references are kept as they are in the target. Objects with status carry the RestoringAnnotationKey
+// annotation until their status is restored.
+func copyObject(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured) (types.UID, error) {
+	desired := WithoutServerFields(obj)
+	desired.SetOwnerReferences(nil)
+	_, hasStatus, err := unstructured.NestedFieldNoCopy(desired.Object, "status")
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		creating := desired
+		if hasStatus {
+			creating = withRestoringAnnotation(desired)
+		}
+		created, err := client.Create(ctx, creating, metav1.CreateOptions{})
+		if err != nil {
+			return "", err
+		}
+		return created.GetUID(), restoreStatus(ctx, client, desired, created)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	desired.SetOwnerReferences(existing.GetOwnerReferences())
+	if equality.Semantic.DeepEqual(desired.Object, WithoutServerFields(existing).Object) {
+		return existing.GetUID(), nil
+	}
+	updating := desired
+	if _, restoring := existing.GetAnnotations()[RestoringAnnotationKey]; restoring {
+		// a previous copy failed before restoring the status
+		updating = withRestoringAnnotation(desired)
+	}
+	updating.SetResourceVersion(existing.GetResourceVersion())
+	updated, err := client.Update(ctx, updating, metav1.UpdateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return updated.GetUID(), restoreStatus(ctx, client, desired, updated)
+}
+
+// withRestoringAnnotation returns a copy of the object with the RestoringAnnotationKey annotation.
+func withRestoringAnnotation(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[RestoringAnnotationKey] = "true"
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+// restoreStatus sets the status of the desired object on the current object, and removes the
+// RestoringAnnotationKey annotation afterwards.
+func restoreStatus(ctx context.Context, client dynamic.ResourceInterface, desired, current *unstructured.Unstructured) error {
+	current, err := updateStatus(ctx, client, desired, current)
+	if err != nil {
+		return err
+	}
+	if _, restoring := current.GetAnnotations()[RestoringAnnotationKey]; !restoring {
+		return nil
+	}
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, RestoringAnnotationKey))
+	_, err = client.Patch(ctx, current.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// updateStatus updates the status of the object if the resource has a status subresource which ignored
+// the status on creation or update. It returns the object after the update.
+func updateStatus(ctx context.Context, client dynamic.ResourceInterface, desired, current *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	status, found, err := unstructured.NestedFieldNoCopy(desired.Object, "status")
+	if err != nil || !found {
+		return current, err
+	}
+	if currentStatus, _, _ := unstructured.NestedFieldNoCopy(current.Object, "status"); equality.Semantic.DeepEqual(status, currentStatus) {
+		return current, nil
+	}
+
+	updated := current.DeepCopy()
+	if err := unstructured.SetNestedField(updated.Object, status, "status"); err != nil {
+		return nil, err
+	}
+	updated, err = client.UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+		// no status subresource
+		return current, nil
+	}
+	return updated, err
+}
+
+// ownerResolver returns the UID in the target of the owner of the given source object, or false if the owner
+// does not exist in the target.
+type ownerResolver func(ctx context.Context, source *unstructured.Unstructured, owner metav1.OwnerReference) (types.UID, bool, error)
+
+// ownersByUID resolves the owners copied with the given source to target UIDs.
+func ownersByUID(uids map[types.UID]types.UID) ownerResolver {
+	return func(_ context.Context, _ *unstructured.Unstructured, owner metav1.OwnerReference) (types.UID, bool, error) {
+		uid, ok := uids[owner.UID]
+		return uid, ok, nil
+	}
+}
+
+// remapOwnerReferences sets the owner references of the source object on the target object, with the UIDs
+// of the owners in the target. References to owners which do not exist in the target are dropped.
+func remapOwnerReferences(ctx context.Context, client dynamic.ResourceInterface, source *unstructured.Unstructured, resolve ownerResolver) error {
+	var owners []metav1.OwnerReference
+	for _, owner := range source.GetOwnerReferences() {
+		uid, ok, err := resolve(ctx, source, owner)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		owner.UID = uid
+		owners = append(owners, owner)
+	}
+
+	existing, err := client.Get(ctx, source.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(owners, existing.GetOwnerReferences()) {
+		return nil
+	}
+	existing.SetOwnerReferences(owners)
+	_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// Purge removes all objects from the logical cluster. Finalizers are removed before deletion, as the
+// content is a stale copy of a workspace living on another shard, and the finalizers must not act on
+// behalf of it.
+//
+// It returns the number of objects remaining. Purge is expected to be called until nothing remains.
+func Purge(ctx context.Context, c *Client) (int, error) {
+	logger := klog.FromContext(ctx).WithValues("operation", "purge")
+
+	gvrs, err := discoverResources(c, "list", "delete")
+	if err != nil {
+		return 0, err
+	}
+
+	background := metav1.DeletePropagationBackground
+	remaining := 0
+	var errs []error
+	for _, gvr := range gvrs {
+		list, err := c.Dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", gvr, err))
+			continue
+		}
+		logger.V(4).Info("purging objects", "gvr", gvr.String(), "count", len(list.Items))
+		for i := range list.Items {
+			obj := &list.Items[i]
+			remaining++
+			client := c.Dynamic.Resource(gvr).Namespace(obj.GetNamespace())
+			if len(obj.GetFinalizers()) > 0 {
+				patch := []byte(fmt.Sprintf(`{"metadata":{"finalizers":null,"resourceVersion":%q}}`, obj.GetResourceVersion()))
+				if _, err := client.Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil && !apierrors.IsNotFound(err) {
+					errs = append(errs, fmt.Errorf("failed to remove finalizers of %s %s: %w", gvr, key(obj), err))
+					continue
+				}
+			}
+			if obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			if err := client.Delete(ctx, obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &background}); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsMethodNotSupported(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", gvr, key(obj), err))
+			}
+		}
+	}
+
+	return remaining, utilerrors.NewAggregate(errs)
+}
+
+// discoverResources returns the resources of the logical cluster supporting the given verbs, without the
+// projected resources, ordered such that dependencies come first. Discovery errors are fatal, because
+// a partial list of resources would silently lose content.
+func discoverResources(c *Client, verbs ...string) ([]schema.GroupVersionResource, error) {
+	resources, err := c.DiscoverResources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+
+	var gvrs []schema.GroupVersionResource
+	for _, rl := range discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: verbs}, resources) {
+		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rl.APIResources {
+			gvr := gv.WithResource(r.Name)
+			if projection.Includes(gvr) {
+				// virtual projections of other resources, e.g. tenancy.kcp.dev v1beta1 Workspaces
+				continue
+			}
+			gvrs = append(gvrs, gvr)
+		}
+	}
+
+	order := func(gvr schema.GroupVersionResource) int {
+		for i, gr := range resourcesFirst {
+			if gvr.GroupResource() == gr {
+				return i
+			}
+		}
+		return len(resourcesFirst)
+	}
+	sort.SliceStable(gvrs, func(i, j int) bool {
+		return order(gvrs[i]) < order(gvrs[j])
+	})
+
+	return gvrs, nil
+}
+
+// WithoutServerFields returns a copy of the object without the metadata managed by the API server.
+func WithoutServerFields(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetDeletionTimestamp(nil)
+	obj.SetDeletionGracePeriodSeconds(nil)
+	obj.SetManagedFields(nil)
+	obj.SetSelfLink("")
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+func key(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	namespacesGVR = corev1.SchemeGroupVersion.WithResource("namespaces")
+	configMapsGVR = corev1.SchemeGroupVersion.WithResource("configmaps")
+)
+
+func discoverCore() ([]*metav1.APIResourceList, error) {
+	verbs := metav1.Verbs{"create", "delete", "get", "list", "update"}
+	return []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: verbs},
+				{Name: "namespaces", Kind: "Namespace", Verbs: verbs},
+				{Name: "componentstatuses", Kind: "ComponentStatus", Verbs: metav1.Verbs{"get", "list"}},
+			},
+		},
+	}, nil
+}
+
+func newClient(t *testing.T, objects ...runtime.Object) (*Client, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := dynamicfake.NewSimpleDynamicClient(scheme, objects...)
+
+	// the fake tracker does not assign UIDs
+	created := 0
+	client.PrependReactor("create", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		created++
+		action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured).SetUID(types.UID(fmt.Sprintf("target-%d", created)))
+		return false, nil, nil
+	})
+
+	return &Client{Dynamic: client, DiscoverResources: discoverCore}, client
+}
+
+func toUnstructured(t *testing.T, obj runtime.Object) *unstructured.Unstructured {
+	t.Helper()
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: raw}
+}
+
+func namespace(t *testing.T, name string, uid types.UID) *unstructured.Unstructured {
+	return toUnstructured(t, &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid, ResourceVersion: "1"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	})
+}
+
+func configMap(t *testing.T, namespace, name string, owners ...metav1.OwnerReference) *unstructured.Unstructured {
+	return toUnstructured(t, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID("source-" + name), ResourceVersion: "1", OwnerReferences: owners},
+		Data:       map[string]string{"key": name},
+	})
+}
+
+func TestCopy(t *testing.T) {
+	owner := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "source-owner"}
+	source, _ := newClient(t,
+		namespace(t, "default", "source-ns"),
+		configMap(t, "default", "owner"),
+		configMap(t, "default", "dependent", owner),
+		configMap(t, "default", "orphan", metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "gone", UID: "source-gone"}),
+	)
+	target, targetClient := newClient(t)
+
+	copied, err := Copy(context.Background(), source, target)
+	require.NoError(t, err)
+	require.Equal(t, 4, copied)
+
+	ns, err := targetClient.Resource(namespacesGVR).Get(context.Background(), "default", metav1.GetOptions{})
+	require.NoError(t, err)
+	phase, _, err := unstructured.NestedString(ns.Object, "status", "phase")
+	require.NoError(t, err)
+	require.Equal(t, "Active", phase, "status must be copied")
+
+	ownerCM, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "owner", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotEqual(t, types.UID("source-owner"), ownerCM.GetUID())
+
+	dependent, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "dependent", metav1.GetOptions{})
+	require.NoError(t, err)
+	owner.UID = ownerCM.GetUID()
+	require.Equal(t, []metav1.OwnerReference{owner}, dependent.GetOwnerReferences(), "owner references must point to the copied owner")
+
+	orphan, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "orphan", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, orphan.GetOwnerReferences(), "references to owners that have not been copied must be dropped")
+
+	// copying again is a no-op
+	targetClient.ClearActions()
+	copied, err = Copy(context.Background(), source, target)
+	require.NoError(t, err)
+	require.Equal(t, 4, copied)
+	for _, action := range targetClient.Actions() {
+		require.Equal(t, "get", action.GetVerb(), "unexpected %s", action)
+	}
+}
+
+func TestUIDReferenced(t *testing.T) {
+	syncTargetsGVR := schema.GroupVersionResource{Group: "workload.kcp.dev", Version: "v1alpha1", Resource: "synctargets"}
+	syncTarget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "workload.kcp.dev/v1alpha1",
+		"kind":       "SyncTarget",
+		"metadata":   map[string]interface{}{"name": "cluster", "uid": "source-cluster"},
+	}}
+	serviceAccount := toUnstructured(t, &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "default", UID: "source-sa"},
+	})
+
+	listKinds := map[schema.GroupVersionResource]string{
+		syncTargetsGVR: "SyncTargetList",
+		corev1.SchemeGroupVersion.WithResource("serviceaccounts"): "ServiceAccountList",
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, syncTarget, serviceAccount)
+	referenced, err := UIDReferenced(context.Background(), &Client{Dynamic: client, DiscoverResources: discoverCore})
+	require.NoError(t, err)
+	require.Equal(t, []string{"synctargets.workload.kcp.dev cluster", "serviceaccounts default/default"}, referenced)
+
+	client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	referenced, err = UIDReferenced(context.Background(), &Client{Dynamic: client, DiscoverResources: discoverCore})
+	require.NoError(t, err)
+	require.Empty(t, referenced)
+}
+
+func TestCopyDiscoveryError(t *testing.T) {
+	source, _ := newClient(t)
+	source.DiscoverResources = func() ([]*metav1.APIResourceList, error) {
+		return nil, fmt.Errorf("unavailable")
+	}
+	target, _ := newClient(t)
+
+	_, err := Copy(context.Background(), source, target)
+	require.ErrorContains(t, err, "unavailable")
+}
+
+func TestCopyChildWorkspaces(t *testing.T) {
+	clusterWorkspacesGVR := schema.GroupVersionResource{Group: "tenancy.kcp.dev", Version: "v1alpha1", Resource: "clusterworkspaces"}
+	discover := func() ([]*metav1.APIResourceList, error) {
+		return []*metav1.APIResourceList{{
+			GroupVersion: "tenancy.kcp.dev/v1alpha1",
+			APIResources: []metav1.APIResource{
+				{Name: "clusterworkspaces", Kind: "ClusterWorkspace", Verbs: metav1.Verbs{"create", "delete", "get", "list", "update"}},
+			},
+		}}, nil
+	}
+	child := func(name string, status map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "tenancy.kcp.dev/v1alpha1",
+			"kind":       "ClusterWorkspace",
+			"metadata":   map[string]interface{}{"name": name, "uid": "source-" + name},
+		}}
+		if status != nil {
+			obj.Object["status"] = status
+		}
+		return obj
+	}
+	ready := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"phase":    "Ready",
+			"baseURL":  "https://front-proxy/clusters/root:org:ws:" + name,
+			"location": map[string]interface{}{"current": "source"},
+		}
+	}
+	listKinds := map[schema.GroupVersionResource]string{clusterWorkspacesGVR: "ClusterWorkspaceList"}
+
+	source := &Client{
+		Dynamic:           dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, child("new", ready("new")), child("retried", ready("retried"))),
+		DiscoverResources: discover,
+	}
+
+	// "retried" was created by a previous copy which failed before restoring the status
+	retried := child("retried", nil)
+	retried.SetAnnotations(map[string]string{RestoringAnnotationKey: "true"})
+	targetClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, retried)
+	target := &Client{Dynamic: targetClient, DiscoverResources: discover}
+
+	// like the API server, ignore the status outside of the status subresource, and check that controllers
+	// like the scheduler can tell the workspaces without status apart.
+	ignoreStatus := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "status" {
+			return false, nil, nil
+		}
+		var obj *unstructured.Unstructured
+		switch action := action.(type) {
+		case clienttesting.CreateAction:
+			obj = action.GetObject().(*unstructured.Unstructured)
+		case clienttesting.UpdateAction:
+			obj = action.GetObject().(*unstructured.Unstructured)
+		}
+		unstructured.RemoveNestedField(obj.Object, "status")
+		require.Equal(t, "true", obj.GetAnnotations()[RestoringAnnotationKey], "%s %s without status must be marked as restoring", action.GetVerb(), obj.GetName())
+		return false, nil, nil
+	}
+	targetClient.PrependReactor("create", "clusterworkspaces", ignoreStatus)
+	targetClient.PrependReactor("update", "clusterworkspaces", ignoreStatus)
+
+	copied, err := Copy(context.Background(), source, target)
+	require.NoError(t, err)
+	require.Equal(t, 2, copied)
+
+	for _, name := range []string{"new", "retried"} {
+		ws, err := targetClient.Resource(clusterWorkspacesGVR).Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		status, _, err := unstructured.NestedMap(ws.Object, "status")
+		require.NoError(t, err)
+		require.Equal(t, ready(name), status, "location and phase of %s must be restored", name)
+		require.NotContains(t, ws.GetAnnotations(), RestoringAnnotationKey, "%s must be released after restoring the status", name)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	finalized := configMap(t, "default", "finalized")
+	finalized.SetFinalizers([]string{"example.com/finalizer"})
+	c, client := newClient(t,
+		namespace(t, "default", "ns"),
+		configMap(t, "default", "cm"),
+		finalized,
+	)
+
+	remaining, err := Purge(context.Background(), c)
+	require.NoError(t, err)
+	require.Equal(t, 3, remaining)
+
+	var patched []string
+	for _, action := range client.Actions() {
+		if action, ok := action.(clienttesting.PatchAction); ok {
+			patched = append(patched, action.GetName())
+		}
+	}
+	require.Equal(t, []string{"finalized"}, patched, "finalizers must be removed")
+
+	remaining, err = Purge(context.Background(), c)
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+}
+
+func TestDiscoverResources(t *testing.T) {
+	c := &Client{DiscoverResources: func() ([]*metav1.APIResourceList, error) {
+		verbs := metav1.Verbs{"create", "delete", "get", "list", "update"}
+		return []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "configmaps", Verbs: verbs}, {Name: "namespaces", Verbs: verbs}}},
+			{GroupVersion: "apis.kcp.dev/v1alpha1", APIResources: []metav1.APIResource{{Name: "apibindings", Verbs: verbs}}},
+			{GroupVersion: "tenancy.kcp.dev/v1beta1", APIResources: []metav1.APIResource{{Name: "workspaces", Verbs: verbs}}},
+			{GroupVersion: "apiextensions.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "customresourcedefinitions", Verbs: verbs}}},
+		}, nil
+	}}
+
+	gvrs, err := discoverResources(c, "list", "create")
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupVersionResource{
+		namespacesGVR,
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+		{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apibindings"},
+		configMapsGVR,
+	}, gvrs, "dependencies first, projected workspaces skipped")
+}
+
+func TestExportRestore(t *testing.T) {
+	owner := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "source-owner"}
+	source, _ := newClient(t,
+		namespace(t, "default", "source-ns"),
+		configMap(t, "default", "owner"),
+		configMap(t, "default", "dependent", owner),
+		configMap(t, "default", "kube-root-ca.crt"),
+	)
+
+	objects, err := Export(context.Background(), source)
+	require.NoError(t, err)
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.GetName())
+		require.Empty(t, obj.GetResourceVersion(), "server fields must be stripped")
+		require.NotEmpty(t, obj.GetUID(), "UIDs are needed to remap owner references")
+	}
+	require.ElementsMatch(t, []string{"default", "owner", "dependent"}, names, "objects maintained per workspace must be skipped")
+
+	// restore in reverse order to check that dependencies are restored first
+	for i, j := 0, len(objects)-1; i < j; i, j = i+1, j-1 {
+		objects[i], objects[j] = objects[j], objects[i]
+	}
+	target, targetClient := newClient(t)
+	restored, err := Restore(context.Background(), target, objects)
+	require.NoError(t, err)
+	require.Equal(t, 3, restored)
+	var created []string
+	for _, action := range targetClient.Actions() {
+		if action.GetVerb() == "create" {
+			created = append(created, action.GetResource().Resource)
+		}
+	}
+	require.Equal(t, []string{"namespaces", "configmaps", "configmaps"}, created)
+
+	ownerCM, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "owner", metav1.GetOptions{})
+	require.NoError(t, err)
+	dependent, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "dependent", metav1.GetOptions{})
+	require.NoError(t, err)
+	owner.UID = ownerCM.GetUID()
+	require.Equal(t, []metav1.OwnerReference{owner}, dependent.GetOwnerReferences(), "owner references must point to the restored owner")
+}
+
+func TestRestoreUnknownKind(t *testing.T) {
+	target, _ := newClient(t)
+	unknown := &unstructured.Unstructured{}
+	unknown.SetAPIVersion("example.com/v1")
+	unknown.SetKind("Widget")
+	unknown.SetName("foo")
+
+	restored, err := Restore(context.Background(), target, []unstructured.Unstructured{*unknown, *namespace(t, "default", "ns")})
+	require.ErrorContains(t, err, "no resource found for kind Widget.example.com")
+	require.Equal(t, 1, restored)
+}
+
+func TestRestoreOwnersByName(t *testing.T) {
+	owner := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "source-owner"}
+	missing := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "missing", UID: "source-missing"}
+	target, targetClient := newClient(t)
+
+	// the owner is restored in an earlier request, e.g. in another chunk of the snapshot
+	_, err := Restore(context.Background(), target, []unstructured.Unstructured{*configMap(t, "default", "owner")})
+	require.NoError(t, err)
+	_, err = Restore(context.Background(), target, []unstructured.Unstructured{*configMap(t, "default", "dependent", owner, missing)})
+	require.NoError(t, err)
+
+	ownerCM, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "owner", metav1.GetOptions{})
+	require.NoError(t, err)
+	dependent, err := targetClient.Resource(configMapsGVR).Namespace("default").Get(context.Background(), "dependent", metav1.GetOptions{})
+	require.NoError(t, err)
+	owner.UID = ownerCM.GetUID()
+	require.Equal(t, []metav1.OwnerReference{owner}, dependent.GetOwnerReferences(), "owner references must point to the existing owner, and missing owners be dropped")
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// kindsFirst are the kinds restored before all others, in this order, for the same reasons as resourcesFirst.
+var kindsFirst = []schema.GroupKind{
+	{Group: "", Kind: "Namespace"},
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"},
+	{Group: "apis.kcp.dev", Kind: "APIBinding"},
+}
+
+// statusDroppedOnExport are the resources exported without status, because their controllers must act on
+// them anew on restore, e.g. child ClusterWorkspaces must be scheduled and initialized again.
+var statusDroppedOnExport = map[schema.GroupResource]bool{
+	tenancyv1alpha1.Resource("clusterworkspaces"):                          true,
+	{Group: "apis.kcp.dev", Resource: "apibindings"}:                       true,
+	{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}: true,
+}
+
+// Export returns all objects of the logical cluster in a portable form, i.e. without the metadata managed by
+// the API server, except for the UID which Restore needs to remap owner references. Objects which are
+// maintained by controllers in every workspace, like events and service account tokens, are skipped.
+//
+// Objects are ordered such that dependencies come first.
+func Export(ctx context.Context, c *Client) ([]unstructured.Unstructured, error) {
+	logger := klog.FromContext(ctx).WithValues("operation", "export")
+
+	gvrs, err := discoverResources(c, "list", "get", "create")
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []unstructured.Unstructured
+	var errs []error
+	for _, gvr := range gvrs {
+		list, err := c.Dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", gvr, err))
+			continue
+		}
+		logger.V(4).Info("exporting objects", "gvr", gvr.String(), "count", len(list.Items))
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if obj.GetDeletionTimestamp() != nil || isMaintainedPerWorkspace(gvr, obj) {
+				continue
+			}
+			exported := WithoutServerFields(obj)
+			exported.SetUID(obj.GetUID())
+			if statusDroppedOnExport[gvr.GroupResource()] {
+				unstructured.RemoveNestedField(exported.Object, "status")
+			}
+			objects = append(objects, *exported)
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		// a partial snapshot would silently lose content
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// isMaintainedPerWorkspace returns true for objects which controllers create in every workspace, or which
+// are only meaningful in the workspace they were created in.
+func isMaintainedPerWorkspace(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) bool {
+	switch gvr.GroupResource() {
+	case schema.GroupResource{Resource: "events"}, schema.GroupResource{Group: "events.k8s.io", Resource: "events"}:
+		return true
+	case schema.GroupResource{Resource: "secrets"}:
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType == string(corev1.SecretTypeServiceAccountToken)
+	case schema.GroupResource{Resource: "configmaps"}:
+		return obj.GetName() == "kube-root-ca.crt"
+	}
+	return false
+}
+
+// restoreOrder returns the position of the kind of the object in kindsFirst, or len(kindsFirst) for all others.
+func restoreOrder(obj *unstructured.Unstructured) int {
+	for i, gk := range kindsFirst {
+		if obj.GroupVersionKind().GroupKind() == gk {
+			return i
+		}
+	}
+	return len(kindsFirst)
+}
+
+// SortForRestore sorts the objects into the order Restore restores them in, i.e. such that dependencies
+// come first. Snapshots restored in chunks must be sorted before they are split.
+func SortForRestore(objects []unstructured.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return restoreOrder(&objects[i]) < restoreOrder(&objects[j])
+	})
+}
+
+// Restore creates or updates the given objects, as returned by Export, in the logical cluster. Owner
+// references are remapped to the UIDs of the restored owners, or of owners with the same name restored
+// before, e.g. when a snapshot is restored in chunks. Objects are restored in dependency order, and
+// resources are discovered again after namespaces, CRDs and APIBindings are restored. As the resources of
+// CRDs and APIBindings become available asynchronously, Restore is expected to be called repeatedly until
+// it succeeds.
+//
+// It returns the number of restored objects.
+func Restore(ctx context.Context, c *Client, objects []unstructured.Unstructured) (int, error) {
+	logger := klog.FromContext(ctx).WithValues("operation", "restore")
+
+	sorted := make([]unstructured.Unstructured, len(objects))
+	copy(sorted, objects)
+	SortForRestore(sorted)
+
+	var restored []copiedObject
+	uids := map[types.UID]types.UID{}
+	var errs []error
+	resources, err := discoverKinds(c)
+	if err != nil {
+		return 0, err
+	}
+	rediscovered := false
+	for i := range sorted {
+		obj := &sorted[i]
+		if !rediscovered && restoreOrder(obj) == len(kindsFirst) {
+			// restored CRDs and APIBindings might serve new resources by now
+			if resources, err = discoverKinds(c); err != nil {
+				return len(restored), err
+			}
+			rediscovered = true
+		}
+
+		gvk := obj.GroupVersionKind()
+		resource, ok := resources[gvk.GroupKind()]
+		if !ok {
+			errs = append(errs, fmt.Errorf("failed to restore %s %s: no resource found for kind %s", gvk.Kind, key(obj), gvk.GroupKind()))
+			continue
+		}
+		gvr := gvk.GroupVersion().WithResource(resource)
+		logger.V(4).Info("restoring object", "gvr", gvr.String(), "key", key(obj))
+		uid, err := copyObject(ctx, c.Dynamic.Resource(gvr).Namespace(obj.GetNamespace()), obj)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s %s: %w", gvr, key(obj), err))
+			continue
+		}
+		uids[obj.GetUID()] = uid
+		restored = append(restored, copiedObject{gvr: gvr, source: obj})
+	}
+
+	// owner references must only be set when the owners exist, otherwise the garbage collector
+	// would delete the objects.
+	resolve := ownersByUIDOrName(c, resources, uids)
+	for _, r := range restored {
+		if len(r.source.GetOwnerReferences()) == 0 {
+			continue
+		}
+		if err := remapOwnerReferences(ctx, c.Dynamic.Resource(r.gvr).Namespace(r.source.GetNamespace()), r.source, resolve); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set owner references of %s %s: %w", r.gvr, key(r.source), err))
+		}
+	}
+
+	return len(restored), utilerrors.NewAggregate(errs)
+}
+
+// ownersByUIDOrName resolves the owners restored with the given source to target UIDs, and all other owners
+// to the UIDs of the objects of their kind and name in the logical cluster.
+func ownersByUIDOrName(c *Client, resources map[schema.GroupKind]string, uids map[types.UID]types.UID) ownerResolver {
+	byUID := ownersByUID(uids)
+	return func(ctx context.Context, source *unstructured.Unstructured, owner metav1.OwnerReference) (types.UID, bool, error) {
+		if uid, ok, err := byUID(ctx, source, owner); ok || err != nil {
+			return uid, ok, err
+		}
+		gvk := schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind)
+		resource, ok := resources[gvk.GroupKind()]
+		if !ok {
+			return "", false, nil
+		}
+		// owners are in the namespace of the object, or cluster-scoped.
+		for _, namespace := range sets.NewString(source.GetNamespace(), "").List() {
+			existing, err := c.Dynamic.Resource(gvk.GroupVersion().WithResource(resource)).Namespace(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return "", false, err
+			}
+			return existing.GetUID(), true, nil
+		}
+		return "", false, nil
+	}
+}
+
+// discoverKinds returns the resource names of the kinds served in the logical cluster which can be restored.
+func discoverKinds(c *Client) (map[schema.GroupKind]string, error) {
+	gvrs, err := discoverResources(c, "get", "create", "update")
+	if err != nil {
+		return nil, err
+	}
+	resources, err := c.DiscoverResources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+
+	restorable := map[schema.GroupVersionResource]bool{}
+	for _, gvr := range gvrs {
+		restorable[gvr] = true
+	}
+	kinds := map[schema.GroupKind]string{}
+	for _, rl := range resources {
+		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rl.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue // subresource
+			}
+			if restorable[gv.WithResource(r.Name)] {
+				kinds[gv.WithKind(r.Kind).GroupKind()] = r.Name
+			}
+		}
+	}
+	return kinds, nil
+}
@@ -21,11 +21,17 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/clusters"
+
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 const (
 	SyncTargetsBySyncTargetKey = "SyncTargetsBySyncTargetKey"
+	// PinnedByLogicalClusterAndNamespace is the name for the index that indexes the resources pinned to
+	// sync targets by logical cluster and namespace.
+	PinnedByLogicalClusterAndNamespace = "PinnedByLogicalClusterAndNamespace"
 )
 
 func IndexSyncTargetsBySyncTargetKey(obj interface{}) ([]string, error) {
@@ -36,3 +42,17 @@ func IndexSyncTargetsBySyncTargetKey(obj interface{}) ([]string, error) {
 
 	return []string{workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name)}, nil
 }
+
+// IndexPinnedByLogicalClusterAndNamespace indexes the resources with the workload.kcp.dev/sync-targets
+// annotation by logical cluster and namespace.
+func IndexPinnedByLogicalClusterAndNamespace(obj interface{}) ([]string, error) {
+	a, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	if _, found := a.GetAnnotations()[workloadv1alpha1.SyncTargetsAnnotationKey]; !found || a.GetNamespace() == "" {
+		return []string{}, nil
+	}
+
+	return []string{clusters.ToClusterAwareKey(logicalcluster.From(a), a.GetNamespace())}, nil
+}
@@ -70,6 +70,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1.WorkspaceExportReference":                    schema_pkg_apis_apis_v1alpha1_WorkspaceExportReference(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.AvailableSelectorLabel":                schema_pkg_apis_scheduling_v1alpha1_AvailableSelectorLabel(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource":                  schema_pkg_apis_scheduling_v1alpha1_GroupVersionResource(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.InstanceSelection":                     schema_pkg_apis_scheduling_v1alpha1_InstanceSelection(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.Location":                              schema_pkg_apis_scheduling_v1alpha1_Location(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationList":                          schema_pkg_apis_scheduling_v1alpha1_LocationList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.LocationReference":                     schema_pkg_apis_scheduling_v1alpha1_LocationReference(ref),
@@ -79,10 +80,14 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementList":                         schema_pkg_apis_scheduling_v1alpha1_PlacementList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementSpec":                         schema_pkg_apis_scheduling_v1alpha1_PlacementSpec(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.PlacementStatus":                       schema_pkg_apis_scheduling_v1alpha1_PlacementStatus(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.SchedulingPolicy":                      schema_pkg_apis_scheduling_v1alpha1_SchedulingPolicy(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.ScorePluginConfig":                     schema_pkg_apis_scheduling_v1alpha1_ScorePluginConfig(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.WeightedInstanceSelector":              schema_pkg_apis_scheduling_v1alpha1_WeightedInstanceSelector(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.APIExportReference":                       schema_pkg_apis_tenancy_v1alpha1_APIExportReference(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspace":                         schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspace(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceList":                     schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceLocation":                 schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceLocation(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceMigration":                schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceMigration(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceShard":                    schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceShard(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceShardList":                schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceShardList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceShardSpec":                schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceShardSpec(ref),
@@ -103,11 +108,18 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1.WorkspaceSpec":                             schema_pkg_apis_tenancy_v1beta1_WorkspaceSpec(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1.WorkspaceStatus":                           schema_pkg_apis_tenancy_v1beta1_WorkspaceStatus(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition": schema_conditions_apis_conditions_v1alpha1_Condition(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ContainerOverride":                       schema_pkg_apis_workload_v1alpha1_ContainerOverride(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ControllerHealth":                        schema_pkg_apis_workload_v1alpha1_ControllerHealth(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.DownstreamHealth":                        schema_pkg_apis_workload_v1alpha1_DownstreamHealth(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceHealth":                          schema_pkg_apis_workload_v1alpha1_ResourceHealth(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceToSync":                          schema_pkg_apis_workload_v1alpha1_ResourceToSync(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SpecOverride":                            schema_pkg_apis_workload_v1alpha1_SpecOverride(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTarget":                              schema_pkg_apis_workload_v1alpha1_SyncTarget(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTargetList":                          schema_pkg_apis_workload_v1alpha1_SyncTargetList(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTargetSpec":                          schema_pkg_apis_workload_v1alpha1_SyncTargetSpec(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncTargetStatus":                        schema_pkg_apis_workload_v1alpha1_SyncTargetStatus(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncerHealth":                            schema_pkg_apis_workload_v1alpha1_SyncerHealth(ref),
+		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncerVersionRange":                      schema_pkg_apis_workload_v1alpha1_SyncerVersionRange(ref),
 		"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.VirtualWorkspace":                        schema_pkg_apis_workload_v1alpha1_VirtualWorkspace(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIGroup":                                             schema_pkg_apis_meta_v1_APIGroup(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIGroupList":                                         schema_pkg_apis_meta_v1_APIGroupList(ref),
@@ -2135,6 +2147,33 @@ func schema_pkg_apis_scheduling_v1alpha1_GroupVersionResource(ref common.Referen
 	}
 }
 
+func schema_pkg_apis_scheduling_v1alpha1_InstanceSelection(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "InstanceSelection controls how many instances of a location are scheduled.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "type is either Count to schedule a fixed number of instances, or All to schedule every feasible instance of the location.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"count": {
+						SchemaProps: spec.SchemaProps{
+							Description: "count is the number of instances to schedule if type is Count. If fewer instances are feasible, all of them are scheduled.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_scheduling_v1alpha1_Location(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2310,12 +2349,18 @@ func schema_pkg_apis_scheduling_v1alpha1_LocationSpec(ref common.ReferenceCallba
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
 						},
 					},
+					"schedulingPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "schedulingPolicy configures how the instances of this location are filtered and scored when one of them is picked for a placement. If it is not set, the LeastAllocated and Spread score plugins are used with equal weight.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.SchedulingPolicy"),
+						},
+					},
 				},
 				Required: []string{"resource"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.AvailableSelectorLabel", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.AvailableSelectorLabel", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.SchedulingPolicy", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 	}
 }
 
@@ -2350,7 +2395,7 @@ func schema_pkg_apis_scheduling_v1alpha1_Placement(ref common.ReferenceCallback)
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "Placement defines a selection rule to choose ONE location for MULTIPLE namespaces in a workspace.\n\nplacement is in Pending state initially. When a location is selected by the placement, the placement turns to Unbound state. In Pending or Unbound state, the selection rule can be updated to select another location. When the a namespace is annotated by another controller or user with the key of \"scheduling.kcp.dev/placement\", the namespace will pick one placement, and this placement is transferred to Bound state. Any update to spec of the placement is ignored in Bound state and reflected in the conditions. The placement will turn back to Unbound state when no namespace uses this placement any more.",
+				Description: "Placement defines a selection rule to choose ONE location for MULTIPLE namespaces in a workspace. Within the location, the namespaces are scheduled to one or, according to instanceSelection, multiple instances.\n\nplacement is in Pending state initially. When a location is selected by the placement, the placement turns to Unbound state. In Pending or Unbound state, the selection rule can be updated to select another location. When the a namespace is annotated by another controller or user with the key of \"scheduling.kcp.dev/placement\", the namespace will pick one placement, and this placement is transferred to Bound state. Any update to spec of the placement is ignored in Bound state and reflected in the conditions. The placement will turn back to Unbound state when no namespace uses this placement any more.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"kind": {
@@ -2482,12 +2527,18 @@ func schema_pkg_apis_scheduling_v1alpha1_PlacementSpec(ref common.ReferenceCallb
 							Format:      "",
 						},
 					},
+					"instanceSelection": {
+						SchemaProps: spec.SchemaProps{
+							Description: "instanceSelection controls how many instances of the selected location the namespaces bound to this placement are scheduled to. By default, they are scheduled to one instance.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.InstanceSelection"),
+						},
+					},
 				},
 				Required: []string{"locationResource"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.GroupVersionResource", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.InstanceSelection", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 	}
 }
 
@@ -2532,6 +2583,131 @@ func schema_pkg_apis_scheduling_v1alpha1_PlacementStatus(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_scheduling_v1alpha1_SchedulingPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SchedulingPolicy configures the filter and score plugins used to pick an instance of a location.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"scorePlugins": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "scorePlugins is the list of score plugins with their weights. Every plugin scores an instance between 0 and 100, and the instance with the highest weighted sum wins. Ties are broken by instance name.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.ScorePluginConfig"),
+									},
+								},
+							},
+						},
+					},
+					"preferredInstances": {
+						SchemaProps: spec.SchemaProps{
+							Description: "preferredInstances is a list of weighted label selectors used by the LabelAffinity score plugin. An instance matching a selector gains the weight of that selector.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.WeightedInstanceSelector"),
+									},
+								},
+							},
+						},
+					},
+					"excludedInstances": {
+						SchemaProps: spec.SchemaProps{
+							Description: "excludedInstances is a list of label selectors. Instances matching any of them are never selected, similar to a NoSchedule taint on a node. Placements already scheduled onto an excluded instance are rescheduled.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.ScorePluginConfig", "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1.WeightedInstanceSelector", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
+func schema_pkg_apis_scheduling_v1alpha1_ScorePluginConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ScorePluginConfig enables a score plugin with a weight.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "name is the name of the score plugin.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Description: "weight is multiplied with the score of the plugin.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_scheduling_v1alpha1_WeightedInstanceSelector(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WeightedInstanceSelector is a label selector with a weight.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"selector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "selector selects the preferred instances.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Description: "weight is added to the LabelAffinity score of a matching instance.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"selector", "weight"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
 func schema_pkg_apis_tenancy_v1alpha1_APIExportReference(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2674,14 +2850,68 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceLocation(ref common.Refere
 					},
 					"target": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Target workspace placement (shard).",
+							Description: "Target workspace placement (shard). Setting a target different from the current shard starts a migration of the workspace content to that shard. Clearing it before the content has been switched to the target shard rolls the migration back.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceMigration(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ClusterWorkspaceMigration describes the movement of the workspace content between shards.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "phase of the migration (Freezing / Copying / CleaningUp / RollingBack).",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"source": {
+						SchemaProps: spec.SchemaProps{
+							Description: "source is the name of the ClusterWorkspaceShard the content is moved from.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"target": {
+						SchemaProps: spec.SchemaProps{
+							Description: "target is the name of the ClusterWorkspaceShard the content is moved to.",
+							Default:     "",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "startTime is the time the migration was started, i.e. when writes were frozen.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"copiedObjects": {
+						SchemaProps: spec.SchemaProps{
+							Description: "copiedObjects is the number of objects copied to the target shard by the last copy pass.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 				},
+				Required: []string{"phase", "source", "target", "startTime"},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -2810,6 +3040,13 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceShardSpec(ref common.Refer
 							Format:      "",
 						},
 					},
+					"unschedulable": {
+						SchemaProps: spec.SchemaProps{
+							Description: "unschedulable cordons the shard, i.e. no new workspaces are scheduled onto it. Workspaces already on the shard are not affected.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"externalURL"},
 			},
@@ -2826,7 +3063,7 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceShardStatus(ref common.Ref
 				Properties: map[string]spec.Schema{
 					"capacity": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Set of integer resources that workspaces can be scheduled into",
+							Description: "Set of integer resources that workspaces can be scheduled into. No new workspaces are scheduled onto the shard when the usage of one of these resources reaches the capacity.",
 							Type:        []string{"object"},
 							AdditionalProperties: &spec.SchemaOrBool{
 								Allows: true,
@@ -2853,6 +3090,21 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceShardStatus(ref common.Ref
 							},
 						},
 					},
+					"usage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "usage is the observed usage of the resources of the shard, reported by the shard controller.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -2870,8 +3122,9 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceSpec(ref common.ReferenceC
 				Properties: map[string]spec.Schema{
 					"readOnly": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"boolean"},
-							Format: "",
+							Description: "readOnly denies all writes to the content of the workspace, e.g. to freeze it during audits. Privileged subjects and the subjects exempted by the kcp server flags --read-only-workspace-exempt-users and --read-only-workspace-exempt-groups can still write. The workspace object itself, living in the parent workspace, can still be changed.",
+							Type:        []string{"boolean"},
+							Format:      "",
 						},
 					},
 					"type": {
@@ -2952,11 +3205,17 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceStatus(ref common.Referenc
 							},
 						},
 					},
+					"migration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "migration describes the movement of the workspace content from the current shard to the shard in location.target. It is set by the system while the workspace is migrated, and removed when the migration has finished or has been rolled back.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceMigration"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceLocation", "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition"},
+			"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceLocation", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceMigration", "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition"},
 	}
 }
 
@@ -3228,11 +3487,17 @@ func schema_pkg_apis_tenancy_v1alpha1_ClusterWorkspaceTypeSpec(ref common.Refere
 							},
 						},
 					},
+					"deletionRetention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "deletionRetention is how long workspaces of this type are retained after deletion. During that time, a deleted workspace is hidden and inaccessible, but can be undeleted by setting the experimental.tenancy.kcp.dev/undelete annotation. Its content is purged after the retention period. If unset, the content of deleted workspaces is purged immediately.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.APIExportReference", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceTypeExtension", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceTypeReference", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceTypeSelector"},
+			"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.APIExportReference", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceTypeExtension", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceTypeReference", "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspaceTypeSelector", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -3566,6 +3831,200 @@ func schema_conditions_apis_conditions_v1alpha1_Condition(ref common.ReferenceCa
 	}
 }
 
+func schema_pkg_apis_workload_v1alpha1_ContainerOverride(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ContainerOverride overrides a container of a pod template.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "name is the name of the container to override.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "image overrides the image of the container.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"env": {
+						SchemaProps: spec.SchemaProps{
+							Description: "env is merged into the environment of the container. Variables with the same name are replaced.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "resources overrides the resource requests and limits of the container. Only the given resource names are replaced.",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements"},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_ControllerHealth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ControllerHealth is the state of the work queue of a controller of the syncer.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "name is the name of the controller.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"queueDepth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "queueDepth is the number of objects waiting to be reconciled.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"errors": {
+						SchemaProps: spec.SchemaProps{
+							Description: "errors is the number of failed reconciliations since the syncer started.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"consecutiveErrors": {
+						SchemaProps: spec.SchemaProps{
+							Description: "consecutiveErrors is the number of failed reconciliations since the last successful one.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastSuccessTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "lastSuccessTime is the time of the last successful reconciliation, e.g. the last object applied to the physical cluster by the spec controller.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"name", "queueDepth", "errors", "consecutiveErrors"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_DownstreamHealth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DownstreamHealth is the health of the API server of a physical cluster.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"reachable": {
+						SchemaProps: spec.SchemaProps{
+							Description: "reachable is true if the syncer reached the API server with the last heartbeat.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Description: "version is the version of the API server, e.g. v1.24.3.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "error is the error reaching the API server if it is not reachable.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"reachable"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_ResourceHealth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ResourceHealth is the state of the informers of a synced resource.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"group": {
+						SchemaProps: spec.SchemaProps{
+							Description: "group is the API group of the resource, empty for the core group.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Description: "version is the API version of the resource.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "resource is the name of the resource.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"upstreamSynced": {
+						SchemaProps: spec.SchemaProps{
+							Description: "upstreamSynced is true if the informer of the resource in kcp has synced.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"downstreamSynced": {
+						SchemaProps: spec.SchemaProps{
+							Description: "downstreamSynced is true if the informer of the resource in the physical cluster has synced.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"version", "resource", "upstreamSynced", "downstreamSynced"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_workload_v1alpha1_ResourceToSync(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3609,6 +4068,55 @@ func schema_pkg_apis_workload_v1alpha1_ResourceToSync(ref common.ReferenceCallba
 	}
 }
 
+func schema_pkg_apis_workload_v1alpha1_SpecOverride(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SpecOverride overrides parts of the spec of a resource on the sync targets it applies to. Exactly one of syncTargetKey and syncTargetSelector must be set.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"syncTargetKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "syncTargetKey selects a single sync target by its key, as shown in the Key column of the SyncTarget resource.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"syncTargetSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "syncTargetSelector selects the sync targets by their labels, e.g. the labels the instance selector of a Location matches.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "replicas overrides spec.replicas of the resource.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"containers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "containers overrides the containers of the pod template of the resource, or of the pod itself.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ContainerOverride"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ContainerOverride", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
 func schema_pkg_apis_workload_v1alpha1_SyncTarget(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3758,11 +4266,25 @@ func schema_pkg_apis_workload_v1alpha1_SyncTargetSpec(ref common.ReferenceCallba
 							},
 						},
 					},
+					"clusterScopedResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterScopedResources is the allow-list of cluster-scoped resources the syncer syncs to the SyncTarget, e.g. priorityclasses in the scheduling.k8s.io group. Cluster-scoped resources not in the list are never synced. The synced objects are renamed on the SyncTarget with a prefix unique to their workspace, and references to them, e.g. priorityClassName, are rewritten accordingly.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1.GroupResource"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1.ExportReference", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1.ExportReference", "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1.GroupResource", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -3851,11 +4373,110 @@ func schema_pkg_apis_workload_v1alpha1_SyncTargetStatus(ref common.ReferenceCall
 							},
 						},
 					},
+					"syncerHealth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerHealth is the health of the syncer and of the physical cluster, as reported by the syncer with its heartbeats.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncerHealth"),
+						},
+					},
+					"syncerVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncerVersion is the build version of the syncer, as reported by the syncer with its heartbeats.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"supportedSyncerVersions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SupportedSyncerVersions is the range of syncer versions supported by kcp. It is set by kcp, unless kcp is a development build.",
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncerVersionRange"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceToSync", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.VirtualWorkspace", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1.Condition", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceToSync", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncerHealth", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.SyncerVersionRange", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.VirtualWorkspace", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_SyncerHealth(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SyncerHealth is the health of a syncer and of the physical cluster it syncs to.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"downstream": {
+						SchemaProps: spec.SchemaProps{
+							Description: "downstream is the health of the API server of the physical cluster.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.DownstreamHealth"),
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "resources are the synced resources with the state of their informers.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceHealth"),
+									},
+								},
+							},
+						},
+					},
+					"controllers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "controllers are the controllers of the syncer with the state of their work queues.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ControllerHealth"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ControllerHealth", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.DownstreamHealth", "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1.ResourceHealth"},
+	}
+}
+
+func schema_pkg_apis_workload_v1alpha1_SyncerVersionRange(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SyncerVersionRange is a range of minor versions of the syncer, e.g. v0.7 to v0.8.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"min": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Min is the oldest supported minor version, e.g. v0.7.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"max": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Max is the newest supported minor version, i.e. the one of kcp, e.g. v0.8.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"min", "max"},
+			},
+		},
 	}
 }
 
@@ -17,10 +17,16 @@ limitations under the License.
 // Package proxy provides a reverse proxy that accepts client certificates and
 // forwards Common Name and Organizations to backend API servers in HTTP
 // headers. The proxy terminates client TLS and communicates with API servers
-// via mTLS. Traffic is routed based on paths.
+// via mTLS. Traffic is routed based on paths. The /clusters/ and the /services/syncer-tunnels/
+// paths are routed to the shard of the logical cluster in the path, their backend is not used.
 //
 // An example configuration:
 //
+//  - path: /services/syncer-tunnels/
+//    backend: https://localhost:6443
+//    backend_server_ca: certs/kcp-ca-cert.pem
+//    proxy_client_cert: certs/proxy-client-cert.pem
+//    proxy_client_key: certs/proxy-client-key.pem
 //  - path: /services/
 //    backend: https://localhost:6444
 //    backend_server_ca: certs/kcp-ca-cert.pem
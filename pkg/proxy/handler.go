@@ -33,9 +33,11 @@ import (
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
 )
 
-func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
+// shardHandler routes the requests with a path of the form <prefix>/clusters/<name>/... to the shard of
+// the logical cluster with the given name.
+func shardHandler(index index.Index, prefix string, proxy http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		var cs = strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 3)
+		var cs = strings.SplitN(strings.TrimLeft(strings.TrimPrefix(req.URL.Path, prefix), "/"), "/", 3)
 		if len(cs) != 3 || cs[0] != "clusters" {
 			http.NotFound(w, req)
 			return
@@ -31,6 +31,9 @@ import (
 	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
 )
 
+// syncerTunnelsPath is the path of the syncer tunnels, served by the shards, see pkg/tunneler.
+const syncerTunnelsPath = "/services/syncer-tunnels/"
+
 // PathMapping describes how to route traffic from a path to a backend server.
 // Each Path is registered with the DefaultServeMux with a handler that
 // delegates to the specified backend.
@@ -84,11 +87,19 @@ func NewHandler(ctx context.Context, o *proxyoptions.Options, index index.Index)
 		}
 
 		var handler http.HandlerFunc
-		if m.Path == "/clusters/" {
+		switch m.Path {
+		case "/clusters/":
 			clusterProxy := newShardReverseProxy()
 			clusterProxy.Transport = transport
-			handler = shardHandler(index, clusterProxy)
-		} else {
+			handler = shardHandler(index, "", clusterProxy)
+		case syncerTunnelsPath:
+			// the syncer tunnels are served by the shard of the workspace of the SyncTarget.
+			tunnelProxy := newShardReverseProxy()
+			tunnelProxy.Transport = transport
+			// the tunnel connections are long-running streams in both directions.
+			tunnelProxy.FlushInterval = -1
+			handler = shardHandler(index, syncerTunnelsPath, tunnelProxy)
+		default:
 			// TODO: handle virtual workspace apiservers per shard
 			proxy := httputil.NewSingleHostReverseProxy(u)
 			proxy.Transport = transport
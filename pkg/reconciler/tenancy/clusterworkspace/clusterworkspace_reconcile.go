@@ -27,6 +27,7 @@ import (
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/content"
 )
 
 type reconcileStatus int
@@ -41,8 +42,15 @@ type reconciler interface {
 }
 
 func (c *Controller) reconcile(ctx context.Context, ws *tenancyv1alpha1.ClusterWorkspace) (bool, error) {
+	if _, restoring := ws.Annotations[content.RestoringAnnotationKey]; restoring {
+		// copied by the migration of the parent workspace. Location and phase are restored with the status,
+		// and the workspace must not be scheduled again in the meantime.
+		return false, nil
+	}
+
 	reconcilers := []reconciler{
 		&metaDataReconciler{},
+		&writableReconciler{},
 		&schedulingReconciler{
 			getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
 				return c.clusterWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
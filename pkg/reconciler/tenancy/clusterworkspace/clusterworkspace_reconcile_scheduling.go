@@ -21,14 +21,16 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -136,8 +138,10 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				}
 			}
 
-			if len(validShards) > 0 {
-				targetShard := validShards[rand.Intn(len(validShards))]
+			schedulableShards, unschedulableShards := filterSchedulableShards(validShards)
+
+			if len(schedulableShards) > 0 {
+				targetShard := leastLoadedShard(schedulableShards)
 
 				u, err := url.Parse(targetShard.Spec.ExternalURL)
 				if err != nil {
@@ -152,6 +156,9 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 
 				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
 				logging.WithObject(logger, targetShard).Info("scheduled workspace to shard")
+			} else if len(unschedulableShards) > 0 {
+				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No shard has capacity for the workspace: %s.", strings.Join(unschedulableShards, ", "))
+				logger.Info("no schedulable shards found for workspace, skipping", "unschedulableShards", unschedulableShards)
 			} else {
 				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace.")
 				failures := make([]error, 0, len(invalidShards))
@@ -161,32 +168,11 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				logger.Error(utilerrors.NewAggregate(failures), "no valid shards found for workspace, skipping")
 			}
 		}
-	case tenancyv1alpha1.ClusterWorkspacePhaseInitializing, tenancyv1alpha1.ClusterWorkspacePhaseReady:
-		// movement can only happen after scheduling
-		if workspace.Status.Location.Target == "" {
-			break
-		}
-
-		current, target := workspace.Status.Location.Current, workspace.Status.Location.Target
-		if current == target {
-			workspace.Status.Location.Target = ""
-			break
-		}
-
-		_, err := r.getShard(target)
-		if apierrors.IsNotFound(err) {
-			logger.Info("cannot move to nonexistent shard", "ClusterWorkspaceShard", target)
-		} else if err != nil {
-			return reconcileStatusStopAndRequeue, err
-		}
-
-		logger.Info("moving workspace to shard", "ClusterWorkspaceShard", workspace.Status.Location.Target)
-		workspace.Status.Location.Current = workspace.Status.Location.Target
-		workspace.Status.Location.Target = ""
 	}
 
-	// check scheduled shard. This has no influence on the workspace baseURL or shard assignment. This might be a trigger for
-	// a movement controller in the future (or a human intervention) to move workspaces off a shard.
+	// check scheduled shard. This has no influence on the workspace baseURL or shard assignment. Movement to another
+	// shard is done by the migration controller when status.location.target is set, e.g. below when the shard
+	// constraints change, or by a human intervention.
 	if workspace.Status.Location.Current != "" {
 		shard, err := r.getShard(workspace.Status.Location.Current)
 		if apierrors.IsNotFound(err) {
@@ -201,6 +187,7 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 
 		if workspace.Spec.Shard != nil && shard != nil {
 			needsRescheduling := false
+			var candidates []*tenancyv1alpha1.ClusterWorkspaceShard
 			if workspace.Spec.Shard.Selector != nil {
 				var err error
 				selector, err := metav1.LabelSelectorAsSelector(workspace.Spec.Shard.Selector)
@@ -208,12 +195,21 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "spec.location.shardSelector is invalid: %v", err)
 					return reconcileStatusContinue, nil // don't retry, cannot do anything useful
 				}
-				needsRescheduling = !selector.Matches(labels.Set(shard.Labels))
+				if needsRescheduling = !selector.Matches(labels.Set(shard.Labels)); needsRescheduling {
+					if candidates, err = r.listShards(selector); err != nil {
+						return reconcileStatusStopAndRequeue, err
+					}
+				}
 			} else if shardName := workspace.Spec.Shard.Name; shardName != "" && shardName != workspace.Status.Location.Current {
 				needsRescheduling = true
+				if candidate, err := r.getShard(shardName); err == nil {
+					candidates = append(candidates, candidate)
+				} else if !apierrors.IsNotFound(err) {
+					return reconcileStatusStopAndRequeue, err
+				}
 			}
 			if needsRescheduling {
-				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but movement is not supported yet")
+				r.reschedule(ctx, workspace, candidates)
 			} else {
 				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
 			}
@@ -225,6 +221,112 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 	return reconcileStatusContinue, nil
 }
 
+// reschedule sets the migration target of the workspace to one of the valid candidate shards, unless a
+// migration is already in progress.
+func (r *schedulingReconciler) reschedule(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, candidates []*tenancyv1alpha1.ClusterWorkspaceShard) {
+	if target := workspace.Status.Location.Target; target != "" || workspace.Status.Migration != nil {
+		if workspace.Status.Migration != nil {
+			target = workspace.Status.Migration.Target
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonRescheduling, conditionsv1alpha1.ConditionSeverityInfo, "Moving to shard %q.", target)
+		return
+	}
+
+	validShards := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(candidates))
+	for _, shard := range candidates {
+		if valid, _, _ := isValidShard(shard); valid && shard.Name != workspace.Status.Location.Current {
+			validShards = append(validShards, shard)
+		}
+	}
+	if len(validShards) == 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but no shard matches the shard constraints.")
+		return
+	}
+	schedulableShards, unschedulableShards := filterSchedulableShards(validShards)
+	if len(schedulableShards) == 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but no shard matching the shard constraints has capacity: %s.", strings.Join(unschedulableShards, ", "))
+		return
+	}
+
+	targetShard := leastLoadedShard(schedulableShards)
+	workspace.Status.Location.Target = targetShard.Name
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonRescheduling, conditionsv1alpha1.ConditionSeverityInfo, "Moving to shard %q.", targetShard.Name)
+	logging.WithObject(klog.FromContext(ctx), targetShard).Info("rescheduling workspace to shard")
+}
+
 func isValidShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) (valid bool, reason, message string) {
 	return true, "", ""
 }
+
+// isSchedulableShard returns whether new workspaces can be scheduled onto the shard, i.e. it is not
+// cordoned and the usage of no resource has reached its capacity. Workspaces already on the shard are
+// not affected.
+func isSchedulableShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) (schedulable bool, message string) {
+	if shard.Spec.Unschedulable {
+		return false, "cordoned"
+	}
+
+	names := make([]string, 0, len(shard.Status.Capacity))
+	for name := range shard.Status.Capacity {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		capacity := shard.Status.Capacity[corev1.ResourceName(name)]
+		usage := shard.Status.Usage[corev1.ResourceName(name)]
+		if usage.Cmp(capacity) >= 0 {
+			return false, fmt.Sprintf("full, %s usage %s reached capacity %s", name, usage.String(), capacity.String())
+		}
+	}
+
+	return true, ""
+}
+
+// filterSchedulableShards splits the shards into those new workspaces can be scheduled onto, and
+// messages about the others.
+func filterSchedulableShards(shards []*tenancyv1alpha1.ClusterWorkspaceShard) (schedulable []*tenancyv1alpha1.ClusterWorkspaceShard, unschedulable []string) {
+	for _, shard := range shards {
+		if ok, message := isSchedulableShard(shard); ok {
+			schedulable = append(schedulable, shard)
+		} else {
+			unschedulable = append(unschedulable, fmt.Sprintf("shard %q is %s", shard.Name, message))
+		}
+	}
+	sort.Strings(unschedulable)
+	return schedulable, unschedulable
+}
+
+// shardLoad is the highest ratio of usage to capacity of all resources of the shard. Shards
+// without capacity have no load.
+func shardLoad(shard *tenancyv1alpha1.ClusterWorkspaceShard) float64 {
+	var load float64
+	for name, capacity := range shard.Status.Capacity {
+		if capacity.IsZero() {
+			continue
+		}
+		usage := shard.Status.Usage[name]
+		if ratio := usage.AsApproximateFloat64() / capacity.AsApproximateFloat64(); ratio > load {
+			load = ratio
+		}
+	}
+	return load
+}
+
+// leastLoadedShard returns the shard with the lowest load. Ties are broken by the number of
+// workspaces on the shard, and then by name. The shards must not be empty.
+func leastLoadedShard(shards []*tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+	sorted := make([]*tenancyv1alpha1.ClusterWorkspaceShard, len(shards))
+	copy(sorted, shards)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if li, lj := shardLoad(sorted[i]), shardLoad(sorted[j]); li != lj {
+			return li < lj
+		}
+		wi := sorted[i].Status.Usage[tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspaces]
+		wj := sorted[j].Status.Usage[tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspaces]
+		if c := wi.Cmp(wj); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted[0]
+}
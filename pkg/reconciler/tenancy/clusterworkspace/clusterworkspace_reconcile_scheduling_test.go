@@ -24,6 +24,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -236,6 +237,229 @@ func TestSchedulingReconciler(t *testing.T) {
 			),
 			wantStatus: reconcileStatusContinue,
 		},
+		{
+			name: "spec shard name changed, rescheduled",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				withURLs("https://foo", "https://front-proxy", shard("foo")),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				movingTo("foo", scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace())))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityInfo,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonRescheduling,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard name changed to nonexistent shard, not rescheduled",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "unknown"}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "unknown"}, workspace()))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnreschedulable,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard selector, least loaded shard",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"a": "1"}},
+				}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withUsage("workspaces", "10", "8", withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo")))),
+				withUsage("workspaces", "100", "20", withLabels(map[string]string{"a": "1"}, withURLs("https://bar", "https://front-proxy", shard("bar")))),
+				withUsage("workspaces", "100", "50", withLabels(map[string]string{"a": "1"}, withURLs("https://baz", "https://front-proxy", shard("baz")))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("bar", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard selector, full and cordoned shards skipped",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"a": "1"}},
+				}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withUsage("workspaces", "10", "10", withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo")))),
+				cordoned(withLabels(map[string]string{"a": "1"}, withURLs("https://bar", "https://front-proxy", shard("bar")))),
+				withUsage("workspaces", "10", "9", withLabels(map[string]string{"a": "1"}, withURLs("https://baz", "https://front-proxy", shard("baz")))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("baz", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name:      "root shard full, unschedulable",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withUsage("workspaces", "10", "10", withURLs("https://root", "https://front-proxy", shard("root"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard name cordoned, unschedulable",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				cordoned(withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace())),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard name changed to cordoned shard, not rescheduled",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				cordoned(withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace()))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnreschedulable,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard selector not matching anymore, rescheduled to least loaded shard",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{"b": "2"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withUsage("workspaces", "10", "5", withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo")))),
+				withUsage("workspaces", "10", "2", withLabels(map[string]string{"a": "1"}, withURLs("https://bar", "https://front-proxy", shard("bar")))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				movingTo("bar", scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace())))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityInfo,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonRescheduling,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard selector not matching anymore, migration in progress",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				migrating("foo", scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace())))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{"b": "2"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+				withLabels(map[string]string{"a": "1"}, withURLs("https://bar", "https://front-proxy", shard("bar"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				migrating("foo", scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace())))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityInfo,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonRescheduling,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -300,6 +524,21 @@ func scheduled(shard string, baseURL string, ws *tenancyv1alpha1.ClusterWorkspac
 	return ws
 }
 
+func movingTo(shard string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Location.Target = shard
+	return ws
+}
+
+func migrating(shard string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Location.Target = shard
+	ws.Status.Migration = &tenancyv1alpha1.ClusterWorkspaceMigration{
+		Phase:  tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying,
+		Source: ws.Status.Location.Current,
+		Target: shard,
+	}
+	return ws
+}
+
 func constrained(constraints tenancyv1alpha1.ShardConstraints, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
 	ws.Spec.Shard = &constraints
 	return ws
@@ -328,3 +567,20 @@ func withLabels(labels map[string]string, shard *tenancyv1alpha1.ClusterWorkspac
 	shard.Labels = labels
 	return shard
 }
+
+func withUsage(resourceName, capacity, usage string, shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+	if shard.Status.Capacity == nil {
+		shard.Status.Capacity = corev1.ResourceList{}
+	}
+	if shard.Status.Usage == nil {
+		shard.Status.Usage = corev1.ResourceList{}
+	}
+	shard.Status.Capacity[corev1.ResourceName(resourceName)] = resource.MustParse(capacity)
+	shard.Status.Usage[corev1.ResourceName(resourceName)] = resource.MustParse(usage)
+	return shard
+}
+
+func cordoned(shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+	shard.Spec.Unschedulable = true
+	return shard
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspace
+
+import (
+	"context"
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/content"
+)
+
+func TestReconcileChildOfMigratedWorkspace(t *testing.T) {
+	root := withURLs("https://root", "https://front-proxy", shard("root"))
+	root.Annotations = map[string]string{logicalcluster.AnnotationKey: tenancyv1alpha1.RootCluster.String()}
+	shardIndexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, shardIndexer.Add(root))
+	c := &Controller{
+		kcpClusterClient:            kcpfake.NewSimpleClientset(root),
+		clusterWorkspaceShardLister: tenancylisters.NewClusterWorkspaceShardLister(shardIndexer),
+	}
+
+	child := func() *tenancyv1alpha1.ClusterWorkspace {
+		ws := phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace())
+		ws.Annotations = map[string]string{logicalcluster.AnnotationKey: "root:org:ws"}
+		ws.Labels = map[string]string{tenancyv1alpha1.ClusterWorkspacePhaseLabel: string(tenancyv1alpha1.ClusterWorkspacePhaseScheduling)}
+		return ws
+	}
+
+	// created by the migration of root:org:ws, before its status is restored
+	restoring := child()
+	restoring.Annotations[content.RestoringAnnotationKey] = "true"
+	ws := restoring.DeepCopy()
+	requeue, err := c.reconcile(context.Background(), ws)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, restoring, ws, "workspace must not be scheduled while its status is restored")
+
+	ws = child()
+	_, err = c.reconcile(context.Background(), ws)
+	require.NoError(t, err)
+	require.Equal(t, "root", ws.Status.Location.Current, "workspace must be scheduled otherwise")
+}
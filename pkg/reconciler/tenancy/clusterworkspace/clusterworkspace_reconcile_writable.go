@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspace
+
+import (
+	"context"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// writableReconciler reflects in the WorkspaceWritable condition whether writes to the workspace
+// content are denied by the workspace freeze authorizer.
+type writableReconciler struct {
+}
+
+func (r *writableReconciler) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (reconcileStatus, error) {
+	if migration := workspace.Status.Migration; migration != nil &&
+		(migration.Phase == tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing || migration.Phase == tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying) {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceWritable, tenancyv1alpha1.WorkspaceWritableReasonMigrating, conditionsv1alpha1.ConditionSeverityInfo, "Writes are denied while moving to shard %q.", migration.Target)
+	} else if workspace.Spec.ReadOnly {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceWritable, tenancyv1alpha1.WorkspaceWritableReasonReadOnly, conditionsv1alpha1.ConditionSeverityInfo, "Writes are denied for all but exempted subjects because spec.readOnly is set.")
+	} else {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceWritable)
+	}
+	return reconcileStatusContinue, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestWritableReconciler(t *testing.T) {
+	readOnly := func(ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+		ws.Spec.ReadOnly = true
+		return ws
+	}
+	withMigration := func(phase tenancyv1alpha1.ClusterWorkspaceMigrationPhaseType, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+		ws.Status.Migration = &tenancyv1alpha1.ClusterWorkspaceMigration{Phase: phase, Source: "root", Target: "foo"}
+		return ws
+	}
+
+	tests := []struct {
+		name       string
+		workspace  *tenancyv1alpha1.ClusterWorkspace
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "writable",
+			workspace:  workspace(),
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "read-only",
+			workspace:  readOnly(workspace()),
+			wantStatus: corev1.ConditionFalse,
+			wantReason: tenancyv1alpha1.WorkspaceWritableReasonReadOnly,
+		},
+		{
+			name:       "copying",
+			workspace:  readOnly(withMigration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, workspace())),
+			wantStatus: corev1.ConditionFalse,
+			wantReason: tenancyv1alpha1.WorkspaceWritableReasonMigrating,
+		},
+		{
+			name:       "cleaning up after the switch",
+			workspace:  withMigration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp, workspace()),
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "read-only again",
+			workspace:  withConditions(readOnly(workspace()), *conditions.TrueCondition(tenancyv1alpha1.WorkspaceWritable)),
+			wantStatus: corev1.ConditionFalse,
+			wantReason: tenancyv1alpha1.WorkspaceWritableReasonReadOnly,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &writableReconciler{}
+			ws := tt.workspace.DeepCopy()
+			status, err := r.reconcile(context.Background(), ws)
+			require.NoError(t, err)
+			require.Equal(t, reconcileStatusContinue, status)
+
+			c := conditions.Get(ws, tenancyv1alpha1.WorkspaceWritable)
+			require.NotNil(t, c)
+			require.Equal(t, tt.wantStatus, c.Status)
+			require.Equal(t, tt.wantReason, c.Reason)
+		})
+	}
+}
@@ -62,6 +62,7 @@ func NewController(
 	kcpClusterClient kcpclient.Interface,
 	metadataClusterClient metadata.Interface,
 	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	workspaceTypeInformer tenancyinformers.ClusterWorkspaceTypeInformer,
 	discoverResourcesFn func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error),
 ) *Controller {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
@@ -72,8 +73,9 @@ func NewController(
 		kcpClusterClient:      kcpClusterClient,
 		metadataClusterClient: metadataClusterClient,
 		workspaceLister:       workspaceInformer.Lister(),
-		deleter:               deletion.NewWorkspacedResourcesDeleter(metadataClusterClient, discoverResourcesFn),
+		workspaceTypeLister:   workspaceTypeInformer.Lister(),
 	}
+	c.deleter = deletion.NewWorkspacedResourcesDeleter(metadataClusterClient, discoverResourcesFn, c.deletionRetention)
 
 	workspaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
@@ -100,8 +102,9 @@ type Controller struct {
 	kcpClusterClient      kcpclient.Interface
 	metadataClusterClient metadata.Interface
 
-	workspaceLister tenancylisters.ClusterWorkspaceLister
-	deleter         deletion.WorkspaceResourcesDeleterInterface
+	workspaceLister     tenancylisters.ClusterWorkspaceLister
+	workspaceTypeLister tenancylisters.ClusterWorkspaceTypeLister
+	deleter             deletion.WorkspaceResourcesDeleterInterface
 }
 
 func (c *Controller) enqueue(obj interface{}) {
@@ -162,7 +165,14 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	}
 
 	var estimate *deletion.ResourcesRemainingError
-	if errors.As(err, &estimate) {
+	var retained *deletion.ResourcesRetainedError
+	if errors.As(err, &retained) {
+		duration := time.Until(retained.Until)
+		logger.V(2).Info("content of workspace is retained", "until", retained.Until, "waiting", duration)
+
+		c.queue.Forget(key)
+		c.queue.AddAfter(key, duration)
+	} else if errors.As(err, &estimate) {
 		t := estimate.Estimate/2 + 1
 		duration := time.Duration(t) * time.Second
 		logger.V(2).Error(err, "content remaining in workspace after a wait, waiting more to continue", "duration", time.Since(startTime), "waiting", duration)
@@ -196,6 +206,17 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		return nil
 	}
 
+	if _, found := workspace.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceUndeleteAnnotationKey]; found && helper.IsRetained(workspace) {
+		retention, err := c.deletionRetention(workspace)
+		if err != nil {
+			return err
+		}
+		if time.Now().Before(workspace.DeletionTimestamp.Add(retention)) {
+			return c.undelete(ctx, workspace)
+		}
+		logger.V(2).Info("ignoring undelete annotation, the retention period of the ClusterWorkspace expired")
+	}
+
 	workspaceCopy := workspace.DeepCopy()
 
 	logger.V(2).Info("deleting ClusterWorkspace")
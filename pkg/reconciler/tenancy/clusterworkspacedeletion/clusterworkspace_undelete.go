@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacedeletion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion/deletion"
+)
+
+// deletionRetention returns the retention period of the type of the given ClusterWorkspace. Workspaces of
+// types without a ClusterWorkspaceType object are not retained.
+func (c *Controller) deletionRetention(workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	ref := workspace.Spec.Type
+	cwt, err := c.workspaceTypeLister.Get(clusters.ToClusterAwareKey(logicalcluster.New(ref.Path), tenancyv1alpha1.ObjectName(ref.Name)))
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if cwt.Spec.DeletionRetention == nil {
+		return 0, nil
+	}
+	return cwt.Spec.DeletionRetention.Duration, nil
+}
+
+// undelete restores a retained ClusterWorkspace. As the deletion of an object cannot be reverted, the
+// ClusterWorkspace is finalized without removing its content, and created again with the same spec
+// and status. The logical cluster of the workspace is derived from its name, hence the new
+// ClusterWorkspace owns the retained content.
+func (c *Controller) undelete(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := klog.FromContext(ctx)
+	ctx = logicalcluster.WithCluster(ctx, logicalcluster.From(workspace))
+
+	restored := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        workspace.Name,
+			Labels:      workspace.Labels,
+			Annotations: map[string]string{},
+		},
+		Spec: workspace.Spec,
+	}
+	for k, v := range workspace.Annotations {
+		if k != tenancyv1alpha1.ExperimentalClusterWorkspaceUndeleteAnnotationKey {
+			restored.Annotations[k] = v
+		}
+	}
+
+	// make sure admission lets the workspace be created again before giving up the old object, e.g. that
+	// its type still exists. The dry-run fails with AlreadyExists only after admission passed.
+	if _, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, restored, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("cannot undelete ClusterWorkspace: %w", err)
+	}
+
+	// remove the finalizer without deleting content or RBAC. If we crash between the removal and the
+	// creation below, the content is orphaned until a ClusterWorkspace with the same name is created.
+	finalizers := make([]string, 0, len(workspace.Finalizers))
+	for _, f := range workspace.Finalizers {
+		if f != deletion.WorkspaceFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	if len(finalizers) != len(workspace.Finalizers) {
+		workspaceCopy := workspace.DeepCopy()
+		workspaceCopy.Finalizers = finalizers
+		logger.V(2).Info("removing finalizer from ClusterWorkspace to undelete it")
+		if _, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Update(ctx, workspaceCopy, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	logger.V(2).Info("creating undeleted ClusterWorkspace")
+	created, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, restored, metav1.CreateOptions{})
+	if err != nil {
+		// other finalizers might still hold the old object. We are requeued until it is gone.
+		return fmt.Errorf("cannot undelete ClusterWorkspace: %w", err)
+	}
+
+	// the scheduler would place a new workspace anywhere, but the content lives where it was.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		created.Status = *workspace.Status.DeepCopy()
+		conditions.Delete(created, tenancyv1alpha1.WorkspaceContentDeleted)
+		conditions.Delete(created, tenancyv1alpha1.WorkspaceDeletionContentSuccess)
+		_, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(ctx, created, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			if latest, getErr := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, created.Name, metav1.GetOptions{}); getErr == nil {
+				created = latest
+			}
+		}
+		return err
+	})
+}
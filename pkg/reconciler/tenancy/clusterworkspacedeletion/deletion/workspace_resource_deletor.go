@@ -37,6 +37,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -68,13 +69,17 @@ type WorkspaceResourcesDeleterInterface interface {
 	Delete(ctx context.Context, ws *tenancyv1alpha1.ClusterWorkspace) error
 }
 
-// NewWorkspacedResourcesDeleter returns a new NamespacedResourcesDeleter.
+// NewWorkspacedResourcesDeleter returns a new NamespacedResourcesDeleter. The content of a deleted workspace
+// is retained for the duration returned by deletionRetentionFn before it is removed.
 func NewWorkspacedResourcesDeleter(
 	metadataClusterClient metadata.Interface,
-	discoverResourcesFn func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error)) WorkspaceResourcesDeleterInterface {
+	discoverResourcesFn func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error),
+	deletionRetentionFn func(ws *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error)) WorkspaceResourcesDeleterInterface {
 	d := &workspacedResourcesDeleter{
 		metadataClusterClient: metadataClusterClient,
 		discoverResourcesFn:   discoverResourcesFn,
+		deletionRetentionFn:   deletionRetentionFn,
+		now:                   time.Now,
 	}
 	return d
 }
@@ -87,11 +92,19 @@ type workspacedResourcesDeleter struct {
 	metadataClusterClient metadata.Interface
 
 	discoverResourcesFn func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error)
+
+	// deletionRetentionFn returns how long the content of a deleted workspace is kept.
+	deletionRetentionFn func(ws *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error)
+
+	now func() time.Time
 }
 
 // Delete deletes all resources in the given workspace.
 // Before deleting resources:
 //
+// Returns ResourcesRetainedError if the retention period of the workspace
+// has not expired yet, and nothing is deleted.
+//
 // Returns ResourcesRemainingError if it deleted some resources but needs
 // to wait for them to go away.
 // Caller is expected to keep calling this until it succeeds.
@@ -115,6 +128,26 @@ func (d *workspacedResourcesDeleter) Delete(ctx context.Context, workspace *tena
 		return nil
 	}
 
+	// keep the content until the retention period expires. Once the content deletion started, it is
+	// finished even if the retention of the type is extended meanwhile.
+	if !conditions.Has(workspace, tenancyv1alpha1.WorkspaceDeletionContentSuccess) {
+		retention, err := d.deletionRetentionFn(workspace)
+		if err != nil {
+			return err
+		}
+		if until := workspace.DeletionTimestamp.Add(retention); d.now().Before(until) {
+			conditions.MarkFalse(
+				workspace,
+				tenancyv1alpha1.WorkspaceContentDeleted,
+				tenancyv1alpha1.WorkspaceContentDeletedReasonRetained,
+				conditionsv1alpha1.ConditionSeverityInfo,
+				"The workspace can be undeleted until %s",
+				until.UTC().Format(time.RFC3339),
+			)
+			return &ResourcesRetainedError{Until: until}
+		}
+	}
+
 	// there may still be content for us to remove
 	estimate, message, err := d.deleteAllContent(ctx, workspace)
 	if err != nil {
@@ -142,6 +175,16 @@ func (e *ResourcesRemainingError) Error() string {
 	return fmt.Sprintf("%s: %s", ret, e.Message)
 }
 
+// ResourcesRetainedError is used to inform the caller that the content of the workspace is retained until the
+// given time.
+type ResourcesRetainedError struct {
+	Until time.Time
+}
+
+func (e *ResourcesRetainedError) Error() string {
+	return fmt.Sprintf("the content of the workspace is retained until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
 // operation is used for caching if an operation is supported on a dynamic client.
 type operation string
 
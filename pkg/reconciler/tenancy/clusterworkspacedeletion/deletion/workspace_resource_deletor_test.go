@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -132,7 +133,7 @@ func TestWorkspaceTerminating(t *testing.T) {
 				return resources, tt.gvrError
 			}
 			mockMetadataClient := metadatafake.NewSimpleMetadataClient(scheme, tt.existingObject...)
-			d := NewWorkspacedResourcesDeleter(mockMetadataClient, fn)
+			d := NewWorkspacedResourcesDeleter(mockMetadataClient, fn, noRetention)
 
 			err := d.Delete(context.TODO(), ws)
 			if !matchErrors(err, tt.expectErrorOnDelete) {
@@ -162,6 +163,93 @@ func TestWorkspaceTerminating(t *testing.T) {
 	}
 }
 
+func TestWorkspaceRetained(t *testing.T) {
+	deleted := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	retention := func(*tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) { return time.Hour, nil }
+
+	tests := []struct {
+		name                    string
+		now                     time.Time
+		contentDeletionStarted  bool
+		metadataClientActionSet metaActionSet
+		expectErrorOnDelete     error
+		expectContentDeleted    v1.ConditionStatus
+		expectReason            string
+	}{
+		{
+			name:                 "retention not expired",
+			now:                  deleted.Add(time.Minute),
+			expectErrorOnDelete:  &ResourcesRetainedError{Until: deleted.Add(time.Hour)},
+			expectContentDeleted: v1.ConditionFalse,
+			expectReason:         tenancyv1alpha1.WorkspaceContentDeletedReasonRetained,
+		},
+		{
+			name: "retention expired",
+			now:  deleted.Add(2 * time.Hour),
+			metadataClientActionSet: []metaAction{
+				{"customresourcedefinitions", "delete-collection"},
+				{"customresourcedefinitions", "list"},
+			},
+			expectContentDeleted: v1.ConditionTrue,
+		},
+		{
+			name:                   "content deletion already started",
+			now:                    deleted.Add(time.Minute),
+			contentDeletionStarted: true,
+			metadataClientActionSet: []metaAction{
+				{"customresourcedefinitions", "delete-collection"},
+				{"customresourcedefinitions", "list"},
+			},
+			expectContentDeleted: v1.ConditionTrue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test",
+					DeletionTimestamp: &metav1.Time{Time: deleted},
+					Finalizers:        []string{WorkspaceFinalizer},
+				},
+			}
+			if tt.contentDeletionStarted {
+				conditions.MarkTrue(ws, tenancyv1alpha1.WorkspaceDeletionContentSuccess)
+			}
+			fn := func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error) {
+				return testResources(), nil
+			}
+			mockMetadataClient := metadatafake.NewSimpleMetadataClient(scheme)
+			d := NewWorkspacedResourcesDeleter(mockMetadataClient, fn, retention).(*workspacedResourcesDeleter)
+			d.now = func() time.Time { return tt.now }
+
+			err := d.Delete(context.TODO(), ws)
+			if !matchErrors(err, tt.expectErrorOnDelete) {
+				t.Errorf("expected error %q when deleting workspace, got %q", tt.expectErrorOnDelete, err)
+			}
+
+			cond := conditions.Get(ws, tenancyv1alpha1.WorkspaceContentDeleted)
+			if cond == nil {
+				t.Fatalf("Missing status condition %v", tenancyv1alpha1.WorkspaceContentDeleted)
+			}
+			if cond.Status != tt.expectContentDeleted {
+				t.Errorf("expect condition status %q, got %q", tt.expectContentDeleted, cond.Status)
+			}
+			if cond.Reason != tt.expectReason {
+				t.Errorf("expect condition reason %q, got %q", tt.expectReason, cond.Reason)
+			}
+
+			if len(mockMetadataClient.Actions()) != len(tt.metadataClientActionSet) {
+				t.Fatalf("mismatched actions, expect %d actions, got %d actions", len(tt.metadataClientActionSet), len(mockMetadataClient.Actions()))
+			}
+		})
+	}
+}
+
+func noRetention(*tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	return 0, nil
+}
+
 type metaAction struct {
 	resource string
 	verb     string
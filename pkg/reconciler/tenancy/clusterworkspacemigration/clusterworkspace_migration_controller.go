@@ -0,0 +1,270 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemigration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/content"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-migration"
+)
+
+// ShardClientGetter returns a client for the content of the given logical cluster on the given shard.
+// The client must be privileged, i.e. not be subject to the write freeze of the migration.
+type ShardClientGetter func(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (*content.Client, error)
+
+// NewController returns a controller moving the content of ClusterWorkspaces to the shard set in
+// status.location.target: writes to the workspace are frozen, the content is copied to the target
+// shard, the workspace is switched to the target shard, and the stale content is removed from the
+// source shard.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
+	shardClientGetter ShardClientGetter,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:                       queue,
+		kcpClusterClient:            kcpClusterClient,
+		workspaceLister:             workspaceInformer.Lister(),
+		clusterWorkspaceShardLister: clusterWorkspaceShardInformer.Lister(),
+	}
+	c.reconciler = &migrationReconciler{
+		getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+			return c.clusterWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
+		},
+		getClient:     shardClientGetter,
+		uidReferenced: content.UIDReferenced,
+		copy:          content.Copy,
+		purge:         content.Purge,
+		now:           time.Now,
+	}
+
+	workspaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			switch obj := obj.(type) {
+			case *tenancyv1alpha1.ClusterWorkspace:
+				return isMigrating(obj)
+			default:
+				return false
+			}
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	})
+
+	clusterWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueueMigrating() },
+	})
+
+	return c
+}
+
+// Controller moves ClusterWorkspaces between ClusterWorkspaceShards.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.Interface
+
+	workspaceLister             tenancylisters.ClusterWorkspaceLister
+	clusterWorkspaceShardLister tenancylisters.ClusterWorkspaceShardLister
+
+	reconciler *migrationReconciler
+}
+
+func isMigrating(workspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	return workspace.Status.Location.Target != "" || workspace.Status.Migration != nil
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := kcpcache.MetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace")
+	c.queue.Add(key)
+}
+
+// enqueueMigrating enqueues all workspaces to be migrated, e.g. to start migrations waiting for their
+// target shard to show up.
+func (c *Controller) enqueueMigrating() {
+	workspaces, err := c.workspaceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, workspace := range workspaces {
+		if isMigrating(workspace) {
+			c.enqueue(workspace)
+		}
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	requeueAfter, err := c.process(ctx, key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) (time.Duration, error) {
+	obj, err := c.workspaceLister.Get(key)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil // object deleted before we handled it
+		}
+		return 0, err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	requeueAfter, err := c.reconciler.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Regardless of whether reconcile returned an error or not, always try to patch status if needed. Return the
+	// reconciliation error at the end.
+	if err := c.patchStatusIfNeeded(ctx, old, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	return requeueAfter, utilerrors.NewAggregate(errs)
+}
+
+func (c *Controller) patchStatusIfNeeded(ctx context.Context, old, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(old)
+	name := old.Name
+
+	// the resource version is a precondition, such that the switch to the target shard is based on
+	// the migration state the content was copied for.
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, name, err)
+	}
+
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, name, err)
+	}
+
+	klog.FromContext(ctx).WithValues("patch", string(patchBytes)).V(2).Info("patching ClusterWorkspace")
+	_, err = c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return fmt.Errorf("failed to patch ClusterWorkspace %s|%s: %w", clusterName, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemigration
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/content"
+)
+
+const (
+	// freezeGracePeriod is the time given to the shards to observe the write freeze through their
+	// informers, and to in-flight requests to finish, before the content is copied.
+	freezeGracePeriod = 10 * time.Second
+
+	// purgeRequeueDelay is the delay before checking again for content remaining after a purge,
+	// e.g. namespaces waiting for their content to be deleted.
+	purgeRequeueDelay = 5 * time.Second
+
+	// confirmationRequeueDelay is the delay before checking again for objects whose UIDs are referenced
+	// outside of kcp, e.g. after they have been deleted, while the migration waits for confirmation.
+	confirmationRequeueDelay = 1 * time.Minute
+)
+
+type migrationReconciler struct {
+	getShard      func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	getClient     func(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (*content.Client, error)
+	uidReferenced func(ctx context.Context, c *content.Client) ([]string, error)
+	copy          func(ctx context.Context, source, target *content.Client) (int, error)
+	purge         func(ctx context.Context, c *content.Client) (int, error)
+	now           func() time.Time
+}
+
+// reconcile moves the workspace content to the shard in status.location.target. It returns the duration
+// after which the workspace has to be reconciled again, if not zero.
+func (r *migrationReconciler) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	logger := klog.FromContext(ctx)
+	migration := workspace.Status.Migration
+	target := workspace.Status.Location.Target
+	clusterName := logicalcluster.From(workspace).Join(workspace.Name)
+
+	if migration == nil {
+		if target == "" {
+			return 0, nil
+		}
+		if target == workspace.Status.Location.Current {
+			workspace.Status.Location.Target = ""
+			return 0, nil
+		}
+		// movement can only happen after scheduling
+		if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			return 0, nil
+		}
+		if !workspace.DeletionTimestamp.IsZero() {
+			return 0, nil
+		}
+
+		if _, err := r.getShard(target); apierrors.IsNotFound(err) {
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonTargetShardInvalid, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspaceShard %q does not exist.", target)
+			return 0, nil // retried when shards show up
+		} else if err != nil {
+			return 0, err
+		}
+
+		if !migrateWithNewUIDs(workspace) {
+			sourceShard, err := r.getShard(workspace.Status.Location.Current)
+			if err != nil {
+				return 0, err
+			}
+			sourceClient, err := r.getClient(sourceShard, clusterName)
+			if err != nil {
+				return 0, err
+			}
+			if referenced, err := r.uidReferenced(ctx, sourceClient); err != nil {
+				return 0, err
+			} else if len(referenced) > 0 {
+				confirmationRequired(workspace, target, referenced)
+				return confirmationRequeueDelay, nil
+			}
+		}
+
+		logger.Info("starting migration of workspace", "source", workspace.Status.Location.Current, "target", target)
+		workspace.Status.Migration = &tenancyv1alpha1.ClusterWorkspaceMigration{
+			Phase:     tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing,
+			Source:    workspace.Status.Location.Current,
+			Target:    target,
+			StartTime: metav1.NewTime(r.now()),
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonInProgress, conditionsv1alpha1.ConditionSeverityInfo, "Freezing writes before moving from shard %q to shard %q.", workspace.Status.Location.Current, target)
+		return freezeGracePeriod, nil
+	}
+
+	// changing the target before the switch aborts the migration
+	if (migration.Phase == tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing || migration.Phase == tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying) && target != migration.Target {
+		logger.Info("rolling back migration of workspace", "source", migration.Source, "target", migration.Target)
+		migration.Phase = tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonInProgress, conditionsv1alpha1.ConditionSeverityInfo, "Rolling back the move to shard %q.", migration.Target)
+		return 0, nil
+	}
+
+	switch migration.Phase {
+	case tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing:
+		if wait := migration.StartTime.Add(freezeGracePeriod).Sub(r.now()); wait > 0 {
+			return wait, nil
+		}
+		migration.Phase = tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonInProgress, conditionsv1alpha1.ConditionSeverityInfo, "Copying content from shard %q to shard %q.", migration.Source, migration.Target)
+		return 0, nil
+
+	case tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying:
+		sourceShard, err := r.getShard(migration.Source)
+		if err != nil {
+			return 0, r.failed(workspace, "Failed to get source shard %q: %v.", migration.Source, err)
+		}
+		targetShard, err := r.getShard(migration.Target)
+		if err != nil {
+			return 0, r.failed(workspace, "Failed to get target shard %q: %v.", migration.Target, err)
+		}
+		baseURL, err := workspaceBaseURL(targetShard, clusterName)
+		if err != nil {
+			return 0, r.failed(workspace, "Invalid connection information on target shard %q: %v.", migration.Target, err)
+		}
+		sourceClient, err := r.getClient(sourceShard, clusterName)
+		if err != nil {
+			return 0, r.failed(workspace, "Failed to connect to source shard %q: %v.", migration.Source, err)
+		}
+		targetClient, err := r.getClient(targetShard, clusterName)
+		if err != nil {
+			return 0, r.failed(workspace, "Failed to connect to target shard %q: %v.", migration.Target, err)
+		}
+
+		// check again on the frozen content, objects might have been created since the start
+		if !migrateWithNewUIDs(workspace) {
+			referenced, err := r.uidReferenced(ctx, sourceClient)
+			if err != nil {
+				return 0, r.failed(workspace, "Failed to list objects on source shard %q: %v.", migration.Source, err)
+			}
+			if len(referenced) > 0 {
+				logger.Info("rolling back migration of workspace, confirmation required", "source", migration.Source, "target", migration.Target)
+				migration.Phase = tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack
+				confirmationRequired(workspace, migration.Target, referenced)
+				return 0, nil
+			}
+		}
+
+		copied, err := r.copy(ctx, sourceClient, targetClient)
+		migration.CopiedObjects = int64(copied)
+		if err != nil {
+			return 0, r.failed(workspace, "Failed to copy content to shard %q: %v.", migration.Target, err)
+		}
+
+		// Switch to the target shard in one update: the front-proxy follows location.current, and
+		// clients follow the base URL.
+		logger.Info("switching workspace to target shard", "source", migration.Source, "target", migration.Target, "copiedObjects", copied)
+		workspace.Status.Location.Current = migration.Target
+		workspace.Status.Location.Target = ""
+		workspace.Status.BaseURL = baseURL
+		migration.Phase = tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonInProgress, conditionsv1alpha1.ConditionSeverityInfo, "Removing content from shard %q.", migration.Source)
+		return 0, nil
+
+	case tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp:
+		done, err := r.purgeFrom(ctx, workspace, migration.Source, clusterName)
+		if err != nil || !done {
+			return purgeRequeueDelay, err
+		}
+		logger.Info("finished migration of workspace", "source", migration.Source, "target", migration.Target)
+		workspace.Status.Migration = nil
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceMigrated)
+		return 0, nil
+
+	case tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack:
+		done, err := r.purgeFrom(ctx, workspace, migration.Target, clusterName)
+		if err != nil || !done {
+			return purgeRequeueDelay, err
+		}
+		logger.Info("rolled back migration of workspace", "source", migration.Source, "target", migration.Target)
+		workspace.Status.Migration = nil
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonRolledBack, conditionsv1alpha1.ConditionSeverityInfo, "The move to shard %q was rolled back.", migration.Target)
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("unknown migration phase %q", migration.Phase)
+}
+
+// purgeFrom removes the content of the workspace from the given shard. It returns true when nothing
+// remains. A shard that does not exist anymore has nothing to remove.
+func (r *migrationReconciler) purgeFrom(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shardName string, clusterName logicalcluster.Name) (bool, error) {
+	shard, err := r.getShard(shardName)
+	if apierrors.IsNotFound(err) {
+		klog.FromContext(ctx).Info("shard to remove workspace content from does not exist anymore", "ClusterWorkspaceShard", shardName)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	c, err := r.getClient(shard, clusterName)
+	if err != nil {
+		return false, r.failed(workspace, "Failed to connect to shard %q: %v.", shardName, err)
+	}
+	remaining, err := r.purge(ctx, c)
+	if err != nil {
+		return false, r.failed(workspace, "Failed to remove content from shard %q: %v.", shardName, err)
+	}
+	return remaining == 0, nil
+}
+
+// migrateWithNewUIDs returns true if the migration of the workspace has been confirmed although objects get
+// new UIDs.
+func migrateWithNewUIDs(workspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	return workspace.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceMigrateWithNewUIDsAnnotationKey] == "true"
+}
+
+// confirmationRequired marks the migration as waiting for confirmation to give new UIDs to the given objects.
+func confirmationRequired(workspace *tenancyv1alpha1.ClusterWorkspace, target string, referenced []string) {
+	const maxListed = 5
+	listed := referenced
+	if len(listed) > maxListed {
+		listed = append(listed[:maxListed:maxListed], fmt.Sprintf("and %d more", len(referenced)-maxListed))
+	}
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonConfirmationRequired, conditionsv1alpha1.ConditionSeverityWarning,
+		"Objects get new UIDs on shard %q, but the UIDs of %s are referenced outside of kcp. Set the %s annotation to \"true\" to move anyway.",
+		target, strings.Join(listed, ", "), tenancyv1alpha1.ExperimentalClusterWorkspaceMigrateWithNewUIDsAnnotationKey)
+}
+
+// failed marks the migration as failed and returns an error to retry.
+func (r *migrationReconciler) failed(workspace *tenancyv1alpha1.ClusterWorkspace, format string, args ...interface{}) error {
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigratedReasonFailed, conditionsv1alpha1.ConditionSeverityWarning, format, args...)
+	return fmt.Errorf(format, args...)
+}
+
+// workspaceBaseURL returns the URL of the workspace when living on the given shard.
+func workspaceBaseURL(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (string, error) {
+	u, err := url.Parse(shard.Spec.ExternalURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, clusterName.Path())
+	return u.String(), nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemigration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/content"
+)
+
+var now = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func workspace(phase tenancyv1alpha1.ClusterWorkspacePhaseType, current, target string, migration *tenancyv1alpha1.ClusterWorkspaceMigration) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ws",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:     phase,
+			BaseURL:   "https://" + current + "/clusters/root:org:ws",
+			Location:  tenancyv1alpha1.ClusterWorkspaceLocation{Current: current, Target: target},
+			Migration: migration,
+		},
+	}
+}
+
+// confirmed sets the annotation confirming the migration although objects get new UIDs.
+func confirmed(ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceMigrateWithNewUIDsAnnotationKey] = "true"
+	return ws
+}
+
+func migration(phase tenancyv1alpha1.ClusterWorkspaceMigrationPhaseType, startTime time.Time) *tenancyv1alpha1.ClusterWorkspaceMigration {
+	return &tenancyv1alpha1.ClusterWorkspaceMigration{
+		Phase:     phase,
+		Source:    "source",
+		Target:    "target",
+		StartTime: metav1.NewTime(startTime),
+	}
+}
+
+func TestMigrationReconciler(t *testing.T) {
+	ready := tenancyv1alpha1.ClusterWorkspacePhaseReady
+
+	tests := map[string]struct {
+		workspace  *tenancyv1alpha1.ClusterWorkspace
+		referenced []string
+		copyErr    error
+		remaining  int
+
+		wantWorkspace    *tenancyv1alpha1.ClusterWorkspace
+		wantRequeueAfter time.Duration
+		wantErr          bool
+		wantReason       string
+		wantCopied       bool
+		wantPurged       string
+	}{
+		"no target": {
+			workspace:     workspace(ready, "source", "", nil),
+			wantWorkspace: workspace(ready, "source", "", nil),
+		},
+		"target is current": {
+			workspace:     workspace(ready, "source", "source", nil),
+			wantWorkspace: workspace(ready, "source", "", nil),
+		},
+		"not scheduled yet": {
+			workspace:     workspace(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, "source", "target", nil),
+			wantWorkspace: workspace(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, "source", "target", nil),
+		},
+		"nonexistent target shard": {
+			workspace:     workspace(ready, "source", "unknown", nil),
+			wantWorkspace: workspace(ready, "source", "unknown", nil),
+			wantReason:    tenancyv1alpha1.WorkspaceMigratedReasonTargetShardInvalid,
+		},
+		"start freezing": {
+			workspace:        workspace(ready, "source", "target", nil),
+			wantWorkspace:    workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing, now)),
+			wantRequeueAfter: freezeGracePeriod,
+			wantReason:       tenancyv1alpha1.WorkspaceMigratedReasonInProgress,
+		},
+		"sync target, confirmation required": {
+			workspace:        workspace(ready, "source", "target", nil),
+			referenced:       []string{"synctargets.workload.kcp.dev cluster"},
+			wantWorkspace:    workspace(ready, "source", "target", nil),
+			wantRequeueAfter: confirmationRequeueDelay,
+			wantReason:       tenancyv1alpha1.WorkspaceMigratedReasonConfirmationRequired,
+		},
+		"sync target, confirmed, start freezing": {
+			workspace:        confirmed(workspace(ready, "source", "target", nil)),
+			referenced:       []string{"synctargets.workload.kcp.dev cluster"},
+			wantWorkspace:    confirmed(workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing, now))),
+			wantRequeueAfter: freezeGracePeriod,
+			wantReason:       tenancyv1alpha1.WorkspaceMigratedReasonInProgress,
+		},
+		"freezing, waiting for the grace period": {
+			workspace:        workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing, now.Add(-time.Second))),
+			wantWorkspace:    workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing, now.Add(-time.Second))),
+			wantRequeueAfter: freezeGracePeriod - time.Second,
+		},
+		"frozen, start copying": {
+			workspace:     workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseFreezing, now.Add(-freezeGracePeriod))),
+			wantWorkspace: workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now.Add(-freezeGracePeriod))),
+			wantReason:    tenancyv1alpha1.WorkspaceMigratedReasonInProgress,
+		},
+		"copied, switch": {
+			workspace: workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now)),
+			wantWorkspace: func() *tenancyv1alpha1.ClusterWorkspace {
+				ws := workspace(ready, "target", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp, now))
+				ws.Status.BaseURL = "https://front-proxy/clusters/root:org:ws"
+				ws.Status.Migration.CopiedObjects = 42
+				return ws
+			}(),
+			wantReason: tenancyv1alpha1.WorkspaceMigratedReasonInProgress,
+			wantCopied: true,
+		},
+		"sync target created before the freeze, roll back": {
+			workspace:     workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now)),
+			referenced:    []string{"synctargets.workload.kcp.dev cluster", "serviceaccounts default/default"},
+			wantWorkspace: workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack, now)),
+			wantReason:    tenancyv1alpha1.WorkspaceMigratedReasonConfirmationRequired,
+		},
+		"sync target, confirmed, copied, switch": {
+			workspace:  confirmed(workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now))),
+			referenced: []string{"synctargets.workload.kcp.dev cluster"},
+			wantWorkspace: func() *tenancyv1alpha1.ClusterWorkspace {
+				ws := confirmed(workspace(ready, "target", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp, now)))
+				ws.Status.BaseURL = "https://front-proxy/clusters/root:org:ws"
+				ws.Status.Migration.CopiedObjects = 42
+				return ws
+			}(),
+			wantReason: tenancyv1alpha1.WorkspaceMigratedReasonInProgress,
+			wantCopied: true,
+		},
+		"copy failed": {
+			workspace: workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now)),
+			copyErr:   errors.New("boom"),
+			wantWorkspace: func() *tenancyv1alpha1.ClusterWorkspace {
+				ws := workspace(ready, "source", "target", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now))
+				ws.Status.Migration.CopiedObjects = 42
+				return ws
+			}(),
+			wantErr:    true,
+			wantReason: tenancyv1alpha1.WorkspaceMigratedReasonFailed,
+			wantCopied: true,
+		},
+		"target cleared while copying, roll back": {
+			workspace:     workspace(ready, "source", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCopying, now)),
+			wantWorkspace: workspace(ready, "source", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack, now)),
+			wantReason:    tenancyv1alpha1.WorkspaceMigratedReasonInProgress,
+		},
+		"target cleared after the switch, no roll back": {
+			workspace:        workspace(ready, "target", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp, now)),
+			remaining:        3,
+			wantWorkspace:    workspace(ready, "target", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp, now)),
+			wantRequeueAfter: purgeRequeueDelay,
+			wantPurged:       "source",
+		},
+		"cleaned up": {
+			workspace:     workspace(ready, "target", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseCleaningUp, now)),
+			wantWorkspace: workspace(ready, "target", "", nil),
+			wantPurged:    "source",
+		},
+		"rolled back": {
+			workspace:     workspace(ready, "source", "", migration(tenancyv1alpha1.ClusterWorkspaceMigrationPhaseRollingBack, now)),
+			wantWorkspace: workspace(ready, "source", "", nil),
+			wantReason:    tenancyv1alpha1.WorkspaceMigratedReasonRolledBack,
+			wantPurged:    "target",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var copied bool
+			var purged string
+			r := &migrationReconciler{
+				getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+					if name != "source" && name != "target" {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaceshards"), name)
+					}
+					return &tenancyv1alpha1.ClusterWorkspaceShard{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+							BaseURL:     "https://" + name,
+							ExternalURL: "https://front-proxy",
+						},
+					}, nil
+				},
+				getClient: func(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (*content.Client, error) {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					return &content.Client{DiscoverResources: func() ([]*metav1.APIResourceList, error) {
+						// abuse discovery to identify the shard of the client
+						return []*metav1.APIResourceList{{GroupVersion: shard.Name}}, nil
+					}}, nil
+				},
+				uidReferenced: func(ctx context.Context, c *content.Client) ([]string, error) {
+					rls, _ := c.DiscoverResources()
+					require.Equal(t, "source", rls[0].GroupVersion)
+					return tc.referenced, nil
+				},
+				copy: func(ctx context.Context, source, target *content.Client) (int, error) {
+					copied = true
+					s, _ := source.DiscoverResources()
+					d, _ := target.DiscoverResources()
+					require.Equal(t, "source", s[0].GroupVersion)
+					require.Equal(t, "target", d[0].GroupVersion)
+					return 42, tc.copyErr
+				},
+				purge: func(ctx context.Context, c *content.Client) (int, error) {
+					rls, _ := c.DiscoverResources()
+					purged = rls[0].GroupVersion
+					return tc.remaining, nil
+				},
+				now: func() time.Time { return now },
+			}
+
+			ws := tc.workspace.DeepCopy()
+			requeueAfter, err := r.reconcile(context.Background(), ws)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+			require.Equal(t, tc.wantCopied, copied)
+			require.Equal(t, tc.wantPurged, purged)
+
+			if tc.wantReason != "" {
+				require.True(t, conditions.IsFalse(ws, tenancyv1alpha1.WorkspaceMigrated))
+				require.Equal(t, tc.wantReason, conditions.GetReason(ws, tenancyv1alpha1.WorkspaceMigrated))
+			} else if tc.wantWorkspace.Status.Migration == nil && tc.workspace.Status.Migration != nil {
+				require.True(t, conditions.IsTrue(ws, tenancyv1alpha1.WorkspaceMigrated))
+			}
+			ws.Status.Conditions = nil
+			require.Equal(t, tc.wantWorkspace, ws)
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,19 +47,40 @@ import (
 
 const (
 	controllerName = "kcp-clusterworkspaceshard"
+
+	byCurrentShard = controllerName + "byCurrentShard"
+
+	// usageResyncPeriod is the interval in which the storage usage of the local shard is refreshed.
+	usageResyncPeriod = time.Minute
 )
 
+// NewController returns a controller reporting the usage of ClusterWorkspaceShards. Every shard reports the
+// number of workspaces whose ClusterWorkspace objects it stores, as seen by the local clusterWorkspaceInformer,
+// into all ClusterWorkspaceShards of the root shard. The storage usage, as returned by storageUsage, is only
+// known for the local shard with the given name.
 func NewController(
+	shardName string,
 	rootKcpClient kcpclient.Interface,
 	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
+	clusterWorkspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	storageUsage func() (corev1.ResourceList, error),
 ) (*Controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &Controller{
 		queue:                        queue,
+		shardName:                    shardName,
 		kcpClient:                    rootKcpClient,
 		clusterWorkspaceShardIndexer: clusterWorkspaceShardInformer.Informer().GetIndexer(),
 		clusterWorkspaceShardLister:  clusterWorkspaceShardInformer.Lister(),
+		clusterWorkspaceIndexer:      clusterWorkspaceInformer.Informer().GetIndexer(),
+		storageUsage:                 storageUsage,
+	}
+
+	if err := c.clusterWorkspaceIndexer.AddIndexers(map[string]cache.IndexFunc{
+		byCurrentShard: indexByCurrentShard,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add indexer for ClusterWorkspace: %w", err)
 	}
 
 	clusterWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -66,18 +88,43 @@ func NewController(
 		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
 	})
 
+	clusterWorkspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueueWorkspace(obj) },
+		UpdateFunc: func(old, obj interface{}) {
+			oldWorkspace, ok := old.(*tenancyv1alpha1.ClusterWorkspace)
+			if !ok {
+				return
+			}
+			newWorkspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+			if !ok {
+				return
+			}
+			if oldWorkspace.Status.Location.Current != newWorkspace.Status.Location.Current {
+				c.enqueueWorkspace(old)
+				c.enqueueWorkspace(obj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueWorkspace(obj) },
+	})
+
 	return c, nil
 }
 
-// Controller watches WorkspaceShards and Secrets in order to make sure every ClusterWorkspaceShard
-// has its URL exposed when a valid kubeconfig is connected to it.
+// Controller watches ClusterWorkspaceShards and the local ClusterWorkspaces in order to report the usage
+// of every ClusterWorkspaceShard, i.e. the number of workspaces scheduled onto it and, for the
+// local shard, the size of its etcd database and the number of stored objects.
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
+	shardName string
 	kcpClient kcpclient.Interface
 
 	clusterWorkspaceShardIndexer cache.Indexer
 	clusterWorkspaceShardLister  tenancylisters.ClusterWorkspaceShardLister
+
+	clusterWorkspaceIndexer cache.Indexer
+
+	storageUsage func() (corev1.ResourceList, error)
 }
 
 func (c *Controller) enqueue(obj interface{}) {
@@ -86,8 +133,32 @@ func (c *Controller) enqueue(obj interface{}) {
 		runtime.HandleError(err)
 		return
 	}
+	_, _, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
 	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
-	logger.V(2).Info("queueing ClusterWorkspaceShard")
+	logger.V(2).Info("queueing ClusterWorkspaceShard", "name", name)
+	c.queue.Add(key)
+}
+
+func (c *Controller) enqueueWorkspace(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a ClusterWorkspace, but is %T", obj))
+		return
+	}
+	name := workspace.Status.Location.Current
+	if name == "" {
+		return
+	}
+	key := kcpcache.ToClusterAwareKey(tenancyv1alpha1.RootCluster.String(), "", name)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspaceShard because of ClusterWorkspace change", "ClusterWorkspace", workspace.Name)
 	c.queue.Add(key)
 }
 
@@ -190,14 +261,16 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		if err != nil {
 			return fmt.Errorf("failed to create patch for workspace shard %s|%s/%s: %w", tenancyv1alpha1.RootCluster, namespace, name, err)
 		}
-		_, uerr := c.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Patch(logicalcluster.WithCluster(ctx, tenancyv1alpha1.RootCluster), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-		return uerr
+		if _, err := c.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Patch(logicalcluster.WithCluster(ctx, tenancyv1alpha1.RootCluster), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
+			return err
+		}
 	}
 
-	logger.V(6).Info("processed ClusterWorkspaceShard")
-	return nil
-}
+	if obj.Name == c.shardName {
+		// the storage usage changes without any event
+		c.queue.AddAfter(key, usageResyncPeriod)
+	}
 
-func (c *Controller) reconcile(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+	logger.V(6).Info("processed ClusterWorkspaceShard")
 	return nil
 }
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func indexByCurrentShard(obj interface{}) ([]string, error) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a tenancyv1alpha1.ClusterWorkspace, but is %T", obj)
+	}
+	if workspace.Status.Location.Current == "" {
+		return []string{}, nil
+	}
+	return []string{workspace.Status.Location.Current}, nil
+}
+
+// workspacesReportedBy returns the usage resource of the number of workspaces reported by the given shard.
+func workspacesReportedBy(shardName string) corev1.ResourceName {
+	return tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspacesReportedByPrefix + corev1.ResourceName(shardName)
+}
+
+func (c *Controller) reconcile(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+	usage := shard.Status.Usage.DeepCopy()
+	if usage == nil {
+		usage = corev1.ResourceList{}
+	}
+
+	// ClusterWorkspaces are stored on the shard of their parent. Every shard reports the ClusterWorkspaces it stores,
+	// and the number of workspaces is the sum of the reports of the existing shards.
+	workspaces, err := c.clusterWorkspaceIndexer.ByIndex(byCurrentShard, shard.Name)
+	if err != nil {
+		return err
+	}
+	usage[workspacesReportedBy(c.shardName)] = *resource.NewQuantity(int64(len(workspaces)), resource.DecimalSI)
+
+	var total int64
+	for name, quantity := range usage {
+		reporter := strings.TrimPrefix(string(name), string(tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspacesReportedByPrefix))
+		if reporter == string(name) {
+			continue
+		}
+		if reporter != c.shardName {
+			if _, err := c.clusterWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, reporter)); kerrors.IsNotFound(err) {
+				delete(usage, name) // the reporting shard is gone
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+		total += quantity.Value()
+	}
+	usage[tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspaces] = *resource.NewQuantity(total, resource.DecimalSI)
+
+	// only the shard itself knows about its storage. The values of other shards are kept as reported by them.
+	if shard.Name == c.shardName && c.storageUsage != nil {
+		storage, err := c.storageUsage()
+		if err != nil {
+			return err
+		}
+		for name, quantity := range storage {
+			usage[name] = quantity
+		}
+	}
+
+	shard.Status.Usage = usage
+	return nil
+}
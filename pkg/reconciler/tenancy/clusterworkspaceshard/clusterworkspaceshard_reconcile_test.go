@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{byCurrentShard: indexByCurrentShard})
+	for name, shard := range map[string]string{"a": "local", "b": "local", "c": "other", "d": ""} {
+		require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+				Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard},
+			},
+		}))
+	}
+
+	shardIndexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"local", "other", "remote"} {
+		require.NoError(t, shardIndexer.Add(&tenancyv1alpha1.ClusterWorkspaceShard{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: tenancyv1alpha1.RootCluster.String()},
+			},
+		}))
+	}
+
+	c := &Controller{
+		shardName:                   "local",
+		clusterWorkspaceIndexer:     indexer,
+		clusterWorkspaceShardLister: tenancylisters.NewClusterWorkspaceShardLister(shardIndexer),
+		storageUsage: func() (corev1.ResourceList, error) {
+			return corev1.ResourceList{
+				tenancyv1alpha1.ClusterWorkspaceShardResourceObjects: resource.MustParse("42"),
+			}, nil
+		},
+	}
+
+	local := &tenancyv1alpha1.ClusterWorkspaceShard{ObjectMeta: metav1.ObjectMeta{Name: "local"}}
+	require.NoError(t, c.reconcile(context.Background(), local))
+	require.Equal(t, corev1.ResourceList{
+		tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspaces: *resource.NewQuantity(2, resource.DecimalSI),
+		"workspaces.tenancy.kcp.dev/local":                      *resource.NewQuantity(2, resource.DecimalSI),
+		tenancyv1alpha1.ClusterWorkspaceShardResourceObjects:    resource.MustParse("42"),
+	}, local.Status.Usage)
+
+	// the storage usage of other shards, and the workspaces reported by other existing shards, are kept
+	other := &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Status: tenancyv1alpha1.ClusterWorkspaceShardStatus{
+			Usage: corev1.ResourceList{
+				tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspaces: resource.MustParse("8"),
+				"workspaces.tenancy.kcp.dev/remote":                     resource.MustParse("3"),
+				"workspaces.tenancy.kcp.dev/gone":                       resource.MustParse("5"),
+				tenancyv1alpha1.ClusterWorkspaceShardResourceObjects:    resource.MustParse("7"),
+			},
+		},
+	}
+	require.NoError(t, c.reconcile(context.Background(), other))
+	require.Equal(t, corev1.ResourceList{
+		tenancyv1alpha1.ClusterWorkspaceShardResourceWorkspaces: *resource.NewQuantity(4, resource.DecimalSI),
+		"workspaces.tenancy.kcp.dev/local":                      *resource.NewQuantity(1, resource.DecimalSI),
+		"workspaces.tenancy.kcp.dev/remote":                     resource.MustParse("3"),
+		tenancyv1alpha1.ClusterWorkspaceShardResourceObjects:    resource.MustParse("7"),
+	}, other.Status.Usage)
+}
+
+func TestStorageUsage(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	dbSize := metrics.NewGaugeVec(&metrics.GaugeOpts{Name: etcdDatabaseSizeMetric}, []string{"endpoint"})
+	objects := metrics.NewGaugeVec(&metrics.GaugeOpts{Name: storageObjectsMetric}, []string{"resource"})
+	registry.MustRegister(dbSize, objects)
+
+	usage, err := StorageUsage(registry)()
+	require.NoError(t, err)
+	require.Empty(t, usage, "no usage is reported before the storage reports metrics")
+
+	dbSize.WithLabelValues("https://etcd-1").Set(1024)
+	dbSize.WithLabelValues("https://etcd-2").Set(2048)
+	objects.WithLabelValues("configmaps").Set(10)
+	objects.WithLabelValues("secrets").Set(5)
+	objects.WithLabelValues("broken").Set(-1)
+
+	usage, err = StorageUsage(registry)()
+	require.NoError(t, err)
+	require.Equal(t, corev1.ResourceList{
+		tenancyv1alpha1.ClusterWorkspaceShardResourceEtcdDatabaseSize: *resource.NewQuantity(2048, resource.BinarySI),
+		tenancyv1alpha1.ClusterWorkspaceShardResourceObjects:          *resource.NewQuantity(15, resource.DecimalSI),
+	}, usage)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/component-base/metrics"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// etcdDatabaseSizeMetric is reported by the etcd3 storage with one series per etcd endpoint.
+	etcdDatabaseSizeMetric = "etcd_db_total_size_in_bytes"
+	// storageObjectsMetric is reported by the etcd3 storage with one series per resource.
+	storageObjectsMetric = "apiserver_storage_objects"
+)
+
+// StorageUsage returns a function reading the etcd database size and the number of stored
+// objects of the local shard from the storage metrics in the given gatherer. Resources
+// without metrics, e.g. before the storage reported them the first time, are omitted.
+func StorageUsage(gatherer metrics.Gatherer) func() (corev1.ResourceList, error) {
+	return func() (corev1.ResourceList, error) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			return nil, err
+		}
+
+		usage := corev1.ResourceList{}
+		for _, family := range families {
+			switch family.GetName() {
+			case etcdDatabaseSizeMetric:
+				// all endpoints serve the same database
+				var size float64
+				for _, m := range family.GetMetric() {
+					if v := m.GetGauge().GetValue(); v > size {
+						size = v
+					}
+				}
+				usage[tenancyv1alpha1.ClusterWorkspaceShardResourceEtcdDatabaseSize] = *resource.NewQuantity(int64(size), resource.BinarySI)
+			case storageObjectsMetric:
+				var objects float64
+				for _, m := range family.GetMetric() {
+					// -1 is reported for resources which could not be counted
+					if v := m.GetGauge().GetValue(); v > 0 {
+						objects += v
+					}
+				}
+				usage[tenancyv1alpha1.ClusterWorkspaceShardResourceObjects] = *resource.NewQuantity(int64(objects), resource.DecimalSI)
+			}
+		}
+		return usage, nil
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globalendpoint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const controllerName = "kcp-workload-global-endpoint"
+
+var (
+	servicesGVR  = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	ingressesGVR = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+
+	// federatedResources are the resources with load balancer status a global endpoint is published for.
+	federatedResources = map[schema.GroupResource]schema.GroupVersionResource{
+		servicesGVR.GroupResource():  servicesGVR,
+		ingressesGVR.GroupResource(): ingressesGVR,
+	}
+)
+
+// NewController returns a new controller which publishes the load balancer endpoints of Services and Ingresses
+// synced to multiple sync targets in a generated ConfigMap next to them.
+func NewController(
+	kubeClusterClient kubernetesclient.Interface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+	configMapInformer coreinformers.ConfigMapInformer,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+
+		kubeClusterClient: kubeClusterClient,
+
+		ddsif: ddsif,
+
+		configMapLister: configMapInformer.Lister(),
+	}
+
+	c.ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueResource(gvr, obj) },
+		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueueResource(gvr, obj) },
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueResource(gvr, obj) },
+	})
+
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			configMap, ok := obj.(*corev1.ConfigMap)
+			if !ok {
+				return false
+			}
+			_, found := configMap.Labels[workloadv1alpha1.GlobalEndpointLabel]
+			return found
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) { c.enqueueConfigMap(obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueueConfigMap(obj) },
+		},
+	})
+
+	return c, nil
+}
+
+// controller watches Services and Ingresses and maintains their global endpoint ConfigMaps.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kubeClusterClient kubernetesclient.Interface
+
+	ddsif *informer.DynamicDiscoverySharedInformerFactory
+
+	configMapLister corelisters.ConfigMapLister
+}
+
+// enqueueResource adds the Service or Ingress to the queue, as gvr::key.
+func (c *controller) enqueueResource(gvr schema.GroupVersionResource, obj interface{}) {
+	if _, found := federatedResources[gvr.GroupResource()]; !found {
+		return
+	}
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	queueKey := strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, ".") + "::" + key
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), queueKey)
+	logger.V(2).Info("queueing resource")
+	c.queue.Add(queueKey)
+}
+
+// enqueueConfigMap adds the owner of the global endpoint ConfigMap to the queue.
+func (c *controller) enqueueConfigMap(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return
+	}
+	gvr, found := federatedResources[schema.ParseGroupResource(configMap.Labels[workloadv1alpha1.GlobalEndpointLabel])]
+	if !found {
+		return
+	}
+	for _, ownerRef := range configMap.OwnerReferences {
+		owner := &metav1.ObjectMeta{
+			Name:      ownerRef.Name,
+			Namespace: configMap.Namespace,
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: logicalcluster.From(configMap).String(),
+			},
+		}
+		c.enqueueResource(gvr, owner)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// key is gvr::KEY
+func (c *controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+	parts := strings.SplitN(key, "::", 2)
+	if len(parts) != 2 {
+		logger.Info("error parsing key; dropping")
+		return nil
+	}
+	gvr, _ := schema.ParseResourceArg(parts[0])
+	if gvr == nil {
+		logger.Info("error parsing GVR; dropping")
+		return nil
+	}
+
+	clusterName, namespace, name, err := kcpcache.SplitMetaClusterNamespaceKey(parts[1])
+	if err != nil {
+		logger.Error(err, "failed to split key, dropping")
+		return nil
+	}
+
+	inf, err := c.ddsif.ForResource(*gvr)
+	if err != nil {
+		return err
+	}
+	obj, err := inf.Lister().ByNamespace(namespace).Get(clusters.ToClusterAwareKey(clusterName, name))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	var resource *unstructured.Unstructured
+	if err == nil {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			logger.WithValues("objectType", fmt.Sprintf("%T", obj)).Info("object was not Unstructured, dropping")
+			return nil
+		}
+		resource = u.DeepCopy()
+	}
+
+	r := &reconciler{
+		getConfigMap:    c.getConfigMap,
+		createConfigMap: c.createConfigMap,
+		updateConfigMap: c.updateConfigMap,
+		deleteConfigMap: c.deleteConfigMap,
+	}
+	return r.reconcile(ctx, clusterName, *gvr, namespace, name, resource)
+}
+
+func (c *controller) getConfigMap(clusterName logicalcluster.Name, namespace, name string) (*corev1.ConfigMap, error) {
+	return c.configMapLister.ConfigMaps(namespace).Get(clusters.ToClusterAwareKey(clusterName, name))
+}
+
+func (c *controller) createConfigMap(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+	_, err := c.kubeClusterClient.CoreV1().ConfigMaps(configMap.Namespace).Create(logicalcluster.WithCluster(ctx, clusterName), configMap, metav1.CreateOptions{})
+	return err
+}
+
+func (c *controller) updateConfigMap(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+	_, err := c.kubeClusterClient.CoreV1().ConfigMaps(configMap.Namespace).Update(logicalcluster.WithCluster(ctx, clusterName), configMap, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *controller) deleteConfigMap(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error {
+	return c.kubeClusterClient.CoreV1().ConfigMaps(namespace).Delete(logicalcluster.WithCluster(ctx, clusterName), name, metav1.DeleteOptions{})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globalendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	syncershared "github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// EndpointsKey is the key of the global endpoint ConfigMap holding the JSON list of Endpoint.
+const EndpointsKey = "endpoints.json"
+
+// Endpoint is a load balancer ingress point of a Service or Ingress on one sync target.
+type Endpoint struct {
+	// SyncTarget is the key of the sync target.
+	SyncTarget string `json:"syncTarget"`
+	// IP is set for load balancer ingress points that are IP based.
+	IP string `json:"ip,omitempty"`
+	// Hostname is set for load balancer ingress points that are DNS based.
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// ConfigMapName returns the name of the global endpoint ConfigMap of the Service or Ingress with the given name.
+func ConfigMapName(gvr schema.GroupVersionResource, name string) string {
+	kind := "service"
+	if gvr.GroupResource() == ingressesGVR.GroupResource() {
+		kind = "ingress"
+	}
+	return fmt.Sprintf("%s-%s-global-endpoint", name, kind)
+}
+
+type reconciler struct {
+	getConfigMap    func(clusterName logicalcluster.Name, namespace, name string) (*corev1.ConfigMap, error)
+	createConfigMap func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error
+	updateConfigMap func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error
+	deleteConfigMap func(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error
+}
+
+// reconcile publishes the load balancer endpoints of the resource in the global endpoint ConfigMap when the
+// resource is synced to multiple sync targets, and removes the ConfigMap otherwise. The resource is nil if it
+// does not exist anymore.
+func (r *reconciler) reconcile(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, namespace, name string, resource *unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+	configMapName := ConfigMapName(gvr, name)
+
+	existing, err := r.getConfigMap(clusterName, namespace, configMapName)
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return err
+	}
+	if existing != nil && existing.Labels[workloadv1alpha1.GlobalEndpointLabel] != gvr.GroupResource().String() {
+		logger.V(2).Info("ConfigMap is not a global endpoint of the resource, skipping", "configMap", configMapName)
+		return nil
+	}
+
+	var syncTargetKeys []string
+	if resource != nil && resource.GetDeletionTimestamp() == nil {
+		syncTargetKeys = syncershared.SyncTargetKeys(resource.GetLabels())
+	}
+	if len(syncTargetKeys) < 2 {
+		if existing == nil {
+			return nil
+		}
+		logger.V(2).Info("deleting global endpoint ConfigMap", "configMap", configMapName)
+		if err := r.deleteConfigMap(ctx, clusterName, namespace, configMapName); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	endpoints := loadBalancerEndpoints(logger, resource, syncTargetKeys)
+	data, err := json.Marshal(endpoints)
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		workloadv1alpha1.GlobalEndpointLabel: gvr.GroupResource().String(),
+	}
+	ownerReferences := []metav1.OwnerReference{{
+		APIVersion: gvr.GroupVersion().String(),
+		Kind:       resource.GetKind(),
+		Name:       resource.GetName(),
+		UID:        resource.GetUID(),
+	}}
+	configMapData := map[string]string{
+		EndpointsKey: string(data),
+	}
+
+	if existing == nil {
+		logger.V(2).Info("creating global endpoint ConfigMap", "configMap", configMapName, "endpoints", len(endpoints))
+		return r.createConfigMap(ctx, clusterName, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            configMapName,
+				Namespace:       namespace,
+				Labels:          labels,
+				OwnerReferences: ownerReferences,
+			},
+			Data: configMapData,
+		})
+	}
+
+	if equality.Semantic.DeepEqual(existing.Data, configMapData) && equality.Semantic.DeepEqual(existing.OwnerReferences, ownerReferences) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.OwnerReferences = ownerReferences
+	updated.Data = configMapData
+	logger.V(2).Info("updating global endpoint ConfigMap", "configMap", configMapName, "endpoints", len(endpoints))
+	return r.updateConfigMap(ctx, clusterName, updated)
+}
+
+// loadBalancerEndpoints returns the load balancer ingress points reported by the syncers of the given
+// sync targets in the per sync target status annotations, in the order of the sync target keys.
+func loadBalancerEndpoints(logger klog.Logger, resource *unstructured.Unstructured, syncTargetKeys []string) []Endpoint {
+	endpoints := []Endpoint{}
+	for _, key := range syncTargetKeys {
+		value, found := resource.GetAnnotations()[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+key]
+		if !found {
+			continue
+		}
+		var status struct {
+			LoadBalancer corev1.LoadBalancerStatus `json:"loadBalancer"`
+		}
+		if err := json.Unmarshal([]byte(value), &status); err != nil {
+			logger.Error(err, "failed to decode status of sync target", "syncTargetKey", key)
+			continue
+		}
+		for _, ingress := range status.LoadBalancer.Ingress {
+			endpoints = append(endpoints, Endpoint{
+				SyncTarget: key,
+				IP:         ingress.IP,
+				Hostname:   ingress.Hostname,
+			})
+		}
+	}
+	return endpoints
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globalendpoint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		gvr       schema.GroupVersionResource
+		resource  *unstructured.Unstructured
+		configMap *corev1.ConfigMap
+
+		wantCreated *corev1.ConfigMap
+		wantUpdated *corev1.ConfigMap
+		wantDeleted bool
+	}{
+		"service synced to a single sync target": {
+			gvr:      servicesGVR,
+			resource: newResource("Service", map[string]string{"state.workload.kcp.dev/c1": "Sync"}, nil),
+		},
+		"service synced to multiple sync targets": {
+			gvr: servicesGVR,
+			resource: newResource("Service",
+				map[string]string{
+					"state.workload.kcp.dev/c1": "Sync",
+					"state.workload.kcp.dev/c2": "Sync",
+					"state.workload.kcp.dev/c3": "Sync",
+				},
+				map[string]string{
+					"experimental.status.workload.kcp.dev/c2": `{"loadBalancer":{"ingress":[{"hostname":"lb.c2.example.com"}]}}`,
+					"experimental.status.workload.kcp.dev/c1": `{"loadBalancer":{"ingress":[{"ip":"34.120.1.2"},{"ip":"34.120.1.3"}]}}`,
+					"experimental.status.workload.kcp.dev/c3": `{"loadBalancer":{}}`,
+				}),
+			wantCreated: newConfigMap("foo-service-global-endpoint", "services",
+				`[{"syncTarget":"c1","ip":"34.120.1.2"},{"syncTarget":"c1","ip":"34.120.1.3"},{"syncTarget":"c2","hostname":"lb.c2.example.com"}]`),
+		},
+		"ingress synced to multiple sync targets without status yet": {
+			gvr: ingressesGVR,
+			resource: newResource("Ingress",
+				map[string]string{
+					"state.workload.kcp.dev/c1": "Sync",
+					"state.workload.kcp.dev/c2": "Sync",
+				}, nil),
+			wantCreated: newConfigMap("foo-ingress-global-endpoint", "ingresses.networking.k8s.io", `[]`),
+		},
+		"sync target still being synced is not considered": {
+			gvr: servicesGVR,
+			resource: newResource("Service",
+				map[string]string{
+					"state.workload.kcp.dev/c1": "Sync",
+					"state.workload.kcp.dev/c2": "Sync",
+					"state.workload.kcp.dev/c3": "",
+				},
+				map[string]string{
+					"experimental.status.workload.kcp.dev/c1": `{"loadBalancer":{"ingress":[{"ip":"34.120.1.2"}]}}`,
+					"experimental.status.workload.kcp.dev/c3": `{"loadBalancer":{"ingress":[{"ip":"34.120.1.4"}]}}`,
+				}),
+			configMap: newConfigMap("foo-service-global-endpoint", "services", `[]`),
+			wantUpdated: newConfigMap("foo-service-global-endpoint", "services",
+				`[{"syncTarget":"c1","ip":"34.120.1.2"}]`),
+		},
+		"up to date": {
+			gvr: servicesGVR,
+			resource: newResource("Service",
+				map[string]string{
+					"state.workload.kcp.dev/c1": "Sync",
+					"state.workload.kcp.dev/c2": "Sync",
+				},
+				map[string]string{
+					"experimental.status.workload.kcp.dev/c1": `{"loadBalancer":{"ingress":[{"ip":"34.120.1.2"}]}}`,
+				}),
+			configMap: newConfigMap("foo-service-global-endpoint", "services", `[{"syncTarget":"c1","ip":"34.120.1.2"}]`),
+		},
+		"service not synced to multiple sync targets anymore": {
+			gvr:         servicesGVR,
+			resource:    newResource("Service", map[string]string{"state.workload.kcp.dev/c1": "Sync"}, nil),
+			configMap:   newConfigMap("foo-service-global-endpoint", "services", `[]`),
+			wantDeleted: true,
+		},
+		"service deleted": {
+			gvr:         servicesGVR,
+			configMap:   newConfigMap("foo-service-global-endpoint", "services", `[]`),
+			wantDeleted: true,
+		},
+		"ConfigMap with the same name not owned by the controller": {
+			gvr: servicesGVR,
+			resource: newResource("Service",
+				map[string]string{
+					"state.workload.kcp.dev/c1": "Sync",
+					"state.workload.kcp.dev/c2": "Sync",
+				}, nil),
+			configMap: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo-service-global-endpoint", Namespace: "ns"}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created, updated *corev1.ConfigMap
+			var deleted bool
+			r := &reconciler{
+				getConfigMap: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.ConfigMap, error) {
+					if tc.configMap == nil {
+						return nil, apierrors.NewNotFound(corev1.Resource("configmaps"), name)
+					}
+					return tc.configMap, nil
+				},
+				createConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+					created = configMap
+					return nil
+				},
+				updateConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+					updated = configMap
+					return nil
+				},
+				deleteConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error {
+					deleted = true
+					return nil
+				},
+			}
+
+			err := r.reconcile(context.Background(), logicalcluster.New("root:org:ws"), tc.gvr, "ns", "foo", tc.resource)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantCreated, created)
+			require.Equal(t, tc.wantUpdated, updated)
+			require.Equal(t, tc.wantDeleted, deleted)
+		})
+	}
+}
+
+func newResource(kind string, labels, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetKind(kind)
+	u.SetName("foo")
+	u.SetNamespace("ns")
+	u.SetUID("uid")
+	u.SetLabels(labels)
+	u.SetAnnotations(annotations)
+	return u
+}
+
+func newConfigMap(name, owner, endpoints string) *corev1.ConfigMap {
+	apiVersion, kind := "v1", "Service"
+	if owner == "ingresses.networking.k8s.io" {
+		apiVersion, kind = "networking.k8s.io/v1", "Ingress"
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			Labels: map[string]string{
+				"workload.kcp.dev/global-endpoint": owner,
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: apiVersion,
+				Kind:       kind,
+				Name:       "foo",
+				UID:        "uid",
+			}},
+		},
+		Data: map[string]string{
+			"endpoints.json": endpoints,
+		},
+	}
+}
@@ -19,6 +19,8 @@ package heartbeat
 import (
 	"time"
 
+	"k8s.io/client-go/pkg/version"
+
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apiresourceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
@@ -30,9 +32,12 @@ func NewController(
 	clusterInformer workloadinformers.SyncTargetInformer,
 	apiResourceImportInformer apiresourceinformer.APIResourceImportInformer,
 	heartbeatThreshold time.Duration,
+	syncerVersionSkew int,
 ) (*basecontroller.ClusterReconciler, error) {
 	cm := &clusterManager{
 		heartbeatThreshold: heartbeatThreshold,
+		kcpVersion:         version.Get().GitVersion,
+		syncerVersionSkew:  syncerVersionSkew,
 	}
 
 	r, queue, err := basecontroller.NewClusterReconciler(
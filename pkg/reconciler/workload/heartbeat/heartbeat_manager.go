@@ -18,8 +18,11 @@ package heartbeat
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/klog/v2"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
@@ -30,9 +33,18 @@ import (
 
 var _ basecontroller.ClusterReconcileImpl = (*clusterManager)(nil)
 
+// failingConsecutiveErrors is the number of consecutive failed reconciliations after which a controller
+// of the syncer is considered failing.
+const failingConsecutiveErrors = 10
+
 type clusterManager struct {
 	heartbeatThreshold  time.Duration
 	enqueueClusterAfter func(*workloadv1alpha1.SyncTarget, time.Duration)
+
+	// kcpVersion is the build version of kcp, the newest supported syncer version.
+	kcpVersion string
+	// syncerVersionSkew is the number of minor versions a syncer may be older than kcp.
+	syncerVersionSkew int
 }
 
 func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1.SyncTarget) error {
@@ -73,8 +85,149 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 		c.enqueueClusterAfter(cluster, dur)
 	}
 
+	reconcileSyncerHealth(cluster)
+	c.reconcileSyncerVersion(cluster)
+
 	return nil
 }
 
+// reconcileSyncerVersion publishes the range of syncer versions supported by kcp, and checks the version
+// reported by the syncer against it. Development builds of kcp, without a release version, do not restrict
+// the syncer version.
+func (c *clusterManager) reconcileSyncerVersion(syncTarget *workloadv1alpha1.SyncTarget) {
+	kcpVersion, err := utilversion.ParseSemantic(c.kcpVersion)
+	if err != nil || (kcpVersion.Major() == 0 && kcpVersion.Minor() == 0) {
+		syncTarget.Status.SupportedSyncerVersions = nil
+		conditions.Delete(syncTarget, workloadv1alpha1.SyncerVersionSupported)
+		return
+	}
+
+	oldestMinor := uint(0)
+	if kcpVersion.Minor() > uint(c.syncerVersionSkew) {
+		oldestMinor = kcpVersion.Minor() - uint(c.syncerVersionSkew)
+	}
+	supported := &workloadv1alpha1.SyncerVersionRange{
+		Min: fmt.Sprintf("v%d.%d", kcpVersion.Major(), oldestMinor),
+		Max: fmt.Sprintf("v%d.%d", kcpVersion.Major(), kcpVersion.Minor()),
+	}
+	syncTarget.Status.SupportedSyncerVersions = supported
+
+	if syncTarget.Status.SyncerVersion == "" {
+		// no heartbeat yet, or the syncer does not report its version
+		conditions.Delete(syncTarget, workloadv1alpha1.SyncerVersionSupported)
+		return
+	}
+	syncerVersion, err := utilversion.ParseSemantic(syncTarget.Status.SyncerVersion)
+	if err != nil {
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.SyncerVersionSupported,
+			workloadv1alpha1.SyncerVersionInvalidReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"Invalid syncer version %q: %v", syncTarget.Status.SyncerVersion, err)
+		return
+	}
+
+	switch {
+	case syncerVersion.Major() < kcpVersion.Major() || (syncerVersion.Major() == kcpVersion.Major() && syncerVersion.Minor() < oldestMinor):
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.SyncerVersionSupported,
+			workloadv1alpha1.SyncerTooOldReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"The syncer version %s is older than the supported versions %s to %s. Upgrade it with \"kubectl kcp workload upgrade\"",
+			syncTarget.Status.SyncerVersion, supported.Min, supported.Max)
+	case syncerVersion.Major() > kcpVersion.Major() || (syncerVersion.Major() == kcpVersion.Major() && syncerVersion.Minor() > kcpVersion.Minor()):
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.SyncerVersionSupported,
+			workloadv1alpha1.SyncerTooNewReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"The syncer version %s is newer than the supported versions %s to %s",
+			syncTarget.Status.SyncerVersion, supported.Min, supported.Max)
+	default:
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.SyncerVersionSupported)
+	}
+}
+
+// reconcileSyncerHealth sets the health conditions from the health reported by the syncer. They are not
+// part of the Ready summary: a degraded SyncTarget, i.e. with a false SyncerHealthy condition, is avoided
+// by the scheduler, but its workloads are not evicted.
+func reconcileSyncerHealth(syncTarget *workloadv1alpha1.SyncTarget) {
+	healthConditions := []conditionsv1alpha1.ConditionType{
+		workloadv1alpha1.DownstreamReachable,
+		workloadv1alpha1.InformersSynced,
+		workloadv1alpha1.SyncControllersHealthy,
+	}
+
+	health := syncTarget.Status.SyncerHealth
+	if health == nil {
+		// the syncer does not report its health
+		for _, t := range append(healthConditions, workloadv1alpha1.SyncerHealthy) {
+			conditions.Delete(syncTarget, t)
+		}
+		return
+	}
+
+	if health.Downstream.Reachable {
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.DownstreamReachable)
+	} else {
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.DownstreamReachable,
+			workloadv1alpha1.DownstreamUnreachableReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"The API server of the physical cluster is not reachable: %s", health.Downstream.Error)
+	}
+
+	var notSynced []string
+	for _, r := range health.Resources {
+		if !r.UpstreamSynced || !r.DownstreamSynced {
+			name := r.Resource + "." + r.Version
+			if r.Group != "" {
+				name += "." + r.Group
+			}
+			notSynced = append(notSynced, name)
+		}
+	}
+	if len(notSynced) == 0 {
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.InformersSynced)
+	} else {
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.InformersSynced,
+			workloadv1alpha1.InformersNotSyncedReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"The informers of %s have not synced", strings.Join(notSynced, ", "))
+	}
+
+	var failing []string
+	for _, controller := range health.Controllers {
+		if controller.ConsecutiveErrors >= failingConsecutiveErrors {
+			failing = append(failing, fmt.Sprintf("%s (%d consecutive errors, %d queued)", controller.Name, controller.ConsecutiveErrors, controller.QueueDepth))
+		}
+	}
+	if len(failing) == 0 {
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.SyncControllersHealthy)
+	} else {
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.SyncControllersHealthy,
+			workloadv1alpha1.ControllersFailingReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"The syncer controllers %s keep failing", strings.Join(failing, ", "))
+	}
+
+	var messages []string
+	for _, t := range healthConditions {
+		if conditions.IsFalse(syncTarget, t) {
+			messages = append(messages, conditions.GetMessage(syncTarget, t))
+		}
+	}
+	if len(messages) == 0 {
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.SyncerHealthy)
+	} else {
+		conditions.MarkFalse(syncTarget,
+			workloadv1alpha1.SyncerHealthy,
+			workloadv1alpha1.DegradedReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"%s", strings.Join(messages, "; "))
+	}
+}
+
 func (c *clusterManager) Cleanup(ctx context.Context, deletedCluster *workloadv1alpha1.SyncTarget) {
 }
@@ -21,10 +21,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
@@ -84,3 +87,164 @@ func TestManager(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileSyncerHealth(t *testing.T) {
+	healthy := &workloadv1alpha1.SyncerHealth{
+		Downstream: workloadv1alpha1.DownstreamHealth{Reachable: true, Version: "v1.24.3"},
+		Resources: []workloadv1alpha1.ResourceHealth{
+			{Version: "v1", Resource: "configmaps", UpstreamSynced: true, DownstreamSynced: true},
+		},
+		Controllers: []workloadv1alpha1.ControllerHealth{
+			{Name: "kcp-workload-syncer-spec", QueueDepth: 3, Errors: 20, ConsecutiveErrors: 2},
+		},
+	}
+
+	for _, c := range []struct {
+		desc          string
+		health        func(h *workloadv1alpha1.SyncerHealth) *workloadv1alpha1.SyncerHealth
+		wantFalse     []conditionsv1alpha1.ConditionType
+		wantDegraded  bool
+		wantMessage   string
+		wantNoHealthy bool
+	}{{
+		desc:          "no health reported",
+		health:        func(*workloadv1alpha1.SyncerHealth) *workloadv1alpha1.SyncerHealth { return nil },
+		wantNoHealthy: true,
+	}, {
+		desc:   "healthy",
+		health: func(h *workloadv1alpha1.SyncerHealth) *workloadv1alpha1.SyncerHealth { return h },
+	}, {
+		desc: "downstream unreachable",
+		health: func(h *workloadv1alpha1.SyncerHealth) *workloadv1alpha1.SyncerHealth {
+			h.Downstream = workloadv1alpha1.DownstreamHealth{Error: "connection refused"}
+			return h
+		},
+		wantFalse:    []conditionsv1alpha1.ConditionType{workloadv1alpha1.DownstreamReachable},
+		wantDegraded: true,
+		wantMessage:  "The API server of the physical cluster is not reachable: connection refused",
+	}, {
+		desc: "informers not synced",
+		health: func(h *workloadv1alpha1.SyncerHealth) *workloadv1alpha1.SyncerHealth {
+			h.Resources = append(h.Resources, workloadv1alpha1.ResourceHealth{Group: "apps", Version: "v1", Resource: "deployments", UpstreamSynced: true})
+			return h
+		},
+		wantFalse:    []conditionsv1alpha1.ConditionType{workloadv1alpha1.InformersSynced},
+		wantDegraded: true,
+		wantMessage:  "The informers of deployments.v1.apps have not synced",
+	}, {
+		desc: "controller failing",
+		health: func(h *workloadv1alpha1.SyncerHealth) *workloadv1alpha1.SyncerHealth {
+			h.Controllers[0].ConsecutiveErrors = failingConsecutiveErrors
+			return h
+		},
+		wantFalse:    []conditionsv1alpha1.ConditionType{workloadv1alpha1.SyncControllersHealthy},
+		wantDegraded: true,
+		wantMessage:  "The syncer controllers kcp-workload-syncer-spec (10 consecutive errors, 3 queued) keep failing",
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			heartbeat := metav1.NewTime(time.Now())
+			syncTarget := &workloadv1alpha1.SyncTarget{
+				Status: workloadv1alpha1.SyncTargetStatus{
+					LastSyncerHeartbeatTime: &heartbeat,
+					SyncerHealth:            c.health(healthy.DeepCopy()),
+				},
+			}
+			mgr := clusterManager{
+				heartbeatThreshold:  time.Minute,
+				enqueueClusterAfter: func(*workloadv1alpha1.SyncTarget, time.Duration) {},
+			}
+			require.NoError(t, mgr.Reconcile(context.Background(), syncTarget))
+
+			require.True(t, conditions.IsTrue(syncTarget, conditionsv1alpha1.ReadyCondition), "a degraded SyncTarget must stay ready")
+			if c.wantNoHealthy {
+				require.False(t, conditions.Has(syncTarget, workloadv1alpha1.SyncerHealthy))
+				return
+			}
+			for _, conditionType := range []conditionsv1alpha1.ConditionType{workloadv1alpha1.DownstreamReachable, workloadv1alpha1.InformersSynced, workloadv1alpha1.SyncControllersHealthy} {
+				wantFalse := false
+				for _, f := range c.wantFalse {
+					wantFalse = wantFalse || f == conditionType
+				}
+				require.Equal(t, wantFalse, conditions.IsFalse(syncTarget, conditionType), "condition %s", conditionType)
+			}
+			require.Equal(t, c.wantDegraded, conditions.IsFalse(syncTarget, workloadv1alpha1.SyncerHealthy))
+			if c.wantDegraded {
+				require.Equal(t, workloadv1alpha1.DegradedReason, conditions.GetReason(syncTarget, workloadv1alpha1.SyncerHealthy))
+				require.Equal(t, c.wantMessage, conditions.GetMessage(syncTarget, workloadv1alpha1.SyncerHealthy))
+			}
+		})
+	}
+}
+
+func TestReconcileSyncerVersion(t *testing.T) {
+	for _, c := range []struct {
+		desc          string
+		kcpVersion    string
+		syncerVersion string
+		wantSupported *workloadv1alpha1.SyncerVersionRange
+		wantReason    string
+		wantTrue      bool
+	}{{
+		desc:          "development build of kcp",
+		kcpVersion:    "v0.0.0-master+$Format:%H$",
+		syncerVersion: "v0.5.0",
+	}, {
+		desc:          "no syncer version",
+		kcpVersion:    "v0.8.2",
+		wantSupported: &workloadv1alpha1.SyncerVersionRange{Min: "v0.7", Max: "v0.8"},
+	}, {
+		desc:          "same version",
+		kcpVersion:    "v0.8.2",
+		syncerVersion: "v0.8.0",
+		wantSupported: &workloadv1alpha1.SyncerVersionRange{Min: "v0.7", Max: "v0.8"},
+		wantTrue:      true,
+	}, {
+		desc:          "in the skew window",
+		kcpVersion:    "v0.8.2",
+		syncerVersion: "v0.7.1-12-gabcdef",
+		wantSupported: &workloadv1alpha1.SyncerVersionRange{Min: "v0.7", Max: "v0.8"},
+		wantTrue:      true,
+	}, {
+		desc:          "too old",
+		kcpVersion:    "v0.8.2",
+		syncerVersion: "v0.6.3",
+		wantSupported: &workloadv1alpha1.SyncerVersionRange{Min: "v0.7", Max: "v0.8"},
+		wantReason:    workloadv1alpha1.SyncerTooOldReason,
+	}, {
+		desc:          "too new",
+		kcpVersion:    "v0.8.2",
+		syncerVersion: "v0.9.0",
+		wantSupported: &workloadv1alpha1.SyncerVersionRange{Min: "v0.7", Max: "v0.8"},
+		wantReason:    workloadv1alpha1.SyncerTooNewReason,
+	}, {
+		desc:          "invalid syncer version",
+		kcpVersion:    "v0.8.2",
+		syncerVersion: "latest",
+		wantSupported: &workloadv1alpha1.SyncerVersionRange{Min: "v0.7", Max: "v0.8"},
+		wantReason:    workloadv1alpha1.SyncerVersionInvalidReason,
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			syncTarget := &workloadv1alpha1.SyncTarget{
+				Status: workloadv1alpha1.SyncTargetStatus{
+					SyncerVersion: c.syncerVersion,
+				},
+			}
+			mgr := clusterManager{
+				kcpVersion:        c.kcpVersion,
+				syncerVersionSkew: 1,
+			}
+			mgr.reconcileSyncerVersion(syncTarget)
+
+			require.Equal(t, c.wantSupported, syncTarget.Status.SupportedSyncerVersions)
+			switch {
+			case c.wantTrue:
+				require.True(t, conditions.IsTrue(syncTarget, workloadv1alpha1.SyncerVersionSupported))
+			case c.wantReason != "":
+				require.True(t, conditions.IsFalse(syncTarget, workloadv1alpha1.SyncerVersionSupported))
+				require.Equal(t, c.wantReason, conditions.GetReason(syncTarget, workloadv1alpha1.SyncerVersionSupported))
+			default:
+				require.False(t, conditions.Has(syncTarget, workloadv1alpha1.SyncerVersionSupported))
+			}
+		})
+	}
+}
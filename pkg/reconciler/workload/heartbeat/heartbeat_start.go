@@ -26,21 +26,27 @@ import (
 func DefaultOptions() *Options {
 	return &Options{
 		HeartbeatThreshold: time.Minute,
+		SyncerVersionSkew:  1,
 	}
 }
 
 func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
 	fs.DurationVar(&o.HeartbeatThreshold, "sync-target-heartbeat-threshold", o.HeartbeatThreshold, "Amount of time to wait for a successful heartbeat before marking the cluster as not ready")
+	fs.IntVar(&o.SyncerVersionSkew, "sync-target-syncer-version-skew", o.SyncerVersionSkew, "Number of minor versions a syncer may be older than kcp before its SyncTarget is marked with a false SyncerVersionSupported condition")
 	return o
 }
 
 type Options struct {
 	HeartbeatThreshold time.Duration
+	SyncerVersionSkew  int
 }
 
 func (o *Options) Validate() error {
 	if o.HeartbeatThreshold <= 0 {
 		return fmt.Errorf("--sync-target-heartbeat-threshold must be >0 (%s)", o.HeartbeatThreshold)
 	}
+	if o.SyncerVersionSkew < 0 {
+		return fmt.Errorf("--sync-target-syncer-version-skew must be >=0 (%d)", o.SyncerVersionSkew)
+	}
 	return nil
 }
@@ -60,6 +60,7 @@ func NewController(
 	kubeClusterClient kubernetesclient.Interface,
 	namespaceInformer coreinformers.NamespaceInformer,
 	placementInformer schedulinginformers.PlacementInformer,
+	migrationTimeout time.Duration,
 ) (*controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
@@ -77,6 +78,8 @@ func NewController(
 
 		placmentLister:   placementInformer.Lister(),
 		placementIndexer: placementInformer.Informer().GetIndexer(),
+
+		migrationTimeout: migrationTimeout,
 	}
 
 	if err := namespaceInformer.Informer().AddIndexers(cache.Indexers{
@@ -133,6 +136,8 @@ type controller struct {
 
 	placmentLister   schedulinglisters.PlacementLister
 	placementIndexer cache.Indexer
+
+	migrationTimeout time.Duration
 }
 
 func (c *controller) enqueueNamespace(obj interface{}) {
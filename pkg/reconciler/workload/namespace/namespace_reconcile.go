@@ -46,10 +46,11 @@ func (c *controller) reconcile(ctx context.Context, ns *corev1.Namespace) error
 			patchNamespace: c.patchNamespace,
 		},
 		&placementSchedulingReconciler{
-			listPlacement:  c.listPlacement,
-			enqueueAfter:   c.enqueueAfter,
-			patchNamespace: c.patchNamespace,
-			now:            time.Now,
+			listPlacement:    c.listPlacement,
+			enqueueAfter:     c.enqueueAfter,
+			patchNamespace:   c.patchNamespace,
+			now:              time.Now,
+			migrationTimeout: c.migrationTimeout,
 		},
 		&statusConditionReconciler{
 			patchNamespace: c.patchNamespace,
@@ -38,7 +38,7 @@ import (
 const removingGracePeriod = 5 * time.Second
 
 // placementSchedulingReconciler reconciles the state.workload.kcp.dev/<syncTarget> labels according the
-// selected synctarget stored in the internal.workload.kcp.dev/synctarget annotation
+// selected synctargets stored in the internal.workload.kcp.dev/synctarget annotation
 // on each placement.
 type placementSchedulingReconciler struct {
 	listPlacement func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
@@ -48,6 +48,10 @@ type placementSchedulingReconciler struct {
 	enqueueAfter func(*corev1.Namespace, time.Duration)
 
 	now func() time.Time
+
+	// migrationTimeout is the maximal time the removal of a synctarget is held for stateful resources
+	// to be migrated to the newly scheduled synctargets.
+	migrationTimeout time.Duration
 }
 
 func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev1.Namespace) (reconcileStatus, *corev1.Namespace, error) {
@@ -73,31 +77,67 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 		if !foundScheduled {
 			continue
 		}
-		scheduledSyncTargets.Insert(currentScheduled)
+		scheduledSyncTargets.Insert(workloadv1alpha1.SyncTargetKeysFromPlacementAnnotation(currentScheduled)...)
 	}
 
+	// 1a. add the synctargets resources of the ns are pinned to. Only the pinned resources are synced to
+	// synctargets not selected by a placement.
+	pinnedOnlySyncTargets := pinnedSyncTargets(ns).Difference(scheduledSyncTargets)
+	scheduledSyncTargets.Insert(pinnedOnlySyncTargets.List()...)
+
 	// 2. find the scheduled synctarget to the ns, including synced, removing
 	synced, removing := syncedRemovingCluster(ns)
 
-	// 3. if the synced synctarget is not in the scheduled synctargets, mark it as removing.
+	// 3. if the synced synctarget is not in the scheduled synctargets, mark it as removing. If new synctargets
+	// are scheduled at the same time, the stateful resources are migrated to them.
 	expectedAnnotations := map[string]interface{}{} // nil means to remove the key
 	expectedLabels := map[string]interface{}{}      // nil means to remove the key
 
+	if current, found := ns.Annotations[workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey]; pinnedOnlySyncTargets.Len() > 0 {
+		if value := strings.Join(pinnedOnlySyncTargets.List(), ","); value != current {
+			expectedAnnotations[workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey] = value
+		}
+	} else if found {
+		expectedAnnotations[workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey] = nil
+	}
+
+	newSyncTargets := scheduledSyncTargets.Difference(synced)
+	for syncTarget := range removing {
+		newSyncTargets.Delete(syncTarget)
+	}
 	for syncTarget := range synced {
 		if !scheduledSyncTargets.Has(syncTarget) {
 			// it is no longer a synced synctarget, mark it as removing.
 			now := r.now().UTC().Format(time.RFC3339)
 			expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTarget] = now
 			logger.WithValues("syncTarget", syncTarget).V(4).Info("setting SyncTarget as removing for Namespace since it is not a valid syncTarget anymore")
+			if newSyncTargets.Len() > 0 {
+				expectedAnnotations[workloadv1alpha1.MigrationAnnotationPrefix+syncTarget] = strings.Join(newSyncTargets.List(), ",")
+				logger.WithValues("syncTarget", syncTarget, "to", newSyncTargets.List()).V(4).Info("migrating Namespace away from SyncTarget")
+			}
 		}
 	}
 
-	// 4. remove the synctarget after grace period
+	// 4. remove the synctarget after grace period, and after the stateful resources are migrated
 	minEnqueueDuration := removingGracePeriod + 1
+	var migrationEnqueueDuration time.Duration
 	for cluster, removingTime := range removing {
+		_, migrating := ns.Annotations[workloadv1alpha1.MigrationAnnotationPrefix+cluster]
+		if migrating && removingTime.Add(r.migrationTimeout).After(r.now()) {
+			logger.WithValues("syncTarget", cluster).V(4).Info("waiting for resources to be migrated away from SyncTarget")
+			enqueueDuration := removingTime.Add(r.migrationTimeout).Sub(r.now())
+			if migrationEnqueueDuration == 0 || enqueueDuration < migrationEnqueueDuration {
+				migrationEnqueueDuration = enqueueDuration
+			}
+			continue
+		}
 		if removingTime.Add(removingGracePeriod).Before(r.now()) {
 			expectedLabels[workloadv1alpha1.ClusterResourceStateLabelPrefix+cluster] = nil
 			expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+cluster] = nil
+			if migrating {
+				logger.WithValues("syncTarget", cluster).Info("migration of resources away from SyncTarget timed out")
+				expectedAnnotations[workloadv1alpha1.MigrationAnnotationPrefix+cluster] = nil
+			}
 			logger.WithValues("syncTarget", cluster).V(4).Info("removing SyncTarget for Namespace")
 		} else {
 			enqueuDuration := time.Until(removingTime.Add(removingGracePeriod))
@@ -129,6 +169,9 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 	if minEnqueueDuration <= removingGracePeriod {
 		logger.WithValues("after", minEnqueueDuration).V(2).Info("enqueue Namespace later")
 		r.enqueueAfter(ns, minEnqueueDuration)
+	} else if migrationEnqueueDuration > 0 {
+		logger.WithValues("after", migrationEnqueueDuration).V(2).Info("enqueue Namespace for migration timeout")
+		r.enqueueAfter(ns, migrationEnqueueDuration)
 	}
 
 	return reconcileStatusContinue, ns, nil
@@ -159,6 +202,17 @@ func (r *placementSchedulingReconciler) patchNamespaceLabelAnnotation(ctx contex
 	return updated, nil
 }
 
+// pinnedSyncTargets returns the synctargets resources of the ns are pinned to.
+func pinnedSyncTargets(ns *corev1.Namespace) sets.String {
+	pinned := sets.NewString()
+	for _, syncTarget := range strings.Split(ns.Annotations[workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey], ",") {
+		if syncTarget = strings.TrimSpace(syncTarget); syncTarget != "" {
+			pinned.Insert(syncTarget)
+		}
+	}
+	return pinned
+}
+
 // syncedRemovingCluster finds synced and removing clusters for this ns.
 func syncedRemovingCluster(ns *corev1.Namespace) (sets.String, map[string]time.Time) {
 	synced := sets.NewString()
@@ -129,6 +129,7 @@ func TestScheduling(t *testing.T) {
 			expectedAnnotations: map[string]string{
 				schedulingv1alpha1.PlacementAnnotationKey: "",
 				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
+				workloadv1alpha1.MigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq":                        "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
 			},
 			expectedLabels: map[string]string{
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
@@ -170,6 +171,111 @@ func TestScheduling(t *testing.T) {
 			},
 			expectedLabels: map[string]string{},
 		},
+		{
+			name: "hold removal of cluster while resources are migrated",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now.Add(-1 * (removingGracePeriod + 1)).UTC().Format(time.RFC3339),
+				workloadv1alpha1.MigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq":                        "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster-2"),
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now.Add(-1 * (removingGracePeriod + 1)).UTC().Format(time.RFC3339),
+				workloadv1alpha1.MigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq":                        "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "remove cluster when migration times out",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now.Add(-1 * (time.Hour + 1)).UTC().Format(time.RFC3339),
+				workloadv1alpha1.MigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq":                        "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster-2"),
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "schedule a synctarget resources are pinned to",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:               "",
+				workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey: "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster"),
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                   "",
+				workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey:     "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+				workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey: "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "pinned synctarget is selected by the placement",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                   "",
+				workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey:     "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq",
+				workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey: "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster"),
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:               "",
+				workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey: "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "no resource is pinned to a synctarget anymore",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                   "",
+				workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey: "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster"),
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -191,10 +297,11 @@ func TestScheduling(t *testing.T) {
 
 			var patched bool
 			reconciler := &placementSchedulingReconciler{
-				listPlacement:  listPlacement,
-				patchNamespace: patchNamespaceFunc(&patched, ns),
-				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
-				now:            func() time.Time { return now },
+				listPlacement:    listPlacement,
+				patchNamespace:   patchNamespaceFunc(&patched, ns),
+				enqueueAfter:     func(*corev1.Namespace, time.Duration) {},
+				now:              func() time.Time { return now },
+				migrationTimeout: time.Hour,
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), ns)
@@ -239,6 +346,45 @@ func TestMultiplePlacements(t *testing.T) {
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
+		{
+			name: "placement scheduled to two synctargets",
+			placements: []*schedulingv1alpha1.Placement{
+				newPlacement("p1", "loc1", "c1", "c2"),
+			},
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "one of two synctargets of a placement is unscheduled",
+			placements: []*schedulingv1alpha1.Placement{
+				newPlacement("p1", "loc1", "c1"),
+			},
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4": string(workloadv1alpha1.ResourceStateSync),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 		{
 			name: "placement select the same location",
 			placements: []*schedulingv1alpha1.Placement{
@@ -324,10 +470,11 @@ func TestMultiplePlacements(t *testing.T) {
 
 			var patched bool
 			reconciler := &placementSchedulingReconciler{
-				listPlacement:  listPlacement,
-				patchNamespace: patchNamespaceFunc(&patched, ns),
-				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
-				now:            func() time.Time { return now },
+				listPlacement:    listPlacement,
+				patchNamespace:   patchNamespaceFunc(&patched, ns),
+				enqueueAfter:     func(*corev1.Namespace, time.Duration) {},
+				now:              func() time.Time { return now },
+				migrationTimeout: time.Hour,
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), ns)
@@ -339,7 +486,7 @@ func TestMultiplePlacements(t *testing.T) {
 	}
 }
 
-func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placement {
+func newPlacement(name, location string, synctargets ...string) *schedulingv1alpha1.Placement {
 	placement := &schedulingv1alpha1.Placement{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
@@ -354,9 +501,15 @@ func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placeme
 		},
 	}
 
-	if len(synctarget) > 0 {
+	var keys []string
+	for _, synctarget := range synctargets {
+		if len(synctarget) > 0 {
+			keys = append(keys, workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), synctarget))
+		}
+	}
+	if len(keys) > 0 {
 		placement.Annotations = map[string]string{
-			workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), synctarget),
+			workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: workloadv1alpha1.PlacementAnnotationFromSyncTargetKeys(keys),
 		}
 	}
 
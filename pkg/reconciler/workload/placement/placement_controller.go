@@ -18,14 +18,19 @@ package placement
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/go-logr/logr"
 	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -46,6 +51,7 @@ const (
 	controllerName      = "kcp-workload-placement"
 	byWorkspace         = controllerName + "-byWorkspace" // will go away with scoping
 	byLocationWorkspace = controllerName + "-byLocationWorkspace"
+	bySyncTargetKey     = controllerName + "-bySyncTargetKey"
 )
 
 // NewController returns a new controller starting the process of selecting synctarget for a placement
@@ -87,6 +93,7 @@ func NewController(
 	if err := placementInformer.Informer().AddIndexers(cache.Indexers{
 		byWorkspace:         indexByWorkspace,
 		byLocationWorkspace: indexByLocationWorkspace,
+		bySyncTargetKey:     indexBySyncTargetKey,
 	}); err != nil {
 		return nil, err
 	}
@@ -268,6 +275,12 @@ func (c *controller) processNextWorkItem(ctx context.Context) bool {
 }
 
 func (c *controller) process(ctx context.Context, key string) error {
+	clusterName, _, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+
 	obj, err := c.placmentLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -275,12 +288,42 @@ func (c *controller) process(ctx context.Context, key string) error {
 		}
 		return err
 	}
+	old := obj
 	obj = obj.DeepCopy()
 
 	logger := logging.WithObject(klog.FromContext(ctx), obj)
 	ctx = klog.NewContext(ctx, logger)
 
-	reconcileErr := c.reconcile(ctx, obj)
+	obj, reconcileErr := c.reconcile(ctx, obj)
+
+	// If the status of the object being reconciled changed as a result, update it.
+	if !equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		oldData, err := json.Marshal(schedulingv1alpha1.Placement{
+			Status: old.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to Marshal old data for placement %s|%s: %w", clusterName, name, err)
+		}
+
+		newData, err := json.Marshal(schedulingv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				UID:             obj.UID,
+				ResourceVersion: obj.ResourceVersion,
+			}, // to ensure they appear in the patch as preconditions
+			Status: obj.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to Marshal new data for placement %s|%s: %w", clusterName, name, err)
+		}
+
+		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+		if err != nil {
+			return fmt.Errorf("failed to create patch for placement %s|%s: %w", clusterName, name, err)
+		}
+		if _, err := c.patchPlacement(ctx, clusterName, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
+			return err
+		}
+	}
 
 	return reconcileErr
 }
@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 func indexByWorkspace(obj interface{}) ([]string, error) {
@@ -48,3 +49,17 @@ func indexByLocationWorkspace(obj interface{}) ([]string, error) {
 
 	return []string{placement.Status.SelectedLocation.Path}, nil
 }
+
+func indexBySyncTargetKey(obj interface{}) ([]string, error) {
+	placement, ok := obj.(*schedulingv1alpha1.Placement)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a Placement, but is %T", obj)
+	}
+
+	value, found := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
+	if !found {
+		return []string{}, nil
+	}
+
+	return workloadv1alpha1.SyncTargetKeysFromPlacementAnnotation(value), nil
+}
@@ -26,6 +26,7 @@ import (
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
@@ -42,12 +43,14 @@ type reconciler interface {
 	reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error)
 }
 
-func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) error {
+func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (*schedulingv1alpha1.Placement, error) {
 	reconcilers := []reconciler{
 		&placementSchedulingReconciler{
-			listSyncTarget: c.listSyncTarget,
-			getLocation:    c.getLocation,
-			patchPlacement: c.patchPlacement,
+			listSyncTarget:            c.listSyncTarget,
+			listPlacementsScheduledTo: c.listPlacementsScheduledTo,
+			getLocation:               c.getLocation,
+			patchPlacement:            c.patchPlacement,
+			clock:                     clock.RealClock{},
 		},
 	}
 
@@ -65,7 +68,7 @@ func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha
 		}
 	}
 
-	return utilserrors.NewAggregate(errs)
+	return placement, utilserrors.NewAggregate(errs)
 }
 
 func (c *controller) listSyncTarget(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
@@ -80,6 +83,18 @@ func (c *controller) listSyncTarget(clusterName logicalcluster.Name) ([]*workloa
 	return ret, nil
 }
 
+func (c *controller) listPlacementsScheduledTo(syncTargetKey string) ([]*schedulingv1alpha1.Placement, error) {
+	items, err := c.placementIndexer.ByIndex(bySyncTargetKey, syncTargetKey)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*schedulingv1alpha1.Placement, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*schedulingv1alpha1.Placement))
+	}
+	return ret, nil
+}
+
 func (c *controller) getLocation(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
 	key := clusters.ToClusterAwareKey(clusterName, name)
 	return c.locationLister.Get(key)
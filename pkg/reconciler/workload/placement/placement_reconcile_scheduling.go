@@ -19,28 +19,42 @@ package placement
 import (
 	"context"
 	"encoding/json"
-	"math/rand"
+	"fmt"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/placement/scheduler"
 )
 
 // placementSchedulingReconciler schedules placments according to the selected locations.
-// It considers only valid SyncTargets and updates the internal.workload.kcp.dev/synctarget
-// annotation with the selected one on the placement object.
+// It filters and scores the SyncTargets of the location with the scheduler framework configured
+// by the location's scheduling policy, updates the internal.workload.kcp.dev/synctarget
+// annotation with the best ones on the placement object, as many as requested by the
+// instance selection of the placement, and reports the score breakdown of the decision
+// in the Scheduled condition.
 type placementSchedulingReconciler struct {
-	listSyncTarget func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
-	getLocation    func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
-	patchPlacement func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*schedulingv1alpha1.Placement, error)
+	listSyncTarget            func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
+	listPlacementsScheduledTo func(syncTargetKey string) ([]*schedulingv1alpha1.Placement, error)
+	getLocation               func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
+	patchPlacement            func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*schedulingv1alpha1.Placement, error)
+
+	clock clock.PassiveClock
 }
 
 func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
@@ -50,47 +64,150 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 	expectedAnnotations := map[string]interface{}{} // nil means to remove the key
 	currentScheduled, foundScheduled := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
 
-	// 2. pick all valid synctargets in this placements
-	syncTargetClusterName, syncTargets, err := r.getAllValidSyncTargetsForPlacement(clusterName, placement)
+	// 2. pick all synctargets in the selected location of this placement
+	location, syncTargets, err := r.getLocationSyncTargetsForPlacement(placement)
 	if err != nil {
 		return reconcileStatusStop, placement, err
 	}
 
-	// no valid synctarget, clean the annotation.
-	if foundScheduled && len(syncTargets) == 0 {
+	// no location, clean the annotation.
+	if location == nil {
+		if !foundScheduled {
+			return reconcileStatusContinue, placement, nil
+		}
 		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = nil
 		updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
 		return reconcileStatusContinue, updated, err
 	}
 
-	// 2. do nothing if scheduled cluster is in the valid clusters
-	if foundScheduled && len(syncTargets) > 0 {
-		for _, syncTarget := range syncTargets {
-			syncTargetKey := workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name)
-			if syncTargetKey != currentScheduled {
-				continue
+	// 3. filter and score the synctargets
+	framework, err := scheduler.NewFramework(location, r.placementCounter(), r.clock)
+	if err != nil {
+		conditions.MarkFalse(placement, schedulingv1alpha1.PlacementScheduled, schedulingv1alpha1.NoInstanceAvailableReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
+		return reconcileStatusStop, placement, nil
+	}
+	current := sets.NewString(workloadv1alpha1.SyncTargetKeysFromPlacementAnnotation(currentScheduled)...)
+	result := framework.Schedule(syncTargets, current)
+
+	// no valid synctarget, clean the annotation.
+	if len(result.Feasible) == 0 {
+		updated := placement
+		if foundScheduled {
+			expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = nil
+			if updated, err = r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations); err != nil {
+				return reconcileStatusContinue, updated, err
 			}
-			return reconcileStatusContinue, placement, nil
 		}
+		if len(syncTargets) > 0 {
+			conditions.MarkFalse(updated, schedulingv1alpha1.PlacementScheduled, schedulingv1alpha1.NoInstanceAvailableReason, conditionsv1alpha1.ConditionSeverityWarning,
+				"No SyncTarget in location %s is feasible: %s", location.Name, result.InfeasibleMessage())
+		} else {
+			conditions.MarkFalse(updated, schedulingv1alpha1.PlacementScheduled, schedulingv1alpha1.NoInstanceAvailableReason, conditionsv1alpha1.ConditionSeverityWarning,
+				"No SyncTarget found in location %s", location.Name)
+		}
+		return reconcileStatusContinue, updated, nil
 	}
 
-	// 3. randomly select one as the scheduled cluster
+	// 4. do nothing if the scheduled synctargets are still feasible and their number matches the instance selection
 	// TODO(qiujian16): we currently schedule each in each location independently. It cannot guarantee 1 cluster is scheduled per location
 	// when the same synctargets are in multiple locations, we need to rethink whether we need a better algorithm or we need location
 	// to be exclusive.
-	if len(syncTargets) > 0 {
-		scheduledSyncTarget := syncTargets[rand.Intn(len(syncTargets))]
-		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = workloadv1alpha1.ToSyncTargetKey(syncTargetClusterName, scheduledSyncTarget.Name)
-		updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
+	requested := requestedInstances(placement, len(result.Feasible))
+	selected := selectSyncTargets(result.Feasible, current, requested)
+	selectedKeys := make([]string, 0, len(selected))
+	for _, scored := range selected {
+		selectedKeys = append(selectedKeys, syncTargetKey(scored))
+	}
+	if current.Equal(sets.NewString(selectedKeys...)) {
+		if !conditions.IsTrue(placement, schedulingv1alpha1.PlacementScheduled) {
+			setScheduledCondition(placement, selected, requested, result)
+		}
+		return reconcileStatusContinue, placement, nil
+	}
+
+	// 5. update the scheduled synctargets
+	expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = workloadv1alpha1.PlacementAnnotationFromSyncTargetKeys(selectedKeys)
+	updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
+	if err != nil {
 		return reconcileStatusContinue, updated, err
 	}
+	setScheduledCondition(updated, selected, requested, result)
+
+	return reconcileStatusContinue, updated, nil
+}
+
+// requestedInstances returns the number of synctargets the placement asks for, given the
+// number of feasible synctargets.
+func requestedInstances(placement *schedulingv1alpha1.Placement, feasible int) int {
+	selection := placement.Spec.InstanceSelection
+	switch {
+	case selection == nil:
+		return 1
+	case selection.Type == schedulingv1alpha1.InstanceSelectionAll:
+		return feasible
+	case selection.Count < 1:
+		return 1
+	default:
+		return int(selection.Count)
+	}
+}
+
+// selectSyncTargets picks up to count of the feasible synctargets. Currently scheduled synctargets
+// are kept in favour of better scored ones, in order to not move workloads around without need.
+func selectSyncTargets(feasible []scheduler.ScoredSyncTarget, current sets.String, count int) []scheduler.ScoredSyncTarget {
+	selected := make([]scheduler.ScoredSyncTarget, 0, count)
+	for _, scored := range feasible {
+		if len(selected) < count && current.Has(syncTargetKey(scored)) {
+			selected = append(selected, scored)
+		}
+	}
+	for _, scored := range feasible {
+		if len(selected) < count && !current.Has(syncTargetKey(scored)) {
+			selected = append(selected, scored)
+		}
+	}
+	return selected
+}
 
-	return reconcileStatusContinue, placement, nil
+func syncTargetKey(scored scheduler.ScoredSyncTarget) string {
+	return workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(scored.SyncTarget), scored.SyncTarget.Name)
 }
 
-func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement) (logicalcluster.Name, []*workloadv1alpha1.SyncTarget, error) {
+func setScheduledCondition(placement *schedulingv1alpha1.Placement, selected []scheduler.ScoredSyncTarget, requested int, result *scheduler.Result) {
+	parts := make([]string, 0, len(selected))
+	for _, scored := range selected {
+		parts = append(parts, scored.String())
+	}
+	message := strings.Join(parts, "; ")
+	if len(selected) < requested {
+		message = fmt.Sprintf("Only %d of %d requested SyncTargets are feasible: %s", len(selected), requested, message)
+	}
+	if len(result.Infeasible) > 0 {
+		message += "; filtered " + result.InfeasibleMessage()
+	}
+	conditions.Set(placement, &conditionsv1alpha1.Condition{
+		Type:    schedulingv1alpha1.PlacementScheduled,
+		Status:  corev1.ConditionTrue,
+		Reason:  schedulingv1alpha1.InstanceScheduledReason,
+		Message: message,
+	})
+}
+
+// placementCounter returns a counter of the placements scheduled onto a synctarget.
+func (r *placementSchedulingReconciler) placementCounter() scheduler.PlacementCounter {
+	return func(syncTarget *workloadv1alpha1.SyncTarget) int {
+		placements, err := r.listPlacementsScheduledTo(workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name))
+		if err != nil {
+			runtime.HandleError(err)
+			return 0
+		}
+		return len(placements)
+	}
+}
+
+func (r *placementSchedulingReconciler) getLocationSyncTargetsForPlacement(placement *schedulingv1alpha1.Placement) (*schedulingv1alpha1.Location, []*workloadv1alpha1.SyncTarget, error) {
 	if placement.Status.Phase == schedulingv1alpha1.PlacementPending || placement.Status.SelectedLocation == nil {
-		return logicalcluster.Name{}, nil, nil
+		return nil, nil, nil
 	}
 
 	locationWorkspace := logicalcluster.New(placement.Status.SelectedLocation.Path)
@@ -99,27 +216,24 @@ func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clust
 		placement.Status.SelectedLocation.LocationName)
 	switch {
 	case errors.IsNotFound(err):
-		return locationWorkspace, nil, nil
+		return nil, nil, nil
 	case err != nil:
-		return locationWorkspace, nil, err
+		return nil, nil, err
 	}
 
 	// find all synctargets in the location workspace
 	syncTargets, err := r.listSyncTarget(locationWorkspace)
 	if err != nil {
-		return locationWorkspace, nil, err
+		return location, nil, err
 	}
 
 	// filter the sync targets by location
 	locationClusters, err := locationreconciler.LocationSyncTargets(syncTargets, location)
 	if err != nil {
-		return locationWorkspace, nil, err
+		return location, nil, err
 	}
 
-	// find all the valid sync targets.
-	validClusters := locationreconciler.FilterNonEvicting(locationreconciler.FilterReady(locationClusters))
-
-	return locationWorkspace, validClusters, nil
+	return location, locationClusters, nil
 }
 
 func (r *placementSchedulingReconciler) patchPlacementAnnotation(ctx context.Context, clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement, annotations map[string]interface{}) (*schedulingv1alpha1.Placement, error) {
@@ -20,15 +20,19 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
@@ -46,6 +50,7 @@ func TestSchedulingReconcile(t *testing.T) {
 
 		wantPatch           bool
 		expectedAnnotations map[string]string
+		wantCondition       *conditionsapi.Condition
 	}{
 		{
 			name:      "no location",
@@ -55,6 +60,13 @@ func TestSchedulingReconcile(t *testing.T) {
 			name:      "no synctarget",
 			placement: newPlacement("test", "test-location", ""),
 			location:  newLocation("test-location"),
+			wantCondition: &conditionsapi.Condition{
+				Type:     schedulingv1alpha1.PlacementScheduled,
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsapi.ConditionSeverityWarning,
+				Reason:   schedulingv1alpha1.NoInstanceAvailableReason,
+				Message:  "No SyncTarget found in location test-location",
+			},
 		},
 		{
 			name:        "schedule one synctarget",
@@ -65,6 +77,12 @@ func TestSchedulingReconcile(t *testing.T) {
 			expectedAnnotations: map[string]string{
 				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa",
 			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c1 scored 100 (LeastAllocated=0x1, Spread=100x1)",
+			},
 		},
 		{
 			name:        "synctarget scheduled",
@@ -74,6 +92,12 @@ func TestSchedulingReconcile(t *testing.T) {
 			expectedAnnotations: map[string]string{
 				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa",
 			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c1 scored 100 (LeastAllocated=0x1, Spread=100x1)",
+			},
 		},
 		{
 			name:                "unschedule synctarget",
@@ -82,6 +106,13 @@ func TestSchedulingReconcile(t *testing.T) {
 			syncTargets:         []*workloadv1alpha1.SyncTarget{newSyncTarget("c1", false)},
 			wantPatch:           true,
 			expectedAnnotations: map[string]string{},
+			wantCondition: &conditionsapi.Condition{
+				Type:     schedulingv1alpha1.PlacementScheduled,
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsapi.ConditionSeverityWarning,
+				Reason:   schedulingv1alpha1.NoInstanceAvailableReason,
+				Message:  "No SyncTarget in location test-location is feasible: c1: Ready: not ready",
+			},
 		},
 		{
 			name:        "reschedule synctarget",
@@ -92,6 +123,131 @@ func TestSchedulingReconcile(t *testing.T) {
 			expectedAnnotations: map[string]string{
 				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
 			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c2 scored 100 (LeastAllocated=0x1, Spread=100x1); filtered c1: Ready: not ready",
+			},
+		},
+		{
+			name:      "schedule least allocated synctarget",
+			placement: newPlacement("test", "test-location", ""),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withAllocatable(newSyncTarget("c1", true), "1", "4"),
+				withAllocatable(newSyncTarget("c2", true), "3", "4"),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c2 scored 175 (LeastAllocated=75x1, Spread=100x1)",
+			},
+		},
+		{
+			name:      "reschedule from excluded synctarget",
+			placement: newPlacement("test", "test-location", "c1"),
+			location: withSchedulingPolicy(newLocation("test-location"), &schedulingv1alpha1.SchedulingPolicy{
+				ExcludedInstances: []metav1.LabelSelector{{MatchLabels: map[string]string{"maintenance": "true"}}},
+			}),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withLabels(newSyncTarget("c1", true), map[string]string{"maintenance": "true"}),
+				newSyncTarget("c2", true),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: `c2 scored 100 (LeastAllocated=0x1, Spread=100x1); filtered c1: Excluded: matches "maintenance=true"`,
+			},
+		},
+		{
+			name:      "schedule two best synctargets",
+			placement: withInstanceSelection(newPlacement("test", "test-location", ""), schedulingv1alpha1.InstanceSelectionCount, 2),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withAllocatable(newSyncTarget("c1", true), "1", "4"),
+				withAllocatable(newSyncTarget("c2", true), "3", "4"),
+				withAllocatable(newSyncTarget("c3", true), "2", "4"),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4,5iSfzYTm7pPirj6HKlmfvXMb6AuqSBxNB7vkVP",
+			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c2 scored 175 (LeastAllocated=75x1, Spread=100x1); c3 scored 150 (LeastAllocated=50x1, Spread=100x1)",
+			},
+		},
+		{
+			name:      "keep scheduled synctarget and add the best one",
+			placement: withInstanceSelection(newPlacement("test", "test-location", "c1"), schedulingv1alpha1.InstanceSelectionCount, 2),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withAllocatable(newSyncTarget("c1", true), "1", "4"),
+				withAllocatable(newSyncTarget("c2", true), "3", "4"),
+				withAllocatable(newSyncTarget("c3", true), "2", "4"),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa,aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c1 scored 125 (LeastAllocated=25x1, Spread=100x1); c2 scored 175 (LeastAllocated=75x1, Spread=100x1)",
+			},
+		},
+		{
+			name:      "schedule all feasible synctargets",
+			placement: withInstanceSelection(newPlacement("test", "test-location", ""), schedulingv1alpha1.InstanceSelectionAll, 0),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1", true),
+				newSyncTarget("c2", true),
+				newSyncTarget("c3", false),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa,aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "c1 scored 100 (LeastAllocated=0x1, Spread=100x1); c2 scored 100 (LeastAllocated=0x1, Spread=100x1); filtered c3: Ready: not ready",
+			},
+		},
+		{
+			name:      "fewer feasible synctargets than requested",
+			placement: withInstanceSelection(newPlacement("test", "test-location", "c1", "c2"), schedulingv1alpha1.InstanceSelectionCount, 3),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1", true),
+				newSyncTarget("c2", true),
+				newSyncTarget("c3", false),
+			},
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa,aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+			wantCondition: &conditionsapi.Condition{
+				Type:    schedulingv1alpha1.PlacementScheduled,
+				Status:  corev1.ConditionTrue,
+				Reason:  schedulingv1alpha1.InstanceScheduledReason,
+				Message: "Only 2 of 3 requested SyncTargets are feasible: c1 scored 100 (LeastAllocated=0x1, Spread=100x1); c2 scored 100 (LeastAllocated=0x1, Spread=100x1); filtered c3: Ready: not ready",
+			},
 		},
 	}
 
@@ -100,6 +256,9 @@ func TestSchedulingReconcile(t *testing.T) {
 			listSyncTarget := func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
 				return testCase.syncTargets, nil
 			}
+			listPlacementsScheduledTo := func(syncTargetKey string) ([]*schedulingv1alpha1.Placement, error) {
+				return nil, nil
+			}
 			getLocation := func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
 				if testCase.location == nil {
 					return nil, errors.NewNotFound(schema.GroupResource{}, name)
@@ -123,20 +282,31 @@ func TestSchedulingReconcile(t *testing.T) {
 				return &patchedPlacement, err
 			}
 			reconciler := &placementSchedulingReconciler{
-				listSyncTarget: listSyncTarget,
-				getLocation:    getLocation,
-				patchPlacement: patchPlacement,
+				listSyncTarget:            listSyncTarget,
+				listPlacementsScheduledTo: listPlacementsScheduledTo,
+				getLocation:               getLocation,
+				patchPlacement:            patchPlacement,
+				clock:                     clocktesting.NewFakePassiveClock(time.Now()),
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), testCase.placement)
 			require.NoError(t, err)
 			require.Equal(t, testCase.wantPatch, patched)
 			require.Equal(t, testCase.expectedAnnotations, updated.Annotations)
+
+			c := conditions.Get(updated, schedulingv1alpha1.PlacementScheduled)
+			if testCase.wantCondition == nil {
+				require.Nil(t, c)
+				return
+			}
+			require.NotNil(t, c)
+			c.LastTransitionTime = metav1.Time{}
+			require.Equal(t, *testCase.wantCondition, *c)
 		})
 	}
 }
 
-func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placement {
+func newPlacement(name, location string, synctargets ...string) *schedulingv1alpha1.Placement {
 	placement := &schedulingv1alpha1.Placement{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
@@ -151,15 +321,29 @@ func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placeme
 		},
 	}
 
-	if len(synctarget) > 0 {
+	var keys []string
+	for _, synctarget := range synctargets {
+		if len(synctarget) > 0 {
+			keys = append(keys, workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), synctarget))
+		}
+	}
+	if len(keys) > 0 {
 		placement.Annotations = map[string]string{
-			workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), synctarget),
+			workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: workloadv1alpha1.PlacementAnnotationFromSyncTargetKeys(keys),
 		}
 	}
 
 	return placement
 }
 
+func withInstanceSelection(placement *schedulingv1alpha1.Placement, selectionType schedulingv1alpha1.InstanceSelectionType, count int32) *schedulingv1alpha1.Placement {
+	placement.Spec.InstanceSelection = &schedulingv1alpha1.InstanceSelection{
+		Type:  selectionType,
+		Count: count,
+	}
+	return placement
+}
+
 func newLocation(name string) *schedulingv1alpha1.Location {
 	return &schedulingv1alpha1.Location{
 		ObjectMeta: metav1.ObjectMeta{
@@ -184,3 +368,19 @@ func newSyncTarget(name string, ready bool) *workloadv1alpha1.SyncTarget {
 
 	return syncTarget
 }
+
+func withSchedulingPolicy(location *schedulingv1alpha1.Location, policy *schedulingv1alpha1.SchedulingPolicy) *schedulingv1alpha1.Location {
+	location.Spec.SchedulingPolicy = policy
+	return location
+}
+
+func withLabels(syncTarget *workloadv1alpha1.SyncTarget, labels map[string]string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Labels = labels
+	return syncTarget
+}
+
+func withAllocatable(syncTarget *workloadv1alpha1.SyncTarget, allocatableCPU, capacityCPU string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.Allocatable = &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(allocatableCPU)}
+	syncTarget.Status.Capacity = &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(capacityCPU)}
+	return syncTarget
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// MaxScore is the highest score a ScorePlugin can give to a SyncTarget.
+const MaxScore int64 = 100
+
+// FilterPlugin decides whether a SyncTarget can be selected at all.
+type FilterPlugin interface {
+	Name() string
+
+	// Filter returns an empty string if the SyncTarget is feasible, and otherwise
+	// a short human readable reason why it is not.
+	Filter(syncTarget *workloadv1alpha1.SyncTarget) string
+}
+
+// ScorePlugin ranks the feasible SyncTargets.
+type ScorePlugin interface {
+	Name() string
+
+	// Score returns a score between 0 and MaxScore for each of the given SyncTargets,
+	// in the same order. The SyncTargets are passed all at once such that plugins
+	// can normalize their scores.
+	Score(syncTargets []*workloadv1alpha1.SyncTarget) []int64
+}
+
+type weightedScorePlugin struct {
+	ScorePlugin
+	weight int64
+}
+
+// PlacementCounter returns the number of placements currently scheduled onto the given SyncTarget.
+type PlacementCounter func(syncTarget *workloadv1alpha1.SyncTarget) int
+
+// Framework filters and scores the SyncTargets of a Location.
+type Framework struct {
+	filters []FilterPlugin
+	// avoidFilters only filter the SyncTargets which are not scheduled yet, i.e. the scheduler
+	// avoids them for new workloads, but does not move workloads away from them.
+	avoidFilters []FilterPlugin
+	scores       []weightedScorePlugin
+}
+
+// NewFramework returns a Framework configured by the scheduling policy of the given location. The clock
+// decides whether SyncTargets are evicting.
+func NewFramework(location *schedulingv1alpha1.Location, countPlacements PlacementCounter, clock clock.PassiveClock) (*Framework, error) {
+	policy := location.Spec.SchedulingPolicy
+	if policy == nil {
+		policy = &schedulingv1alpha1.SchedulingPolicy{}
+	}
+
+	excluded, err := newExcludedFilter(policy.ExcludedInstances)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduling policy in location %s: %w", location.Name, err)
+	}
+	f := &Framework{
+		filters: []FilterPlugin{
+			readyFilter{},
+			nonEvictingFilter{clock: clock},
+			allocatableFilter{},
+			excluded,
+		},
+		avoidFilters: []FilterPlugin{
+			healthyFilter{},
+		},
+	}
+
+	scorePlugins := policy.ScorePlugins
+	if len(scorePlugins) == 0 {
+		scorePlugins = []schedulingv1alpha1.ScorePluginConfig{
+			{Name: schedulingv1alpha1.LeastAllocatedScorePlugin, Weight: 1},
+			{Name: schedulingv1alpha1.SpreadScorePlugin, Weight: 1},
+		}
+	}
+	for _, cfg := range scorePlugins {
+		var p ScorePlugin
+		switch cfg.Name {
+		case schedulingv1alpha1.LeastAllocatedScorePlugin:
+			p = leastAllocatedScore{}
+		case schedulingv1alpha1.SpreadScorePlugin:
+			p = spreadScore{countPlacements: countPlacements}
+		case schedulingv1alpha1.LabelAffinityScorePlugin:
+			p, err = newLabelAffinityScore(policy.PreferredInstances)
+			if err != nil {
+				return nil, fmt.Errorf("invalid scheduling policy in location %s: %w", location.Name, err)
+			}
+		default:
+			return nil, fmt.Errorf("invalid scheduling policy in location %s: unknown score plugin %q", location.Name, cfg.Name)
+		}
+		f.scores = append(f.scores, weightedScorePlugin{ScorePlugin: p, weight: int64(cfg.Weight)})
+	}
+
+	return f, nil
+}
+
+// PluginScore is the score a single plugin gave to a SyncTarget.
+type PluginScore struct {
+	Plugin string
+	Score  int64
+	Weight int64
+}
+
+// ScoredSyncTarget is a feasible SyncTarget with its score breakdown.
+type ScoredSyncTarget struct {
+	SyncTarget *workloadv1alpha1.SyncTarget
+	Total      int64
+	Scores     []PluginScore
+}
+
+// String returns the score breakdown, e.g. "c1 scored 150 (LeastAllocated=50x1, Spread=100x1)".
+func (s ScoredSyncTarget) String() string {
+	parts := make([]string, 0, len(s.Scores))
+	for _, ps := range s.Scores {
+		parts = append(parts, fmt.Sprintf("%s=%dx%d", ps.Plugin, ps.Score, ps.Weight))
+	}
+	return fmt.Sprintf("%s scored %d (%s)", s.SyncTarget.Name, s.Total, strings.Join(parts, ", "))
+}
+
+// Result is the outcome of a scheduling cycle.
+type Result struct {
+	// Feasible are the SyncTargets that passed all filters, sorted from the best to the
+	// worst score. Ties are broken by name, so the order is deterministic.
+	Feasible []ScoredSyncTarget
+	// Infeasible maps the names of the filtered SyncTargets to the reason they were filtered.
+	Infeasible map[string]string
+}
+
+// InfeasibleMessage returns a human readable summary of the filtered SyncTargets.
+func (r *Result) InfeasibleMessage() string {
+	names := make([]string, 0, len(r.Infeasible))
+	for name := range r.Infeasible {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %s", name, r.Infeasible[name]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Schedule filters and scores the given SyncTargets. The scheduled SyncTargets, given by their keys,
+// are not subject to the avoid filters, e.g. they stay feasible when they are degraded.
+func (f *Framework) Schedule(syncTargets []*workloadv1alpha1.SyncTarget, scheduled sets.String) *Result {
+	result := &Result{Infeasible: map[string]string{}}
+
+	feasible := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	for _, syncTarget := range syncTargets {
+		filters := f.filters
+		if !scheduled.Has(workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name)) {
+			filters = append(filters[:len(filters):len(filters)], f.avoidFilters...)
+		}
+		if reason := filter(filters, syncTarget); reason != "" {
+			result.Infeasible[syncTarget.Name] = reason
+			continue
+		}
+		feasible = append(feasible, syncTarget)
+	}
+
+	result.Feasible = make([]ScoredSyncTarget, len(feasible))
+	for i, syncTarget := range feasible {
+		result.Feasible[i].SyncTarget = syncTarget
+	}
+	for _, p := range f.scores {
+		scores := p.Score(feasible)
+		for i := range result.Feasible {
+			result.Feasible[i].Scores = append(result.Feasible[i].Scores, PluginScore{Plugin: p.Name(), Score: scores[i], Weight: p.weight})
+			result.Feasible[i].Total += scores[i] * p.weight
+		}
+	}
+
+	sort.SliceStable(result.Feasible, func(i, j int) bool {
+		if result.Feasible[i].Total != result.Feasible[j].Total {
+			return result.Feasible[i].Total > result.Feasible[j].Total
+		}
+		return result.Feasible[i].SyncTarget.Name < result.Feasible[j].SyncTarget.Name
+	})
+
+	return result
+}
+
+func filter(filters []FilterPlugin, syncTarget *workloadv1alpha1.SyncTarget) string {
+	for _, p := range filters {
+		if reason := p.Filter(syncTarget); reason != "" {
+			return fmt.Sprintf("%s: %s", p.Name(), reason)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSchedule(t *testing.T) {
+	testCases := []struct {
+		name string
+
+		policy      *schedulingv1alpha1.SchedulingPolicy
+		syncTargets []*workloadv1alpha1.SyncTarget
+		placements  map[string]int
+		scheduled   sets.String
+
+		wantFeasible   []string
+		wantInfeasible map[string]string
+		wantBest       string
+	}{
+		{
+			name:           "no synctargets",
+			wantInfeasible: map[string]string{},
+		},
+		{
+			name:           "ties are broken by name",
+			syncTargets:    []*workloadv1alpha1.SyncTarget{newSyncTarget("c2"), newSyncTarget("c1"), newSyncTarget("c3")},
+			wantFeasible:   []string{"c1", "c2", "c3"},
+			wantInfeasible: map[string]string{},
+			wantBest:       "c1 scored 100 (LeastAllocated=0x1, Spread=100x1)",
+		},
+		{
+			name: "filters",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1"),
+				notReady(newSyncTarget("not-ready")),
+				unschedulable(newSyncTarget("unschedulable")),
+				evicting(newSyncTarget("evicting")),
+				withResources(newSyncTarget("full"), "0", "0", "4", "8Gi"),
+				withLabels(newSyncTarget("gpu"), "gpu", "true"),
+			},
+			policy: &schedulingv1alpha1.SchedulingPolicy{
+				ExcludedInstances: []metav1.LabelSelector{{MatchLabels: map[string]string{"gpu": "true"}}},
+			},
+			wantFeasible: []string{"c1"},
+			wantInfeasible: map[string]string{
+				"not-ready":     "Ready: not ready",
+				"unschedulable": "Ready: unschedulable",
+				"evicting":      "NonEvicting: evicting",
+				"full":          "Allocatable: no allocatable cpu",
+				"gpu":           `Excluded: matches "gpu=true"`,
+			},
+			wantBest: "c1 scored 100 (LeastAllocated=0x1, Spread=100x1)",
+		},
+		{
+			name: "degraded synctargets are only feasible if scheduled already",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1"),
+				degraded(newSyncTarget("degraded")),
+				degraded(newSyncTarget("scheduled-degraded")),
+			},
+			scheduled:    sets.NewString(workloadv1alpha1.ToSyncTargetKey(logicalcluster.Name{}, "scheduled-degraded")),
+			wantFeasible: []string{"c1", "scheduled-degraded"},
+			wantInfeasible: map[string]string{
+				"degraded": "Healthy: degraded: downstream unreachable",
+			},
+			wantBest: "c1 scored 100 (LeastAllocated=0x1, Spread=100x1)",
+		},
+		{
+			name: "least allocated",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withResources(newSyncTarget("c1"), "1", "2Gi", "4", "8Gi"),
+				withResources(newSyncTarget("c2"), "3", "6Gi", "4", "8Gi"),
+				newSyncTarget("c3"),
+			},
+			policy: &schedulingv1alpha1.SchedulingPolicy{
+				ScorePlugins: []schedulingv1alpha1.ScorePluginConfig{{Name: schedulingv1alpha1.LeastAllocatedScorePlugin, Weight: 2}},
+			},
+			wantFeasible:   []string{"c2", "c1", "c3"},
+			wantInfeasible: map[string]string{},
+			wantBest:       "c2 scored 150 (LeastAllocated=75x2)",
+		},
+		{
+			name:        "spread",
+			syncTargets: []*workloadv1alpha1.SyncTarget{newSyncTarget("c1"), newSyncTarget("c2"), newSyncTarget("c3")},
+			placements:  map[string]int{"c1": 4, "c2": 1, "c3": 2},
+			policy: &schedulingv1alpha1.SchedulingPolicy{
+				ScorePlugins: []schedulingv1alpha1.ScorePluginConfig{{Name: schedulingv1alpha1.SpreadScorePlugin, Weight: 1}},
+			},
+			wantFeasible:   []string{"c2", "c3", "c1"},
+			wantInfeasible: map[string]string{},
+			wantBest:       "c2 scored 75 (Spread=75x1)",
+		},
+		{
+			name: "label affinity",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1"),
+				withLabels(newSyncTarget("c2"), "region", "eu"),
+				withLabels(newSyncTarget("c3"), "region", "us"),
+			},
+			policy: &schedulingv1alpha1.SchedulingPolicy{
+				ScorePlugins: []schedulingv1alpha1.ScorePluginConfig{
+					{Name: schedulingv1alpha1.LabelAffinityScorePlugin, Weight: 1},
+					{Name: schedulingv1alpha1.SpreadScorePlugin, Weight: 1},
+				},
+				PreferredInstances: []schedulingv1alpha1.WeightedInstanceSelector{
+					{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"region": "us"}}, Weight: 3},
+					{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}}, Weight: 1},
+				},
+			},
+			wantFeasible:   []string{"c3", "c2", "c1"},
+			wantInfeasible: map[string]string{},
+			wantBest:       "c3 scored 175 (LabelAffinity=75x1, Spread=100x1)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			location := &schedulingv1alpha1.Location{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-location"},
+				Spec:       schedulingv1alpha1.LocationSpec{SchedulingPolicy: tc.policy},
+			}
+			f, err := NewFramework(location, func(syncTarget *workloadv1alpha1.SyncTarget) int {
+				return tc.placements[syncTarget.Name]
+			}, clocktesting.NewFakePassiveClock(time.Now()))
+			require.NoError(t, err)
+
+			result := f.Schedule(tc.syncTargets, tc.scheduled)
+
+			var feasible []string
+			for _, scored := range result.Feasible {
+				feasible = append(feasible, scored.SyncTarget.Name)
+			}
+			require.Equal(t, tc.wantFeasible, feasible)
+			require.Equal(t, tc.wantInfeasible, result.Infeasible)
+			if tc.wantBest != "" {
+				require.Equal(t, tc.wantBest, result.Feasible[0].String())
+			}
+		})
+	}
+}
+
+func TestNewFrameworkInvalidPolicy(t *testing.T) {
+	location := &schedulingv1alpha1.Location{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-location"},
+		Spec: schedulingv1alpha1.LocationSpec{
+			SchedulingPolicy: &schedulingv1alpha1.SchedulingPolicy{
+				ExcludedInstances: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "Bogus"}}}},
+			},
+		},
+	}
+	_, err := NewFramework(location, nil, clocktesting.NewFakePassiveClock(time.Now()))
+	require.Error(t, err)
+}
+
+func TestNonEvictingFilter(t *testing.T) {
+	evictAfter := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{name: "before EvictAfter", now: evictAfter.Add(-time.Second)},
+		{name: "at EvictAfter", now: evictAfter, want: "evicting"},
+		{name: "after EvictAfter", now: evictAfter.Add(time.Second), want: "evicting"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			syncTarget := newSyncTarget("c1")
+			syncTarget.Spec.EvictAfter = &metav1.Time{Time: evictAfter}
+			filter := nonEvictingFilter{clock: clocktesting.NewFakePassiveClock(tc.now)}
+			require.Equal(t, tc.want, filter.Filter(syncTarget))
+			require.Empty(t, filter.Filter(newSyncTarget("c2")), "SyncTargets without EvictAfter are never evicting")
+		})
+	}
+}
+
+func newSyncTarget(name string) *workloadv1alpha1.SyncTarget {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	conditions.MarkTrue(syncTarget, conditionsapi.ReadyCondition)
+	return syncTarget
+}
+
+func notReady(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	conditions.MarkFalse(syncTarget, conditionsapi.ReadyCondition, "NotReady", conditionsapi.ConditionSeverityError, "")
+	return syncTarget
+}
+
+func degraded(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	conditions.MarkFalse(syncTarget, workloadv1alpha1.SyncerHealthy, workloadv1alpha1.DegradedReason, conditionsapi.ConditionSeverityWarning, "downstream unreachable")
+	return syncTarget
+}
+
+func unschedulable(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.Unschedulable = true
+	return syncTarget
+}
+
+func evicting(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.EvictAfter = &metav1.Time{}
+	return syncTarget
+}
+
+func withLabels(syncTarget *workloadv1alpha1.SyncTarget, kv ...string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Labels = map[string]string{}
+	for i := 0; i < len(kv); i += 2 {
+		syncTarget.Labels[kv[i]] = kv[i+1]
+	}
+	return syncTarget
+}
+
+func withResources(syncTarget *workloadv1alpha1.SyncTarget, allocatableCPU, allocatableMemory, capacityCPU, capacityMemory string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Status.Allocatable = &corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(allocatableCPU),
+		corev1.ResourceMemory: resource.MustParse(allocatableMemory),
+	}
+	syncTarget.Status.Capacity = &corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(capacityCPU),
+		corev1.ResourceMemory: resource.MustParse(capacityMemory),
+	}
+	return syncTarget
+}
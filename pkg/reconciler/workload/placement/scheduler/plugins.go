@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/clock"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// readyFilter filters out SyncTargets which are not ready or are marked as unschedulable.
+type readyFilter struct{}
+
+func (readyFilter) Name() string { return "Ready" }
+
+func (readyFilter) Filter(syncTarget *workloadv1alpha1.SyncTarget) string {
+	if !conditions.IsTrue(syncTarget, conditionsv1alpha1.ReadyCondition) {
+		return "not ready"
+	}
+	if syncTarget.Spec.Unschedulable {
+		return "unschedulable"
+	}
+	return ""
+}
+
+// nonEvictingFilter filters out SyncTargets which are evicting their workloads, i.e. whose EvictAfter
+// time has been reached.
+type nonEvictingFilter struct {
+	clock clock.PassiveClock
+}
+
+func (nonEvictingFilter) Name() string { return "NonEvicting" }
+
+func (f nonEvictingFilter) Filter(syncTarget *workloadv1alpha1.SyncTarget) string {
+	if syncTarget.Spec.EvictAfter != nil && !f.clock.Now().Before(syncTarget.Spec.EvictAfter.Time) {
+		return "evicting"
+	}
+	return ""
+}
+
+// healthyFilter filters out SyncTargets which are degraded, i.e. whose syncer reports
+// an unhealthy state.
+type healthyFilter struct{}
+
+func (healthyFilter) Name() string { return "Healthy" }
+
+func (healthyFilter) Filter(syncTarget *workloadv1alpha1.SyncTarget) string {
+	if conditions.IsFalse(syncTarget, workloadv1alpha1.SyncerHealthy) {
+		return "degraded: " + conditions.GetMessage(syncTarget, workloadv1alpha1.SyncerHealthy)
+	}
+	return ""
+}
+
+// allocatableFilter filters out SyncTargets which report no allocatable cpu or memory.
+// SyncTargets not reporting allocatable resources at all are feasible.
+type allocatableFilter struct{}
+
+func (allocatableFilter) Name() string { return "Allocatable" }
+
+func (allocatableFilter) Filter(syncTarget *workloadv1alpha1.SyncTarget) string {
+	if syncTarget.Status.Allocatable == nil {
+		return ""
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if q, found := (*syncTarget.Status.Allocatable)[name]; found && q.Sign() <= 0 {
+			return fmt.Sprintf("no allocatable %s", name)
+		}
+	}
+	return ""
+}
+
+// excludedFilter filters out SyncTargets matching any of the excluded instance selectors.
+type excludedFilter struct {
+	selectors []labels.Selector
+}
+
+func newExcludedFilter(selectors []metav1.LabelSelector) (*excludedFilter, error) {
+	f := &excludedFilter{}
+	for i := range selectors {
+		sel, err := metav1.LabelSelectorAsSelector(&selectors[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded instance selector: %w", err)
+		}
+		f.selectors = append(f.selectors, sel)
+	}
+	return f, nil
+}
+
+func (*excludedFilter) Name() string { return "Excluded" }
+
+func (f *excludedFilter) Filter(syncTarget *workloadv1alpha1.SyncTarget) string {
+	for _, sel := range f.selectors {
+		if sel.Matches(labels.Set(syncTarget.Labels)) {
+			return fmt.Sprintf("matches %q", sel.String())
+		}
+	}
+	return ""
+}
+
+// leastAllocatedScore favors SyncTargets with the highest ratio of allocatable to capacity
+// cpu and memory. SyncTargets not reporting capacity get a score of zero.
+type leastAllocatedScore struct{}
+
+func (leastAllocatedScore) Name() string { return string(schedulingv1alpha1.LeastAllocatedScorePlugin) }
+
+func (leastAllocatedScore) Score(syncTargets []*workloadv1alpha1.SyncTarget) []int64 {
+	scores := make([]int64, len(syncTargets))
+	for i, syncTarget := range syncTargets {
+		if syncTarget.Status.Allocatable == nil || syncTarget.Status.Capacity == nil {
+			continue
+		}
+		var sum, count int64
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			capacity, found := (*syncTarget.Status.Capacity)[name]
+			if !found || capacity.Sign() <= 0 {
+				continue
+			}
+			allocatable := (*syncTarget.Status.Allocatable)[name]
+			ratio := allocatable.AsApproximateFloat64() / capacity.AsApproximateFloat64()
+			if ratio > 1 {
+				ratio = 1
+			}
+			sum += int64(ratio * float64(MaxScore))
+			count++
+		}
+		if count > 0 {
+			scores[i] = sum / count
+		}
+	}
+	return scores
+}
+
+// spreadScore favors SyncTargets with the fewest placements scheduled onto them.
+type spreadScore struct {
+	countPlacements PlacementCounter
+}
+
+func (spreadScore) Name() string { return string(schedulingv1alpha1.SpreadScorePlugin) }
+
+func (p spreadScore) Score(syncTargets []*workloadv1alpha1.SyncTarget) []int64 {
+	counts := make([]int, len(syncTargets))
+	max := 0
+	for i, syncTarget := range syncTargets {
+		if p.countPlacements != nil {
+			counts[i] = p.countPlacements(syncTarget)
+		}
+		if counts[i] > max {
+			max = counts[i]
+		}
+	}
+
+	scores := make([]int64, len(syncTargets))
+	for i := range syncTargets {
+		if max == 0 {
+			scores[i] = MaxScore
+			continue
+		}
+		scores[i] = int64(max-counts[i]) * MaxScore / int64(max)
+	}
+	return scores
+}
+
+type weightedSelector struct {
+	selector labels.Selector
+	weight   int64
+}
+
+// labelAffinityScore favors SyncTargets matching the preferred instance selectors.
+type labelAffinityScore struct {
+	selectors   []weightedSelector
+	totalWeight int64
+}
+
+func newLabelAffinityScore(preferred []schedulingv1alpha1.WeightedInstanceSelector) (*labelAffinityScore, error) {
+	p := &labelAffinityScore{}
+	for i := range preferred {
+		sel, err := metav1.LabelSelectorAsSelector(&preferred[i].Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferred instance selector: %w", err)
+		}
+		p.selectors = append(p.selectors, weightedSelector{selector: sel, weight: int64(preferred[i].Weight)})
+		p.totalWeight += int64(preferred[i].Weight)
+	}
+	return p, nil
+}
+
+func (*labelAffinityScore) Name() string { return string(schedulingv1alpha1.LabelAffinityScorePlugin) }
+
+func (p *labelAffinityScore) Score(syncTargets []*workloadv1alpha1.SyncTarget) []int64 {
+	scores := make([]int64, len(syncTargets))
+	if p.totalWeight == 0 {
+		return scores
+	}
+	for i, syncTarget := range syncTargets {
+		var matched int64
+		for _, ws := range p.selectors {
+			if ws.selector.Matches(labels.Set(syncTarget.Labels)) {
+				matched += ws.weight
+			}
+		}
+		scores[i] = matched * MaxScore / p.totalWeight
+	}
+	return scores
+}
@@ -43,6 +43,8 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
@@ -63,14 +65,17 @@ func NewController(
 ) (*Controller, error) {
 	resourceQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-resource")
 	gvrQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-gvr")
+	migrationQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-migration")
 
 	c := &Controller{
-		resourceQueue: resourceQueue,
-		gvrQueue:      gvrQueue,
+		resourceQueue:  resourceQueue,
+		gvrQueue:       gvrQueue,
+		migrationQueue: migrationQueue,
 
 		dynClusterClient: dynamicClusterClient,
 
-		namespaceLister: namespaceInformer.Lister(),
+		namespaceLister:  namespaceInformer.Lister(),
+		namespaceIndexer: namespaceInformer.Informer().GetIndexer(),
 
 		syncTargetLister:  syncTargetInformer.Lister(),
 		syncTargetIndexer: syncTargetInformer.Informer().GetIndexer(),
@@ -78,6 +83,10 @@ func NewController(
 		ddsif: ddsif,
 	}
 
+	indexers.AddIfNotPresentOrDie(namespaceInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+	})
+
 	namespaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: filterNamespace,
 		Handler: cache.ResourceEventHandlerFuncs{
@@ -91,17 +100,26 @@ func NewController(
 				}
 
 			},
-			DeleteFunc: nil, // Nothing to do.
+			DeleteFunc: func(obj interface{}) { c.enqueueClusterScopedResourcesOfNamespace(obj) },
 		},
 	})
 
 	c.ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
 		AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueResource(gvr, obj) },
 		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueueResource(gvr, obj) },
-		DeleteFunc: nil, // Nothing to do.
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueNamespaceMigrationOf(obj) },
 	})
 
 	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSyncTarget, newSyncTarget := oldObj.(*workloadv1alpha1.SyncTarget), newObj.(*workloadv1alpha1.SyncTarget)
+			if conditions.IsTrue(oldSyncTarget, conditionsv1alpha1.ReadyCondition) != conditions.IsTrue(newSyncTarget, conditionsv1alpha1.ReadyCondition) {
+				c.enqueueSyncTargetResources(newSyncTarget)
+			}
+			if oldResources, newResources := clusterScopedResourcesOf(oldSyncTarget), clusterScopedResourcesOf(newSyncTarget); !oldResources.Equal(newResources) {
+				c.enqueueClusterScopedResources(logicalcluster.Name{}, oldResources.Union(newResources))
+			}
+		},
 		DeleteFunc: func(obj interface{}) {
 			c.enqueueSyncTarget(obj)
 		},
@@ -123,7 +141,7 @@ func scheduleStateLabels(ls map[string]string) map[string]string {
 func scheduleStateAnnotations(ls map[string]string) map[string]string {
 	ret := make(map[string]string, len(ls))
 	for k, v := range ls {
-		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix) {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix) || k == workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey {
 			ret[k] = v
 		}
 	}
@@ -131,12 +149,14 @@ func scheduleStateAnnotations(ls map[string]string) map[string]string {
 }
 
 type Controller struct {
-	resourceQueue workqueue.RateLimitingInterface
-	gvrQueue      workqueue.RateLimitingInterface
+	resourceQueue  workqueue.RateLimitingInterface
+	gvrQueue       workqueue.RateLimitingInterface
+	migrationQueue workqueue.RateLimitingInterface
 
 	dynClusterClient dynamic.Interface
 
-	namespaceLister corelisters.NamespaceLister
+	namespaceLister  corelisters.NamespaceLister
+	namespaceIndexer cache.Indexer
 
 	syncTargetLister  workloadlisters.SyncTargetLister
 	syncTargetIndexer cache.Indexer
@@ -181,6 +201,43 @@ func (c *Controller) enqueueGVR(gvr schema.GroupVersionResource) {
 	c.gvrQueue.Add(queueKey)
 }
 
+// enqueueNamespaceMigration queues the namespace to check whether its migration is finished. Many
+// resources of the namespace trigger the check, but the queue collapses them into one.
+func (c *Controller) enqueueNamespaceMigration(ns *corev1.Namespace) {
+	if !isMigrating(ns) {
+		return
+	}
+	queueKey := clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), queueKey)
+	logger.V(4).Info("queueing Namespace migration")
+	c.migrationQueue.Add(queueKey)
+}
+
+// enqueueNamespaceMigrationOf queues the migration check of the namespace of a deleted resource.
+func (c *Controller) enqueueNamespaceMigrationOf(obj interface{}) {
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName, namespace, _, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	if namespace == "" {
+		return
+	}
+	ns, err := c.namespaceLister.Get(clusters.ToClusterAwareKey(clusterName, namespace))
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			runtime.HandleError(err)
+		}
+		return
+	}
+	c.enqueueNamespaceMigration(ns)
+}
+
 func (c *Controller) enqueueNamespace(obj interface{}) {
 	key, err := kcpcache.MetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
@@ -212,6 +269,7 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
 	defer c.resourceQueue.ShutDown()
 	defer c.gvrQueue.ShutDown()
+	defer c.migrationQueue.ShutDown()
 
 	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
 	ctx = klog.NewContext(ctx, logger)
@@ -221,6 +279,7 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 	for i := 0; i < numThreads; i++ {
 		go wait.Until(func() { c.startResourceWorker(ctx) }, time.Second, ctx.Done())
 		go wait.Until(func() { c.startGVRWorker(ctx) }, time.Second, ctx.Done())
+		go wait.Until(func() { c.startMigrationWorker(ctx) }, time.Second, ctx.Done())
 	}
 	<-ctx.Done()
 }
@@ -233,6 +292,10 @@ func (c *Controller) startGVRWorker(ctx context.Context) {
 	for processNext(ctx, c.gvrQueue, c.processGVR) {
 	}
 }
+func (c *Controller) startMigrationWorker(ctx context.Context) {
+	for processNext(ctx, c.migrationQueue, c.processNamespaceMigration) {
+	}
+}
 
 func processNext(
 	ctx context.Context,
@@ -330,6 +393,17 @@ func (c *Controller) processGVR(ctx context.Context, gvrstr string) error {
 	return c.reconcileGVR(*gvr)
 }
 
+func (c *Controller) processNamespaceMigration(ctx context.Context, key string) error {
+	ns, err := c.namespaceLister.Get(key)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.reconcileNamespaceMigration(ctx, ns)
+}
+
 // namespaceBlocklist holds a set of namespaces that should never be synced from kcp to physical clusters.
 var namespaceBlocklist = sets.NewString("kube-system", "kube-public", "kube-node-lease")
 
@@ -365,8 +439,9 @@ func (c *Controller) enqueueResourcesForNamespace(ns *corev1.Namespace) error {
 			}
 
 			objLocations, objDeleting := locations(u.GetAnnotations(), u.GetLabels(), false)
+			selectedLocations := selectLocations(ns, nsLocations, u)
 			logger := logging.WithObject(logger, u).WithValues("gvk", gvr.GroupVersion().WithKind(u.GetKind()))
-			if !objLocations.Equal(nsLocations) || !objDeleting.Equal(nsDeleting) {
+			if !objLocations.Equal(selectedLocations) || !objDeleting.Equal(nsDeleting.Intersection(selectedLocations)) {
 				c.enqueueResource(gvr, obj)
 
 				if klog.V(2).Enabled() && !klog.V(4).Enabled() && len(enqueuedResources) < 10 {
@@ -394,9 +469,28 @@ func (c *Controller) enqueueResourcesForNamespace(ns *corev1.Namespace) error {
 		c.enqueueGVR(gvr)
 	}
 
+	c.enqueueNamespaceMigration(ns)
+	c.enqueueClusterScopedResources(clusterName, c.allowedClusterScopedResources())
+
 	return utilerrors.NewAggregate(errs)
 }
 
+// enqueueClusterScopedResourcesOfNamespace queues the cluster-scoped resources of the workspace of a deleted
+// namespace, which might not be synced to the sync targets of the namespace anymore.
+func (c *Controller) enqueueClusterScopedResourcesOfNamespace(obj interface{}) {
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.enqueueClusterScopedResources(clusterName, c.allowedClusterScopedResources())
+}
+
 func (c *Controller) enqueueSyncTarget(obj interface{}) {
 	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), obj.(*workloadv1alpha1.SyncTarget)).WithValues("operation", "enqueueSyncTarget")
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
@@ -437,6 +531,35 @@ func (c *Controller) enqueueSyncTarget(obj interface{}) {
 	}
 }
 
+// enqueueSyncTargetResources queues the resources synced to the given sync target and other sync targets, whose
+// status writer depends on the readiness of the sync target.
+func (c *Controller) enqueueSyncTargetResources(syncTarget *workloadv1alpha1.SyncTarget) {
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), syncTarget).WithValues("operation", "enqueueSyncTargetResources")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name)
+	selector := labels.SelectorFromSet(labels.Set{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: string(workloadv1alpha1.ResourceStateSync)})
+
+	listers, _ := c.ddsif.Listers()
+	queued := map[string]int{}
+	for gvr, lister := range listers {
+		objs, err := lister.List(selector)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		for _, obj := range objs {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok || len(syncershared.SyncTargetKeys(u.GetLabels())) < 2 {
+				continue
+			}
+			c.enqueueResource(gvr, obj)
+			queued[gvr.String()]++
+		}
+	}
+	if len(queued) > 0 {
+		logger.WithValues("resources", queued).V(2).Info("queued resources synced to multiple SyncTargets because the readiness of the SyncTarget changed")
+	}
+}
+
 func locations(annotations, labels map[string]string, skipPending bool) (locations sets.String, deleting sets.String) {
 	locations = sets.NewString()
 	deleting = sets.NewString()
@@ -33,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
@@ -48,13 +49,22 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), obj).WithValues("groupVersionResource", gvr.String(), "logicalCluster", lclusterName.String())
 	logger.V(4).Info("reconciling resource")
 
-	// If the resource is not namespaced (incl if the resource is itself a
-	// namespace), ignore it.
-	if obj.GetNamespace() == "" {
-		logger.V(4).Info("resource had no namespace; ignoring")
+	// Namespaces are scheduled by the namespace scheduler.
+	if gvr.Group == "" && gvr.Resource == "namespaces" {
+		logger.V(4).Info("resource is a namespace; ignoring")
 		return nil
 	}
 
+	if _, found := obj.GetLabels()[workloadv1alpha1.GlobalEndpointLabel]; found {
+		logger.V(4).Info("skipping global endpoint of multi-cluster resource")
+		return nil
+	}
+
+	// Cluster-scoped resources are synced to the sync targets of the workspace allowing them.
+	if obj.GetNamespace() == "" {
+		return c.reconcileClusterScopedResource(ctx, logger, lclusterName, obj, gvr)
+	}
+
 	if namespaceBlocklist.Has(obj.GetNamespace()) {
 		logger.V(4).Info("skipping syncing namespace")
 		return nil
@@ -72,6 +82,10 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 		return fmt.Errorf("error reconciling resource %s|%s/%s: error getting namespace: %w", lclusterName, obj.GetNamespace(), obj.GetName(), err)
 	}
 
+	if syncershared.IsUpsynced(obj.GetLabels()) {
+		return c.reconcileUpsyncedResource(logicalcluster.WithCluster(klog.NewContext(ctx, logger), lclusterName), ns, obj, gvr)
+	}
+
 	var annotationPatch, labelPatch map[string]interface{}
 
 	// If the object DeletionTimestamp is set, we should set all locations deletion timestamps annotations to the same value.
@@ -80,9 +94,45 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 	} else {
 		// We only need to compute the new placements if the resource is not being deleted.
 		annotationPatch, labelPatch = computePlacement(ns, obj)
+
+		// Stateful resources are held on the sync targets they are removed from until they are migrated.
+		if migrationPatch := computeMigration(ns, *gvr, obj, annotationPatch); migrationPatch != nil {
+			if annotationPatch == nil {
+				annotationPatch = map[string]interface{}{}
+			}
+			for k, v := range migrationPatch {
+				annotationPatch[k] = v
+			}
+		}
+
+		// Only one syncer writes the aggregated status of resources synced to multiple sync targets.
+		if writerPatch := computeStatusWriter(obj, c.syncTargetReady); writerPatch != nil {
+			if annotationPatch == nil {
+				annotationPatch = map[string]interface{}{}
+			}
+			for k, v := range writerPatch {
+				annotationPatch[k] = v
+			}
+		}
 	}
 
-	// clean finalizers from removed syncers
+	filteredFinalizers := c.filterSyncerFinalizers(logger, obj)
+	if len(labelPatch) == 0 && len(annotationPatch) == 0 && len(filteredFinalizers) == len(obj.GetFinalizers()) {
+		logger.V(4).Info("nothing to change for resource")
+		if err := c.reconcileNamespacePinning(ctx, ns, obj); err != nil {
+			return err
+		}
+		if migrationFinished(ns, obj) {
+			c.enqueueNamespaceMigration(ns)
+		}
+		return nil
+	}
+
+	return c.patchResource(ctx, logger, lclusterName, obj, gvr, annotationPatch, labelPatch, filteredFinalizers)
+}
+
+// filterSyncerFinalizers returns the finalizers of the object without the ones of the syncers of deleted sync targets.
+func (c *Controller) filterSyncerFinalizers(logger logr.Logger, obj *unstructured.Unstructured) []string {
 	filteredFinalizers := make([]string, 0, len(obj.GetFinalizers()))
 	for _, f := range obj.GetFinalizers() {
 		logger := logger.WithValues("finalizer", f)
@@ -106,13 +156,12 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 		logging.WithObject(logger, aCluster).V(5).Info("keeping finalizer because of SyncTarget")
 		filteredFinalizers = append(filteredFinalizers, f)
 	}
+	return filteredFinalizers
+}
 
-	// create patch
-	if len(labelPatch) == 0 && len(annotationPatch) == 0 && len(filteredFinalizers) == len(obj.GetFinalizers()) {
-		logger.V(4).Info("nothing to change for resource")
-		return nil
-	}
-
+// patchResource patches the labels, annotations and finalizers of the object.
+func (c *Controller) patchResource(ctx context.Context, logger logr.Logger, lclusterName logicalcluster.Name, obj *unstructured.Unstructured, gvr *schema.GroupVersionResource,
+	annotationPatch, labelPatch map[string]interface{}, filteredFinalizers []string) error {
 	patch := map[string]interface{}{
 		"metadata": map[string]interface{}{
 			"UID":             obj.GetUID(),
@@ -143,7 +192,7 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 	}
 
 	logger.WithValues("patch", string(patchBytes)).V(2).Info("patching resource")
-	if _, err := c.dynClusterClient.Resource(*gvr).Namespace(ns.Name).
+	if _, err := c.dynClusterClient.Resource(*gvr).Namespace(obj.GetNamespace()).
 		Patch(logicalcluster.WithCluster(ctx, lclusterName), obj.GetName(), types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
 		return err
 	}
@@ -168,7 +217,8 @@ func propagateDeletionTimestamp(logger logr.Logger, obj metav1.Object) map[strin
 func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch map[string]interface{}, labelPatch map[string]interface{}) {
 	nsLocations, nsDeleting := locations(ns.Annotations, ns.Labels, true)
 	objLocations, objDeleting := locations(obj.GetAnnotations(), obj.GetLabels(), false)
-	if objLocations.Equal(nsLocations) && objDeleting.Equal(nsDeleting) {
+	selectedLocations := selectLocations(ns, nsLocations, obj)
+	if objLocations.Equal(selectedLocations) && objDeleting.Equal(nsDeleting.Intersection(selectedLocations)) {
 		// already correctly assigned.
 		return
 	}
@@ -177,8 +227,8 @@ func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch
 	annotationPatch = map[string]interface{}{}
 	labelPatch = map[string]interface{}{}
 
-	// unschedule objects on locations where the namespace is not scheduled
-	for _, loc := range objLocations.Difference(nsLocations).List() {
+	// unschedule objects on locations where the namespace is not scheduled, or which are not selected for the object
+	for _, loc := range objLocations.Difference(selectedLocations).List() {
 		// That's an inconsistent state, probably due to the namespace deletion reaching its grace period => let's repair it
 		var hasSyncerFinalizer, hasClusterFinalizer bool
 		// Check if there's still the syncer or the cluster finalizer.
@@ -198,12 +248,15 @@ func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch
 			if _, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]; found {
 				annotationPatch[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc] = nil
 				labelPatch[workloadv1alpha1.ClusterResourceStateLabelPrefix+loc] = nil
+			} else if nsLocations.Has(loc) {
+				// not selected for the object anymore before the syncer picked it up.
+				labelPatch[workloadv1alpha1.ClusterResourceStateLabelPrefix+loc] = nil
 			}
 		}
 	}
 
 	// sync deletion timestamps if both namespace and object are scheduled
-	for _, loc := range nsLocations.Intersection(objLocations).List() {
+	for _, loc := range selectedLocations.Intersection(objLocations).List() {
 		if nsTimestamp, found := ns.Annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]; found && validRFC3339(nsTimestamp) {
 			objTimestamp, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]
 			if !found || !validRFC3339(objTimestamp) {
@@ -213,7 +266,7 @@ func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch
 	}
 
 	// set label on unscheduled objects if namespace is scheduled and not deleting
-	for _, loc := range nsLocations.Difference(objLocations).List() {
+	for _, loc := range selectedLocations.Difference(objLocations).List() {
 		if nsDeleting.Has(loc) {
 			continue
 		}
@@ -231,6 +284,20 @@ func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch
 	return
 }
 
+// selectLocations returns the locations of the namespace the object is synced to. Objects with the
+// workload.kcp.dev/sync-exclude annotation are not synced at all, objects with the workload.kcp.dev/sync-targets
+// annotation only to the pinned sync targets, and all other objects to the sync targets of the namespace,
+// except those the namespace is only scheduled to for pinned objects.
+func selectLocations(ns *corev1.Namespace, nsLocations sets.String, obj metav1.Object) sets.String {
+	if obj.GetAnnotations()[workloadv1alpha1.SyncExcludeAnnotationKey] == "true" {
+		return sets.NewString()
+	}
+	if value, found := obj.GetAnnotations()[workloadv1alpha1.SyncTargetsAnnotationKey]; found {
+		return nsLocations.Intersection(sets.NewString(splitList(value)...))
+	}
+	return nsLocations.Difference(sets.NewString(splitList(ns.Annotations[workloadv1alpha1.InternalPinnedOnlySyncTargetsAnnotationKey])...))
+}
+
 func (c *Controller) reconcileGVR(gvr schema.GroupVersionResource) error {
 	inf, err := c.ddsif.ForResource(gvr)
 	if err != nil {
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	syncershared "github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// reconcileClusterScopedResource schedules a cluster-scoped resource to the sync targets the namespaces of its
+// workspace are scheduled to, if the resource is in their allow-list of cluster-scoped resources.
+func (c *Controller) reconcileClusterScopedResource(ctx context.Context, logger logr.Logger, lclusterName logicalcluster.Name, obj *unstructured.Unstructured, gvr *schema.GroupVersionResource) error {
+	var annotationPatch, labelPatch map[string]interface{}
+	if obj.GetDeletionTimestamp() != nil {
+		annotationPatch = propagateDeletionTimestamp(logger, obj)
+	} else {
+		workspaceLocations, err := c.clusterScopedLocations(lclusterName, gvr.GroupResource())
+		if err != nil {
+			return err
+		}
+		annotationPatch, labelPatch = computeClusterScopedPlacement(workspaceLocations, obj)
+
+		// Only one syncer writes the aggregated status of resources synced to multiple sync targets.
+		if writerPatch := computeStatusWriter(obj, c.syncTargetReady); writerPatch != nil {
+			if annotationPatch == nil {
+				annotationPatch = map[string]interface{}{}
+			}
+			for k, v := range writerPatch {
+				annotationPatch[k] = v
+			}
+		}
+	}
+
+	filteredFinalizers := c.filterSyncerFinalizers(logger, obj)
+	if len(labelPatch) == 0 && len(annotationPatch) == 0 && len(filteredFinalizers) == len(obj.GetFinalizers()) {
+		logger.V(4).Info("nothing to change for resource")
+		return nil
+	}
+
+	return c.patchResource(ctx, logger, lclusterName, obj, gvr, annotationPatch, labelPatch, filteredFinalizers)
+}
+
+// clusterScopedLocations returns the keys of the sync targets the cluster-scoped resource of the given workspace is
+// synced to: the sync targets any namespace of the workspace is scheduled to, which allow the resource.
+func (c *Controller) clusterScopedLocations(clusterName logicalcluster.Name, gr schema.GroupResource) (sets.String, error) {
+	namespaces, err := indexers.ByIndex[*corev1.Namespace](c.namespaceIndexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	scheduled := sets.NewString()
+	for _, ns := range namespaces {
+		if namespaceBlocklist.Has(ns.Name) {
+			continue
+		}
+		nsLocations, nsDeleting := locations(ns.Annotations, ns.Labels, true)
+		scheduled.Insert(nsLocations.Difference(nsDeleting).UnsortedList()...)
+	}
+
+	allowed := sets.NewString()
+	for _, syncTargetKey := range scheduled.UnsortedList() {
+		syncTargets, err := indexers.ByIndex[*workloadv1alpha1.SyncTarget](c.syncTargetIndexer, indexers.SyncTargetsBySyncTargetKey, syncTargetKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(syncTargets) == 1 && clusterScopedResourceAllowed(syncTargets[0], gr) {
+			allowed.Insert(syncTargetKey)
+		}
+	}
+	return allowed, nil
+}
+
+// clusterScopedResourceAllowed returns true if the cluster-scoped resource is in the allow-list of the SyncTarget.
+func clusterScopedResourceAllowed(syncTarget *workloadv1alpha1.SyncTarget, gr schema.GroupResource) bool {
+	for _, allowed := range syncTarget.Spec.ClusterScopedResources {
+		if allowed.Group == gr.Group && allowed.Resource == gr.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+// computeClusterScopedPlacement computes the patch against the annotations and labels of a cluster-scoped object,
+// synced to the given sync targets of its workspace. Nil means to remove the key. Unlike namespaced objects, whose
+// removal from a sync target starts with the namespace, the object is removed from the sync targets it is not
+// synced to anymore by setting the deletion timestamp of the sync target, until its syncer removed its finalizer.
+func computeClusterScopedPlacement(workspaceLocations sets.String, obj metav1.Object) (annotationPatch map[string]interface{}, labelPatch map[string]interface{}) {
+	objLocations, objDeleting := locations(obj.GetAnnotations(), obj.GetLabels(), false)
+	selectedLocations := selectClusterScopedLocations(workspaceLocations, obj)
+
+	annotationPatch = map[string]interface{}{}
+	labelPatch = map[string]interface{}{}
+
+	hasFinalizers := func(loc string) bool {
+		for _, finalizer := range obj.GetFinalizers() {
+			if finalizer == syncershared.SyncerFinalizerNamePrefix+loc {
+				return true
+			}
+		}
+		return obj.GetAnnotations()[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+loc] != ""
+	}
+
+	// unschedule objects on sync targets they are not synced to anymore
+	for _, loc := range objLocations.Difference(selectedLocations).List() {
+		if hasFinalizers(loc) {
+			if !objDeleting.Has(loc) {
+				annotationPatch[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc] = time.Now().Format(time.RFC3339)
+			}
+			continue
+		}
+		if objDeleting.Has(loc) {
+			annotationPatch[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc] = nil
+		}
+		labelPatch[workloadv1alpha1.ClusterResourceStateLabelPrefix+loc] = nil
+	}
+
+	// sync objects again that were selected again after their syncer removed them
+	for _, loc := range selectedLocations.Intersection(objLocations).Intersection(objDeleting).List() {
+		if !hasFinalizers(loc) {
+			annotationPatch[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc] = nil
+		}
+	}
+
+	// set label on unscheduled objects
+	for _, loc := range selectedLocations.Difference(objLocations).List() {
+		labelPatch[workloadv1alpha1.ClusterResourceStateLabelPrefix+loc] = string(workloadv1alpha1.ResourceStateSync)
+	}
+
+	if len(annotationPatch) == 0 {
+		annotationPatch = nil
+	}
+	if len(labelPatch) == 0 {
+		labelPatch = nil
+	}
+	return
+}
+
+// selectClusterScopedLocations returns the sync targets of the workspace the cluster-scoped object is synced to,
+// honouring the workload.kcp.dev/sync-exclude and workload.kcp.dev/sync-targets annotations like for namespaced
+// objects.
+func selectClusterScopedLocations(workspaceLocations sets.String, obj metav1.Object) sets.String {
+	if obj.GetAnnotations()[workloadv1alpha1.SyncExcludeAnnotationKey] == "true" {
+		return sets.NewString()
+	}
+	if value, found := obj.GetAnnotations()[workloadv1alpha1.SyncTargetsAnnotationKey]; found {
+		return workspaceLocations.Intersection(sets.NewString(splitList(value)...))
+	}
+	return workspaceLocations
+}
+
+// enqueueClusterScopedResources queues the cluster-scoped objects of the given resources in the workspace, or in
+// all workspaces if clusterName is empty. Their sync targets depend on the scheduling of the namespaces of their
+// workspace and on the allow-lists of the SyncTargets.
+func (c *Controller) enqueueClusterScopedResources(clusterName logicalcluster.Name, grs sets.String) {
+	logger := logging.WithReconciler(klog.Background(), controllerName).WithValues("operation", "enqueueClusterScopedResources", "logicalCluster", clusterName.String())
+	if grs.Len() == 0 {
+		return
+	}
+
+	listers, _ := c.ddsif.Listers()
+	queued := map[string]int{}
+	for gvr := range listers {
+		if !grs.Has(gvr.GroupResource().String()) {
+			continue
+		}
+		inf, err := c.ddsif.ForResource(gvr)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		var objs []interface{}
+		if clusterName.Empty() {
+			objs = inf.Informer().GetIndexer().List()
+		} else if objs, err = inf.Informer().GetIndexer().ByIndex(indexers.ByLogicalCluster, clusterName.String()); err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		for _, obj := range objs {
+			if u, ok := obj.(*unstructured.Unstructured); !ok || u.GetNamespace() != "" {
+				continue
+			}
+			c.enqueueResource(gvr, obj)
+			queued[gvr.String()]++
+		}
+	}
+	if len(queued) > 0 {
+		logger.WithValues("resources", queued).V(2).Info("queued cluster-scoped resources")
+	}
+}
+
+// allowedClusterScopedResources returns the cluster-scoped resources in the allow-list of any SyncTarget.
+func (c *Controller) allowedClusterScopedResources() sets.String {
+	grs := sets.NewString()
+	for _, obj := range c.syncTargetIndexer.List() {
+		if syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget); ok {
+			grs.Insert(clusterScopedResourcesOf(syncTarget).UnsortedList()...)
+		}
+	}
+	return grs
+}
+
+// clusterScopedResourcesOf returns the allow-list of cluster-scoped resources of the SyncTarget.
+func clusterScopedResourcesOf(syncTarget *workloadv1alpha1.SyncTarget) sets.String {
+	grs := sets.NewString()
+	for _, gr := range syncTarget.Spec.ClusterScopedResources {
+		grs.Insert(schema.GroupResource{Group: gr.Group, Resource: gr.Resource}.String())
+	}
+	return grs
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+	"github.com/kcp-dev/kcp/pkg/syncer/spec"
+)
+
+var priorityClassesGVR = schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+
+func TestComputeClusterScopedPlacement(t *testing.T) {
+	tests := []struct {
+		name               string
+		workspaceLocations sets.String
+		annotations        map[string]string
+		labels             map[string]string
+		finalizers         []string
+		wantAnnotations    map[string]interface{}
+		wantLabels         map[string]interface{}
+	}{
+		{name: "synced to the sync targets of the workspace",
+			workspaceLocations: sets.NewString("cluster-1", "cluster-2"),
+			wantLabels:         map[string]interface{}{"state.workload.kcp.dev/cluster-1": "Sync", "state.workload.kcp.dev/cluster-2": "Sync"},
+		},
+		{name: "already synced",
+			workspaceLocations: sets.NewString("cluster-1"),
+			labels:             map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+		},
+		{name: "excluded",
+			workspaceLocations: sets.NewString("cluster-1"),
+			annotations:        map[string]string{"workload.kcp.dev/sync-exclude": "true"},
+		},
+		{name: "pinned",
+			workspaceLocations: sets.NewString("cluster-1", "cluster-2"),
+			annotations:        map[string]string{"workload.kcp.dev/sync-targets": "cluster-2"},
+			wantLabels:         map[string]interface{}{"state.workload.kcp.dev/cluster-2": "Sync"},
+		},
+		{name: "not picked up by the syncer yet",
+			labels:     map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			wantLabels: map[string]interface{}{"state.workload.kcp.dev/cluster-1": nil},
+		},
+		{name: "removed from the syncer",
+			labels:          map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			finalizers:      []string{"workload.kcp.dev/syncer-cluster-1"},
+			wantAnnotations: map[string]interface{}{"deletion.internal.workload.kcp.dev/cluster-1": "<timestamp>"},
+		},
+		{name: "being removed from the syncer",
+			annotations: map[string]string{"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00"},
+			labels:      map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			finalizers:  []string{"workload.kcp.dev/syncer-cluster-1"},
+		},
+		{name: "removed by the syncer",
+			annotations:     map[string]string{"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00"},
+			labels:          map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			wantAnnotations: map[string]interface{}{"deletion.internal.workload.kcp.dev/cluster-1": nil},
+			wantLabels:      map[string]interface{}{"state.workload.kcp.dev/cluster-1": nil},
+		},
+		{name: "selected again after the syncer removed it",
+			workspaceLocations: sets.NewString("cluster-1"),
+			annotations:        map[string]string{"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00"},
+			labels:             map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			wantAnnotations:    map[string]interface{}{"deletion.internal.workload.kcp.dev/cluster-1": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.workspaceLocations == nil {
+				tt.workspaceLocations = sets.NewString()
+			}
+			obj := &metav1.ObjectMeta{Annotations: tt.annotations, Labels: tt.labels, Finalizers: tt.finalizers}
+			gotAnnotations, gotLabels := computeClusterScopedPlacement(tt.workspaceLocations, obj)
+			for k, v := range gotAnnotations {
+				if v != nil && tt.wantAnnotations[k] == "<timestamp>" {
+					gotAnnotations[k] = "<timestamp>"
+				}
+			}
+			require.Equal(t, tt.wantAnnotations, gotAnnotations, "unexpected annotations patch")
+			require.Equal(t, tt.wantLabels, gotLabels, "unexpected labels patch")
+		})
+	}
+}
+
+// TestClusterScopedResourceSyncedBySyncer checks that the labels set by the reconciler make the upstream informer
+// of the syncer, filtered on the state label of the SyncTarget, see a cluster-scoped object, which the spec syncer
+// then syncs downstream.
+func TestClusterScopedResourceSyncedBySyncer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workspace := logicalcluster.New("root:org:ws")
+	syncTargetWorkspace := logicalcluster.New("root:org:compute")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, "us-west1")
+
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "us-west1",
+			UID:         "syncTargetUID",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: syncTargetWorkspace.String()},
+		},
+		Spec: workloadv1alpha1.SyncTargetSpec{
+			ClusterScopedResources: []apisv1alpha1.GroupResource{{Group: "scheduling.k8s.io", Resource: "priorityclasses"}},
+		},
+	}
+	syncTargetIndexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{indexers.SyncTargetsBySyncTargetKey: indexers.IndexSyncTargetsBySyncTargetKey})
+	require.NoError(t, syncTargetIndexer.Add(syncTarget))
+
+	namespaceIndexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{indexers.ByLogicalCluster: indexers.IndexByLogicalCluster})
+	require.NoError(t, namespaceIndexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "default",
+		Annotations: map[string]string{logicalcluster.AnnotationKey: workspace.String()},
+		Labels:      map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: string(workloadv1alpha1.ResourceStateSync)},
+	}}))
+
+	upstreamPriorityClass := &unstructured.Unstructured{}
+	upstreamPriorityClass.SetAPIVersion("scheduling.k8s.io/v1")
+	upstreamPriorityClass.SetKind("PriorityClass")
+	upstreamPriorityClass.SetName("high-priority")
+	upstreamPriorityClass.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: workspace.String()})
+
+	listKinds := map[schema.GroupVersionResource]string{
+		priorityClassesGVR:                      "PriorityClassList",
+		{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+		{Version: "v1", Resource: "secrets"}:    "SecretList",
+	}
+	upstreamClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, upstreamPriorityClass)
+	downstreamClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	applied := make(chan string, 10)
+	downstreamClient.PrependReactor("patch", "priorityclasses", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(clienttesting.PatchAction)
+		if patchAction.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		applied <- patchAction.GetName()
+		return true, nil, nil
+	})
+
+	// the reconciler labels the cluster-scoped object for the SyncTarget the namespaces of the workspace are scheduled to.
+	c := &Controller{
+		dynClusterClient:  upstreamClient,
+		namespaceIndexer:  namespaceIndexer,
+		syncTargetIndexer: syncTargetIndexer,
+	}
+	gvr := priorityClassesGVR
+	require.NoError(t, c.reconcileResource(ctx, workspace, upstreamPriorityClass.DeepCopy(), &gvr))
+
+	// the syncer only sees objects labelled for its SyncTarget.
+	upstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(upstreamClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
+	})
+	downstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(downstreamClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
+	})
+	syncerInformers := &clusterScopedSyncerInformers{
+		informer: &resourcesync.SyncerInformer{
+			UpstreamInformer:   upstreamInformers.ForResource(priorityClassesGVR),
+			DownstreamInformer: downstreamInformers.ForResource(priorityClassesGVR),
+		},
+	}
+	upstreamURL, err := url.Parse("https://kcp.dev:6443")
+	require.NoError(t, err)
+	specSyncer, err := spec.NewSpecSyncer(syncTargetWorkspace, syncTarget.Name, syncTargetKey, upstreamURL, false,
+		singleClusterDynamicClient{upstreamClient}, downstreamClient, upstreamInformers, downstreamInformers, syncerInformers, syncTarget.UID,
+		workloadlisters.NewSyncTargetLister(syncTargetIndexer), 0)
+	require.NoError(t, err)
+
+	upstreamInformers.Start(ctx.Done())
+	downstreamInformers.Start(ctx.Done())
+	upstreamInformers.WaitForCacheSync(ctx.Done())
+	downstreamInformers.WaitForCacheSync(ctx.Done())
+	go specSyncer.Start(ctx, 1)
+
+	select {
+	case name := <-applied:
+		require.Equal(t, shared.PhysicalClusterScopedName(workspace, "high-priority"), name)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("cluster-scoped object was not synced downstream")
+	}
+
+	got, err := upstreamClient.Resource(priorityClassesGVR).Get(ctx, "high-priority", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, got.GetFinalizers(), shared.SyncerFinalizerNamePrefix+syncTargetKey)
+}
+
+// clusterScopedSyncerInformers serves the informers of a single allowed cluster-scoped resource.
+type clusterScopedSyncerInformers struct {
+	informer *resourcesync.SyncerInformer
+}
+
+func (f *clusterScopedSyncerInformers) AddUpstreamEventHandler(handler resourcesync.ResourceEventHandlerPerGVR) {
+	f.informer.UpstreamInformer.Informer().AddEventHandler(handler(priorityClassesGVR))
+}
+func (f *clusterScopedSyncerInformers) AddDownstreamEventHandler(handler resourcesync.ResourceEventHandlerPerGVR) {
+	f.informer.DownstreamInformer.Informer().AddEventHandler(handler(priorityClassesGVR))
+}
+func (f *clusterScopedSyncerInformers) InformerForResource(gvr schema.GroupVersionResource) (*resourcesync.SyncerInformer, bool) {
+	return f.informer, gvr == priorityClassesGVR
+}
+func (f *clusterScopedSyncerInformers) ClusterScopedResourceAllowed(gr schema.GroupResource) bool {
+	return gr == priorityClassesGVR.GroupResource()
+}
+func (f *clusterScopedSyncerInformers) ResourceHealth() []workloadv1alpha1.ResourceHealth {
+	return nil
+}
+func (f *clusterScopedSyncerInformers) Start(ctx context.Context, numThreads int) {}
+
+type singleClusterDynamicClient struct {
+	dynamic.Interface
+}
+
+func (c singleClusterDynamicClient) Cluster(logicalcluster.Name) dynamic.Interface {
+	return c.Interface
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+var (
+	statefulSetsGR           = schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+	deploymentsGR            = schema.GroupResource{Group: "apps", Resource: "deployments"}
+	persistentVolumeClaimsGR = schema.GroupResource{Resource: "persistentvolumeclaims"}
+)
+
+// isStateful returns true for resources whose data must be migrated when they are moved to another
+// sync target: StatefulSets, PersistentVolumeClaims and Deployments mounting PersistentVolumeClaims.
+func isStateful(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) bool {
+	switch gvr.GroupResource() {
+	case statefulSetsGR, persistentVolumeClaimsGR:
+		return true
+	case deploymentsGR:
+		volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "volumes")
+		for _, volume := range volumes {
+			if v, ok := volume.(map[string]interface{}); ok && v["persistentVolumeClaim"] != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// computeMigration computes the annotation patch starting and finishing the migration of a stateful resource
+// away from the sync targets it is removed from. placementPatch is the annotation patch computed by
+// computePlacement for the object.
+//
+// A migration starts together with the removal from a sync target, if the namespace is migrated to newly
+// scheduled sync targets. The migration finalizer, and the finalizers of the migration hooks, then hold the
+// deletion of the resource on the old sync target. The migration finalizer is removed when the new sync targets
+// report the resource as ready, or when the namespace is not migrated anymore.
+func computeMigration(ns *corev1.Namespace, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, placementPatch map[string]interface{}) map[string]interface{} {
+	if !isStateful(gvr, obj) {
+		return nil
+	}
+	annotations := obj.GetAnnotations()
+	patch := map[string]interface{}{}
+
+	for key, value := range placementPatch {
+		if !strings.HasPrefix(key, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix) || value == nil {
+			continue
+		}
+		syncTarget := strings.TrimPrefix(key, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix)
+		if _, found := annotations[key]; found {
+			continue
+		}
+		destinations, found := ns.Annotations[workloadv1alpha1.MigrationAnnotationPrefix+syncTarget]
+		if !found {
+			continue
+		}
+		finalizers := splitList(annotations[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+syncTarget])
+		for _, finalizer := range append([]string{workloadv1alpha1.MigrationFinalizerName}, splitList(annotations[workloadv1alpha1.MigrationHooksAnnotationKey])...) {
+			if !contains(finalizers, finalizer) {
+				finalizers = append(finalizers, finalizer)
+			}
+		}
+		patch[workloadv1alpha1.MigrationAnnotationPrefix+syncTarget] = destinations
+		patch[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+syncTarget] = strings.Join(finalizers, ",")
+	}
+
+	for key, destinations := range annotations {
+		if !strings.HasPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix) {
+			continue
+		}
+		syncTarget := strings.TrimPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix)
+		if _, found := ns.Annotations[key]; found && !migrated(gvr, obj, splitList(destinations)) {
+			continue
+		}
+		patch[key] = nil
+		var finalizers []string
+		for _, finalizer := range splitList(annotations[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+syncTarget]) {
+			if finalizer != workloadv1alpha1.MigrationFinalizerName {
+				finalizers = append(finalizers, finalizer)
+			}
+		}
+		if len(finalizers) == 0 {
+			patch[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+syncTarget] = nil
+		} else {
+			patch[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+syncTarget] = strings.Join(finalizers, ",")
+		}
+	}
+
+	if len(patch) == 0 {
+		return nil
+	}
+	return patch
+}
+
+// migrated returns true if all the given sync targets report the resource as ready in their status annotation:
+// bound for PersistentVolumeClaims, and all replicas ready otherwise.
+func migrated(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, syncTargets []string) bool {
+	desiredReplicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return false
+	}
+	if !found {
+		desiredReplicas = 1
+	}
+
+	for _, syncTarget := range syncTargets {
+		value, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+syncTarget]
+		if !found {
+			return false
+		}
+		var status struct {
+			Phase         string `json:"phase"`
+			ReadyReplicas int64  `json:"readyReplicas"`
+		}
+		if err := json.Unmarshal([]byte(value), &status); err != nil {
+			return false
+		}
+		if gvr.GroupResource() == persistentVolumeClaimsGR {
+			if status.Phase != string(corev1.ClaimBound) {
+				return false
+			}
+		} else if status.ReadyReplicas < desiredReplicas {
+			return false
+		}
+	}
+	return true
+}
+
+// migrationPending returns true if any of the objects, scheduled to the sync target, is not removed from it yet,
+// or is still migrated away from it.
+func migrationPending(syncTarget string, objs []*unstructured.Unstructured) bool {
+	for _, obj := range objs {
+		if _, found := obj.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTarget]; !found {
+			continue
+		}
+		if _, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTarget]; !found {
+			return true
+		}
+		if _, found := obj.GetAnnotations()[workloadv1alpha1.MigrationAnnotationPrefix+syncTarget]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// isMigrating returns true if the namespace is migrated away from any sync target.
+func isMigrating(ns *corev1.Namespace) bool {
+	for key := range ns.Annotations {
+		if strings.HasPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationFinished returns true if the namespace is migrated away from a sync target, and the object is not
+// pending for it anymore, i.e. the migration of the namespace might be finished.
+func migrationFinished(ns *corev1.Namespace, obj *unstructured.Unstructured) bool {
+	for key := range ns.Annotations {
+		if !strings.HasPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix) {
+			continue
+		}
+		if !migrationPending(strings.TrimPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix), []*unstructured.Unstructured{obj}) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileNamespaceMigration removes the migration annotations from the namespace when no resource in the
+// namespace is migrated away from the sync target anymore, which releases the removal of the sync target.
+func (c *Controller) reconcileNamespaceMigration(ctx context.Context, ns *corev1.Namespace) error {
+	var syncTargets []string
+	for key := range ns.Annotations {
+		if strings.HasPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix) {
+			syncTargets = append(syncTargets, strings.TrimPrefix(key, workloadv1alpha1.MigrationAnnotationPrefix))
+		}
+	}
+	if len(syncTargets) == 0 {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(ns)
+	listers, notSynced := c.ddsif.Listers()
+	if len(notSynced) > 0 {
+		return fmt.Errorf("informers for %v are not synced; re-enqueueing", notSynced)
+	}
+	var objs []*unstructured.Unstructured
+	for _, lister := range listers {
+		items, err := lister.ByNamespace(ns.Name).List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			u := item.(*unstructured.Unstructured)
+			if logicalcluster.From(u) == clusterName {
+				objs = append(objs, u)
+			}
+		}
+	}
+
+	annotationPatch := map[string]interface{}{}
+	for _, syncTarget := range syncTargets {
+		if !migrationPending(syncTarget, objs) {
+			annotationPatch[workloadv1alpha1.MigrationAnnotationPrefix+syncTarget] = nil
+		}
+	}
+	if len(annotationPatch) == 0 {
+		return nil
+	}
+
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotationPatch,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	logger := logging.WithObject(klog.FromContext(ctx), ns)
+	logger.WithValues("patch", string(patchBytes)).V(2).Info("finishing migration of Namespace")
+	if _, err := c.dynClusterClient.Resource(corev1.SchemeGroupVersion.WithResource("namespaces")).
+		Patch(logicalcluster.WithCluster(ctx, clusterName), ns.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("error finishing migration of namespace %s|%s: %w", clusterName, ns.Name, err)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
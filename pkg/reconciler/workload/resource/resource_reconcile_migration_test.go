@@ -0,0 +1,254 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	statefulSetsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	deploymentsGVR  = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	pvcsGVR         = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+)
+
+func unstructuredObject(annotations, labels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetName("foo")
+	u.SetAnnotations(annotations)
+	u.SetLabels(labels)
+	if spec != nil {
+		u.Object["spec"] = spec
+	}
+	return u
+}
+
+func TestComputeMigration(t *testing.T) {
+	migratingNamespace := namespace(map[string]string{
+		"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+		"migration.workload.kcp.dev/cluster-1":         "cluster-2",
+	}, map[string]string{
+		"state.workload.kcp.dev/cluster-1": "Sync",
+		"state.workload.kcp.dev/cluster-2": "Sync",
+	})
+	removalPatch := map[string]interface{}{
+		"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+	}
+
+	tests := []struct {
+		name           string
+		gvr            schema.GroupVersionResource
+		obj            *unstructured.Unstructured
+		placementPatch map[string]interface{}
+		wantPatch      map[string]interface{} // nil means delete
+	}{
+		{name: "stateless deployment is not migrated",
+			gvr:            deploymentsGVR,
+			obj:            unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, map[string]interface{}{}),
+			placementPatch: removalPatch,
+		},
+		{name: "statefulset removal starts migration",
+			gvr: statefulSetsGVR,
+			obj: unstructuredObject(map[string]string{
+				"workload.kcp.dev/migration-hooks": "example.com/data-mover",
+			}, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+			placementPatch: removalPatch,
+			wantPatch: map[string]interface{}{
+				"migration.workload.kcp.dev/cluster-1":  "cluster-2",
+				"finalizers.workload.kcp.dev/cluster-1": "workload.kcp.dev/migration,example.com/data-mover",
+			},
+		},
+		{name: "deployment with persistent volume claim removal starts migration, existing cluster finalizers are kept",
+			gvr: deploymentsGVR,
+			obj: unstructuredObject(map[string]string{
+				"finalizers.workload.kcp.dev/cluster-1": "example.com/other",
+			}, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"volumes": []interface{}{
+							map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "data"}},
+						},
+					},
+				},
+			}),
+			placementPatch: removalPatch,
+			wantPatch: map[string]interface{}{
+				"migration.workload.kcp.dev/cluster-1":  "cluster-2",
+				"finalizers.workload.kcp.dev/cluster-1": "example.com/other,workload.kcp.dev/migration",
+			},
+		},
+		{name: "removal without new sync targets does not start migration",
+			gvr: statefulSetsGVR,
+			obj: unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+			placementPatch: map[string]interface{}{
+				"deletion.internal.workload.kcp.dev/cluster-3": "2002-10-02T10:00:00-05:00",
+			},
+		},
+		{name: "migration waits for the new sync target to be ready",
+			gvr: statefulSetsGVR,
+			obj: unstructuredObject(map[string]string{
+				"deletion.internal.workload.kcp.dev/cluster-1":   "2002-10-02T10:00:00-05:00",
+				"migration.workload.kcp.dev/cluster-1":           "cluster-2",
+				"finalizers.workload.kcp.dev/cluster-1":          "workload.kcp.dev/migration",
+				"experimental.status.workload.kcp.dev/cluster-2": `{"replicas":3,"readyReplicas":2}`,
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Sync",
+			}, map[string]interface{}{"replicas": int64(3)}),
+		},
+		{name: "migration finishes when the new sync target is ready, hooks are kept",
+			gvr: statefulSetsGVR,
+			obj: unstructuredObject(map[string]string{
+				"deletion.internal.workload.kcp.dev/cluster-1":   "2002-10-02T10:00:00-05:00",
+				"migration.workload.kcp.dev/cluster-1":           "cluster-2",
+				"finalizers.workload.kcp.dev/cluster-1":          "workload.kcp.dev/migration,example.com/data-mover",
+				"experimental.status.workload.kcp.dev/cluster-2": `{"replicas":3,"readyReplicas":3}`,
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Sync",
+			}, map[string]interface{}{"replicas": int64(3)}),
+			wantPatch: map[string]interface{}{
+				"migration.workload.kcp.dev/cluster-1":  nil,
+				"finalizers.workload.kcp.dev/cluster-1": "example.com/data-mover",
+			},
+		},
+		{name: "persistent volume claim migration finishes when bound on the new sync target",
+			gvr: pvcsGVR,
+			obj: unstructuredObject(map[string]string{
+				"deletion.internal.workload.kcp.dev/cluster-1":   "2002-10-02T10:00:00-05:00",
+				"migration.workload.kcp.dev/cluster-1":           "cluster-2",
+				"finalizers.workload.kcp.dev/cluster-1":          "workload.kcp.dev/migration",
+				"experimental.status.workload.kcp.dev/cluster-2": `{"phase":"Bound"}`,
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Sync",
+			}, nil),
+			wantPatch: map[string]interface{}{
+				"migration.workload.kcp.dev/cluster-1":  nil,
+				"finalizers.workload.kcp.dev/cluster-1": nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPatch := computeMigration(migratingNamespace, tt.gvr, tt.obj, tt.placementPatch)
+			if diff := cmp.Diff(tt.wantPatch, gotPatch); diff != "" {
+				t.Errorf("incorrect annotation patch: %s", diff)
+			}
+		})
+	}
+
+	t.Run("migration is aborted when the namespace is not migrated anymore", func(t *testing.T) {
+		obj := unstructuredObject(map[string]string{
+			"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+			"migration.workload.kcp.dev/cluster-1":         "cluster-2",
+			"finalizers.workload.kcp.dev/cluster-1":        "workload.kcp.dev/migration",
+		}, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil)
+		gotPatch := computeMigration(namespace(nil, nil), statefulSetsGVR, obj, nil)
+		wantPatch := map[string]interface{}{
+			"migration.workload.kcp.dev/cluster-1":  nil,
+			"finalizers.workload.kcp.dev/cluster-1": nil,
+		}
+		if diff := cmp.Diff(wantPatch, gotPatch); diff != "" {
+			t.Errorf("incorrect annotation patch: %s", diff)
+		}
+	})
+}
+
+func TestMigrationPending(t *testing.T) {
+	tests := []struct {
+		name        string
+		objs        []*unstructured.Unstructured
+		wantPending bool
+	}{
+		{name: "no objects"},
+		{name: "object not scheduled to the sync target",
+			objs: []*unstructured.Unstructured{
+				unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-2": "Sync"}, nil),
+			},
+		},
+		{name: "object not removed yet",
+			objs: []*unstructured.Unstructured{
+				unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+			},
+			wantPending: true,
+		},
+		{name: "object being migrated",
+			objs: []*unstructured.Unstructured{
+				unstructuredObject(map[string]string{
+					"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+				}, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+				unstructuredObject(map[string]string{
+					"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+					"migration.workload.kcp.dev/cluster-1":         "cluster-2",
+				}, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+			},
+			wantPending: true,
+		},
+		{name: "all objects removed",
+			objs: []*unstructured.Unstructured{
+				unstructuredObject(map[string]string{
+					"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+				}, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := migrationPending("cluster-1", tt.objs); got != tt.wantPending {
+				t.Errorf("expected pending %t, got %t", tt.wantPending, got)
+			}
+		})
+	}
+}
+
+func TestMigrationFinished(t *testing.T) {
+	migrating := namespace(map[string]string{"migration.workload.kcp.dev/cluster-1": "cluster-2"}, nil)
+	tests := []struct {
+		name         string
+		ns           *corev1.Namespace
+		obj          *unstructured.Unstructured
+		wantFinished bool
+	}{
+		{name: "namespace not migrating",
+			ns:  namespace(nil, nil),
+			obj: unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-2": "Sync"}, nil),
+		},
+		{name: "object still on the sync target",
+			ns:  migrating,
+			obj: unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"}, nil),
+		},
+		{name: "object removed from the sync target",
+			ns:           migrating,
+			obj:          unstructuredObject(nil, map[string]string{"state.workload.kcp.dev/cluster-2": "Sync"}, nil),
+			wantFinished: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := migrationFinished(tt.ns, tt.obj); got != tt.wantFinished {
+				t.Errorf("expected finished %t, got %t", tt.wantFinished, got)
+			}
+		})
+	}
+}
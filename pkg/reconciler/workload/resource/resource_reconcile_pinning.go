@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+// pinnedSyncTargets returns the sorted keys of the sync targets the objects are pinned to. Deleted objects
+// are not taken into account.
+func pinnedSyncTargets(objs []*unstructured.Unstructured) []string {
+	pinned := sets.NewString()
+	for _, obj := range objs {
+		if obj.GetDeletionTimestamp() != nil || obj.GetAnnotations()[workloadv1alpha1.SyncExcludeAnnotationKey] == "true" {
+			continue
+		}
+		pinned.Insert(splitList(obj.GetAnnotations()[workloadv1alpha1.SyncTargetsAnnotationKey])...)
+	}
+	return pinned.List()
+}
+
+// reconcileNamespacePinning updates the internal.workload.kcp.dev/pinned-synctargets annotation of the namespace
+// to the sync targets resources of the namespace are pinned to, which schedules the namespace to them.
+func (c *Controller) reconcileNamespacePinning(ctx context.Context, ns *corev1.Namespace, obj *unstructured.Unstructured) error {
+	current, found := ns.Annotations[workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey]
+	if _, pinned := obj.GetAnnotations()[workloadv1alpha1.SyncTargetsAnnotationKey]; !found && !pinned {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(ns)
+	listers, notSynced := c.ddsif.Listers()
+	if len(notSynced) > 0 {
+		return fmt.Errorf("informers for %v are not synced; re-enqueueing", notSynced)
+	}
+	var objs []*unstructured.Unstructured
+	for gvr := range listers {
+		inf, err := c.ddsif.ForResource(gvr)
+		if err != nil {
+			return err
+		}
+		items, err := inf.Informer().GetIndexer().ByIndex(indexers.PinnedByLogicalClusterAndNamespace, clusters.ToClusterAwareKey(clusterName, ns.Name))
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			objs = append(objs, item.(*unstructured.Unstructured))
+		}
+	}
+
+	var value interface{}
+	if pinned := pinnedSyncTargets(objs); len(pinned) > 0 {
+		if found && current == strings.Join(pinned, ",") {
+			return nil
+		}
+		value = strings.Join(pinned, ",")
+	} else if !found {
+		return nil
+	}
+
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				workloadv1alpha1.InternalPinnedSyncTargetsAnnotationKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	logger := logging.WithObject(klog.FromContext(ctx), ns)
+	logger.WithValues("patch", string(patchBytes)).V(2).Info("updating pinned SyncTargets of Namespace")
+	if _, err := c.dynClusterClient.Resource(corev1.SchemeGroupVersion.WithResource("namespaces")).
+		Patch(logicalcluster.WithCluster(ctx, clusterName), ns.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("error updating pinned sync targets of namespace %s|%s: %w", clusterName, ns.Name, err)
+	}
+	return nil
+}
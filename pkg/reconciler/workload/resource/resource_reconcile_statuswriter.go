@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+// computeStatusWriter returns the annotation patch selecting the sync target whose syncer writes the aggregated
+// status of a resource synced to multiple sync targets, or nil if the annotation is up to date.
+func computeStatusWriter(obj metav1.Object, ready func(syncTargetKey string) bool) map[string]interface{} {
+	current, found := obj.GetAnnotations()[workloadv1alpha1.InternalStatusWriterAnnotationKey]
+	syncing, deleting := locations(obj.GetAnnotations(), obj.GetLabels(), true)
+	syncing = syncing.Difference(deleting)
+
+	if syncing.Len() < 2 {
+		if found {
+			return map[string]interface{}{workloadv1alpha1.InternalStatusWriterAnnotationKey: nil}
+		}
+		return nil
+	}
+
+	// sets.String.List is sorted, i.e. the first ready sync target has the lowest key.
+	writer := syncing.List()[0]
+	for _, key := range syncing.List() {
+		if ready(key) {
+			writer = key
+			break
+		}
+	}
+	if found && current == writer {
+		return nil
+	}
+	return map[string]interface{}{workloadv1alpha1.InternalStatusWriterAnnotationKey: writer}
+}
+
+// syncTargetReady returns whether the sync target with the given key exists and is ready.
+func (c *Controller) syncTargetReady(syncTargetKey string) bool {
+	syncTargets, err := indexers.ByIndex[*workloadv1alpha1.SyncTarget](c.syncTargetIndexer, indexers.SyncTargetsBySyncTargetKey, syncTargetKey)
+	if err != nil || len(syncTargets) == 0 {
+		return false
+	}
+	return conditions.IsTrue(syncTargets[0], conditionsv1alpha1.ReadyCondition)
+}
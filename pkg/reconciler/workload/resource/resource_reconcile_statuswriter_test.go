@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestComputeStatusWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		ready       sets.String
+		want        map[string]interface{}
+	}{
+		{name: "single sync target",
+			labels: map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			ready:  sets.NewString("cluster-1"),
+		},
+		{name: "single sync target, stale writer is removed",
+			annotations: map[string]string{"internal.workload.kcp.dev/status-writer": "cluster-2"},
+			labels:      map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"},
+			ready:       sets.NewString("cluster-1"),
+			want:        map[string]interface{}{"internal.workload.kcp.dev/status-writer": nil},
+		},
+		{name: "ready sync target with the lowest key",
+			labels: map[string]string{"state.workload.kcp.dev/cluster-1": "Sync", "state.workload.kcp.dev/cluster-2": "Sync"},
+			ready:  sets.NewString("cluster-1", "cluster-2"),
+			want:   map[string]interface{}{"internal.workload.kcp.dev/status-writer": "cluster-1"},
+		},
+		{name: "not ready sync targets are skipped",
+			annotations: map[string]string{"internal.workload.kcp.dev/status-writer": "cluster-1"},
+			labels:      map[string]string{"state.workload.kcp.dev/cluster-1": "Sync", "state.workload.kcp.dev/cluster-2": "Sync"},
+			ready:       sets.NewString("cluster-2"),
+			want:        map[string]interface{}{"internal.workload.kcp.dev/status-writer": "cluster-2"},
+		},
+		{name: "lowest key if none is ready",
+			labels: map[string]string{"state.workload.kcp.dev/cluster-1": "Sync", "state.workload.kcp.dev/cluster-2": "Sync"},
+			want:   map[string]interface{}{"internal.workload.kcp.dev/status-writer": "cluster-1"},
+		},
+		{name: "removing and pending sync targets are skipped",
+			annotations: map[string]string{"deletion.internal.workload.kcp.dev/cluster-1": "2022-09-01T12:00:00Z"},
+			labels: map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Sync",
+				"state.workload.kcp.dev/cluster-3": "Sync",
+				"state.workload.kcp.dev/cluster-4": "",
+			},
+			ready: sets.NewString("cluster-1", "cluster-3", "cluster-4"),
+			want:  map[string]interface{}{"internal.workload.kcp.dev/status-writer": "cluster-3"},
+		},
+		{name: "up to date",
+			annotations: map[string]string{"internal.workload.kcp.dev/status-writer": "cluster-2"},
+			labels:      map[string]string{"state.workload.kcp.dev/cluster-1": "Sync", "state.workload.kcp.dev/cluster-2": "Sync"},
+			ready:       sets.NewString("cluster-2"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := object(tt.annotations, tt.labels, nil, nil)
+			require.Equal(t, tt.want, computeStatusWriter(obj, tt.ready.Has))
+		})
+	}
+}
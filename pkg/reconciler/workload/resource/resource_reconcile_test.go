@@ -24,6 +24,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
 
@@ -234,6 +235,102 @@ func TestComputePlacement(t *testing.T) {
 				"deletion.internal.workload.kcp.dev/cluster-4": "2002-10-02T10:00:00-05:00",
 			},
 		},
+		{name: "excluded object, syncing namespace",
+			ns: namespace(nil, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-exclude": "true",
+			}, nil, nil, nil),
+		},
+		{name: "not excluded object, syncing namespace",
+			ns: namespace(nil, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-exclude": "false",
+			}, nil, nil, nil),
+			wantLabelPatch: map[string]interface{}{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			},
+		},
+		{name: "excluded object not picked up by the syncer yet, remove the label",
+			ns: namespace(nil, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-exclude": "true",
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}, nil, nil),
+			wantLabelPatch: map[string]interface{}{
+				"state.workload.kcp.dev/cluster-1": nil,
+			},
+		},
+		{name: "excluded object removed by the syncer, hard delete",
+			ns: namespace(nil, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-exclude":                "true",
+				"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}, nil, nil),
+			wantLabelPatch: map[string]interface{}{
+				"state.workload.kcp.dev/cluster-1": nil,
+			},
+			wantAnnotationPatch: map[string]interface{}{
+				"deletion.internal.workload.kcp.dev/cluster-1": nil,
+			},
+		},
+		{name: "excluded object still synced, wait for the syncer",
+			ns: namespace(nil, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-exclude":                "true",
+				"deletion.internal.workload.kcp.dev/cluster-1": "2002-10-02T10:00:00-05:00",
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}, []string{
+				"workload.kcp.dev/syncer-cluster-1",
+			}, nil),
+		},
+		{name: "pinned object is only scheduled to the pinned location",
+			ns: namespace(map[string]string{
+				"internal.workload.kcp.dev/pinned-only-synctargets": "cluster-2",
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-targets": "cluster-2",
+			}, nil, nil, nil),
+			wantLabelPatch: map[string]interface{}{
+				"state.workload.kcp.dev/cluster-2": "Sync",
+			},
+		},
+		{name: "pinned object waits for the namespace to be scheduled to the pinned location",
+			ns: namespace(nil, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			}),
+			obj: object(map[string]string{
+				"workload.kcp.dev/sync-targets": "cluster-2",
+			}, nil, nil, nil),
+		},
+		{name: "other objects are not scheduled to locations only pinned objects are synced to",
+			ns: namespace(map[string]string{
+				"internal.workload.kcp.dev/pinned-only-synctargets": "cluster-2",
+			}, map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Sync",
+			}),
+			obj: object(nil, nil, nil, nil),
+			wantLabelPatch: map[string]interface{}{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -311,3 +408,24 @@ func TestPropagateDeletionTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestPinnedSyncTargets(t *testing.T) {
+	pinned := func(value string, deleted bool) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAnnotations(map[string]string{"workload.kcp.dev/sync-targets": value})
+		if deleted {
+			u.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		}
+		return u
+	}
+
+	got := pinnedSyncTargets([]*unstructured.Unstructured{
+		pinned("cluster-2, cluster-1", false),
+		pinned("cluster-3", true),
+		pinned("cluster-1", false),
+		{},
+	})
+	if diff := cmp.Diff([]string{"cluster-1", "cluster-2"}, got); diff != "" {
+		t.Errorf("incorrect pinned sync targets: %s", diff)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// reconcileUpsyncedResource deletes a resource upsynced from sync targets the namespace is not scheduled to
+// anymore. The syncer cannot delete it then, as it only mutates resources in namespaces scheduled to its
+// sync target.
+func (c *Controller) reconcileUpsyncedResource(ctx context.Context, ns *corev1.Namespace, obj *unstructured.Unstructured, gvr *schema.GroupVersionResource) error {
+	logger := klog.FromContext(ctx)
+
+	if obj.GetDeletionTimestamp() != nil || upsyncedFromScheduledSyncTarget(ns, obj) {
+		logger.V(4).Info("skipping resource upsynced from a sync target")
+		return nil
+	}
+
+	logger.V(2).Info("deleting resource upsynced from a sync target the namespace is not scheduled to")
+	uid := obj.GetUID()
+	err := c.dynClusterClient.Resource(*gvr).Namespace(ns.Name).Delete(ctx, obj.GetName(), metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// upsyncedFromScheduledSyncTarget returns true if the object is upsynced from a sync target the namespace
+// is scheduled to.
+func upsyncedFromScheduledSyncTarget(ns *corev1.Namespace, obj metav1.Object) bool {
+	for k, v := range obj.GetLabels() {
+		if !strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) || v != string(workloadv1alpha1.ResourceStateUpsync) {
+			continue
+		}
+		if _, found := ns.Labels[k]; found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsyncedFromScheduledSyncTarget(t *testing.T) {
+	scheduledNamespace := namespace(nil, map[string]string{"state.workload.kcp.dev/cluster-1": "Sync"})
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{name: "upsynced from a scheduled sync target",
+			labels: map[string]string{"state.workload.kcp.dev/cluster-1": "Upsync"},
+			want:   true,
+		},
+		{name: "upsynced from a sync target the namespace is not scheduled to",
+			labels: map[string]string{"state.workload.kcp.dev/cluster-2": "Upsync"},
+		},
+		{name: "synced to a sync target the namespace is not scheduled to",
+			labels: map[string]string{
+				"state.workload.kcp.dev/cluster-1": "Sync",
+				"state.workload.kcp.dev/cluster-2": "Upsync",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := unstructuredObject(nil, tt.labels, nil)
+			require.Equal(t, tt.want, upsyncedFromScheduledSyncTarget(scheduledNamespace, obj))
+		})
+	}
+}
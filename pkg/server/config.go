@@ -48,6 +48,7 @@ import (
 
 	kcpadmissioninitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/authorization"
 	bootstrappolicy "github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
@@ -261,7 +262,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		return nil, err
 	}
 
-	if err := opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory); err != nil {
+	if err := opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory, opts.Extra.ShardName); err != nil {
 		return nil, err
 	}
 	var userToken string
@@ -305,7 +306,41 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 	// is called multiple times, but only one of the handler chain will actually be used. Hence, we wrap it
 	// to give handlers below one mux.Handle func to call.
 	c.preHandlerChainMux = &handlerChainMuxes{}
+	// the syncer tunnels are shared by all the handler chains, see above.
+	syncerTunnelSharding := &tunneler.Sharding{
+		ShardName:        opts.Extra.ShardName,
+		SyncTargetLister: c.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets().Lister(),
+		KcpClusterClient: c.KcpClusterClient,
+	}
+	if opts.Extra.ShardName == tenancyv1alpha1.RootShard {
+		syncerTunnelSharding.ShardLister = c.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards().Lister()
+	} else {
+		syncerTunnelSharding.ShardLister = c.TemporaryRootShardKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards().Lister()
+	}
+	if len(opts.Extra.ShardKubeconfigFile) > 0 {
+		// the syncer tunnel requests for tunnels connected to another shard are forwarded to the base URL of that
+		// ClusterWorkspaceShard with the admin credentials of the shards.
+		shardConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: opts.Extra.ShardKubeconfigFile}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for the peer shards, err: %w", opts.Extra.ShardKubeconfigFile, err)
+		}
+		if syncerTunnelSharding.PeerTransport, err = rest.TransportFor(shardConfig); err != nil {
+			return nil, err
+		}
+	}
+	syncerTunneler := tunneler.NewTunneler(tunneler.NewTunnelAuthorizer(
+		c.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets().Lister(),
+		c.KubeClusterClient,
+	), &opts.SyncerTunnels, syncerTunnelSharding)
 	c.GenericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, genericConfig *genericapiserver.Config) (secure http.Handler) {
+		if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
+			apiHandler = syncerTunneler.WithPodSubresourceProxy(apiHandler,
+				c.KubeSharedInformerFactory.Core().V1().Namespaces().Lister(),
+				c.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets().Informer().GetIndexer(),
+				c.KcpSharedInformerFactory.Scheduling().V1alpha1().Placements().Informer().GetIndexer(),
+			)
+		}
+		apiHandler = WithWorkspaceSnapshot(apiHandler, genericConfig)
 		apiHandler = WithWildcardListWatchGuard(apiHandler)
 		apiHandler = WithRequestIdentity(apiHandler)
 		apiHandler = authorization.WithDeepSubjectAccessReview(apiHandler)
@@ -344,7 +379,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		apiHandler = mux
 
 		if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
-			apiHandler = tunneler.WithSyncerTunnel(apiHandler)
+			apiHandler = WithSyncerTunnel(apiHandler, syncerTunneler, genericConfig)
 		}
 
 		apiHandler = WithWorkspaceProjection(apiHandler)
@@ -413,6 +448,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 	c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer().AddIndexers(cache.Indexers{byWorkspace: indexByWorkspace})                                                     //nolint:errcheck
 	c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer().AddIndexers(cache.Indexers{byIdentityGroupResource: indexAPIBindingByIdentityGroupResource})                   //nolint:errcheck
 	c.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets().Informer().GetIndexer().AddIndexers(cache.Indexers{indexers.SyncTargetsBySyncTargetKey: indexers.IndexSyncTargetsBySyncTargetKey}) //nolint:errcheck
+	c.KcpSharedInformerFactory.Scheduling().V1alpha1().Placements().Informer().GetIndexer().AddIndexers(cache.Indexers{indexers.ByLogicalCluster: indexers.IndexByLogicalCluster})                    //nolint:errcheck
 
 	c.ApiExtensions.ExtraConfig.ClusterAwareCRDLister = &apiBindingAwareCRDLister{
 		kcpClusterClient:  c.KcpClusterClient,
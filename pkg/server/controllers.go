@@ -39,8 +39,10 @@ import (
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/controller/certificates/rootcacertpublisher"
 	"k8s.io/kubernetes/pkg/controller/clusterroleaggregation"
@@ -53,6 +55,7 @@ import (
 	bootstrappolicy "github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpexternalversions "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/content"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
@@ -67,12 +70,14 @@ import (
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/bootstrap"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemigration"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspaceshard"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacetype"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/initialization"
 	workloadsapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	workloadsapiexportcreate "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexportcreate"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/defaultplacement"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/globalendpoint"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
 	workloadnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	workloadplacement "github.com/kcp-dev/kcp/pkg/reconciler/workload/placement"
@@ -321,6 +326,7 @@ func (s *Server) installWorkspaceDeletionController(ctx context.Context, config
 		kcpClusterClient,
 		metadataClusterClient,
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
 		discoverResourcesFn,
 	)
 
@@ -336,6 +342,70 @@ func (s *Server) installWorkspaceDeletionController(ctx context.Context, config
 	})
 }
 
+func (s *Server) installWorkspaceMigrationController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-migration-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(kcpclienthelper.SetMultiClusterRoundTripper(rest.CopyConfig(config)))
+	if err != nil {
+		return err
+	}
+
+	// the content on other shards is accessed with the admin credentials of the shards.
+	var peerConfig *rest.Config
+	if len(s.Options.Extra.ShardKubeconfigFile) > 0 {
+		peerConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: s.Options.Extra.ShardKubeconfigFile}, nil).ClientConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load the kubeconfig from: %s, for the peer shards, err: %w", s.Options.Extra.ShardKubeconfigFile, err)
+		}
+		peerConfig = rest.AddUserAgent(peerConfig, controllerName)
+	}
+	shardClientGetter := func(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (*content.Client, error) {
+		var logicalClusterConfig *rest.Config
+		switch {
+		case shard.Name == s.Options.Extra.ShardName:
+			logicalClusterConfig = rest.CopyConfig(config)
+		case peerConfig != nil:
+			logicalClusterConfig = rest.CopyConfig(peerConfig)
+			logicalClusterConfig.Host = shard.Spec.BaseURL
+		default:
+			return nil, fmt.Errorf("no credentials for shard %q, --shard-kubeconfig-file is not set", shard.Name)
+		}
+		logicalClusterConfig.Host += clusterName.Path()
+
+		dynamicClient, err := dynamic.NewForConfig(logicalClusterConfig)
+		if err != nil {
+			return nil, err
+		}
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(logicalClusterConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &content.Client{
+			Dynamic:           dynamicClient,
+			DiscoverResources: discoveryClient.ServerPreferredResources,
+		}, nil
+	}
+
+	workspaceMigrationController := clusterworkspacemigration.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		shardClientGetter,
+	)
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceMigrationController.Start(ctx, 2)
+		return nil
+	})
+}
+
 func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *rest.Config, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
 	controllerName := "kcp-workload-resource-scheduler"
 	config = rest.CopyConfig(config)
@@ -397,11 +467,27 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 		return err
 	}
 
+	// every shard reports its own usage, and the workspaces of the ClusterWorkspaces it stores, into the
+	// ClusterWorkspaceShards in the root shard.
 	var workspaceShardController *clusterworkspaceshard.Controller
 	if s.Options.Extra.ShardName == tenancyv1alpha1.RootShard {
 		workspaceShardController, err = clusterworkspaceshard.NewController(
+			s.Options.Extra.ShardName,
 			kcpClusterClient,
 			s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+			s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+			clusterworkspaceshard.StorageUsage(legacyregistry.DefaultGatherer),
+		)
+		if err != nil {
+			return err
+		}
+	} else if len(s.Options.Extra.RootShardKubeconfigFile) > 0 {
+		workspaceShardController, err = clusterworkspaceshard.NewController(
+			s.Options.Extra.ShardName,
+			s.RootShardKcpClusterClient.Cluster(tenancyv1alpha1.RootCluster),
+			s.TemporaryRootShardKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+			s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+			clusterworkspaceshard.StorageUsage(legacyregistry.DefaultGatherer),
 		)
 		if err != nil {
 			return err
@@ -517,6 +603,7 @@ func (s *Server) installSyncTargetHeartbeatController(ctx context.Context, confi
 		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
 		s.KcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
 		s.Options.Controllers.SyncTargetHeartbeat.HeartbeatThreshold,
+		s.Options.Controllers.SyncTargetHeartbeat.SyncerVersionSkew,
 	)
 	if err != nil {
 		return err
@@ -820,6 +907,7 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 		kubeClusterClient,
 		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
 		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Placements(),
+		s.Options.Controllers.WorkloadNamespaceScheduler.MigrationTimeout,
 	)
 	if err != nil {
 		return err
@@ -874,6 +962,37 @@ func (s *Server) installWorkloadPlacementScheduler(ctx context.Context, config *
 	})
 }
 
+func (s *Server) installWorkloadGlobalEndpointController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-workload-global-endpoint"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := globalendpoint.NewController(
+		kubeClusterClient,
+		s.DynamicDiscoverySharedInformerFactory,
+		s.KubeSharedInformerFactory.Core().V1().ConfigMaps(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), 2)
+
+		return nil
+	})
+}
+
 func (s *Server) installSchedulingPlacementController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	controllerName := "kcp-scheduling-placement-controller"
 	config = rest.CopyConfig(config)
@@ -44,13 +44,16 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	kaudit "k8s.io/apiserver/pkg/audit"
 	apiserverdiscovery "k8s.io/apiserver/pkg/endpoints/discovery"
+	"k8s.io/apiserver/pkg/endpoints/filters"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/aggregator"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/tunneler"
 )
 
 var (
@@ -104,6 +107,28 @@ func WithAuditAnnotation(handler http.Handler) http.HandlerFunc {
 	})
 }
 
+// WithSyncerTunnel serves the syncer tunnels with a handler chain of their own, in front of the generic one.
+// The reverse connections are long-running streams that must neither time out nor be queued by priority and
+// fairness, but the requests are still authenticated and audited like any other. Authorization is done by
+// the tunneler per command.
+func WithSyncerTunnel(apiHandler http.Handler, syncerTunneler *tunneler.Tunneler, c *genericapiserver.Config) http.HandlerFunc {
+	var tunnelHandler http.Handler = syncerTunneler.WithSyncerTunnel(apiHandler)
+	tunnelHandler = filters.WithAudit(tunnelHandler, c.AuditBackend, c.AuditPolicyRuleEvaluator, func(*http.Request, *request.RequestInfo) bool { return true })
+	failedHandler := filters.Unauthorized(c.Serializer)
+	failedHandler = filters.WithFailedAuthenticationAudit(failedHandler, c.AuditBackend, c.AuditPolicyRuleEvaluator)
+	tunnelHandler = filters.WithAuthentication(tunnelHandler, c.Authentication.Authenticator, failedHandler, c.Authentication.APIAudiences)
+	tunnelHandler = filters.WithRequestInfo(tunnelHandler, c.RequestInfoResolver)
+	tunnelHandler = filters.WithAuditID(tunnelHandler)
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !tunneler.IsSyncerTunnelPath(req.URL.Path) {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		tunnelHandler.ServeHTTP(w, req)
+	}
+}
+
 // WithWorkspaceProjection maps the personal virtual workspace "workspaces" resource into the cluster
 // workspace URL space. This means you can do `kubectl get workspaces` from an org workspace.
 func WithWorkspaceProjection(apiHandler http.Handler) http.HandlerFunc {
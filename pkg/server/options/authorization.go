@@ -28,6 +28,7 @@ import (
 	kubernetesinformers "k8s.io/client-go/informers"
 
 	"github.com/kcp-dev/kcp/pkg/authorization"
+	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 )
 
@@ -38,6 +39,13 @@ type Authorization struct {
 
 	// AlwaysAllowGroups are groups which are allowed to take any actions.  In kube, this is system:masters.
 	AlwaysAllowGroups []string
+
+	// ReadOnlyExemptUsers are users which are allowed to write to workspaces with spec.readOnly set.
+	// They can be plain user names or end in * in which case prefix-match is applied.
+	ReadOnlyExemptUsers []string
+
+	// ReadOnlyExemptGroups are groups which are allowed to write to workspaces with spec.readOnly set.
+	ReadOnlyExemptGroups []string
 }
 
 func NewAuthorization() *Authorization {
@@ -46,6 +54,8 @@ func NewAuthorization() *Authorization {
 		// This field can be cleared by callers if they don't want this behavior.
 		AlwaysAllowPaths:  []string{"/healthz", "/readyz", "/livez"},
 		AlwaysAllowGroups: []string{user.SystemPrivilegedGroup},
+		// This allows initializers to bootstrap read-only workspaces.
+		ReadOnlyExemptGroups: []string{bootstrap.SystemKcpWorkspaceBootstrapper},
 	}
 }
 
@@ -79,9 +89,15 @@ func (s *Authorization) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&s.AlwaysAllowPaths, "authorization-always-allow-paths", s.AlwaysAllowPaths,
 		"A list of HTTP paths to skip during authorization, i.e. these are authorized without "+
 			"contacting the 'core' kubernetes server.")
+	fs.StringSliceVar(&s.ReadOnlyExemptUsers, "read-only-workspace-exempt-users", s.ReadOnlyExemptUsers,
+		"A list of users allowed to write to read-only workspaces, e.g. controllers updating status. "+
+			"Names ending in * match by prefix, e.g. system:serviceaccount:default:kcp-syncer-*. "+
+			"Members of system:masters are always allowed.")
+	fs.StringSliceVar(&s.ReadOnlyExemptGroups, "read-only-workspace-exempt-groups", s.ReadOnlyExemptGroups,
+		"A list of groups allowed to write to read-only workspaces.")
 }
 
-func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubernetesinformers.SharedInformerFactory, kcpinformer kcpinformers.SharedInformerFactory) error {
+func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubernetesinformers.SharedInformerFactory, kcpinformer kcpinformers.SharedInformerFactory, shardName string) error {
 	var authorizers []authorizer.Authorizer
 
 	workspaceLister := kcpinformer.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
@@ -111,10 +127,12 @@ func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubern
 	}
 
 	authorizers = append(authorizers,
-		authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
-			authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister,
-				authorization.NewSystemCRDAuthorizer(
-					apiBindingAuth,
+		authorization.NewWorkspaceFreezeAuthorizer(workspaceLister, shardName, s.ReadOnlyExemptUsers, s.ReadOnlyExemptGroups,
+			authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
+				authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister,
+					authorization.NewSystemCRDAuthorizer(
+						apiBindingAuth,
+					),
 				),
 			),
 		),
@@ -31,18 +31,21 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
+	workloadnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 )
 
 type Controllers struct {
-	EnableAll           bool
-	IndividuallyEnabled []string
-	ApiResource         ApiResourceController
-	SyncTargetHeartbeat SyncTargetHeartbeatController
-	SAController        kcmoptions.SAControllerOptions
+	EnableAll                  bool
+	IndividuallyEnabled        []string
+	ApiResource                ApiResourceController
+	SyncTargetHeartbeat        SyncTargetHeartbeatController
+	WorkloadNamespaceScheduler WorkloadNamespaceSchedulerController
+	SAController               kcmoptions.SAControllerOptions
 }
 
 type ApiResourceController = apiresource.Options
 type SyncTargetHeartbeatController = heartbeat.Options
+type WorkloadNamespaceSchedulerController = workloadnamespace.Options
 
 var kcmDefaults *kcmoptions.KubeControllerManagerOptions
 
@@ -59,9 +62,10 @@ func NewControllers() *Controllers {
 	return &Controllers{
 		EnableAll: true,
 
-		ApiResource:         *apiresource.DefaultOptions(),
-		SyncTargetHeartbeat: *heartbeat.DefaultOptions(),
-		SAController:        *kcmDefaults.SAController,
+		ApiResource:                *apiresource.DefaultOptions(),
+		SyncTargetHeartbeat:        *heartbeat.DefaultOptions(),
+		WorkloadNamespaceScheduler: *workloadnamespace.DefaultOptions(),
+		SAController:               *kcmDefaults.SAController,
 	}
 }
 
@@ -73,6 +77,7 @@ func (c *Controllers) AddFlags(fs *pflag.FlagSet) {
 
 	apiresource.BindOptions(&c.ApiResource, fs)
 	heartbeat.BindOptions(&c.SyncTargetHeartbeat, fs)
+	workloadnamespace.BindOptions(&c.WorkloadNamespaceScheduler, fs)
 
 	c.SAController.AddFlags(fs)
 }
@@ -112,6 +117,9 @@ func (c *Controllers) Validate() []error {
 	if err := c.SyncTargetHeartbeat.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if err := c.WorkloadNamespaceScheduler.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 	if saErrs := c.SAController.Validate(); saErrs != nil {
 		errs = append(errs, saErrs...)
 	}
@@ -39,6 +39,7 @@ var (
 		"KCP Controllers",
 		"KCP Home Workspaces",
 		"KCP Cache Server",
+		"KCP Syncer Tunnels",
 		"KCP",
 	}
 
@@ -99,7 +100,9 @@ var (
 		"token-auth-file",                    // If set, the file that will be used to secure the secure port of the API server via token authentication.
 
 		// KCP Authorization flags
-		"authorization-always-allow-paths", // A list of HTTP paths to skip during authorization, i.e. these are authorized without contacting the 'core' kubernetes server.
+		"authorization-always-allow-paths",  // A list of HTTP paths to skip during authorization, i.e. these are authorized without contacting the 'core' kubernetes server.
+		"read-only-workspace-exempt-groups", // A list of groups allowed to write to read-only workspaces.
+		"read-only-workspace-exempt-users",  // A list of users allowed to write to read-only workspaces, e.g. controllers updating status. Names ending in * match by prefix, e.g. system:serviceaccount:default:kcp-syncer-*. Members of system:masters are always allowed.
 
 		// KCP Admin Authentication flags
 		"authentication-admin-token-path", // Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.
@@ -177,11 +180,20 @@ var (
 		"run-virtual-workspaces",                 // Run the virtual workspaces apiservers in-process
 		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
 		"sync-target-heartbeat-threshold",        // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"sync-target-syncer-version-skew",        // Number of minor versions a syncer may be older than kcp before its SyncTarget is marked with a false SyncerVersionSupported condition.
+		"workload-migration-timeout",             // Maximal amount of time the removal of a SyncTarget from a Namespace is held for its stateful resources to be migrated to the newly scheduled SyncTargets
 
 		// KCP Cache Server flags
 		"cache-url",        // A URL address of a cache server associated with this instance (default https://localhost:6443)
 		"run-cache-server", // If set to true it runs the cache server with this instance (default false).
 
+		// KCP Syncer Tunnels flags
+		"syncer-tunnel-close-delay",             // Time a reverse connection of a syncer tunnel waits after its last write before it is closed.
+		"syncer-tunnel-idle-connection-timeout", // Time an idle reverse connection of a syncer tunnel is kept before it is closed.
+		"syncer-tunnel-max-concurrent-requests", // Maximum number of requests in flight through one syncer tunnel, including logs, exec and port-forward streams. Further requests wait. 0 means no limit.
+		"syncer-tunnel-max-idle-connections",    // Number of idle reverse connections kept for reuse per syncer tunnel.
+		"syncer-tunnel-max-request-wait",        // Maximum time a request waits for a free slot of a syncer tunnel before it is rejected with 429 Too Many Requests.
+
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
 		"goaway-chance",                        // To prevent HTTP/2 clients from getting stuck on a single apiserver, randomly close a connection (GOAWAY). The client's other in-flight requests won't be affected, and the client will reconnect, likely landing on a different apiserver after going through the load balancer again. This argument sets the fraction of requests that will be sent a GOAWAY. Clusters with single apiservers, or which don't use a load balancer, should NOT enable this. Min is 0 (off), Max is .02 (1/50 requests); .001 (1/1000) is a recommended starting point.
@@ -38,6 +38,7 @@ import (
 	etcdoptions "github.com/kcp-dev/kcp/pkg/embeddedetcd/options"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/server/options/batteries"
+	"github.com/kcp-dev/kcp/pkg/tunneler"
 )
 
 type Options struct {
@@ -49,6 +50,7 @@ type Options struct {
 	Virtual             Virtual
 	HomeWorkspaces      HomeWorkspaces
 	Cache               Cache
+	SyncerTunnels       tunneler.Options
 
 	Extra ExtraOptions
 }
@@ -77,6 +79,7 @@ type completedOptions struct {
 	Virtual             Virtual
 	HomeWorkspaces      HomeWorkspaces
 	Cache               cacheCompleted
+	SyncerTunnels       tunneler.Options
 
 	Extra ExtraOptions
 }
@@ -98,6 +101,7 @@ func NewOptions(rootDir string) *Options {
 		Virtual:             *NewVirtual(),
 		HomeWorkspaces:      *NewHomeWorkspaces(),
 		Cache:               *NewCache(rootDir),
+		SyncerTunnels:       *tunneler.NewOptions(),
 
 		Extra: ExtraOptions{
 			RootDirectory:            rootDir,
@@ -164,6 +168,7 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	o.Virtual.AddFlags(fss.FlagSet("KCP Virtual Workspaces"))
 	o.HomeWorkspaces.AddFlags(fss.FlagSet("KCP Home Workspaces"))
 	o.Cache.AddFlags(fss.FlagSet("KCP Cache Server"))
+	o.SyncerTunnels.AddFlags(fss.FlagSet("KCP Syncer Tunnels"))
 
 	fs := fss.FlagSet("KCP")
 	fs.StringVar(&o.Extra.ProfilerAddress, "profiler-address", o.Extra.ProfilerAddress, "[Address]:port to bind the profiler to")
@@ -209,6 +214,7 @@ func (o *CompletedOptions) Validate() []error {
 	errs = append(errs, o.Virtual.Validate()...)
 	errs = append(errs, o.HomeWorkspaces.Validate()...)
 	errs = append(errs, o.Cache.Validate()...)
+	errs = append(errs, o.SyncerTunnels.Validate()...)
 
 	differential := false
 	for i, b := range o.Extra.BatteriesIncluded {
@@ -354,6 +360,7 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 			Virtual:             o.Virtual,
 			HomeWorkspaces:      o.HomeWorkspaces,
 			Cache:               cacheCompletedOptions,
+			SyncerTunnels:       o.SyncerTunnels,
 			Extra:               o.Extra,
 		},
 	}, nil
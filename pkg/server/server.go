@@ -92,7 +92,8 @@ func NewServer(c CompletedConfig) (*Server, error) {
 		indexers.AppendOrDie(
 			indexers.NamespaceScoped(),
 			cache.Indexers{
-				indexers.BySyncerFinalizerKey: indexers.IndexBySyncerFinalizerKey,
+				indexers.BySyncerFinalizerKey:               indexers.IndexBySyncerFinalizerKey,
+				indexers.PinnedByLogicalClusterAndNamespace: indexers.IndexPinnedByLogicalClusterAndNamespace,
 			},
 		),
 	)
@@ -421,6 +422,9 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installWorkspaceDeletionController(ctx, controllerConfig); err != nil {
 			return err
 		}
+		if err := s.installWorkspaceMigrationController(ctx, controllerConfig); err != nil {
+			return err
+		}
 	}
 
 	if s.Options.Controllers.EnableAll || enabled.Has("resource-scheduler") {
@@ -455,6 +459,9 @@ func (s *Server) Run(ctx context.Context) error {
 			if err := s.installWorkloadPlacementScheduler(ctx, controllerConfig, delegationChainHead); err != nil {
 				return err
 			}
+			if err := s.installWorkloadGlobalEndpointController(ctx, controllerConfig, delegationChainHead); err != nil {
+				return err
+			}
 			if err := s.installSchedulingLocationStatusController(ctx, controllerConfig, delegationChainHead); err != nil {
 				return err
 			}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/content"
+)
+
+// WorkspaceSnapshotPath is the non-resource path in every workspace to export (GET) a snapshot of its
+// content, and to restore (POST) a snapshot into it. The snapshot is a v1 List of the objects.
+const WorkspaceSnapshotPath = "/snapshot"
+
+// WithWorkspaceSnapshot serves WorkspaceSnapshotPath. The objects are read and written as the requesting
+// user, i.e. only objects the user has access to are exported and restored.
+func WithWorkspaceSnapshot(apiHandler http.Handler, genericConfig *genericapiserver.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != WorkspaceSnapshotPath {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		logger := klog.FromContext(ctx)
+		gv := schema.GroupVersion{Version: "v1"}
+
+		cluster := request.ClusterFrom(ctx)
+		if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+			responsewriters.ErrorNegotiated(apierrors.NewBadRequest("a snapshot requires a workspace"), errorCodecs, gv, w, req)
+			return
+		}
+		user, ok := request.UserFrom(ctx)
+		if !ok {
+			responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("missing user")), errorCodecs, gv, w, req)
+			return
+		}
+
+		config := rest.CopyConfig(genericConfig.LoopbackClientConfig)
+		config.Host += cluster.Name.Path()
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: user.GetName(),
+			Groups:   user.GetGroups(),
+			Extra:    user.GetExtra(),
+		}
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, gv, w, req)
+			return
+		}
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, gv, w, req)
+			return
+		}
+		c := &content.Client{Dynamic: dynamicClient, DiscoverResources: discoveryClient.ServerPreferredResources}
+
+		switch req.Method {
+		case http.MethodGet:
+			objects, err := content.Export(ctx, c)
+			if err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, gv, w, req)
+				return
+			}
+			list := &unstructured.UnstructuredList{Items: objects}
+			list.SetAPIVersion("v1")
+			list.SetKind("List")
+			data, err := list.MarshalJSON()
+			if err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, gv, w, req)
+				return
+			}
+			logger.V(2).Info("exported workspace snapshot", "objects", len(objects))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(data) //nolint:errcheck
+
+		case http.MethodPost:
+			body := req.Body
+			if genericConfig.MaxRequestBodyBytes > 0 {
+				body = http.MaxBytesReader(w, req.Body, genericConfig.MaxRequestBodyBytes)
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewRequestEntityTooLargeError(err.Error()), errorCodecs, gv, w, req)
+				return
+			}
+			list := &unstructured.UnstructuredList{}
+			if err := list.UnmarshalJSON(data); err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("invalid snapshot: %v", err)), errorCodecs, gv, w, req)
+				return
+			}
+			restored, err := content.Restore(ctx, c, list.Items)
+			if err != nil {
+				// resources of restored CRDs and APIBindings become available asynchronously. The client retries.
+				responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("restored %d of %d objects: %w", restored, len(list.Items), err)), errorCodecs, gv, w, req)
+				return
+			}
+			logger.V(2).Info("restored workspace snapshot", "objects", restored)
+			responsewriters.WriteRawJSON(http.StatusOK, &metav1.Status{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+				Status:   metav1.StatusSuccess,
+				Message:  fmt.Sprintf("restored %d objects", restored),
+			}, w)
+
+		default:
+			responsewriters.ErrorNegotiated(apierrors.NewMethodNotSupported(schema.GroupResource{}, req.Method), errorCodecs, gv, w, req)
+		}
+	}
+}
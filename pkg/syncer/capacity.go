@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// capacityPodsFieldSelector selects the pods consuming the capacity of a node: bound and not terminated.
+const capacityPodsFieldSelector = "spec.nodeName!=,status.phase!=Succeeded,status.phase!=Failed"
+
+// capacityReporter publishes the capacity of the physical cluster in the SyncTarget status. The status
+// is only patched when the capacity has changed since the last report.
+type capacityReporter struct {
+	nodeLister corev1listers.NodeLister
+	podLister  corev1listers.PodLister
+	// patch applies the given JSON patch to the SyncTarget status.
+	patch func(ctx context.Context, patch []byte) error
+
+	syncTargetUID string
+
+	reportedCapacity, reportedAllocatable corev1.ResourceList
+}
+
+func (r *capacityReporter) report(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "failed to list nodes")
+		return
+	}
+	pods, err := r.podLister.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "failed to list pods")
+		return
+	}
+	capacity, allocatable := clusterCapacity(nodes, pods)
+	if r.reportedCapacity != nil && equality.Semantic.DeepEqual(capacity, r.reportedCapacity) && equality.Semantic.DeepEqual(allocatable, r.reportedAllocatable) {
+		return
+	}
+
+	patch, err := capacityPatch(r.syncTargetUID, capacity, allocatable)
+	if err != nil {
+		logger.Error(err, "failed to create the capacity patch")
+		return
+	}
+	if err := r.patch(ctx, patch); err != nil {
+		logger.Error(err, "failed to update the capacity of the SyncTarget")
+		return
+	}
+	logger.V(4).Info("reported capacity", "capacity", capacity, "allocatable", allocatable)
+	r.reportedCapacity, r.reportedAllocatable = capacity, allocatable
+}
+
+// clusterCapacity returns the capacity of the physical cluster, i.e. the sum of the allocatable resources of its
+// ready and schedulable nodes, and the part of it not requested by the pods running on these nodes.
+func clusterCapacity(nodes []*corev1.Node, pods []*corev1.Pod) (capacity, allocatable corev1.ResourceList) {
+	capacity = corev1.ResourceList{}
+	schedulable := map[string]bool{}
+	for _, node := range nodes {
+		if node.Spec.Unschedulable || !isNodeReady(node) {
+			continue
+		}
+		schedulable[node.Name] = true
+		addResources(capacity, node.Status.Allocatable)
+	}
+
+	requested := corev1.ResourceList{}
+	for _, pod := range pods {
+		if !schedulable[pod.Spec.NodeName] || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		addResources(requested, podRequests(pod))
+		addResources(requested, corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI)})
+	}
+
+	allocatable = corev1.ResourceList{}
+	for name, q := range capacity {
+		free := q.DeepCopy()
+		if used, found := requested[name]; found {
+			free.Sub(used)
+		}
+		if free.Sign() < 0 {
+			free.Set(0)
+		}
+		allocatable[name] = free
+	}
+	return capacity, allocatable
+}
+
+// podRequests returns the resources requested by the pod, computed like the kube-scheduler does: the
+// maximum of the requests of the containers and of each init container, plus the pod overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		addResources(requests, c.Resources.Requests)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		for name, q := range c.Resources.Requests {
+			if current, found := requests[name]; !found || q.Cmp(current) > 0 {
+				requests[name] = q.DeepCopy()
+			}
+		}
+	}
+	addResources(requests, pod.Spec.Overhead)
+	return requests
+}
+
+func addResources(total, resources corev1.ResourceList) {
+	for name, q := range resources {
+		if current, found := total[name]; found {
+			current.Add(q)
+			total[name] = current
+		} else {
+			total[name] = q.DeepCopy()
+		}
+	}
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// capacityPatch returns the JSON patch of the SyncTarget status setting its capacity and allocatable resources.
+func capacityPatch(syncTargetUID string, capacity, allocatable corev1.ResourceList) ([]byte, error) {
+	return json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/uid", "value": syncTargetUID},
+		{"op": "add", "path": "/status/capacity", "value": capacity},
+		{"op": "add", "path": "/status/allocatable", "value": allocatable},
+	})
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestClusterCapacity(t *testing.T) {
+	tests := map[string]struct {
+		nodes           []*corev1.Node
+		pods            []*corev1.Pod
+		wantCapacity    corev1.ResourceList
+		wantAllocatable corev1.ResourceList
+	}{
+		"no nodes": {
+			wantCapacity:    corev1.ResourceList{},
+			wantAllocatable: corev1.ResourceList{},
+		},
+		"sums ready and schedulable nodes": {
+			nodes: []*corev1.Node{
+				node("n1", "4", "8Gi", true, false),
+				node("n2", "2", "4Gi", true, false),
+				node("not-ready", "8", "16Gi", false, false),
+				node("cordoned", "8", "16Gi", true, true),
+			},
+			wantCapacity:    resources("6", "12Gi", "220"),
+			wantAllocatable: resources("6", "12Gi", "220"),
+		},
+		"subtracts the requests of the pods": {
+			nodes: []*corev1.Node{
+				node("n1", "4", "8Gi", true, false),
+				node("n2", "2", "4Gi", true, false),
+				node("cordoned", "8", "16Gi", true, true),
+			},
+			pods: []*corev1.Pod{
+				pod("p1", "n1", corev1.PodRunning, "1", "1Gi"),
+				pod("p2", "n2", corev1.PodPending, "500m", "512Mi"),
+				pod("on-cordoned", "cordoned", corev1.PodRunning, "4", "4Gi"),
+				pod("succeeded", "n1", corev1.PodSucceeded, "4", "4Gi"),
+			},
+			wantCapacity:    resources("6", "12Gi", "220"),
+			wantAllocatable: resources("4500m", "10752Mi", "218"),
+		},
+		"allocatable is never negative": {
+			nodes: []*corev1.Node{
+				node("n1", "1", "1Gi", true, false),
+			},
+			pods: []*corev1.Pod{
+				pod("p1", "n1", corev1.PodRunning, "2", "512Mi"),
+			},
+			wantCapacity:    resources("1", "1Gi", "110"),
+			wantAllocatable: resources("0", "512Mi", "109"),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			capacity, allocatable := clusterCapacity(tc.nodes, tc.pods)
+			requireResources(t, tc.wantCapacity, capacity)
+			requireResources(t, tc.wantAllocatable, allocatable)
+		})
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	p := pod("p", "n1", corev1.PodRunning, "1", "1Gi")
+	p.Spec.Containers = append(p.Spec.Containers, corev1.Container{
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+	})
+	p.Spec.InitContainers = []corev1.Container{
+		{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("2Gi")}}},
+	}
+	p.Spec.Overhead = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+
+	requireResources(t, corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1600m"),
+		corev1.ResourceMemory: resource.MustParse("2Gi"),
+	}, podRequests(p))
+}
+
+func TestCapacityReporterOnlyPatchesChanges(t *testing.T) {
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, nodes.Add(node("n1", "4", "8Gi", true, false)))
+
+	var patches int
+	r := &capacityReporter{
+		nodeLister: corev1listers.NewNodeLister(nodes),
+		podLister:  corev1listers.NewPodLister(pods),
+		patch: func(ctx context.Context, patch []byte) error {
+			patches++
+			return nil
+		},
+		syncTargetUID: "uid",
+	}
+
+	r.report(context.Background())
+	require.Equal(t, 1, patches)
+	r.report(context.Background())
+	require.Equal(t, 1, patches, "unchanged capacity must not be reported again")
+
+	require.NoError(t, pods.Add(pod("p1", "n1", corev1.PodRunning, "1", "1Gi")))
+	r.report(context.Background())
+	require.Equal(t, 2, patches)
+}
+
+func requireResources(t *testing.T, expected, actual corev1.ResourceList) {
+	t.Helper()
+	require.Len(t, actual, len(expected))
+	for name, q := range expected {
+		got, found := actual[name]
+		require.True(t, found, "missing %s", name)
+		require.Zero(t, q.Cmp(got), "%s: expected %s, got %s", name, q.String(), got.String())
+	}
+}
+
+func resources(cpu, memory, pods string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+		corev1.ResourcePods:   resource.MustParse(pods),
+	}
+}
+
+func node(name, cpu, memory string, ready, unschedulable bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		Status: corev1.NodeStatus{
+			Allocatable: resources(cpu, memory, "110"),
+			Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func pod(name, nodeName string, phase corev1.PodPhase, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
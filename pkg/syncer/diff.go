@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/pmezard/go-difflib/difflib"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/pkg/version"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/spec"
+	"github.com/kcp-dev/kcp/third_party/keyfunctions"
+)
+
+// DiffSyncer computes what the spec syncer of the SyncTarget would apply downstream, and writes the difference to
+// the objects currently in the physical cluster to out. Only the resources in ResourcesToSync are compared, e.g.
+// "deployments.apps", or all the resources synced to the SyncTarget if empty. Nothing is written, neither upstream
+// nor downstream.
+func DiffSyncer(ctx context.Context, cfg *SyncerConfig, out io.Writer) error {
+	kcpVersion := version.Get().GitVersion
+
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(rest.AddUserAgent(rest.CopyConfig(cfg.UpstreamConfig), "kcp#syncer-diff/"+kcpVersion))
+	if err != nil {
+		return err
+	}
+	syncTarget, err := kcpClusterClient.Cluster(cfg.SyncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Get(ctx, cfg.SyncTargetName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if cfg.SyncTargetUID != "" && cfg.SyncTargetUID != string(syncTarget.UID) {
+		return fmt.Errorf("unexpected SyncTarget UID %s, expected %s", syncTarget.UID, cfg.SyncTargetUID)
+	}
+	if len(syncTarget.Status.VirtualWorkspaces) == 0 {
+		return fmt.Errorf("SyncTarget %s|%s has no syncer virtual workspace URL yet", cfg.SyncTargetWorkspace, cfg.SyncTargetName)
+	}
+
+	upstreamConfig := rest.CopyConfig(cfg.UpstreamConfig)
+	upstreamConfig.Host = syncTarget.Status.VirtualWorkspaces[0].URL
+	upstreamConfig.UserAgent = "kcp#syncer-diff/" + kcpVersion
+	downstreamConfig := rest.CopyConfig(cfg.DownstreamConfig)
+	downstreamConfig.UserAgent = "kcp#syncer-diff/" + kcpVersion
+
+	upstreamDynamicClusterClient, err := dynamic.NewClusterForConfig(upstreamConfig)
+	if err != nil {
+		return err
+	}
+	downstreamDynamicClient, err := dynamic.NewForConfig(downstreamConfig)
+	if err != nil {
+		return err
+	}
+
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(cfg.SyncTargetWorkspace, cfg.SyncTargetName)
+	upstreamInformers, downstreamInformers := newSyncerInformerFactories(upstreamDynamicClusterClient, downstreamDynamicClient, syncTargetKey)
+
+	syncerInformers := &staticSyncerInformers{
+		informers:              map[schema.GroupVersionResource]*resourcesync.SyncerInformer{},
+		clusterScopedResources: map[schema.GroupResource]bool{},
+	}
+	for _, gr := range syncTarget.Spec.ClusterScopedResources {
+		syncerInformers.clusterScopedResources[schema.GroupResource{Group: gr.Group, Resource: gr.Resource}] = true
+	}
+	var gvrs []schema.GroupVersionResource
+	for _, gvr := range resourcesync.SyncedGVRs(syncTarget) {
+		if cfg.ResourcesToSync.Len() > 0 && !cfg.ResourcesToSync.Has(gvr.GroupResource().String()) {
+			continue
+		}
+		gvrs = append(gvrs, gvr)
+		syncerInformers.informers[gvr] = &resourcesync.SyncerInformer{
+			UpstreamInformer:   upstreamInformers.ForResource(gvr),
+			DownstreamInformer: downstreamInformers.ForResource(gvr),
+		}
+	}
+
+	upstreamURL, err := url.Parse(cfg.UpstreamConfig.Host)
+	if err != nil {
+		return err
+	}
+	// the diff is computed once, hence the SyncTarget fetched above is good enough
+	syncTargetIndexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{})
+	if err := syncTargetIndexer.Add(syncTarget); err != nil {
+		return err
+	}
+	advancedSchedulingEnabled := syncTarget.GetAnnotations()[AdvancedSchedulingFeatureAnnotation] == "true"
+	specSyncer, err := spec.NewSpecSyncer(cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, upstreamURL, advancedSchedulingEnabled,
+		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncerInformers, syncTarget.GetUID(), workloadlisters.NewSyncTargetLister(syncTargetIndexer), 0)
+	if err != nil {
+		return err
+	}
+
+	upstreamInformers.Start(ctx.Done())
+	downstreamInformers.Start(ctx.Done())
+	// a diff against caches which are not synced would report every downstream object as orphaned.
+	if notSynced := notSyncedResources(upstreamInformers.WaitForCacheSync(ctx.Done())); len(notSynced) > 0 {
+		return fmt.Errorf("failed to sync the upstream informers of %v", notSynced)
+	}
+	if notSynced := notSyncedResources(downstreamInformers.WaitForCacheSync(ctx.Done())); len(notSynced) > 0 {
+		return fmt.Errorf("failed to sync the downstream informers of %v", notSynced)
+	}
+
+	for _, gvr := range gvrs {
+		diffs, err := specSyncer.Diff(ctx, gvr)
+		if err != nil {
+			return fmt.Errorf("failed to compute the difference of %s: %w", gvr.GroupResource(), err)
+		}
+		for _, diff := range diffs {
+			if err := writeDiff(out, diff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeDiff writes the difference of the live and the desired downstream object as unified diff of their YAML.
+// notSyncedResources returns the sorted resources of the informers which did not sync.
+func notSyncedResources(synced map[schema.GroupVersionResource]bool) []string {
+	notSynced := sets.NewString()
+	for gvr, ok := range synced {
+		if !ok {
+			notSynced.Insert(gvr.GroupResource().String())
+		}
+	}
+	return notSynced.List()
+}
+
+func writeDiff(out io.Writer, diff spec.ObjectDiff) error {
+	resource := diff.Resource.GroupResource().String()
+	upstream := diff.Upstream
+	if upstream == "" {
+		upstream = "deleted upstream"
+	}
+
+	if diff.Operation == spec.DiffSkip {
+		_, err := fmt.Fprintf(out, "# Skip %s %s (%s): %s\n", resource, diff.Downstream, upstream, diff.Message)
+		return err
+	}
+
+	live, err := toYAML(diff.Live)
+	if err != nil {
+		return err
+	}
+	desired, err := toYAML(diff.Desired)
+	if err != nil {
+		return err
+	}
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(live),
+		B:        difflib.SplitLines(desired),
+		FromFile: "live/" + resource + "/" + diff.Downstream,
+		ToFile:   "desired/" + resource + "/" + diff.Downstream,
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "# %s %s %s (%s)\n%s", diff.Operation, resource, diff.Downstream, upstream, text)
+	return err
+}
+
+func toYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// staticSyncerInformers serves the informers of a fixed set of resources, without starting or stopping
+// them when the resources synced to the SyncTarget change.
+type staticSyncerInformers struct {
+	informers              map[schema.GroupVersionResource]*resourcesync.SyncerInformer
+	clusterScopedResources map[schema.GroupResource]bool
+}
+
+var _ resourcesync.SyncerInformerFactory = &staticSyncerInformers{}
+
+func (s *staticSyncerInformers) AddUpstreamEventHandler(handler resourcesync.ResourceEventHandlerPerGVR) {
+}
+
+func (s *staticSyncerInformers) AddDownstreamEventHandler(handler resourcesync.ResourceEventHandlerPerGVR) {
+}
+
+func (s *staticSyncerInformers) InformerForResource(gvr schema.GroupVersionResource) (*resourcesync.SyncerInformer, bool) {
+	informer, ok := s.informers[gvr]
+	return informer, ok
+}
+
+func (s *staticSyncerInformers) ClusterScopedResourceAllowed(gr schema.GroupResource) bool {
+	return s.clusterScopedResources[gr]
+}
+
+func (s *staticSyncerInformers) ResourceHealth() []workloadv1alpha1.ResourceHealth {
+	return nil
+}
+
+func (s *staticSyncerInformers) Start(ctx context.Context, numThreads int) {}
+
+// newSyncerInformerFactories returns the informer factories of the objects synced to and from the SyncTarget.
+func newSyncerInformerFactories(upstreamClient *dynamic.Cluster, downstreamClient dynamic.Interface, syncTargetKey string) (upstream, downstream dynamicinformer.DynamicSharedInformerFactory) {
+	upstream = dynamicinformer.NewFilteredDynamicSharedInformerFactory(upstreamClient.Cluster(logicalcluster.Wildcard), resyncPeriod, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
+	})
+	downstream = dynamicinformer.NewFilteredDynamicSharedInformerFactoryWithOptions(downstreamClient, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
+	}, cache.WithResyncPeriod(resyncPeriod), cache.WithKeyFunction(keyfunctions.DeletionHandlingMetaNamespaceKeyFunc))
+	return upstream, downstream
+}
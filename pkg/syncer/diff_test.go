@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNotSyncedResources(t *testing.T) {
+	require.Empty(t, notSyncedResources(nil))
+	require.Empty(t, notSyncedResources(map[schema.GroupVersionResource]bool{
+		{Version: "v1", Resource: "configmaps"}: true,
+	}))
+	require.Equal(t, []string{"configmaps", "deployments.apps"}, notSyncedResources(map[schema.GroupVersionResource]bool{
+		{Group: "apps", Version: "v1", Resource: "deployments"}: false,
+		{Version: "v1", Resource: "configmaps"}:                 false,
+		{Version: "v1", Resource: "secrets"}:                    true,
+	}))
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairqueue
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "kcp"
+	metricsSubsystem = "syncer_queue"
+)
+
+var (
+	queueLabels = []string{"controller", "workspace"}
+
+	queueDepth = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "depth",
+		Help:           "Number of queued items per syncer controller and workspace.",
+		StabilityLevel: metrics.ALPHA,
+	}, queueLabels)
+
+	queueLatency = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "latency_seconds",
+		Help:           "Time items stay queued before being processed per syncer controller and workspace.",
+		Buckets:        metrics.ExponentialBuckets(0.001, 2, 16),
+		StabilityLevel: metrics.ALPHA,
+	}, queueLabels)
+)
+
+var registerMetrics sync.Once
+
+func init() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(queueDepth)
+		legacyregistry.MustRegister(queueLatency)
+	})
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fairqueue provides a work queue which is fair across workspaces: the items are partitioned
+// by workspace, and the workers take items from the workspaces in turn, so that a workspace with many
+// items does not starve the others. The number of items of a workspace processed concurrently can be
+// limited.
+package fairqueue
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// WorkspaceFunc returns the workspace of an item of the queue.
+type WorkspaceFunc func(item interface{}) string
+
+// Queue is a rate limiting work queue which hands out the items of the workspaces in round-robin order.
+// Like the client-go work queues, an item is never processed concurrently by multiple workers, and an item
+// added multiple times before being processed is only processed once.
+type Queue struct {
+	name        string
+	workspaceOf WorkspaceFunc
+	// maxInFlight is the maximal number of items of a workspace processed concurrently, unlimited if zero.
+	maxInFlight int
+	rateLimiter workqueue.RateLimiter
+	now         func() time.Time
+
+	lock sync.Mutex
+	cond *sync.Cond
+
+	workspaces map[string]*workspaceQueue
+	// active are the workspaces with queued items, in the order they are served.
+	active []string
+	// queued is the number of queued items.
+	queued int
+	// dirty are the items to be processed, with their workspace.
+	dirty map[interface{}]string
+	// processing are the items being processed, with their workspace.
+	processing map[interface{}]string
+	// addedAt is the time the dirty items were added.
+	addedAt map[interface{}]time.Time
+
+	shuttingDown bool
+}
+
+type workspaceQueue struct {
+	items    []interface{}
+	inFlight int
+}
+
+var _ workqueue.RateLimitingInterface = &Queue{}
+
+// New returns a fair queue with the given name, used in the metrics, and the given maximal number of items
+// of a workspace processed concurrently, unlimited if zero.
+func New(name string, workspaceOf WorkspaceFunc, maxInFlight int) *Queue {
+	q := &Queue{
+		name:        name,
+		workspaceOf: workspaceOf,
+		maxInFlight: maxInFlight,
+		rateLimiter: workqueue.DefaultControllerRateLimiter(),
+		now:         time.Now,
+		workspaces:  map[string]*workspaceQueue{},
+		dirty:       map[interface{}]string{},
+		processing:  map[interface{}]string{},
+		addedAt:     map[interface{}]time.Time{},
+	}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// Add marks the item as needing processing.
+func (q *Queue) Add(item interface{}) {
+	workspace := q.workspaceOf(item)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if _, found := q.dirty[item]; found {
+		return
+	}
+	q.dirty[item] = workspace
+	q.addedAt[item] = q.now()
+	if _, found := q.processing[item]; found {
+		// queued again when done
+		return
+	}
+	q.push(workspace, item)
+	q.cond.Signal()
+}
+
+func (q *Queue) push(workspace string, item interface{}) {
+	w, found := q.workspaces[workspace]
+	if !found {
+		w = &workspaceQueue{}
+		q.workspaces[workspace] = w
+	}
+	if len(w.items) == 0 {
+		q.active = append(q.active, workspace)
+	}
+	w.items = append(w.items, item)
+	q.queued++
+	queueDepth.WithLabelValues(q.name, workspace).Inc()
+}
+
+// Len returns the number of queued items.
+func (q *Queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.queued
+}
+
+// Get blocks until it can return an item to be processed. It takes the next item of the first workspace
+// in turn below its concurrency limit. If shutdown = true, the caller should end their goroutine. You must
+// call Done with the item when you have finished processing it.
+func (q *Queue) Get() (item interface{}, shutdown bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for {
+		if q.shuttingDown && q.queued == 0 {
+			return nil, true
+		}
+		if item, ok := q.pop(); ok {
+			return item, false
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *Queue) pop() (interface{}, bool) {
+	for i, workspace := range q.active {
+		w := q.workspaces[workspace]
+		if q.maxInFlight > 0 && w.inFlight >= q.maxInFlight {
+			continue
+		}
+
+		item := w.items[0]
+		w.items[0] = nil
+		w.items = w.items[1:]
+		w.inFlight++
+		q.queued--
+
+		// the workspace goes to the end of the line
+		q.active = append(q.active[:i], q.active[i+1:]...)
+		if len(w.items) > 0 {
+			q.active = append(q.active, workspace)
+		}
+
+		delete(q.dirty, item)
+		q.processing[item] = workspace
+		queueDepth.WithLabelValues(q.name, workspace).Dec()
+		queueLatency.WithLabelValues(q.name, workspace).Observe(q.now().Sub(q.addedAt[item]).Seconds())
+		delete(q.addedAt, item)
+		return item, true
+	}
+	return nil, false
+}
+
+// Done marks the item as done processing, and if it has been marked as dirty again while it was being
+// processed, it will be re-added to the queue for re-processing.
+func (q *Queue) Done(item interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	workspace, found := q.processing[item]
+	if !found {
+		return
+	}
+	delete(q.processing, item)
+	w := q.workspaces[workspace]
+	w.inFlight--
+
+	if dirtyWorkspace, found := q.dirty[item]; found {
+		q.push(dirtyWorkspace, item)
+	}
+	if len(w.items) == 0 && w.inFlight == 0 {
+		delete(q.workspaces, workspace)
+	}
+	// a worker may wait for the concurrency of this workspace to drop, or for the queue to drain
+	q.cond.Broadcast()
+}
+
+// ShutDown makes the queue ignore all new items. Get returns shutdown = true once the queued items
+// are processed.
+func (q *Queue) ShutDown() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain is like ShutDown, but waits for the items being processed to be done.
+func (q *Queue) ShutDownWithDrain() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.shuttingDown = true
+	q.cond.Broadcast()
+	for len(q.processing) > 0 || q.queued > 0 {
+		q.cond.Wait()
+	}
+}
+
+// ShuttingDown returns whether the queue is shutting down.
+func (q *Queue) ShuttingDown() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.shuttingDown
+}
+
+// AddAfter adds the item after the given duration.
+func (q *Queue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() { q.Add(item) })
+}
+
+// AddRateLimited adds the item after the rate limiter says it's ok.
+func (q *Queue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+// Forget indicates that an item is finished being retried.
+func (q *Queue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// NumRequeues returns back how many times the item was requeued.
+func (q *Queue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
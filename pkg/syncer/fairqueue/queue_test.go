@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairqueue
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func workspaceOf(item interface{}) string {
+	return strings.SplitN(item.(string), "|", 2)[0]
+}
+
+func get(t *testing.T, q *Queue) string {
+	t.Helper()
+	item, shutdown := q.Get()
+	require.False(t, shutdown)
+	return item.(string)
+}
+
+func TestRoundRobin(t *testing.T) {
+	q := New("test", workspaceOf, 0)
+	for _, item := range []string{"noisy|a", "noisy|b", "noisy|c", "noisy|d", "quiet|a", "other|a", "other|b"} {
+		q.Add(item)
+	}
+	require.Equal(t, 7, q.Len())
+
+	var got []string
+	for q.Len() > 0 {
+		item := get(t, q)
+		got = append(got, item)
+		q.Done(item)
+	}
+	require.Equal(t, []string{"noisy|a", "quiet|a", "other|a", "noisy|b", "other|b", "noisy|c", "noisy|d"}, got)
+}
+
+func TestDeduplication(t *testing.T) {
+	q := New("test", workspaceOf, 0)
+	q.Add("ws|a")
+	q.Add("ws|a")
+	require.Equal(t, 1, q.Len())
+
+	item := get(t, q)
+	// added again while processing: queued again when done, but not handed out concurrently
+	q.Add(item)
+	require.Equal(t, 0, q.Len())
+	q.Done(item)
+	require.Equal(t, 1, q.Len())
+	require.Equal(t, "ws|a", get(t, q))
+}
+
+func TestWorkspaceConcurrency(t *testing.T) {
+	q := New("test", workspaceOf, 1)
+	q.Add("noisy|a")
+	q.Add("noisy|b")
+	q.Add("quiet|a")
+
+	first := get(t, q)
+	require.Equal(t, "noisy|a", first)
+	// noisy is at its concurrency limit
+	require.Equal(t, "quiet|a", get(t, q))
+
+	got := make(chan string)
+	go func() {
+		item, _ := q.Get()
+		got <- item.(string)
+	}()
+	select {
+	case item := <-got:
+		t.Fatalf("unexpected item %q while the workspace is at its concurrency limit", item)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	q.Done(first)
+	select {
+	case item := <-got:
+		require.Equal(t, "noisy|b", item)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the next item of the workspace was not handed out")
+	}
+}
+
+func TestShutDown(t *testing.T) {
+	q := New("test", workspaceOf, 0)
+	q.Add("ws|a")
+	q.ShutDown()
+	q.Add("ws|b")
+	require.True(t, q.ShuttingDown())
+
+	// queued items are still handed out
+	require.Equal(t, "ws|a", get(t, q))
+	q.Done("ws|a")
+	_, shutdown := q.Get()
+	require.True(t, shutdown)
+}
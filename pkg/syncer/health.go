@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// downstreamHealthTimeout bounds the time the heartbeat waits for the API server of the physical cluster.
+const downstreamHealthTimeout = 10 * time.Second
+
+// healthReporter collects the health of the syncer, reported in the SyncTarget status with the heartbeats.
+type healthReporter struct {
+	// downstream is a REST client of the API server of the physical cluster.
+	downstream  rest.Interface
+	resources   func() []workloadv1alpha1.ResourceHealth
+	controllers []*shared.ControllerHealth
+}
+
+func (r *healthReporter) health(ctx context.Context) *workloadv1alpha1.SyncerHealth {
+	health := &workloadv1alpha1.SyncerHealth{
+		Downstream: r.downstreamHealth(ctx),
+		Resources:  r.resources(),
+	}
+	for _, c := range r.controllers {
+		health.Controllers = append(health.Controllers, c.Health())
+	}
+	return health
+}
+
+func (r *healthReporter) downstreamHealth(ctx context.Context) workloadv1alpha1.DownstreamHealth {
+	ctx, cancel := context.WithTimeout(ctx, downstreamHealthTimeout)
+	defer cancel()
+
+	body, err := r.downstream.Get().AbsPath("/version").Do(ctx).Raw()
+	if err != nil {
+		return workloadv1alpha1.DownstreamHealth{Error: err.Error()}
+	}
+	var info apimachineryversion.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		// reachable, but the version is unknown
+		return workloadv1alpha1.DownstreamHealth{Reachable: true}
+	}
+	return workloadv1alpha1.DownstreamHealth{Reachable: true, Version: info.GitVersion}
+}
+
+// heartbeatPatch returns the JSON patch of the SyncTarget status setting the heartbeat time, and the version and
+// the health of the syncer.
+func heartbeatPatch(syncTargetUID string, heartbeat time.Time, syncerVersion string, health *workloadv1alpha1.SyncerHealth) ([]byte, error) {
+	return json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/uid", "value": syncTargetUID},
+		{"op": "replace", "path": "/status/lastSyncerHeartbeatTime", "value": heartbeat.Format(time.RFC3339)},
+		{"op": "add", "path": "/status/syncerVersion", "value": syncerVersion},
+		{"op": "add", "path": "/status/syncerHealth", "value": health},
+	})
+}
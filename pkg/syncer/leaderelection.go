@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/klog/v2"
+)
+
+// runWhenLeading runs the given function once this replica of the syncer is elected leader. The lock, by default
+// a Lease, is stored in the physical cluster. Until then, the replica is a hot standby: its informers are started
+// by the caller and kept in sync, so that it takes over quickly.
+//
+// The controllers of the syncer cannot be restarted, so the process exits when the leadership is lost.
+func runWhenLeading(ctx context.Context, config *componentbaseconfig.LeaderElectionConfiguration, kubeClient kubernetes.Interface, defaultName string, run func(ctx context.Context)) error {
+	logger := klog.FromContext(ctx)
+
+	name := config.ResourceName
+	if name == "" {
+		name = defaultName
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get the hostname for the leader election identity: %w", err)
+	}
+	identity := hostname + "_" + string(uuid.NewUUID())
+
+	lock, err := resourcelock.New(config.ResourceLock, config.ResourceNamespace, name,
+		kubeClient.CoreV1(), kubeClient.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: identity})
+	if err != nil {
+		return err
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: config.LeaseDuration.Duration,
+		RenewDeadline: config.RenewDeadline.Duration,
+		RetryPeriod:   config.RetryPeriod.Duration,
+		// let a standby take over immediately when the leader shuts down
+		ReleaseOnCancel: true,
+		Name:            name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logger.Info("started leading", "identity", identity)
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					// shutting down
+					return
+				}
+				logger.Error(nil, "lost the leadership, exiting", "identity", identity)
+				klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					logger.Info("waiting for the leadership as a standby", "leader", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("starting leader election", "lock", config.ResourceNamespace+"/"+name, "identity", identity)
+	go elector.Run(ctx)
+	return nil
+}
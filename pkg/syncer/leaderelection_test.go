@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+func TestRunWhenLeading(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := &componentbaseconfig.LeaderElectionConfiguration{
+		LeaderElect:       true,
+		LeaseDuration:     metav1.Duration{Duration: 2 * time.Second},
+		RenewDeadline:     metav1.Duration{Duration: time.Second},
+		RetryPeriod:       metav1.Duration{Duration: 100 * time.Millisecond},
+		ResourceLock:      resourcelock.LeasesResourceLock,
+		ResourceNamespace: "kcp-syncer",
+	}
+
+	start := func(ctx context.Context) chan struct{} {
+		leading := make(chan struct{})
+		require.NoError(t, runWhenLeading(ctx, config, client, "kcp-syncer-us-west1", func(ctx context.Context) {
+			close(leading)
+		}))
+		return leading
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leader := start(ctx)
+	select {
+	case <-leader:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the first replica did not become leader")
+	}
+
+	standbyCtx, standbyCancel := context.WithCancel(context.Background())
+	defer standbyCancel()
+	standby := start(standbyCtx)
+	select {
+	case <-standby:
+		t.Fatal("the standby replica must not lead while the leader renews the lease")
+	case <-time.After(time.Second):
+	}
+
+	// the leader releases the lease when it stops
+	cancel()
+	select {
+	case <-standby:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the standby replica did not take over")
+	}
+
+	lease, err := client.CoordinationV1().Leases("kcp-syncer").Get(context.Background(), "kcp-syncer-us-west1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, lease.Spec.HolderIdentity)
+}
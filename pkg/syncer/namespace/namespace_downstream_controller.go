@@ -38,6 +38,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
@@ -47,7 +48,8 @@ const (
 )
 
 type DownstreamController struct {
-	queue workqueue.RateLimitingInterface
+	queue  workqueue.RateLimitingInterface
+	health *shared.ControllerHealth
 
 	deleteDownstreamNamespace func(ctx context.Context, namespace string) error
 	upstreamNamespaceExists   func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error)
@@ -89,6 +91,7 @@ func NewDownstreamController(
 		syncTargetUID:       syncTargetUID,
 		syncTargetKey:       syncTargetKey,
 	}
+	c.health = shared.NewControllerHealth(downstreamControllerName, c.queue)
 
 	// Those handlers are for start/resync cases, in case a namespace deletion event is missed, these handlers
 	// will make sure that we cleanup the namespace in downstream after restart/resync.
@@ -156,7 +159,9 @@ func (c *DownstreamController) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, namespaceKey); err != nil {
+	err := c.process(ctx, namespaceKey)
+	c.health.Observe(err)
+	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", downstreamControllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -166,3 +171,8 @@ func (c *DownstreamController) processNextWorkItem(ctx context.Context) bool {
 
 	return true
 }
+
+// Health returns the health of the controller, reported with the heartbeats of the syncer.
+func (c *DownstreamController) Health() *shared.ControllerHealth {
+	return c.health
+}
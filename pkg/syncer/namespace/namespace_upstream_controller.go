@@ -50,7 +50,8 @@ const (
 )
 
 type UpstreamController struct {
-	queue workqueue.RateLimitingInterface
+	queue  workqueue.RateLimitingInterface
+	health *shared.ControllerHealth
 
 	deleteDownstreamNamespace                  func(ctx context.Context, namespace string) error
 	upstreamNamespaceExists                    func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error)
@@ -104,6 +105,7 @@ func NewUpstreamController(
 		syncTargetUID:       syncTargetUID,
 		syncTargetKey:       syncTargetKey,
 	}
+	c.health = shared.NewControllerHealth(upstreamControllerName, c.queue)
 
 	// React when there's a namespace deletion upstream.
 	upstreamInformers.ForResource(namespaceGVR).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -174,7 +176,9 @@ func (c *UpstreamController) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, namespaceKey); err != nil {
+	err := c.process(ctx, namespaceKey)
+	c.health.Observe(err)
+	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", upstreamControllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -185,6 +189,11 @@ func (c *UpstreamController) processNextWorkItem(ctx context.Context) bool {
 	return true
 }
 
+// Health returns the health of the controller, reported with the heartbeats of the syncer.
+func (c *UpstreamController) Health() *shared.ControllerHealth {
+	return c.health
+}
+
 // indexByNamespaceLocator is a cache.IndexFunc that indexes namespaces by the namespaceLocator annotation.
 func indexByNamespaceLocator(obj interface{}) ([]string, error) {
 	metaObj, ok := obj.(metav1.Object)
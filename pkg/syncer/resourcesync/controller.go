@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -71,6 +72,11 @@ type SyncerInformerFactory interface {
 	AddUpstreamEventHandler(handler ResourceEventHandlerPerGVR)
 	AddDownstreamEventHandler(handler ResourceEventHandlerPerGVR)
 	InformerForResource(gvr schema.GroupVersionResource) (*SyncerInformer, bool)
+	// ClusterScopedResourceAllowed returns true if the cluster-scoped resource is in the
+	// allow-list of the SyncTarget.
+	ClusterScopedResourceAllowed(gr schema.GroupResource) bool
+	// ResourceHealth returns the synced resources, sorted, with the state of their informers.
+	ResourceHealth() []workloadv1alpha1.ResourceHealth
 	Start(ctx context.Context, numThreads int)
 }
 
@@ -95,8 +101,9 @@ type Controller struct {
 	syncTargetLister    workloadlisters.SyncTargetLister
 	kcpClusterClient    *kcpclient.Cluster
 
-	syncerInformerMap map[schema.GroupVersionResource]*SyncerInformer
-	mutex             sync.RWMutex
+	syncerInformerMap      map[schema.GroupVersionResource]*SyncerInformer
+	clusterScopedResources map[schema.GroupResource]bool
+	mutex                  sync.RWMutex
 }
 
 func NewController(
@@ -195,6 +202,51 @@ func (c *Controller) InformerForResource(gvr schema.GroupVersionResource) (*Sync
 	return nil, false
 }
 
+func (c *Controller) ClusterScopedResourceAllowed(gr schema.GroupResource) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.clusterScopedResources[gr]
+}
+
+func (c *Controller) ResourceHealth() []workloadv1alpha1.ResourceHealth {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	health := make([]workloadv1alpha1.ResourceHealth, 0, len(c.syncerInformerMap))
+	for gvr, informer := range c.syncerInformerMap {
+		health = append(health, workloadv1alpha1.ResourceHealth{
+			Group:            gvr.Group,
+			Version:          gvr.Version,
+			Resource:         gvr.Resource,
+			UpstreamSynced:   informer.UpstreamInformer.Informer().HasSynced(),
+			DownstreamSynced: informer.DownstreamInformer.Informer().HasSynced(),
+		})
+	}
+	sort.Slice(health, func(i, j int) bool {
+		if health[i].Group != health[j].Group {
+			return health[i].Group < health[j].Group
+		}
+		if health[i].Resource != health[j].Resource {
+			return health[i].Resource < health[j].Resource
+		}
+		return health[i].Version < health[j].Version
+	})
+	return health
+}
+
+func (c *Controller) setClusterScopedResources(syncTarget *workloadv1alpha1.SyncTarget) {
+	clusterScopedResources := map[schema.GroupResource]bool{}
+	if syncTarget != nil {
+		for _, gr := range syncTarget.Spec.ClusterScopedResources {
+			clusterScopedResources[schema.GroupResource{Group: gr.Group, Resource: gr.Resource}] = true
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clusterScopedResources = clusterScopedResources
+}
+
 func (c *Controller) startWorker(ctx context.Context) {
 	for c.processNextWorkItem(ctx) {
 	}
@@ -240,6 +292,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 
 	syncTarget, err := c.syncTargetLister.Get(indexKey)
 	if apierrors.IsNotFound(err) {
+		c.setClusterScopedResources(nil)
 		c.stopUnusedSyncerInformers(ctx, map[schema.GroupVersionResource]bool{})
 		return nil
 	}
@@ -252,6 +305,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		return nil
 	}
 
+	c.setClusterScopedResources(syncTarget)
 	requiredGVRs := getAllGVRs(syncTarget)
 
 	var errs []error
@@ -414,6 +468,18 @@ func (c *Controller) startSyncerInformer(ctx context.Context, gvr schema.GroupVe
 	}
 }
 
+// SyncedGVRs returns the sorted resources synced to the SyncTarget.
+func SyncedGVRs(synctarget *workloadv1alpha1.SyncTarget) []schema.GroupVersionResource {
+	var gvrs []schema.GroupVersionResource
+	for gvr := range getAllGVRs(synctarget) {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		return gvrs[i].String() < gvrs[j].String()
+	})
+	return gvrs
+}
+
 func getAllGVRs(synctarget *workloadv1alpha1.SyncTarget) map[schema.GroupVersionResource]bool {
 	// TODO(jmprusi): Added Configmaps and Secrets to the default syncing, but we should figure out
 	//                a way to avoid doing that: https://github.com/kcp-dev/kcp/issues/727
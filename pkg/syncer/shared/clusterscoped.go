@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/martinlindhe/base36"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClusterScopedReference is a field of a namespaced resource referencing a cluster-scoped resource by name.
+type ClusterScopedReference struct {
+	// Resource is the referenced cluster-scoped resource.
+	Resource schema.GroupVersionResource
+	// Fields are the paths of the fields holding the name of the referenced object.
+	Fields [][]string
+}
+
+// ClusterScopedReferences are the references of namespaced resources to cluster-scoped resources that
+// are rewritten by the syncer when the referenced object is synced from the same workspace.
+var ClusterScopedReferences = map[schema.GroupResource][]ClusterScopedReference{
+	{Resource: "pods"}: {
+		{Resource: priorityClassesGVR, Fields: [][]string{{"spec", "priorityClassName"}}},
+	},
+	{Group: "apps", Resource: "deployments"}: {
+		{Resource: priorityClassesGVR, Fields: [][]string{{"spec", "template", "spec", "priorityClassName"}}},
+	},
+	{Group: "apps", Resource: "statefulsets"}: {
+		{Resource: priorityClassesGVR, Fields: [][]string{{"spec", "template", "spec", "priorityClassName"}}},
+	},
+	{Group: "apps", Resource: "daemonsets"}: {
+		{Resource: priorityClassesGVR, Fields: [][]string{{"spec", "template", "spec", "priorityClassName"}}},
+	},
+	{Group: "batch", Resource: "jobs"}: {
+		{Resource: priorityClassesGVR, Fields: [][]string{{"spec", "template", "spec", "priorityClassName"}}},
+	},
+	{Group: "networking.k8s.io", Resource: "ingresses"}: {
+		{Resource: ingressClassesGVR, Fields: [][]string{{"spec", "ingressClassName"}}},
+	},
+	{Resource: "persistentvolumeclaims"}: {
+		{Resource: storageClassesGVR, Fields: [][]string{{"spec", "storageClassName"}}},
+	},
+}
+
+var (
+	priorityClassesGVR = schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+	ingressClassesGVR  = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"}
+	storageClassesGVR  = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+)
+
+// ClusterScopedNamePrefix returns the prefix of the names of the cluster-scoped objects synced
+// from the given workspace to a physical cluster. The encoding is repeatable.
+func ClusterScopedNamePrefix(workspace logicalcluster.Name) string {
+	hash := sha256.Sum224([]byte(workspace.String()))
+	base36hash := strings.ToLower(base36.EncodeBytes(hash[:]))
+	return fmt.Sprintf("kcp-%s-", base36hash[:12])
+}
+
+// PhysicalClusterScopedName returns the name of the cluster-scoped object with the given name,
+// synced from the given workspace, on a physical cluster.
+func PhysicalClusterScopedName(workspace logicalcluster.Name, name string) string {
+	return ClusterScopedNamePrefix(workspace) + name
+}
+
+// UpstreamClusterScopedName returns the upstream name of the cluster-scoped object with the given name
+// on a physical cluster, synced from the given workspace. It returns false if the name does not carry
+// the prefix of the workspace.
+func UpstreamClusterScopedName(workspace logicalcluster.Name, downstreamName string) (string, bool) {
+	prefix := ClusterScopedNamePrefix(workspace)
+	if !strings.HasPrefix(downstreamName, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(downstreamName, prefix), true
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhysicalClusterScopedName(t *testing.T) {
+	workspace := logicalcluster.New("root:org:ws")
+	otherWorkspace := logicalcluster.New("root:org:other")
+
+	name := PhysicalClusterScopedName(workspace, "high-priority")
+	require.True(t, strings.HasPrefix(name, "kcp-"), "name %q should start with kcp-", name)
+	require.True(t, strings.HasSuffix(name, "-high-priority"), "name %q should end with the upstream name", name)
+	require.Equal(t, name, PhysicalClusterScopedName(workspace, "high-priority"), "encoding should be repeatable")
+	require.NotEqual(t, name, PhysicalClusterScopedName(otherWorkspace, "high-priority"), "workspaces should get different names")
+
+	upstreamName, ok := UpstreamClusterScopedName(workspace, name)
+	require.True(t, ok)
+	require.Equal(t, "high-priority", upstreamName)
+
+	_, ok = UpstreamClusterScopedName(otherWorkspace, name)
+	require.False(t, ok, "name should not be mapped to another workspace")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// QueueLengther is the part of a work queue reported in the health of a controller.
+type QueueLengther interface {
+	Len() int
+}
+
+// ControllerHealth tracks the work queue and the outcome of the reconciliations of a syncer
+// controller. It is reported in the status of the SyncTarget with the heartbeats of the syncer.
+type ControllerHealth struct {
+	name  string
+	queue QueueLengther
+
+	lock              sync.Mutex
+	errors            int64
+	consecutiveErrors int64
+	lastSuccess       time.Time
+}
+
+// NewControllerHealth returns the health of the controller with the given name and work queue.
+func NewControllerHealth(name string, queue QueueLengther) *ControllerHealth {
+	return &ControllerHealth{
+		name:  name,
+		queue: queue,
+	}
+}
+
+// Observe records the outcome of a reconciliation.
+func (h *ControllerHealth) Observe(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err != nil {
+		h.errors++
+		h.consecutiveErrors++
+		return
+	}
+	h.consecutiveErrors = 0
+	h.lastSuccess = time.Now()
+}
+
+// Health returns the current health of the controller.
+func (h *ControllerHealth) Health() workloadv1alpha1.ControllerHealth {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	health := workloadv1alpha1.ControllerHealth{
+		Name:              h.name,
+		QueueDepth:        int64(h.queue.Len()),
+		Errors:            h.errors,
+		ConsecutiveErrors: h.consecutiveErrors,
+	}
+	if !h.lastSuccess.IsZero() {
+		lastSuccess := metav1.NewTime(h.lastSuccess)
+		health.LastSuccessTime = &lastSuccess
+	}
+	return health
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue int
+
+func (q fakeQueue) Len() int { return int(q) }
+
+func TestControllerHealth(t *testing.T) {
+	h := NewControllerHealth("test", fakeQueue(3))
+
+	health := h.Health()
+	require.Equal(t, "test", health.Name)
+	require.Equal(t, int64(3), health.QueueDepth)
+	require.Nil(t, health.LastSuccessTime)
+
+	h.Observe(errors.New("failed"))
+	h.Observe(errors.New("failed"))
+	health = h.Health()
+	require.Equal(t, int64(2), health.Errors)
+	require.Equal(t, int64(2), health.ConsecutiveErrors)
+	require.Nil(t, health.LastSuccessTime)
+
+	h.Observe(nil)
+	h.Observe(errors.New("failed"))
+	health = h.Health()
+	require.Equal(t, int64(3), health.Errors)
+	require.Equal(t, int64(1), health.ConsecutiveErrors)
+	require.NotNil(t, health.LastSuccessTime)
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package shared
 
 import (
+	"sort"
 	"strings"
 
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
@@ -37,6 +43,29 @@ func DeprecatedGetAssignedSyncTarget(labels map[string]string) string {
 	return ""
 }
 
+// SyncTargetKeys returns the sorted keys of the sync targets a resource with the given labels
+// is in Sync state for.
+func SyncTargetKeys(labels map[string]string) []string {
+	var keys []string
+	for k, v := range labels {
+		if strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) && v == string(workloadv1alpha1.ResourceStateSync) {
+			keys = append(keys, strings.TrimPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsUpsynced returns whether a resource with the given labels is upsynced from a sync target.
+func IsUpsynced(labels map[string]string) bool {
+	for k, v := range labels {
+		if strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) && v == string(workloadv1alpha1.ResourceStateUpsync) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetUpstreamResourceName returns the name with which the resource is known upstream.
 func GetUpstreamResourceName(downstreamResourceGVR schema.GroupVersionResource, downstreamResourceName string) string {
 	configMapGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
@@ -50,3 +79,21 @@ func GetUpstreamResourceName(downstreamResourceGVR schema.GroupVersionResource,
 	}
 	return downstreamResourceName
 }
+
+// GetUpstreamObject returns the upstream object with the given name from the lister. An empty namespace
+// means that the object is cluster-scoped.
+func GetUpstreamObject(lister cache.GenericLister, logicalClusterName logicalcluster.Name, namespace, name string) (runtime.Object, error) {
+	if namespace == "" {
+		return lister.Get(clusters.ToClusterAwareKey(logicalClusterName, name))
+	}
+	return lister.ByNamespace(namespace).Get(clusters.ToClusterAwareKey(logicalClusterName, name))
+}
+
+// IsValidStatusAggregationStrategy returns whether the given status aggregation strategy is known.
+func IsValidStatusAggregationStrategy(strategy workloadv1alpha1.StatusAggregationStrategy) bool {
+	switch strategy {
+	case workloadv1alpha1.StatusAggregationAggregate, workloadv1alpha1.StatusAggregationPrimary, workloadv1alpha1.StatusAggregationNone:
+		return true
+	}
+	return false
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package shared
 
 import (
+	"reflect"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -81,3 +82,39 @@ func TestGetUpstreamResourceName(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncTargetKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{
+			name: "no labels",
+		},
+		{
+			name: "pending sync target is ignored",
+			labels: map[string]string{
+				"state.workload.kcp.dev/b": "Sync",
+				"state.workload.kcp.dev/c": "",
+				"foo":                      "Sync",
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "keys are sorted",
+			labels: map[string]string{
+				"state.workload.kcp.dev/b": "Sync",
+				"state.workload.kcp.dev/a": "Sync",
+			},
+			want: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SyncTargetKeys(tt.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SyncTargetKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
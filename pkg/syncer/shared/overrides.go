@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// ParseSpecOverrides decodes the value of the workload.kcp.dev/spec-overrides annotation.
+// Unknown fields are rejected.
+func ParseSpecOverrides(value string) ([]workloadv1alpha1.SpecOverride, error) {
+	var overrides []workloadv1alpha1.SpecOverride
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&overrides); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// ValidateSpecOverrides validates the decoded value of the workload.kcp.dev/spec-overrides annotation.
+func ValidateSpecOverrides(overrides []workloadv1alpha1.SpecOverride, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, override := range overrides {
+		overridePath := fldPath.Index(i)
+
+		switch {
+		case override.SyncTargetKey == "" && override.SyncTargetSelector == nil:
+			errs = append(errs, field.Required(overridePath, "one of syncTargetKey or syncTargetSelector is required"))
+		case override.SyncTargetKey != "" && override.SyncTargetSelector != nil:
+			errs = append(errs, field.Invalid(overridePath, override.SyncTargetKey, "only one of syncTargetKey or syncTargetSelector may be set"))
+		case override.SyncTargetSelector != nil:
+			errs = append(errs, metav1validation.ValidateLabelSelector(override.SyncTargetSelector, overridePath.Child("syncTargetSelector"))...)
+		}
+
+		if override.Replicas == nil && len(override.Containers) == 0 {
+			errs = append(errs, field.Required(overridePath, "one of replicas or containers is required"))
+		}
+		if override.Replicas != nil && *override.Replicas < 0 {
+			errs = append(errs, field.Invalid(overridePath.Child("replicas"), *override.Replicas, "must be greater than or equal to 0"))
+		}
+
+		containerNames := sets.NewString()
+		for j, container := range override.Containers {
+			containerPath := overridePath.Child("containers").Index(j)
+			if container.Name == "" {
+				errs = append(errs, field.Required(containerPath.Child("name"), ""))
+			} else if containerNames.Has(container.Name) {
+				errs = append(errs, field.Duplicate(containerPath.Child("name"), container.Name))
+			}
+			containerNames.Insert(container.Name)
+
+			for k, env := range container.Env {
+				if env.Name == "" {
+					errs = append(errs, field.Required(containerPath.Child("env").Index(k).Child("name"), ""))
+				}
+			}
+			if container.Resources != nil {
+				errs = append(errs, validateResourceList(container.Resources.Requests, containerPath.Child("resources", "requests"))...)
+				errs = append(errs, validateResourceList(container.Resources.Limits, containerPath.Child("resources", "limits"))...)
+			}
+		}
+	}
+	return errs
+}
+
+func validateResourceList(resources corev1.ResourceList, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for name, quantity := range resources {
+		if quantity.Sign() < 0 {
+			errs = append(errs, field.Invalid(fldPath.Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+		}
+	}
+	return errs
+}
+
+// SpecOverrideApplies returns true if the override selects the sync target with the given key and labels.
+func SpecOverrideApplies(override workloadv1alpha1.SpecOverride, syncTargetKey string, syncTargetLabels map[string]string) (bool, error) {
+	if override.SyncTargetKey != "" {
+		return override.SyncTargetKey == syncTargetKey, nil
+	}
+	if override.SyncTargetSelector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(override.SyncTargetSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(syncTargetLabels)), nil
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/martinlindhe/base36"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// SyncerIDPrefix is the prefix of the syncer IDs, and of the namespace the syncer is deployed to.
+const SyncerIDPrefix = "kcp-syncer-"
+
+// GetSyncerID returns a unique ID for a syncer derived from the name and its UID. It's
+// a valid DNS segment and can be used as namespace or object names. It is also the name
+// of the service account the syncer authenticates as.
+func GetSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
+	syncerHash := sha256.Sum224([]byte(syncTarget.UID))
+	base36hash := strings.ToLower(base36.EncodeBytes(syncerHash[:]))
+	return fmt.Sprintf("%s%s-%s", SyncerIDPrefix, syncTarget.Name, base36hash[:8])
+}
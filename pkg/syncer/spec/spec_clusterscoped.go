@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// processClusterScoped syncs a cluster-scoped upstream object. The object is only synced if its resource is
+// in the allow-list of the SyncTarget, and it is renamed downstream with the name prefix of its workspace.
+// Downstream objects with the same name that are not synced from the same workspace by this SyncTarget are
+// never touched, the conflict is reported in the conflict.workload.kcp.dev/<sync-target-key> annotation upstream.
+func (c *Controller) processClusterScoped(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, name string) error {
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		return nil
+	}
+	downstreamName := shared.PhysicalClusterScopedName(clusterName, name)
+
+	obj, exists, err := syncerInformer.UpstreamInformer.Informer().GetIndexer().GetByKey(clusterName.String() + "|" + name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// deleted upstream => delete downstream, if it is ours
+		if conflict, err := c.clusterScopedConflict(ctx, gvr, clusterName, downstreamName); err != nil || conflict != "" {
+			return err
+		}
+		klog.Infof("Deleting downstream GVR %q object %s for upstream cluster %q", gvr.String(), downstreamName, clusterName)
+		if err := c.downstreamClient.Resource(gvr).Delete(ctx, downstreamName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	upstreamObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+
+	if !c.syncerInformers.ClusterScopedResourceAllowed(gvr.GroupResource()) {
+		klog.V(2).Infof("Cluster-scoped resource %s is not in the allow-list of SyncTarget %s, not syncing %s|%s", gvr.GroupResource(), c.syncTargetName, clusterName, name)
+		if conflict, err := c.clusterScopedConflict(ctx, gvr, clusterName, downstreamName); err != nil || conflict != "" {
+			return err
+		}
+		if err := c.downstreamClient.Resource(gvr).Delete(ctx, downstreamName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return c.removeSyncerFinalizer(ctx, gvr, upstreamObj)
+	}
+
+	conflict, err := c.clusterScopedConflict(ctx, gvr, clusterName, downstreamName)
+	if err != nil {
+		return err
+	}
+	if updated, err := c.updateConflictAnnotation(ctx, gvr, upstreamObj, conflict); err != nil || updated {
+		// The successful update of the upstream resource annotation will trigger a new reconcile
+		return err
+	}
+	if conflict != "" {
+		klog.Errorf("Not syncing %s %s|%s to SyncTarget %s: %s", gvr.Resource, clusterName, name, c.syncTargetName, conflict)
+		if upstreamObj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+c.syncTargetKey] != "" {
+			return shared.EnsureUpstreamFinalizerRemoved(ctx, gvr, syncerInformer.UpstreamInformer, c.upstreamClient, "", c.syncTargetKey, clusterName, name)
+		}
+		return nil
+	}
+
+	if added, err := c.ensureSyncerFinalizer(ctx, gvr, upstreamObj); added {
+		// The successful update of the upstream resource finalizer will trigger a new reconcile
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return c.applyToDownstream(ctx, gvr, "", upstreamObj)
+}
+
+// clusterScopedConflict returns a message describing the conflict if the cluster-scoped downstream object with
+// the given name exists, but is not synced from the given workspace by this SyncTarget. It returns an empty
+// string if there is no conflict.
+func (c *Controller) clusterScopedConflict(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, downstreamName string) (string, error) {
+	if errs := validation.IsDNS1123Subdomain(downstreamName); len(errs) > 0 {
+		return fmt.Sprintf("downstream name %s is invalid: %s", downstreamName, errs[0]), nil
+	}
+
+	var downstreamObj *unstructured.Unstructured
+	if syncerInformer, ok := c.syncerInformers.InformerForResource(gvr); ok {
+		obj, exists, err := syncerInformer.DownstreamInformer.Informer().GetIndexer().GetByKey(downstreamName)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			downstreamObj, _ = obj.(*unstructured.Unstructured)
+		}
+	}
+	if downstreamObj == nil {
+		// Objects of other SyncTargets, or not created by a syncer, are not in the informer.
+		obj, err := c.downstreamClient.Resource(gvr).Get(ctx, downstreamName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		downstreamObj = obj
+	}
+
+	desiredLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, "")
+	locator, exists, err := shared.LocatorFromAnnotations(downstreamObj.GetAnnotations())
+	if err != nil || !exists {
+		return fmt.Sprintf("%s %s already exists downstream and is not synced by kcp", gvr.Resource, downstreamName), nil //nolint:nilerr
+	}
+	if !reflect.DeepEqual(desiredLocator, *locator) {
+		return fmt.Sprintf("%s %s already exists downstream and is synced from workspace %s by SyncTarget %s|%s", gvr.Resource, downstreamName, locator.Workspace, locator.SyncTarget.Workspace, locator.SyncTarget.Name), nil
+	}
+	return "", nil
+}
+
+// updateConflictAnnotation sets the conflict.workload.kcp.dev/<sync-target-key> annotation of the upstream object
+// to the given conflict message, or removes it if the message is empty. It returns true if the object was updated.
+func (c *Controller) updateConflictAnnotation(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, conflict string) (bool, error) {
+	key := workloadv1alpha1.ClusterScopedConflictAnnotationPrefix + c.syncTargetKey
+	existing, found := upstreamObj.GetAnnotations()[key]
+	if existing == conflict && (found || conflict == "") {
+		return false, nil
+	}
+
+	upstreamObjCopy := upstreamObj.DeepCopy()
+	annotations := upstreamObjCopy.GetAnnotations()
+	if conflict == "" {
+		delete(annotations, key)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = conflict
+	}
+	upstreamObjCopy.SetAnnotations(annotations)
+
+	logicalCluster := logicalcluster.From(upstreamObj)
+	if _, err := c.upstreamClient.Cluster(logicalCluster).Resource(gvr).Update(ctx, upstreamObjCopy, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed updating conflict annotation of resource %s|%s: %v", logicalCluster, upstreamObj.GetName(), err)
+		return false, err
+	}
+	return true, nil
+}
+
+// removeSyncerFinalizer removes the finalizer of this SyncTarget from the upstream object, if present.
+func (c *Controller) removeSyncerFinalizer(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured) error {
+	var finalizers []string
+	for _, finalizer := range upstreamObj.GetFinalizers() {
+		if finalizer != shared.SyncerFinalizerNamePrefix+c.syncTargetKey {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	if len(finalizers) == len(upstreamObj.GetFinalizers()) {
+		return nil
+	}
+
+	upstreamObjCopy := upstreamObj.DeepCopy()
+	upstreamObjCopy.SetFinalizers(finalizers)
+	logicalCluster := logicalcluster.From(upstreamObj)
+	if _, err := c.upstreamClient.Cluster(logicalCluster).Resource(gvr).Namespace(upstreamObj.GetNamespace()).Update(ctx, upstreamObjCopy, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed removing finalizer upstream on resource %s|%s/%s: %v", logicalCluster, upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		return err
+	}
+	return nil
+}
+
+// rewriteClusterScopedReferences rewrites the references of the downstream object to cluster-scoped objects,
+// e.g. the priorityClassName of pods, to the downstream name of the referenced object, if the referenced
+// object is synced from the same workspace. References to objects only existing downstream are kept.
+func (c *Controller) rewriteClusterScopedReferences(gvr schema.GroupVersionResource, upstreamObj, downstreamObj *unstructured.Unstructured) error {
+	clusterName := logicalcluster.From(upstreamObj)
+	for _, reference := range shared.ClusterScopedReferences[gvr.GroupResource()] {
+		if !c.syncerInformers.ClusterScopedResourceAllowed(reference.Resource.GroupResource()) {
+			continue
+		}
+		syncerInformer, ok := c.syncerInformers.InformerForResource(reference.Resource)
+		if !ok {
+			continue
+		}
+		for _, fields := range reference.Fields {
+			name, found, err := unstructured.NestedString(downstreamObj.Object, fields...)
+			if err != nil {
+				return err
+			}
+			if !found || name == "" {
+				continue
+			}
+			if _, exists, err := syncerInformer.UpstreamInformer.Informer().GetIndexer().GetByKey(clusterName.String() + "|" + name); err != nil {
+				return err
+			} else if !exists {
+				continue
+			}
+			if err := unstructured.SetNestedField(downstreamObj.Object, shared.PhysicalClusterScopedName(clusterName, name), fields...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+var priorityClassesGVR = schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+
+func TestClusterScopedConflict(t *testing.T) {
+	workspace := logicalcluster.New("root:org:ws")
+	downstreamName := shared.PhysicalClusterScopedName(workspace, "high-priority")
+	locator := func(workspace string, syncTargetUID string) string {
+		return `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"` + syncTargetUID + `"},"workspace":"` + workspace + `","namespace":""}`
+	}
+
+	tests := map[string]struct {
+		downstreamName string
+		downstream     *unstructured.Unstructured
+		wantConflict   string
+	}{
+		"no downstream object": {
+			downstreamName: downstreamName,
+		},
+		"downstream object synced from the workspace": {
+			downstreamName: downstreamName,
+			downstream:     priorityClass(downstreamName, map[string]string{shared.NamespaceLocatorAnnotation: locator("root:org:ws", "syncTargetUID")}),
+		},
+		"downstream object not synced by kcp": {
+			downstreamName: downstreamName,
+			downstream:     priorityClass(downstreamName, nil),
+			wantConflict:   "already exists downstream and is not synced by kcp",
+		},
+		"downstream object synced from another workspace": {
+			downstreamName: downstreamName,
+			downstream:     priorityClass(downstreamName, map[string]string{shared.NamespaceLocatorAnnotation: locator("root:org:other", "syncTargetUID")}),
+			wantConflict:   "already exists downstream and is synced from workspace root:org:other",
+		},
+		"downstream object synced by another SyncTarget": {
+			downstreamName: downstreamName,
+			downstream:     priorityClass(downstreamName, map[string]string{shared.NamespaceLocatorAnnotation: locator("root:org:ws", "otherUID")}),
+			wantConflict:   "already exists downstream and is synced from workspace root:org:ws",
+		},
+		"downstream name too long": {
+			downstreamName: shared.PhysicalClusterScopedName(workspace, strings.Repeat("a", 250)),
+			wantConflict:   "is invalid",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tc.downstream != nil {
+				objects = append(objects, tc.downstream)
+			}
+			c := newClusterScopedController(dynamicfake.NewSimpleDynamicClient(scheme, objects...))
+
+			conflict, err := c.clusterScopedConflict(context.Background(), priorityClassesGVR, workspace, tc.downstreamName)
+			require.NoError(t, err)
+			if tc.wantConflict == "" {
+				require.Empty(t, conflict)
+			} else {
+				require.Contains(t, conflict, tc.wantConflict)
+			}
+		})
+	}
+}
+
+func TestRewriteClusterScopedReferences(t *testing.T) {
+	workspace := logicalcluster.New("root:org:ws")
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	tests := map[string]struct {
+		allowed           bool
+		priorityClassName string
+		want              string
+	}{
+		"synced priority class is rewritten": {
+			allowed:           true,
+			priorityClassName: "high-priority",
+			want:              shared.PhysicalClusterScopedName(workspace, "high-priority"),
+		},
+		"downstream priority class is kept": {
+			allowed:           true,
+			priorityClassName: "system-cluster-critical",
+			want:              "system-cluster-critical",
+		},
+		"priority classes not in the allow-list are kept": {
+			priorityClassName: "high-priority",
+			want:              "high-priority",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := newClusterScopedController(dynamicfake.NewSimpleDynamicClient(scheme))
+			c.syncerInformers.(*fakeSyncerInformers).clusterScopedResources[priorityClassesGVR.GroupResource()] = tc.allowed
+			upstreamPriorityClass := priorityClass("high-priority", map[string]string{logicalcluster.AnnotationKey: workspace.String()})
+			require.NoError(t, c.syncerInformers.(*fakeSyncerInformers).upstreamInformer.Informer().GetIndexer().Add(upstreamPriorityClass))
+
+			upstream := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			upstream.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: workspace.String()})
+			require.NoError(t, unstructured.SetNestedField(upstream.Object, tc.priorityClassName, "spec", "template", "spec", "priorityClassName"))
+			downstream := upstream.DeepCopy()
+
+			require.NoError(t, c.rewriteClusterScopedReferences(deploymentsGVR, upstream, downstream))
+			got, _, err := unstructured.NestedString(downstream.Object, "spec", "template", "spec", "priorityClassName")
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func newClusterScopedController(downstreamClient *dynamicfake.FakeDynamicClient) *Controller {
+	upstreamInformers := dynamicinformer.NewDynamicSharedInformerFactory(dynamicfake.NewSimpleDynamicClient(scheme), time.Hour)
+	downstreamInformers := dynamicinformer.NewDynamicSharedInformerFactory(downstreamClient, time.Hour)
+	syncerInformers := newFakeSyncerInformers(priorityClassesGVR, upstreamInformers, downstreamInformers)
+	syncerInformers.clusterScopedResources = map[schema.GroupResource]bool{}
+
+	return &Controller{
+		downstreamClient:    downstreamClient,
+		syncerInformers:     syncerInformers,
+		syncTargetName:      "us-west1",
+		syncTargetWorkspace: logicalcluster.New("root:org:ws"),
+		syncTargetUID:       "syncTargetUID",
+	}
+}
+
+func priorityClass(name string, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("scheduling.k8s.io/v1")
+	u.SetKind("PriorityClass")
+	u.SetName(name)
+	u.SetAnnotations(annotations)
+	return u
+}
@@ -27,6 +27,7 @@ import (
 	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -40,7 +41,9 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/syncer/fairqueue"
 	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
@@ -54,7 +57,8 @@ const (
 )
 
 type Controller struct {
-	queue workqueue.RateLimitingInterface
+	queue  workqueue.RateLimitingInterface
+	health *shared.ControllerHealth
 
 	mutators mutatorGvrMap
 
@@ -67,14 +71,18 @@ type Controller struct {
 	syncTargetWorkspace       logicalcluster.Name
 	syncTargetUID             types.UID
 	syncTargetKey             string
+	syncTargetLister          workloadlisters.SyncTargetLister
 	advancedSchedulingEnabled bool
+
+	now func() time.Time
 }
 
 func NewSpecSyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, syncTargetKey string, upstreamURL *url.URL, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncerInformers resourcesync.SyncerInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncerInformers resourcesync.SyncerInformerFactory, syncTargetUID types.UID, syncTargetLister workloadlisters.SyncTargetLister,
+	workspaceConcurrency int) (*Controller, error) {
 
 	c := Controller{
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		queue: fairqueue.New(controllerName, workspaceOfQueueKey, workspaceConcurrency),
 
 		upstreamClient:   upstreamClient,
 		downstreamClient: downstreamClient,
@@ -84,8 +92,12 @@ func NewSpecSyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, sync
 		syncTargetWorkspace:       syncTargetWorkspace,
 		syncTargetUID:             syncTargetUID,
 		syncTargetKey:             syncTargetKey,
+		syncTargetLister:          syncTargetLister,
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
+
+		now: time.Now,
 	}
+	c.health = shared.NewControllerHealth(controllerName, c.queue)
 
 	namespaceGVR := schema.GroupVersionResource{
 		Group:    "",
@@ -139,6 +151,39 @@ func NewSpecSyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, sync
 					}
 					klog.V(3).InfoS("processing delete event", "key", key, "gvr", gvr, "namespace", namespace, "name", name)
 
+					if namespace == "" {
+						// Cluster-scoped objects carry the locator themselves.
+						if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+							obj = tombstone.Obj
+						}
+						objMeta, err := meta.Accessor(obj)
+						if err != nil {
+							utilruntime.HandleError(err)
+							return
+						}
+						locator, found, err := shared.LocatorFromAnnotations(objMeta.GetAnnotations())
+						if err != nil {
+							utilruntime.HandleError(err)
+							return
+						}
+						if !found {
+							utilruntime.HandleError(fmt.Errorf("unable to find the locator annotation in %s %s", gvr.Resource, name))
+							return
+						}
+						upstreamName, ok := shared.UpstreamClusterScopedName(locator.Workspace, name)
+						if !ok {
+							utilruntime.HandleError(fmt.Errorf("%s %s does not carry the name prefix of workspace %s", gvr.Resource, name, locator.Workspace))
+							return
+						}
+						c.AddToQueue(gvr, &metav1.ObjectMeta{
+							Annotations: map[string]string{
+								logicalcluster.AnnotationKey: locator.Workspace.String(),
+							},
+							Name: upstreamName,
+						}, logger)
+						return
+					}
+
 					// Use namespace lister
 					nsObj, err := namespaceLister.Get(namespace)
 					if err != nil {
@@ -197,6 +242,16 @@ type queueKey struct {
 	key string // meta namespace key
 }
 
+// workspaceOfQueueKey returns the upstream logical cluster of the queued resource, to share the workers
+// fairly between the workspaces.
+func workspaceOfQueueKey(item interface{}) string {
+	clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(item.(queueKey).key)
+	if err != nil {
+		return ""
+	}
+	return clusterName.String()
+}
+
 func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}, logger logr.Logger) {
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
@@ -247,7 +302,9 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, qk.gvr, qk.key); err != nil {
+	err := c.process(ctx, qk.gvr, qk.key)
+	c.health.Observe(err)
+	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -258,6 +315,11 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	return true
 }
 
+// Health returns the health of the controller, reported with the heartbeats of the syncer.
+func (c *Controller) Health() *shared.ControllerHealth {
+	return c.health
+}
+
 func newSecretLister(secretIndexer cache.Indexer) specmutators.ListSecretFunc {
 	return func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error) {
 		secretList, err := secretIndexer.ByIndex(byWorkspaceAndNamespaceIndexName, workspaceAndNamespaceIndexKey(clusterName, namespace))
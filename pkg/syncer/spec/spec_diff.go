@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// DiffOperation is the operation the spec syncer would perform on a downstream object.
+type DiffOperation string
+
+const (
+	DiffCreate DiffOperation = "Create"
+	DiffUpdate DiffOperation = "Update"
+	DiffDelete DiffOperation = "Delete"
+	// DiffSkip means that the upstream object would not be synced, e.g. because its spec overrides
+	// cannot be applied, or because the downstream API server would reject it.
+	DiffSkip DiffOperation = "Skip"
+)
+
+// ObjectDiff describes how the spec syncer would change a downstream object.
+type ObjectDiff struct {
+	Operation DiffOperation
+	Resource  schema.GroupVersionResource
+
+	// Upstream is the upstream object, as <workspace>|<namespace>/<name>. It is empty for downstream
+	// objects whose upstream object does not exist anymore.
+	Upstream string
+	// Downstream is the downstream object, as <namespace>/<name>.
+	Downstream string
+
+	// Live is the downstream object as it is, nil if it does not exist.
+	Live *unstructured.Unstructured
+	// Desired is the downstream object as it would be after the spec syncer applied it, nil if it
+	// would be deleted. Server-populated metadata and the status are removed from both objects.
+	Desired *unstructured.Unstructured
+
+	// Message explains why the object would be skipped.
+	Message string
+}
+
+// Diff computes what the spec syncer would apply downstream for the synced objects of the given resource: the
+// fully transformed objects, after renaming, the mutators and the spec overrides, merged into the live downstream
+// objects by a server-side dry-run apply. Downstream objects that would not change are omitted. Nothing is written,
+// neither upstream nor downstream.
+func (c *Controller) Diff(ctx context.Context, gvr schema.GroupVersionResource) ([]ObjectDiff, error) {
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not synced", gvr)
+	}
+
+	var diffs []ObjectDiff
+	upstreamObjs, err := syncerInformer.UpstreamInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range upstreamObjs {
+		upstreamObj, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+		}
+		diff, err := c.diffUpstreamObject(ctx, gvr, upstreamObj)
+		if err != nil {
+			return nil, err
+		}
+		if diff != nil {
+			diffs = append(diffs, *diff)
+		}
+	}
+
+	orphans, err := c.diffOrphanedDownstreamObjects(gvr, syncerInformer)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, orphans...)
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Downstream < diffs[j].Downstream
+	})
+	return diffs, nil
+}
+
+// diffUpstreamObject computes how the spec syncer would change the downstream object of the upstream object,
+// mirroring process and processClusterScoped. It returns nil if nothing would change.
+func (c *Controller) diffUpstreamObject(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured) (*ObjectDiff, error) {
+	clusterName := logicalcluster.From(upstreamObj)
+	diff := &ObjectDiff{
+		Resource: gvr,
+		Upstream: clusterName.String() + "|" + objectKey(upstreamObj.GetNamespace(), upstreamObj.GetName()),
+	}
+
+	var downstreamNamespace string
+	if upstreamObj.GetNamespace() != "" {
+		var err error
+		if downstreamNamespace, err = c.downstreamNamespaceFor(clusterName, upstreamObj.GetNamespace()); err != nil {
+			return nil, err
+		}
+		if downstreamNamespace == "" {
+			diff.Operation, diff.Message = DiffSkip, "the downstream namespace name cannot be computed"
+			return diff, nil
+		}
+	}
+	downstreamName := getDownstreamName(downstreamNamespace, upstreamObj)
+	diff.Downstream = objectKey(downstreamNamespace, downstreamName)
+
+	live, err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Get(ctx, downstreamName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return nil, err
+	}
+	diff.Live = stripServerFields(live)
+
+	if downstreamNamespace == "" {
+		conflict, err := c.clusterScopedConflict(ctx, gvr, clusterName, downstreamName)
+		if err != nil {
+			return nil, err
+		}
+		if conflict != "" {
+			diff.Operation, diff.Message = DiffSkip, conflict
+			return diff, nil
+		}
+	}
+
+	notAllowed := downstreamNamespace == "" && !c.syncerInformers.ClusterScopedResourceAllowed(gvr.GroupResource())
+	if c.removedFromSyncTarget(upstreamObj) || notAllowed {
+		if live == nil {
+			return nil, nil
+		}
+		diff.Operation = DiffDelete
+		return diff, nil
+	}
+
+	desired, _, overridesErr, err := c.desiredDownstreamObject(gvr, downstreamNamespace, upstreamObj)
+	if err != nil {
+		return nil, err
+	}
+	if overridesErr != nil {
+		diff.Operation, diff.Message = DiffSkip, fmt.Sprintf("failed to apply spec overrides: %v", overridesErr)
+		return diff, nil
+	}
+
+	applied, err := c.applyDownstreamObject(ctx, gvr, desired, true)
+	switch {
+	case err == nil:
+		diff.Desired = stripServerFields(applied)
+	case live == nil && apierrors.IsNotFound(err):
+		// the downstream namespace does not exist yet, the syncer would create it first.
+		diff.Desired = stripServerFields(desired)
+	default:
+		diff.Operation, diff.Message = DiffSkip, fmt.Sprintf("the downstream API server would reject the object: %v", err)
+		return diff, nil
+	}
+
+	switch {
+	case live == nil:
+		diff.Operation = DiffCreate
+	case equality.Semantic.DeepEqual(diff.Live, diff.Desired):
+		return nil, nil
+	default:
+		diff.Operation = DiffUpdate
+	}
+	return diff, nil
+}
+
+// diffOrphanedDownstreamObjects returns the downstream objects the spec syncer would delete because their upstream
+// object does not exist anymore.
+func (c *Controller) diffOrphanedDownstreamObjects(gvr schema.GroupVersionResource, syncerInformer *resourcesync.SyncerInformer) ([]ObjectDiff, error) {
+	downstreamObjs, err := syncerInformer.DownstreamInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []ObjectDiff
+	for _, obj := range downstreamObjs {
+		downstreamObj, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("downstream object is expected to be Unstructured, but is %T", obj)
+		}
+
+		var upstreamKey string
+		if downstreamObj.GetNamespace() == "" {
+			locator, found, err := shared.LocatorFromAnnotations(downstreamObj.GetAnnotations())
+			if err != nil || !found {
+				continue
+			}
+			upstreamName, ok := shared.UpstreamClusterScopedName(locator.Workspace, downstreamObj.GetName())
+			if !ok {
+				continue
+			}
+			upstreamKey = locator.Workspace.String() + "|" + upstreamName
+		} else {
+			nsObj, err := c.downstreamNSInformer.Lister().Get(downstreamObj.GetNamespace())
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			ns, ok := nsObj.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("downstream namespace is expected to be Unstructured, but is %T", nsObj)
+			}
+			locator, found, err := shared.LocatorFromAnnotations(ns.GetAnnotations())
+			if err != nil || !found {
+				continue
+			}
+			upstreamKey = locator.Namespace + "/" + locator.Workspace.String() + "|" + shared.GetUpstreamResourceName(gvr, downstreamObj.GetName())
+		}
+
+		_, exists, err := syncerInformer.UpstreamInformer.Informer().GetIndexer().GetByKey(upstreamKey)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+		diffs = append(diffs, ObjectDiff{
+			Operation:  DiffDelete,
+			Resource:   gvr,
+			Downstream: objectKey(downstreamObj.GetNamespace(), downstreamObj.GetName()),
+			Live:       stripServerFields(downstreamObj),
+		})
+	}
+	return diffs, nil
+}
+
+// stripServerFields returns a copy of the object without the metadata populated by the API server, and without
+// the status, which is not written by the spec syncer.
+func stripServerFields(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if obj == nil {
+		return nil
+	}
+	obj = obj.DeepCopy()
+	for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+	return obj
+}
+
+func objectKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
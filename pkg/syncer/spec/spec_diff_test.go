@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestDiff(t *testing.T) {
+	workspace := logicalcluster.New("root:org:ws")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(workspace, "us-west1")
+	downstreamName := shared.PhysicalClusterScopedName(workspace, "high-priority")
+	locator := `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":""}`
+
+	upstream := func(value int64) *unstructured.Unstructured {
+		u := priorityClass("high-priority", map[string]string{logicalcluster.AnnotationKey: workspace.String()})
+		u.SetLabels(map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: string(workloadv1alpha1.ResourceStateSync)})
+		u.Object["value"] = value
+		return u
+	}
+	downstream := func(value int64) *unstructured.Unstructured {
+		u := priorityClass(downstreamName, map[string]string{shared.NamespaceLocatorAnnotation: locator})
+		u.SetLabels(map[string]string{workloadv1alpha1.InternalDownstreamClusterLabel: syncTargetKey})
+		u.Object["value"] = value
+		return u
+	}
+
+	tests := map[string]struct {
+		upstream   *unstructured.Unstructured
+		downstream *unstructured.Unstructured
+		notAllowed bool
+		want       []ObjectDiff
+	}{
+		"not synced yet": {
+			upstream: upstream(1000),
+			want: []ObjectDiff{{
+				Operation:  DiffCreate,
+				Resource:   priorityClassesGVR,
+				Upstream:   "root:org:ws|high-priority",
+				Downstream: downstreamName,
+				Desired:    downstream(1000),
+			}},
+		},
+		"up to date": {
+			upstream:   upstream(1000),
+			downstream: downstream(1000),
+		},
+		"changed upstream": {
+			upstream:   upstream(2000),
+			downstream: downstream(1000),
+			want: []ObjectDiff{{
+				Operation:  DiffUpdate,
+				Resource:   priorityClassesGVR,
+				Upstream:   "root:org:ws|high-priority",
+				Downstream: downstreamName,
+				Live:       downstream(1000),
+				Desired:    downstream(2000),
+			}},
+		},
+		"removed from the allow-list": {
+			upstream:   upstream(1000),
+			downstream: downstream(1000),
+			notAllowed: true,
+			want: []ObjectDiff{{
+				Operation:  DiffDelete,
+				Resource:   priorityClassesGVR,
+				Upstream:   "root:org:ws|high-priority",
+				Downstream: downstreamName,
+				Live:       downstream(1000),
+			}},
+		},
+		"deleted upstream": {
+			downstream: downstream(1000),
+			want: []ObjectDiff{{
+				Operation:  DiffDelete,
+				Resource:   priorityClassesGVR,
+				Downstream: downstreamName,
+				Live:       downstream(1000),
+			}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tc.downstream != nil {
+				objects = append(objects, tc.downstream)
+			}
+			downstreamClient := dynamicfake.NewSimpleDynamicClient(scheme, objects...)
+			// the fake client does not support server-side apply, echo the applied object like a dry-run apply
+			// onto an object fully owned by the syncer.
+			downstreamClient.PrependReactor("patch", "priorityclasses", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				applied := &unstructured.Unstructured{}
+				if err := applied.UnmarshalJSON(action.(clienttesting.PatchAction).GetPatch()); err != nil {
+					return true, nil, err
+				}
+				return true, applied, nil
+			})
+
+			c := newClusterScopedController(downstreamClient)
+			c.syncTargetKey = syncTargetKey
+			syncerInformers := c.syncerInformers.(*fakeSyncerInformers)
+			syncerInformers.clusterScopedResources[priorityClassesGVR.GroupResource()] = !tc.notAllowed
+			if tc.upstream != nil {
+				require.NoError(t, syncerInformers.upstreamInformer.Informer().GetIndexer().Add(tc.upstream))
+			}
+			if tc.downstream != nil {
+				require.NoError(t, syncerInformers.downStreamInformer.Informer().GetIndexer().Add(tc.downstream))
+			}
+
+			got, err := c.Diff(context.Background(), priorityClassesGVR)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// containersPaths are the paths of the containers in the pod templates of the resources
+// that spec overrides can be applied to, i.e. workload resources, cron jobs, and pods.
+var containersPaths = [][]string{
+	{"spec", "template", "spec", "containers"},
+	{"spec", "jobTemplate", "spec", "template", "spec", "containers"},
+	{"spec", "containers"},
+}
+
+// applySpecOverrides renders the spec overrides of the upstream object that apply to this sync target
+// into the downstream object. It returns false if there are no overrides for this sync target.
+func (c *Controller) applySpecOverrides(upstreamObj, downstreamObj *unstructured.Unstructured) (bool, error) {
+	value, found := upstreamObj.GetAnnotations()[workloadv1alpha1.SpecOverridesAnnotationKey]
+	if !found {
+		return false, nil
+	}
+	overrides, err := shared.ParseSpecOverrides(value)
+	if err != nil {
+		return true, fmt.Errorf("failed to decode %s annotation: %w", workloadv1alpha1.SpecOverridesAnnotationKey, err)
+	}
+
+	// the labels of the SyncTarget can change while the syncer is running
+	syncTarget, err := c.syncTargetLister.Get(clusters.ToClusterAwareKey(c.syncTargetWorkspace, c.syncTargetName))
+	if err != nil {
+		return true, fmt.Errorf("failed to get SyncTarget: %w", err)
+	}
+
+	applied := false
+	for i, override := range overrides {
+		applies, err := shared.SpecOverrideApplies(override, c.syncTargetKey, syncTarget.Labels)
+		if err != nil {
+			return true, fmt.Errorf("override %d: %w", i, err)
+		}
+		if !applies {
+			continue
+		}
+		applied = true
+		if err := applySpecOverride(downstreamObj, override); err != nil {
+			return true, fmt.Errorf("override %d: %w", i, err)
+		}
+	}
+	return applied, nil
+}
+
+func applySpecOverride(obj *unstructured.Unstructured, override workloadv1alpha1.SpecOverride) error {
+	if override.Replicas != nil {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec"); !found {
+			return fmt.Errorf("cannot override replicas of %s without spec", obj.GetKind())
+		}
+		if err := unstructured.SetNestedField(obj.Object, int64(*override.Replicas), "spec", "replicas"); err != nil {
+			return err
+		}
+	}
+
+	if len(override.Containers) == 0 {
+		return nil
+	}
+
+	var containers []interface{}
+	var containersPath []string
+	for _, path := range containersPaths {
+		var found bool
+		var err error
+		containers, found, err = unstructured.NestedSlice(obj.Object, path...)
+		if err != nil {
+			return err
+		}
+		if found {
+			containersPath = path
+			break
+		}
+	}
+	if containersPath == nil {
+		return fmt.Errorf("cannot override containers of %s without pod template", obj.GetKind())
+	}
+
+	for _, containerOverride := range override.Containers {
+		found := false
+		for i := range containers {
+			container, ok := containers[i].(map[string]interface{})
+			if !ok || container["name"] != containerOverride.Name {
+				continue
+			}
+			found = true
+			if err := applyContainerOverride(container, containerOverride); err != nil {
+				return fmt.Errorf("container %q: %w", containerOverride.Name, err)
+			}
+		}
+		if !found {
+			return fmt.Errorf("container %q not found in %s", containerOverride.Name, strings.Join(containersPath, "."))
+		}
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, containers, containersPath...)
+}
+
+func applyContainerOverride(container map[string]interface{}, override workloadv1alpha1.ContainerOverride) error {
+	if override.Image != "" {
+		container["image"] = override.Image
+	}
+
+	if len(override.Env) > 0 {
+		env, _, err := unstructured.NestedSlice(container, "env")
+		if err != nil {
+			return err
+		}
+		for _, envVar := range override.Env {
+			unstructuredEnvVar, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&envVar)
+			if err != nil {
+				return err
+			}
+			replaced := false
+			for i := range env {
+				if existing, ok := env[i].(map[string]interface{}); ok && existing["name"] == envVar.Name {
+					env[i] = unstructuredEnvVar
+					replaced = true
+				}
+			}
+			if !replaced {
+				env = append(env, unstructuredEnvVar)
+			}
+		}
+		if err := unstructured.SetNestedSlice(container, env, "env"); err != nil {
+			return err
+		}
+	}
+
+	if override.Resources != nil {
+		for field, resources := range map[string]corev1.ResourceList{"requests": override.Resources.Requests, "limits": override.Resources.Limits} {
+			for name, quantity := range resources {
+				if err := unstructured.SetNestedField(container, quantity.String(), "resources", field, string(name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateSpecOverridesCondition reports the result of applying the spec overrides in the SpecOverridesApplied
+// condition of the upstream object, if the object has a status. A failure of another sync target is not
+// overridden by a success of this sync target.
+func (c *Controller) updateSpecOverridesCondition(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, applyErr error) error {
+	if _, found, _ := unstructured.NestedFieldNoCopy(upstreamObj.Object, "status"); !found {
+		return nil
+	}
+
+	messagePrefix := fmt.Sprintf("SyncTarget %s: ", c.syncTargetName)
+	desired := map[string]interface{}{
+		"type":   string(workloadv1alpha1.SpecOverridesApplied),
+		"status": string(corev1.ConditionTrue),
+	}
+	if applyErr != nil {
+		desired["status"] = string(corev1.ConditionFalse)
+		desired["reason"] = workloadv1alpha1.SpecOverridesFailedReason
+		desired["message"] = messagePrefix + applyErr.Error()
+	}
+
+	conditions, _, err := unstructured.NestedSlice(upstreamObj.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	index := -1
+	for i := range conditions {
+		if condition, ok := conditions[i].(map[string]interface{}); ok && condition["type"] == string(workloadv1alpha1.SpecOverridesApplied) {
+			index = i
+			break
+		}
+	}
+	if index >= 0 {
+		existing := conditions[index].(map[string]interface{})
+		if existing["status"] == desired["status"] && existing["message"] == desired["message"] {
+			return nil
+		}
+		if applyErr == nil && existing["status"] == string(corev1.ConditionFalse) {
+			if message, _ := existing["message"].(string); !strings.HasPrefix(message, messagePrefix) {
+				return nil
+			}
+		}
+	}
+
+	desired["lastTransitionTime"] = c.now().UTC().Format(time.RFC3339)
+	if index >= 0 {
+		conditions[index] = desired
+	} else {
+		conditions = append(conditions, desired)
+	}
+
+	upstreamObjCopy := upstreamObj.DeepCopy()
+	if err := unstructured.SetNestedSlice(upstreamObjCopy.Object, conditions, "status", "conditions"); err != nil {
+		return err
+	}
+	logicalCluster := logicalcluster.From(upstreamObj)
+	if _, err := c.upstreamClient.Cluster(logicalCluster).Resource(gvr).Namespace(upstreamObj.GetNamespace()).UpdateStatus(ctx, upstreamObjCopy, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed updating %s condition of resource %s|%s/%s: %v", workloadv1alpha1.SpecOverridesApplied, logicalCluster, upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		return err
+	}
+	return nil
+}
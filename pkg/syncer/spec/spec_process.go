@@ -23,7 +23,6 @@ import (
 	"reflect"
 	"strings"
 
-	jsonpatch "github.com/evanphx/json-patch"
 	kcpcache "github.com/kcp-dev/apimachinery/pkg/cache"
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -112,35 +111,16 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 		return nil
 	}
 
-	desiredNSLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamNamespace)
-	jsonNSLocator, err := json.Marshal(desiredNSLocator)
-	if err != nil {
-		return err
+	if upstreamNamespace == "" {
+		return c.processClusterScoped(ctx, gvr, clusterName, name)
 	}
-	downstreamNamespaces, err := c.downstreamNSInformer.Informer().GetIndexer().ByIndex(byNamespaceLocatorIndexName, string(jsonNSLocator))
+
+	downstreamNamespace, err := c.downstreamNamespaceFor(clusterName, upstreamNamespace)
 	if err != nil {
 		return err
 	}
-
-	var downstreamNamespace string
-	if len(downstreamNamespaces) == 1 {
-		namespace := downstreamNamespaces[0].(*unstructured.Unstructured)
-		klog.V(4).Infof("Found downstream namespace %s for upstream namespace %s", namespace.GetName(), upstreamNamespace)
-		downstreamNamespace = namespace.GetName()
-	} else if len(downstreamNamespaces) > 1 {
-		// This should never happen unless there's some namespace collision.
-		var namespacesCollisions []string
-		for _, namespace := range downstreamNamespaces {
-			namespacesCollisions = append(namespacesCollisions, namespace.(*unstructured.Unstructured).GetName())
-		}
-		return fmt.Errorf("(namespace collision) found multiple downstream namespaces: %s for upstream namespace %s|%s", strings.Join(namespacesCollisions, ","), clusterName, upstreamNamespace)
-	} else {
-		klog.V(4).Infof("No downstream namespaces found for %s", key)
-		downstreamNamespace, err = shared.PhysicalClusterNamespaceName(desiredNSLocator)
-		if err != nil {
-			klog.Errorf("Error hashing namespace %s|%s: %v", clusterName, upstreamNamespace, err)
-			return nil
-		}
+	if downstreamNamespace == "" {
+		return nil
 	}
 
 	// TODO(skuznets): can we figure out how to not leak this detail up to this code?
@@ -192,6 +172,44 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	return c.applyToDownstream(ctx, gvr, downstreamNamespace, upstreamObj)
 }
 
+// downstreamNamespaceFor returns the name of the downstream namespace of the upstream namespace, i.e. the existing
+// namespace with the namespace locator of the upstream namespace, or the name the syncer creates it with. An empty
+// name means the upstream namespace cannot be synced.
+func (c *Controller) downstreamNamespaceFor(clusterName logicalcluster.Name, upstreamNamespace string) (string, error) {
+	desiredNSLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamNamespace)
+	jsonNSLocator, err := json.Marshal(desiredNSLocator)
+	if err != nil {
+		return "", err
+	}
+	downstreamNamespaces, err := c.downstreamNSInformer.Informer().GetIndexer().ByIndex(byNamespaceLocatorIndexName, string(jsonNSLocator))
+	if err != nil {
+		return "", err
+	}
+
+	var downstreamNamespace string
+	if len(downstreamNamespaces) == 1 {
+		namespace := downstreamNamespaces[0].(*unstructured.Unstructured)
+		klog.V(4).Infof("Found downstream namespace %s for upstream namespace %s", namespace.GetName(), upstreamNamespace)
+		downstreamNamespace = namespace.GetName()
+	} else if len(downstreamNamespaces) > 1 {
+		// This should never happen unless there's some namespace collision.
+		var namespacesCollisions []string
+		for _, namespace := range downstreamNamespaces {
+			namespacesCollisions = append(namespacesCollisions, namespace.(*unstructured.Unstructured).GetName())
+		}
+		return "", fmt.Errorf("(namespace collision) found multiple downstream namespaces: %s for upstream namespace %s|%s", strings.Join(namespacesCollisions, ","), clusterName, upstreamNamespace)
+	} else {
+		klog.V(4).Infof("No downstream namespaces found for upstream namespace %s|%s", clusterName, upstreamNamespace)
+		downstreamNamespace, err = shared.PhysicalClusterNamespaceName(desiredNSLocator)
+		if err != nil {
+			klog.Errorf("Error hashing namespace %s|%s: %v", clusterName, upstreamNamespace, err)
+			return "", nil
+		}
+	}
+
+	return downstreamNamespace, nil
+}
+
 // TODO: This function is there as a quick and dirty implementation of namespace creation.
 //
 //	In fact We should also be getting notifications about namespaces created upstream and be creating downstream equivalents.
@@ -291,26 +309,15 @@ func (c *Controller) ensureSyncerFinalizer(ctx context.Context, gvr schema.Group
 
 func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj *unstructured.Unstructured) error {
 	upstreamObjLogicalCluster := logicalcluster.From(upstreamObj)
-	downstreamObj := upstreamObj.DeepCopy()
-
-	// Run name transformations on the downstreamObj.
-	transformedName := getTransformedName(downstreamObj)
-
-	// TODO(jmprusi): When using syncer virtual workspace we would check the DeletionTimestamp on the upstream object, instead of the DeletionTimestamp annotation,
-	//                as the virtual workspace will set the the deletionTimestamp() on the location view by a transformation.
-	intendedToBeRemovedFromLocation := upstreamObj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+c.syncTargetKey] != ""
-
-	// TODO(jmprusi): When using syncer virtual workspace this condition would not be necessary anymore, since directly tested on the virtual workspace side.
-	stillOwnedByExternalActorForLocation := upstreamObj.GetAnnotations()[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+c.syncTargetKey] != ""
+	downstreamName := getDownstreamName(downstreamNamespace, upstreamObj)
 
 	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
 	if !ok {
 		return nil
 	}
 
-	klog.V(4).Infof("Upstream object %s|%s/%s is intended to be removed %t %t", upstreamObjLogicalCluster, upstreamObj.GetNamespace(), upstreamObj.GetName(), intendedToBeRemovedFromLocation, stillOwnedByExternalActorForLocation)
-	if intendedToBeRemovedFromLocation && !stillOwnedByExternalActorForLocation {
-		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, transformedName, metav1.DeleteOptions{}); err != nil {
+	if c.removedFromSyncTarget(upstreamObj) {
+		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, downstreamName, metav1.DeleteOptions{}); err != nil {
 			if apierrors.IsNotFound(err) {
 				// That's not an error.
 				// Just think about removing the finalizer from the KCP location-specific resource:
@@ -319,21 +326,98 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 				}
 				return nil
 			}
-			klog.Errorf("Error deleting %s %s/%s from downstream %s|%s/%s: %v", gvr.Resource, upstreamObj.GetNamespace(), upstreamObj.GetName(), logicalcluster.From(upstreamObj), downstreamNamespace, downstreamObj.GetName(), err)
+			klog.Errorf("Error deleting %s %s/%s from downstream %s|%s/%s: %v", gvr.Resource, upstreamObj.GetNamespace(), upstreamObj.GetName(), logicalcluster.From(upstreamObj), downstreamNamespace, downstreamName, err)
 			return err
 		}
-		klog.V(2).Infof("Deleted %s %s/%s from downstream %s|%s/%s", gvr.Resource, upstreamObj.GetNamespace(), downstreamObj.GetName(), logicalcluster.From(upstreamObj), downstreamNamespace, downstreamObj.GetName())
+		klog.V(2).Infof("Deleted %s %s/%s from downstream %s|%s/%s", gvr.Resource, upstreamObj.GetNamespace(), downstreamName, logicalcluster.From(upstreamObj), downstreamNamespace, downstreamName)
 		return nil
 	}
 
+	downstreamObj, overridesApplied, overridesErr, err := c.desiredDownstreamObject(gvr, downstreamNamespace, upstreamObj)
+	if err != nil {
+		return err
+	}
+
+	// A failure to apply the spec overrides is reported in the SpecOverridesApplied condition upstream, and
+	// the object is not synced until the overrides are fixed.
+	if overridesApplied {
+		if err := c.updateSpecOverridesCondition(ctx, gvr, upstreamObj, overridesErr); err != nil {
+			return err
+		}
+		if overridesErr != nil {
+			klog.Errorf("Failed to apply spec overrides to %s %s|%s/%s: %v", gvr.Resource, logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), overridesErr)
+			return nil
+		}
+	}
+
+	if _, err := c.applyDownstreamObject(ctx, gvr, downstreamObj, false); err != nil {
+		klog.Errorf("Error upserting %s %s/%s from upstream %s|%s/%s: %v", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		return err
+	}
+	klog.Infof("Upserted %s %s/%s from upstream %s|%s/%s", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName())
+
+	return nil
+}
+
+// removedFromSyncTarget returns true if the upstream object is to be deleted from the sync target, i.e. it is
+// removed from the sync target and no external actor holds it there anymore.
+func (c *Controller) removedFromSyncTarget(upstreamObj *unstructured.Unstructured) bool {
+	// TODO(jmprusi): When using syncer virtual workspace we would check the DeletionTimestamp on the upstream object, instead of the DeletionTimestamp annotation,
+	//                as the virtual workspace will set the the deletionTimestamp() on the location view by a transformation.
+	intendedToBeRemovedFromLocation := upstreamObj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+c.syncTargetKey] != ""
+
+	// TODO(jmprusi): When using syncer virtual workspace this condition would not be necessary anymore, since directly tested on the virtual workspace side.
+	stillOwnedByExternalActorForLocation := upstreamObj.GetAnnotations()[workloadv1alpha1.ClusterFinalizerAnnotationPrefix+c.syncTargetKey] != ""
+
+	klog.V(4).Infof("Upstream object %s|%s/%s is intended to be removed %t %t", logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), intendedToBeRemovedFromLocation, stillOwnedByExternalActorForLocation)
+	return intendedToBeRemovedFromLocation && !stillOwnedByExternalActorForLocation
+}
+
+// desiredDownstreamObject returns the object to be applied downstream for the upstream object: transformed for
+// downstream, and with the spec overrides of the sync target rendered. overridesApplied is true if the upstream
+// object has spec overrides for the sync target, and overridesErr tells why they cannot be applied, in which case
+// the object must not be synced.
+func (c *Controller) desiredDownstreamObject(gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj *unstructured.Unstructured) (desired *unstructured.Unstructured, overridesApplied bool, overridesErr error, err error) {
+	desired, err = c.transformForDownstream(gvr, downstreamNamespace, upstreamObj)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	overridesApplied, overridesErr = c.applySpecOverrides(upstreamObj, desired)
+	return desired, overridesApplied, overridesErr, nil
+}
+
+// applyDownstreamObject server-side applies the desired object downstream, or only pretends to with dryRun.
+func (c *Controller) applyDownstreamObject(ctx context.Context, gvr schema.GroupVersionResource, desired *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	// Marshalling the unstructured object is good enough as SSA patch
+	data, err := json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	options := metav1.PatchOptions{FieldManager: syncerApplyManager, Force: pointer.Bool(true)}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	return c.downstreamClient.Resource(gvr).Namespace(desired.GetNamespace()).Patch(ctx, desired.GetName(), types.ApplyPatchType, data, options)
+}
+
+// transformForDownstream returns the object to be applied downstream for the upstream object: renamed, run through
+// the mutators, with references to cluster-scoped objects rewritten, and without the upstream-only metadata. Spec
+// overrides are not applied yet.
+func (c *Controller) transformForDownstream(gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	upstreamObjLogicalCluster := logicalcluster.From(upstreamObj)
+	downstreamObj := upstreamObj.DeepCopy()
+
 	// Run any transformations on the object before we apply it to the downstream cluster.
 	if mutator, ok := c.mutators[gvr]; ok {
 		if err := mutator(downstreamObj); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	if err := c.rewriteClusterScopedReferences(gvr, upstreamObj, downstreamObj); err != nil {
+		return nil, err
+	}
 
-	downstreamObj.SetName(transformedName)
+	downstreamObj.SetName(getDownstreamName(downstreamNamespace, upstreamObj))
 	downstreamObj.SetUID("")
 	downstreamObj.SetResourceVersion("")
 	downstreamObj.SetNamespace(downstreamNamespace)
@@ -344,6 +428,20 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	delete(downstreamAnnotations, logicalcluster.AnnotationKey)
 	//TODO(jmprusi): To be removed when switching to the syncer Virtual Workspace transformations.
 	delete(downstreamAnnotations, workloadv1alpha1.InternalClusterStatusAnnotationPrefix+c.syncTargetKey)
+	delete(downstreamAnnotations, workloadv1alpha1.SpecOverridesAnnotationKey)
+	delete(downstreamAnnotations, workloadv1alpha1.InternalStatusWriterAnnotationKey)
+	delete(downstreamAnnotations, workloadv1alpha1.ClusterScopedConflictAnnotationPrefix+c.syncTargetKey)
+	if downstreamNamespace == "" {
+		// Cluster-scoped objects have no downstream namespace, so they carry the locator themselves.
+		locator, err := json.Marshal(shared.NewNamespaceLocator(upstreamObjLogicalCluster, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, ""))
+		if err != nil {
+			return nil, err
+		}
+		if downstreamAnnotations == nil {
+			downstreamAnnotations = map[string]string{}
+		}
+		downstreamAnnotations[shared.NamespaceLocatorAnnotation] = string(locator)
+	}
 	// If we're left with 0 annotations, nil out the map so it's not included in the patch
 	if len(downstreamAnnotations) == 0 {
 		downstreamAnnotations = nil
@@ -366,52 +464,16 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	labels[workloadv1alpha1.InternalDownstreamClusterLabel] = c.syncTargetKey
 	downstreamObj.SetLabels(labels)
 
-	if c.advancedSchedulingEnabled {
-		specDiffPatch := upstreamObj.GetAnnotations()[workloadv1alpha1.ClusterSpecDiffAnnotationPrefix+c.syncTargetKey]
-		if specDiffPatch != "" {
-			upstreamSpec, specExists, err := unstructured.NestedFieldCopy(upstreamObj.UnstructuredContent(), "spec")
-			if err != nil {
-				return err
-			}
-			if specExists {
-				// TODO(jmprusi): Surface those errors to the user.
-				patch, err := jsonpatch.DecodePatch([]byte(specDiffPatch))
-				if err != nil {
-					klog.Errorf("Failed to decode spec diff patch: %v", err)
-					return err
-				}
-				upstreamSpecJSON, err := json.Marshal(upstreamSpec)
-				if err != nil {
-					return err
-				}
-				patchedUpstreamSpecJSON, err := patch.Apply(upstreamSpecJSON)
-				if err != nil {
-					return err
-				}
-				var newSpec map[string]interface{}
-				if err := json.Unmarshal(patchedUpstreamSpecJSON, &newSpec); err != nil {
-					return err
-				}
-				if err := unstructured.SetNestedMap(downstreamObj.UnstructuredContent(), newSpec, "spec"); err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	// Marshalling the unstructured object is good enough as SSA patch
-	data, err := json.Marshal(downstreamObj)
-	if err != nil {
-		return err
-	}
+	return downstreamObj, nil
+}
 
-	if _, err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Patch(ctx, downstreamObj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: syncerApplyManager, Force: pointer.Bool(true)}); err != nil {
-		klog.Errorf("Error upserting %s %s/%s from upstream %s|%s/%s: %v", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
-		return err
+// getDownstreamName returns the name of the downstream object of the upstream object. Cluster-scoped objects get
+// the name prefix of their workspace.
+func getDownstreamName(downstreamNamespace string, upstreamObj *unstructured.Unstructured) string {
+	if downstreamNamespace == "" {
+		return shared.PhysicalClusterScopedName(logicalcluster.From(upstreamObj), upstreamObj.GetName())
 	}
-	klog.Infof("Upserted %s %s/%s from upstream %s|%s/%s", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName())
-
-	return nil
+	return getTransformedName(upstreamObj)
 }
 
 // getTransformedName returns the desired object name.
@@ -46,6 +46,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
@@ -463,6 +464,9 @@ func (mdc *mockedDynamicCluster) Cluster(name logicalcluster.Name) dynamic.Inter
 }
 
 func TestSyncerProcess(t *testing.T) {
+	now := time.Now()
+	now3339 := now.UTC().Format(time.RFC3339)
+
 	tests := map[string]struct {
 		fromNamespace *corev1.Namespace
 		gvr           schema.GroupVersionResource
@@ -477,6 +481,7 @@ func TestSyncerProcess(t *testing.T) {
 		syncTargetName            string
 		syncTargetWorkspace       logicalcluster.Name
 		syncTargetUID             types.UID
+		syncTargetLabels          map[string]string
 		advancedSchedulingEnabled bool
 
 		expectError         bool
@@ -775,7 +780,7 @@ func TestSyncerProcess(t *testing.T) {
 				),
 			},
 		},
-		"SpecSyncer with AdvancedScheduling, sync deployment to downstream and apply SpecDiff": {
+		"SpecSyncer sync deployment to downstream and apply spec overrides for the sync target": {
 			upstreamLogicalCluster: "root:org:ws",
 			fromNamespace: namespace("test", "root:org:ws", map[string]string{
 				"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
@@ -789,19 +794,40 @@ func TestSyncerProcess(t *testing.T) {
 						"token":     []byte("token"),
 						"namespace": []byte("namespace"),
 					}),
-				deployment("theDeployment", "test", "root:org:ws",
-					map[string]string{
-						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
-					},
-					map[string]string{"experimental.spec-diff.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "[{\"op\":\"replace\",\"path\":\"/replicas\",\"value\":3}]"},
-					[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+				changeDeployment(
+					deployment("theDeployment", "test", "root:org:ws",
+						map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						},
+						map[string]string{"workload.kcp.dev/spec-overrides": `[{"syncTargetKey":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5","replicas":3,"containers":[{"name":"app","image":"nginx:1.23"}]}]`},
+						[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+					addContainer("app", "nginx")),
 			},
 			resourceToProcessLogicalClusterName: "root:org:ws",
 			resourceToProcessName:               "theDeployment",
 			syncTargetName:                      "us-west1",
-			advancedSchedulingEnabled:           true,
 
-			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnFrom: []clienttesting.Action{
+				updateDeploymentAction("test",
+					changeUnstructured(
+						toUnstructured(t, changeDeployment(
+							deployment("theDeployment", "test", "root:org:ws",
+								map[string]string{
+									"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+								},
+								map[string]string{"workload.kcp.dev/spec-overrides": `[{"syncTargetKey":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5","replicas":3,"containers":[{"name":"app","image":"nginx:1.23"}]}]`},
+								[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+							addContainer("app", "nginx"))),
+						setNestedField([]interface{}{
+							map[string]interface{}{
+								"type":               "SpecOverridesApplied",
+								"status":             "True",
+								"lastTransitionTime": now3339,
+							},
+						}, "status", "conditions"),
+					),
+					"status"),
+			},
 			expectActionsOnTo: []clienttesting.Action{
 				createNamespaceAction(
 					"",
@@ -820,28 +846,143 @@ func TestSyncerProcess(t *testing.T) {
 					"theDeployment",
 					"kcp-hcbsa8z6c2er",
 					types.ApplyPatchType,
-					toJson(t,
-						changeUnstructured(
-							toUnstructured(t, deployment("theDeployment", "kcp-hcbsa8z6c2er", "", map[string]string{
-								"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
-							}, map[string]string{"experimental.spec-diff.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "[{\"op\":\"replace\",\"path\":\"/replicas\",\"value\":3}]"}, nil)),
-							setNestedField(map[string]interface{}{
-								"replicas": int64(3),
-							}, "spec"),
-							// TODO(jmprusi): Those next changes do "nothing", it's just for the test to pass
-							//                as the test expects some null fields to be there...
-							setNestedField(nil, "spec", "selector"),
-							setNestedField(map[string]interface{}{}, "spec", "strategy"),
-							setNestedField(map[string]interface{}{
-								"metadata": map[string]interface{}{
-									"creationTimestamp": nil,
+					[]byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"creationTimestamp":null,"labels":{"internal.workload.kcp.dev/cluster":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"},"name":"theDeployment","namespace":"kcp-hcbsa8z6c2er"},"spec":{"replicas":3,"selector":null,"strategy":{},"template":{"metadata":{"creationTimestamp":null},"spec":{"automountServiceAccountToken":false,"containers":[{"env":[{"name":"KUBERNETES_SERVICE_PORT","value":"6443"},{"name":"KUBERNETES_SERVICE_PORT_HTTPS","value":"6443"},{"name":"KUBERNETES_SERVICE_HOST","value":"kcp.dev"}],"image":"nginx:1.23","name":"app","resources":{},"volumeMounts":[{"mountPath":"/var/run/secrets/kubernetes.io/serviceaccount","name":"kcp-api-access","readOnly":true}]}],"volumes":[{"name":"kcp-api-access","projected":{"defaultMode":420,"sources":[{"secret":{"items":[{"key":"token","path":"token"},{"key":"namespace","path":"namespace"}],"name":"kcp-default-token-abc"}},{"configMap":{"items":[{"key":"ca.crt","path":"ca.crt"}],"name":"kcp-root-ca.crt"}}]}}]}}},"status":{}}`),
+				),
+			},
+		},
+		"SpecSyncer sync deployment to downstream and apply spec overrides matching the sync target labels": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				changeDeployment(
+					deployment("theDeployment", "test", "root:org:ws",
+						map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						},
+						map[string]string{"workload.kcp.dev/spec-overrides": `[{"syncTargetSelector":{"matchLabels":{"region":"us-west"}},"replicas":3},{"syncTargetSelector":{"matchLabels":{"region":"eu"}},"replicas":5}]`},
+						[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+					addContainer("app", "nginx")),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+			syncTargetLabels:                    map[string]string{"region": "us-west"},
+
+			expectActionsOnFrom: []clienttesting.Action{
+				updateDeploymentAction("test",
+					changeUnstructured(
+						toUnstructured(t, changeDeployment(
+							deployment("theDeployment", "test", "root:org:ws",
+								map[string]string{
+									"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
 								},
-								"spec": map[string]interface{}{
-									"containers": nil,
+								map[string]string{"workload.kcp.dev/spec-overrides": `[{"syncTargetSelector":{"matchLabels":{"region":"us-west"}},"replicas":3},{"syncTargetSelector":{"matchLabels":{"region":"eu"}},"replicas":5}]`},
+								[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+							addContainer("app", "nginx"))),
+						setNestedField([]interface{}{
+							map[string]interface{}{
+								"type":               "SpecOverridesApplied",
+								"status":             "True",
+								"lastTransitionTime": now3339,
+							},
+						}, "status", "conditions"),
+					),
+					"status"),
+			},
+			expectActionsOnTo: []clienttesting.Action{
+				createNamespaceAction(
+					"",
+					changeUnstructured(
+						toUnstructured(t, namespace("kcp-hcbsa8z6c2er", "",
+							map[string]string{
+								"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+							},
+							map[string]string{
+								"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+							})),
+						removeNilOrEmptyFields,
+					),
+				),
+				patchDeploymentAction(
+					"theDeployment",
+					"kcp-hcbsa8z6c2er",
+					types.ApplyPatchType,
+					[]byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"creationTimestamp":null,"labels":{"internal.workload.kcp.dev/cluster":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"},"name":"theDeployment","namespace":"kcp-hcbsa8z6c2er"},"spec":{"replicas":3,"selector":null,"strategy":{},"template":{"metadata":{"creationTimestamp":null},"spec":{"automountServiceAccountToken":false,"containers":[{"env":[{"name":"KUBERNETES_SERVICE_PORT","value":"6443"},{"name":"KUBERNETES_SERVICE_PORT_HTTPS","value":"6443"},{"name":"KUBERNETES_SERVICE_HOST","value":"kcp.dev"}],"image":"nginx","name":"app","resources":{},"volumeMounts":[{"mountPath":"/var/run/secrets/kubernetes.io/serviceaccount","name":"kcp-api-access","readOnly":true}]}],"volumes":[{"name":"kcp-api-access","projected":{"defaultMode":420,"sources":[{"secret":{"items":[{"key":"token","path":"token"},{"key":"namespace","path":"namespace"}],"name":"kcp-default-token-abc"}},{"configMap":{"items":[{"key":"ca.crt","path":"ca.crt"}],"name":"kcp-root-ca.crt"}}]}}]}}},"status":{}}`),
+				),
+			},
+		},
+		"SpecSyncer spec overrides referencing an unknown container, expect failed condition and no sync": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				changeDeployment(
+					deployment("theDeployment", "test", "root:org:ws",
+						map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						},
+						map[string]string{"workload.kcp.dev/spec-overrides": `[{"syncTargetKey":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5","containers":[{"name":"sidecar","image":"envoy"}]}]`},
+						[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+					addContainer("app", "nginx")),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{
+				updateDeploymentAction("test",
+					changeUnstructured(
+						toUnstructured(t, changeDeployment(
+							deployment("theDeployment", "test", "root:org:ws",
+								map[string]string{
+									"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
 								},
-							}, "spec", "template"),
-							setNestedField(map[string]interface{}{}, "status"),
-						),
+								map[string]string{"workload.kcp.dev/spec-overrides": `[{"syncTargetKey":"2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5","containers":[{"name":"sidecar","image":"envoy"}]}]`},
+								[]string{"workload.kcp.dev/syncer-2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5"}),
+							addContainer("app", "nginx"))),
+						setNestedField([]interface{}{
+							map[string]interface{}{
+								"type":               "SpecOverridesApplied",
+								"status":             "False",
+								"reason":             "SpecOverridesFailed",
+								"message":            `SyncTarget us-west1: override 0: container "sidecar" not found in spec.template.spec.containers`,
+								"lastTransitionTime": now3339,
+							},
+						}, "status", "conditions"),
+					),
+					"status"),
+			},
+			expectActionsOnTo: []clienttesting.Action{
+				createNamespaceAction(
+					"",
+					changeUnstructured(
+						toUnstructured(t, namespace("kcp-hcbsa8z6c2er", "",
+							map[string]string{
+								"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+							},
+							map[string]string{
+								"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+							})),
+						removeNilOrEmptyFields,
 					),
 				),
 			},
@@ -1001,8 +1142,17 @@ func TestSyncerProcess(t *testing.T) {
 
 			upstreamURL, err := url.Parse("https://kcp.dev:6443")
 			require.NoError(t, err)
-			controller, err := NewSpecSyncer(kcpLogicalCluster, tc.syncTargetName, syncTargetKey, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, fromInformers, toInformers, fakeInformers, syncTargetUID)
+			syncTargetIndexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, syncTargetIndexer.Add(&workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        tc.syncTargetName,
+					Annotations: map[string]string{logicalcluster.AnnotationKey: kcpLogicalCluster.String()},
+					Labels:      tc.syncTargetLabels,
+				},
+			}))
+			controller, err := NewSpecSyncer(kcpLogicalCluster, tc.syncTargetName, syncTargetKey, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, fromInformers, toInformers, fakeInformers, syncTargetUID, workloadlisters.NewSyncTargetLister(syncTargetIndexer), 0)
 			require.NoError(t, err)
+			controller.now = func() time.Time { return now }
 
 			fromInformers.Start(ctx.Done())
 			toInformers.Start(ctx.Done())
@@ -1125,6 +1275,15 @@ func changeDeployment(in *appsv1.Deployment, changes ...deploymentChange) *appsv
 	return in
 }
 
+func addContainer(name, image string) deploymentChange {
+	return func(d *appsv1.Deployment) {
+		d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, corev1.Container{
+			Name:  name,
+			Image: image,
+		})
+	}
+}
+
 func toJson(t require.TestingT, object runtime.Object) []byte {
 	result, err := json.Marshal(object)
 	require.NoError(t, err)
@@ -1256,8 +1415,9 @@ func patchSecretAction(name, namespace string, patchType types.PatchType, patch
 }
 
 type fakeSyncerInformers struct {
-	upstreamInformer   informers.GenericInformer
-	downStreamInformer informers.GenericInformer
+	upstreamInformer       informers.GenericInformer
+	downStreamInformer     informers.GenericInformer
+	clusterScopedResources map[schema.GroupResource]bool
 }
 
 func newFakeSyncerInformers(gvr schema.GroupVersionResource, upstreamInformers, downStreamInformers dynamicinformer.DynamicSharedInformerFactory) *fakeSyncerInformers {
@@ -1277,4 +1437,10 @@ func (f *fakeSyncerInformers) InformerForResource(gvr schema.GroupVersionResourc
 		DownstreamInformer: f.downStreamInformer,
 	}, true
 }
+func (f *fakeSyncerInformers) ClusterScopedResourceAllowed(gr schema.GroupResource) bool {
+	return f.clusterScopedResources[gr]
+}
+func (f *fakeSyncerInformers) ResourceHealth() []workloadv1alpha1.ResourceHealth {
+	return nil
+}
 func (f *fakeSyncerInformers) Start(ctx context.Context, numThreads int) {}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// StatusAggregator combines the statuses of a resource synced to multiple sync targets,
+// ordered by sync target key, into the status of the upstream resource.
+type StatusAggregator func(statuses []map[string]interface{}) (map[string]interface{}, error)
+
+// statusAggregators are the built-in aggregators used by the Aggregate strategy.
+var statusAggregators = map[schema.GroupResource]StatusAggregator{
+	{Group: "apps", Resource: "deployments"}:            aggregateDeploymentStatus,
+	{Group: "", Resource: "services"}:                   aggregateServiceStatus,
+	{Group: "networking.k8s.io", Resource: "ingresses"}: aggregateIngressStatus,
+}
+
+// negativePolarityConditions are condition types that signal a problem when true.
+var negativePolarityConditions = map[string]bool{
+	string(appsv1.DeploymentReplicaFailure): true,
+}
+
+// aggregateStatus returns the status of the upstream resource computed from the per sync target status
+// annotations of the given sync targets, according to the aggregation strategy of the resource, or to
+// defaultStrategy if the resource has none or an unknown one. It returns false if the status of the
+// upstream resource must not be changed.
+func aggregateStatus(logger logr.Logger, gvr schema.GroupVersionResource, upstream *unstructured.Unstructured, syncTargetKeys []string, defaultStrategy workloadv1alpha1.StatusAggregationStrategy) (map[string]interface{}, bool, error) {
+	strategy := defaultStrategy
+	if value, found := upstream.GetAnnotations()[workloadv1alpha1.StatusAggregationAnnotationKey]; found {
+		// the annotation is validated on admission, but objects might predate the validation.
+		if shared.IsValidStatusAggregationStrategy(workloadv1alpha1.StatusAggregationStrategy(value)) {
+			strategy = workloadv1alpha1.StatusAggregationStrategy(value)
+		} else {
+			logger.Info("ignoring unknown status aggregation strategy", "strategy", value, "default", defaultStrategy)
+		}
+	}
+	if strategy == workloadv1alpha1.StatusAggregationNone {
+		return nil, false, nil
+	}
+
+	var statuses []map[string]interface{}
+	for _, key := range syncTargetKeys {
+		value, found := upstream.GetAnnotations()[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+key]
+		if !found {
+			continue
+		}
+		var status map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &status); err != nil {
+			return nil, false, fmt.Errorf("failed to decode status of sync target %s: %w", key, err)
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return nil, false, nil
+	}
+
+	if strategy == workloadv1alpha1.StatusAggregationPrimary {
+		return statuses[0], true, nil
+	}
+	if aggregator, found := statusAggregators[gvr.GroupResource()]; found {
+		status, err := aggregator(statuses)
+		return status, err == nil, err
+	}
+	return statuses[0], true, nil
+}
+
+func aggregateDeploymentStatus(statuses []map[string]interface{}) (map[string]interface{}, error) {
+	var aggregated appsv1.DeploymentStatus
+	for i, unstructuredStatus := range statuses {
+		var status appsv1.DeploymentStatus
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredStatus, &status); err != nil {
+			return nil, err
+		}
+		if i == 0 || status.ObservedGeneration < aggregated.ObservedGeneration {
+			aggregated.ObservedGeneration = status.ObservedGeneration
+		}
+		aggregated.Replicas += status.Replicas
+		aggregated.UpdatedReplicas += status.UpdatedReplicas
+		aggregated.ReadyReplicas += status.ReadyReplicas
+		aggregated.AvailableReplicas += status.AvailableReplicas
+		aggregated.UnavailableReplicas += status.UnavailableReplicas
+	}
+
+	result, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&aggregated)
+	if err != nil {
+		return nil, err
+	}
+	return withAggregatedConditions(result, statuses), nil
+}
+
+func aggregateServiceStatus(statuses []map[string]interface{}) (map[string]interface{}, error) {
+	var aggregated corev1.ServiceStatus
+	for _, unstructuredStatus := range statuses {
+		var status corev1.ServiceStatus
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredStatus, &status); err != nil {
+			return nil, err
+		}
+		aggregated.LoadBalancer.Ingress = appendLoadBalancerIngresses(aggregated.LoadBalancer.Ingress, status.LoadBalancer.Ingress)
+	}
+
+	result, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&aggregated)
+	if err != nil {
+		return nil, err
+	}
+	return withAggregatedConditions(result, statuses), nil
+}
+
+func aggregateIngressStatus(statuses []map[string]interface{}) (map[string]interface{}, error) {
+	var aggregated networkingv1.IngressStatus
+	for _, unstructuredStatus := range statuses {
+		var status networkingv1.IngressStatus
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredStatus, &status); err != nil {
+			return nil, err
+		}
+		aggregated.LoadBalancer.Ingress = appendLoadBalancerIngresses(aggregated.LoadBalancer.Ingress, status.LoadBalancer.Ingress)
+	}
+
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(&aggregated)
+}
+
+// appendLoadBalancerIngresses appends the ingress points that are not yet in the list.
+func appendLoadBalancerIngresses(ingresses, more []corev1.LoadBalancerIngress) []corev1.LoadBalancerIngress {
+	for _, ingress := range more {
+		found := false
+		for _, existing := range ingresses {
+			if existing.IP == ingress.IP && existing.Hostname == ingress.Hostname {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ingresses = append(ingresses, ingress)
+		}
+	}
+	return ingresses
+}
+
+// withAggregatedConditions sets the conditions of the aggregated status to the worst condition per type
+// across all statuses: a false condition wins over an unknown one, which wins over a true one, or the
+// other way around for conditions with negative polarity.
+func withAggregatedConditions(aggregated map[string]interface{}, statuses []map[string]interface{}) map[string]interface{} {
+	var conditions []interface{}
+	indexByType := map[string]int{}
+	for _, status := range statuses {
+		statusConditions, _, err := unstructured.NestedSlice(status, "conditions")
+		if err != nil {
+			continue
+		}
+		for _, condition := range statusConditions {
+			c, ok := condition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditionType, _ := c["type"].(string)
+			i, found := indexByType[conditionType]
+			if !found {
+				indexByType[conditionType] = len(conditions)
+				conditions = append(conditions, c)
+				continue
+			}
+			if conditionSeverity(c) > conditionSeverity(conditions[i].(map[string]interface{})) {
+				conditions[i] = c
+			}
+		}
+	}
+
+	if len(conditions) == 0 {
+		delete(aggregated, "conditions")
+	} else {
+		aggregated["conditions"] = conditions
+	}
+	return aggregated
+}
+
+func conditionSeverity(condition map[string]interface{}) int {
+	conditionType, _ := condition["type"].(string)
+	status, _ := condition["status"].(string)
+	good, bad := string(corev1.ConditionTrue), string(corev1.ConditionFalse)
+	if negativePolarityConditions[conditionType] {
+		good, bad = bad, good
+	}
+	switch status {
+	case good:
+		return 0
+	case bad:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestAggregateStatus(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	tests := map[string]struct {
+		gvr             schema.GroupVersionResource
+		annotations     map[string]string
+		syncTargetKeys  []string
+		defaultStrategy workloadv1alpha1.StatusAggregationStrategy
+
+		wantStatus map[string]interface{}
+		wantUpdate bool
+		wantErr    bool
+	}{
+		"no status reported yet": {
+			gvr:            deployments,
+			syncTargetKeys: []string{"c1", "c2"},
+		},
+		"deployment replicas are summed, worst condition wins": {
+			gvr: deployments,
+			annotations: map[string]string{
+				"experimental.status.workload.kcp.dev/c1": `{"observedGeneration":3,"replicas":2,"availableReplicas":2,"conditions":[{"type":"Available","status":"True"},{"type":"ReplicaFailure","status":"False"}]}`,
+				"experimental.status.workload.kcp.dev/c2": `{"observedGeneration":2,"replicas":3,"availableReplicas":1,"conditions":[{"type":"Available","status":"False","reason":"MinimumReplicasUnavailable"},{"type":"ReplicaFailure","status":"True"}]}`,
+			},
+			syncTargetKeys: []string{"c1", "c2"},
+			wantStatus: map[string]interface{}{
+				"observedGeneration": int64(2),
+				"replicas":           int64(5),
+				"availableReplicas":  int64(3),
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": "False", "reason": "MinimumReplicasUnavailable"},
+					map[string]interface{}{"type": "ReplicaFailure", "status": "True"},
+				},
+			},
+			wantUpdate: true,
+		},
+		"status of sync targets not in the keys is ignored": {
+			gvr: deployments,
+			annotations: map[string]string{
+				"experimental.status.workload.kcp.dev/c1": `{"replicas":2}`,
+				"experimental.status.workload.kcp.dev/c3": `{"replicas":3}`,
+			},
+			syncTargetKeys: []string{"c1", "c2"},
+			wantStatus:     map[string]interface{}{"replicas": int64(2)},
+			wantUpdate:     true,
+		},
+		"service load balancer ingresses are merged": {
+			gvr: services,
+			annotations: map[string]string{
+				"experimental.status.workload.kcp.dev/c1": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"}]}}`,
+				"experimental.status.workload.kcp.dev/c2": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"},{"hostname":"lb.example.com"}]}}`,
+			},
+			syncTargetKeys: []string{"c1", "c2"},
+			wantStatus: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{
+					"ingress": []interface{}{
+						map[string]interface{}{"ip": "10.0.0.1"},
+						map[string]interface{}{"hostname": "lb.example.com"},
+					},
+				},
+			},
+			wantUpdate: true,
+		},
+		"resource without aggregator gets the status of the first sync target": {
+			gvr: configMaps,
+			annotations: map[string]string{
+				"experimental.status.workload.kcp.dev/c1": `{"phase":"one"}`,
+				"experimental.status.workload.kcp.dev/c2": `{"phase":"two"}`,
+			},
+			syncTargetKeys: []string{"c1", "c2"},
+			wantStatus:     map[string]interface{}{"phase": "one"},
+			wantUpdate:     true,
+		},
+		"default strategy of the resource": {
+			gvr: deployments,
+			annotations: map[string]string{
+				"experimental.status.workload.kcp.dev/c1": `{"replicas":2}`,
+				"experimental.status.workload.kcp.dev/c2": `{"replicas":3}`,
+			},
+			syncTargetKeys:  []string{"c1", "c2"},
+			defaultStrategy: workloadv1alpha1.StatusAggregationPrimary,
+			wantStatus:      map[string]interface{}{"replicas": int64(2)},
+			wantUpdate:      true,
+		},
+		"annotation overrides the default strategy of the resource": {
+			gvr: deployments,
+			annotations: map[string]string{
+				"workload.kcp.dev/status-aggregation":     "Aggregate",
+				"experimental.status.workload.kcp.dev/c1": `{"replicas":2}`,
+				"experimental.status.workload.kcp.dev/c2": `{"replicas":3}`,
+			},
+			syncTargetKeys:  []string{"c1", "c2"},
+			defaultStrategy: workloadv1alpha1.StatusAggregationNone,
+			wantStatus:      map[string]interface{}{"replicas": int64(5)},
+			wantUpdate:      true,
+		},
+		"unknown strategy falls back to the default": {
+			gvr: deployments,
+			annotations: map[string]string{
+				"workload.kcp.dev/status-aggregation":     "Sum",
+				"experimental.status.workload.kcp.dev/c1": `{"replicas":2}`,
+				"experimental.status.workload.kcp.dev/c2": `{"replicas":3}`,
+			},
+			syncTargetKeys:  []string{"c1", "c2"},
+			defaultStrategy: workloadv1alpha1.StatusAggregationPrimary,
+			wantStatus:      map[string]interface{}{"replicas": int64(2)},
+			wantUpdate:      true,
+		},
+		"invalid status annotation": {
+			gvr: deployments,
+			annotations: map[string]string{
+				"experimental.status.workload.kcp.dev/c1": `{"replicas":`,
+			},
+			syncTargetKeys: []string{"c1"},
+			wantErr:        true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			upstream := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			upstream.SetAnnotations(tc.annotations)
+
+			status, update, err := aggregateStatus(klog.Background(), tc.gvr, upstream, tc.syncTargetKeys, tc.defaultStrategy)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantUpdate, update)
+			require.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// processClusterScoped syncs the status of a cluster-scoped downstream object. Its upstream workspace is
+// found in the locator annotation of the object itself, as there is no downstream namespace.
+func (c *Controller) processClusterScoped(ctx context.Context, gvr schema.GroupVersionResource, downstreamName string) error {
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		return nil
+	}
+	obj, exists, err := syncerInformer.DownstreamInformer.Informer().GetIndexer().GetByKey(downstreamName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		upstreamObj := upstreamClusterScopedObject(syncerInformer.UpstreamInformer.Informer().GetIndexer().List(), downstreamName)
+		if upstreamObj == nil {
+			return nil
+		}
+		klog.Infof("Downstream GVR %q object %s does not exist. Removing finalizer upstream", gvr.String(), downstreamName)
+		return shared.EnsureUpstreamFinalizerRemoved(ctx, gvr, syncerInformer.UpstreamInformer, c.upstreamClient, "", c.syncTargetKey, logicalcluster.From(upstreamObj), upstreamObj.GetName())
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+	locator, exists, err := shared.LocatorFromAnnotations(u.GetAnnotations())
+	if err != nil {
+		klog.Errorf("%s %s: error decoding annotation: %v", gvr.Resource, downstreamName, err)
+		return nil
+	}
+	if !exists || locator == nil {
+		return nil
+	}
+	if locator.SyncTarget.UID != c.syncTargetUID || locator.SyncTarget.Workspace != c.syncTargetWorkspace.String() {
+		// not our resource.
+		return nil
+	}
+	upstreamName, ok := shared.UpstreamClusterScopedName(locator.Workspace, downstreamName)
+	if !ok {
+		klog.Errorf("%s %s does not carry the name prefix of workspace %s", gvr.Resource, downstreamName, locator.Workspace)
+		return nil
+	}
+
+	return c.updateStatusInUpstream(ctx, gvr, "", locator.Workspace, upstreamName, u)
+}
+
+// upstreamClusterScopedObject returns the upstream object synced to the cluster-scoped downstream object with
+// the given name, or nil if there is none.
+func upstreamClusterScopedObject(upstreamObjs []interface{}, downstreamName string) *unstructured.Unstructured {
+	for _, obj := range upstreamObjs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || u.GetNamespace() != "" {
+			continue
+		}
+		if shared.PhysicalClusterScopedName(logicalcluster.From(u), u.GetName()) == downstreamName {
+			return u
+		}
+	}
+	return nil
+}
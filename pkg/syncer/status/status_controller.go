@@ -19,11 +19,13 @@ package status
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -35,8 +37,12 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/syncer/fairqueue"
 	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+	"github.com/kcp-dev/kcp/pkg/syncer/status/transformers"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
@@ -45,7 +51,8 @@ const (
 )
 
 type Controller struct {
-	queue workqueue.RateLimitingInterface
+	queue  workqueue.RateLimitingInterface
+	health *shared.ControllerHealth
 
 	upstreamClient            dynamic.ClusterInterface
 	downstreamClient          dynamic.Interface
@@ -57,14 +64,18 @@ type Controller struct {
 	syncTargetUID             types.UID
 	syncTargetKey             string
 	advancedSchedulingEnabled bool
+
+	// statusAggregation is the aggregation strategy per resource, unless set on the resource itself.
+	statusAggregation map[schema.GroupResource]workloadv1alpha1.StatusAggregationStrategy
+
+	transformers transformerGvrMap
 }
 
 func NewStatusSyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, syncTargetKey string, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncerInformers resourcesync.SyncerInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncerInformers resourcesync.SyncerInformerFactory, syncTargetUID types.UID,
+	statusAggregation map[schema.GroupResource]workloadv1alpha1.StatusAggregationStrategy, statusTransformers []transformers.Transformer, workspaceConcurrency int) (*Controller, error) {
 
 	c := &Controller{
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
-
 		upstreamClient:            upstreamClient,
 		downstreamClient:          downstreamClient,
 		downstreamNamespaceLister: downstreamInformers.ForResource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Lister(),
@@ -75,6 +86,14 @@ func NewStatusSyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, sy
 		syncTargetUID:             syncTargetUID,
 		syncTargetKey:             syncTargetKey,
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
+		statusAggregation:         statusAggregation,
+	}
+	c.queue = fairqueue.New(controllerName, c.workspaceOfQueueKey, workspaceConcurrency)
+	c.health = shared.NewControllerHealth(controllerName, c.queue)
+
+	c.transformers = transformerGvrMap{}
+	for _, transformer := range statusTransformers {
+		c.transformers[transformer.GVR()] = transformer
 	}
 
 	logger := logging.WithReconciler(klog.Background(), controllerName)
@@ -100,14 +119,105 @@ func NewStatusSyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, sy
 			}
 		})
 
+	// the syncer writing the aggregated status of a resource synced to multiple sync targets must
+	// aggregate again when another syncer reports its status.
+	syncerInformers.AddUpstreamEventHandler(
+		func(gvr schema.GroupVersionResource) cache.ResourceEventHandler {
+			return cache.ResourceEventHandlerFuncs{
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					oldUnstrob := oldObj.(*unstructured.Unstructured)
+					newUnstrob := newObj.(*unstructured.Unstructured)
+
+					syncTargetKeys := shared.SyncTargetKeys(newUnstrob.GetLabels())
+					if len(syncTargetKeys) < 2 || !isStatusWriter(syncTargetKey, newUnstrob, syncTargetKeys) || equalStatusAnnotations(oldUnstrob, newUnstrob) {
+						return
+					}
+					c.enqueueDownstreamOf(gvr, newUnstrob, logger)
+				},
+			}
+		})
+
 	return c, nil
 }
 
+// equalStatusAnnotations returns whether the per sync target status annotations of both objects are equal.
+func equalStatusAnnotations(oldObj, newObj *unstructured.Unstructured) bool {
+	oldAnnotations, newAnnotations := oldObj.GetAnnotations(), newObj.GetAnnotations()
+	if oldAnnotations[workloadv1alpha1.InternalStatusWriterAnnotationKey] != newAnnotations[workloadv1alpha1.InternalStatusWriterAnnotationKey] {
+		return false
+	}
+	for k, v := range newAnnotations {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) && oldAnnotations[k] != v {
+			return false
+		}
+	}
+	for k := range oldAnnotations {
+		if _, found := newAnnotations[k]; strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) && !found {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueueDownstreamOf queues the downstream object of the given upstream object, if it exists. A missing
+// downstream object is handled by its deletion event.
+func (c *Controller) enqueueDownstreamOf(gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, logger logr.Logger) {
+	workspace := logicalcluster.From(upstreamObj)
+	downstream := &metav1.ObjectMeta{Name: upstreamObj.GetName()}
+	if upstreamObj.GetNamespace() == "" {
+		downstream.Name = shared.PhysicalClusterScopedName(workspace, upstreamObj.GetName())
+	} else {
+		locator := shared.NewNamespaceLocator(workspace, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamObj.GetNamespace())
+		downstreamNamespace, err := shared.PhysicalClusterNamespaceName(locator)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		downstream.Namespace = downstreamNamespace
+	}
+
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		return
+	}
+	key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(downstream)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	if _, exists, err := syncerInformer.DownstreamInformer.Informer().GetIndexer().GetByKey(key); err != nil || !exists {
+		return
+	}
+	c.AddToQueue(gvr, downstream, logger)
+}
+
 type queueKey struct {
 	gvr schema.GroupVersionResource
 	key string // meta namespace key
 }
 
+// workspaceOfQueueKey returns the upstream logical cluster of the downstream namespace of the queued resource,
+// to share the workers fairly between the workspaces.
+func (c *Controller) workspaceOfQueueKey(item interface{}) string {
+	namespace, _, err := cache.SplitMetaNamespaceKey(item.(queueKey).key)
+	if err != nil || namespace == "" {
+		return ""
+	}
+	nsObj, err := c.downstreamNamespaceLister.Get(namespace)
+	if err != nil {
+		return ""
+	}
+	nsMeta, ok := nsObj.(metav1.Object)
+	if !ok {
+		return ""
+	}
+	namespaceLocator, exists, err := shared.LocatorFromAnnotations(nsMeta.GetAnnotations())
+	if err != nil || !exists || namespaceLocator == nil {
+		return ""
+	}
+	return namespaceLocator.Workspace.String()
+}
+
 func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}, logger logr.Logger) {
 	key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -158,7 +268,9 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, qk.gvr, qk.key); err != nil {
+	err := c.process(ctx, qk.gvr, qk.key)
+	c.health.Observe(err)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -168,3 +280,8 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 
 	return true
 }
+
+// Health returns the health of the controller, reported with the heartbeats of the syncer.
+func (c *Controller) Health() *shared.ControllerHealth {
+	return c.health
+}
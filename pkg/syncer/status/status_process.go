@@ -30,14 +30,15 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
-	workloadcliplugin "github.com/kcp-dev/kcp/pkg/cliplugins/workload/plugin"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+	"github.com/kcp-dev/kcp/pkg/syncer/status/transformers"
 )
 
+type transformerGvrMap map[schema.GroupVersionResource]transformers.Transformer
+
 func deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob *unstructured.Unstructured) bool {
 	newFinalizers := newUnstrob.GetFinalizers()
 	oldFinalizers := oldUnstrob.GetFinalizers()
@@ -57,8 +58,11 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 		klog.Errorf("Invalid key: %q: %v", key, err)
 		return nil
 	}
+	if downstreamNamespace == "" {
+		return c.processClusterScoped(ctx, gvr, downstreamName)
+	}
 	// TODO(sttts): do not reference the cli plugin here
-	if strings.HasPrefix(downstreamNamespace, workloadcliplugin.SyncerIDPrefix) {
+	if strings.HasPrefix(downstreamNamespace, shared.SyncerIDPrefix) {
 		// skip syncer namespace
 		return nil
 	}
@@ -112,11 +116,17 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	if !ok {
 		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
 	}
-	return c.updateStatusInUpstream(ctx, gvr, upstreamNamespace, upstreamWorkspace, u)
+	return c.updateStatusInUpstream(ctx, gvr, upstreamNamespace, upstreamWorkspace, shared.GetUpstreamResourceName(gvr, downstreamName), u)
 }
 
-func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, upstreamLogicalCluster logicalcluster.Name, downstreamObj *unstructured.Unstructured) error {
-	upstreamName := shared.GetUpstreamResourceName(gvr, downstreamObj.GetName())
+func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, upstreamLogicalCluster logicalcluster.Name, upstreamName string, downstreamObj *unstructured.Unstructured) error {
+	if transformer, ok := c.transformers[gvr]; ok {
+		downstreamObj = downstreamObj.DeepCopy()
+		if err := transformer.Transform(downstreamObj); err != nil {
+			klog.Errorf("Failed transforming status of resource %s|%s/%s from syncTargetName namespace %s: %v", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace(), err)
+			return err
+		}
+	}
 
 	downstreamStatus, statusExists, err := unstructured.NestedFieldCopy(downstreamObj.UnstructuredContent(), "status")
 	if err != nil {
@@ -130,7 +140,7 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 	if !ok {
 		return nil
 	}
-	existingObj, err := syncerInformer.UpstreamInformer.Lister().ByNamespace(upstreamNamespace).Get(clusters.ToClusterAwareKey(upstreamLogicalCluster, upstreamName))
+	existingObj, err := shared.GetUpstreamObject(syncerInformer.UpstreamInformer.Lister(), upstreamLogicalCluster, upstreamNamespace, upstreamName)
 	if err != nil {
 		klog.Errorf("Getting resource %s/%s: %v", upstreamNamespace, upstreamName, err)
 		return err
@@ -144,7 +154,13 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 
 	newUpstream := existing.DeepCopy()
 
-	if c.advancedSchedulingEnabled {
+	// When the resource is synced to multiple sync targets, every syncer reports its status in the
+	// per sync target status annotation, and then writes the status of the upstream resource aggregated
+	// from the annotations of all sync targets according to the aggregation strategy of the resource.
+	syncTargetKeys := shared.SyncTargetKeys(existing.GetLabels())
+	multipleSyncTargets := len(syncTargetKeys) > 1
+
+	if c.advancedSchedulingEnabled || multipleSyncTargets {
 		statusAnnotationValue, err := json.Marshal(downstreamStatus)
 		if err != nil {
 			return err
@@ -157,19 +173,43 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 		newUpstream.SetAnnotations(newUpstreamAnnotations)
 
 		if reflect.DeepEqual(existing, newUpstream) {
-			klog.V(2).Infof("No need to update the status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
+			klog.V(2).Infof("No need to update the status annotation of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
+		} else {
+			updated, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Update(ctx, newUpstream, metav1.UpdateOptions{})
+			if err != nil {
+				klog.Errorf("Failed updating location status annotation of resource %s|%s/%s from syncTargetName namespace %s: %v", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace(), err)
+				return err
+			}
+			klog.Infof("Updated status annotation of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
+			newUpstream = updated
+		}
+
+		if c.advancedSchedulingEnabled {
 			return nil
 		}
 
-		if _, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Update(ctx, newUpstream, metav1.UpdateOptions{}); err != nil {
-			klog.Errorf("Failed updating location status annotation of resource %s|%s/%s from syncTargetName namespace %s: %v", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace(), err)
-			return err
+		// only the syncer of the sync target chosen by kcp writes the aggregated status, to not have all
+		// syncers race for the same object. It is requeued when the other syncers report their status.
+		if !isStatusWriter(c.syncTargetKey, newUpstream, syncTargetKeys) {
+			return nil
 		}
-		klog.Infof("Updated status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
-		return nil
+
+		aggregatedStatus, update, err := aggregateStatus(klog.Background(), gvr, newUpstream, syncTargetKeys, c.statusAggregation[gvr.GroupResource()])
+		if err != nil {
+			return fmt.Errorf("failed aggregating status of resource %s|%s/%s: %w", upstreamLogicalCluster, upstreamNamespace, upstreamName, err)
+		}
+		if !update {
+			return nil
+		}
+		downstreamStatus = aggregatedStatus
 	}
 
-	if err := unstructured.SetNestedField(newUpstream.UnstructuredContent(), downstreamStatus, "status"); err != nil {
+	newStatus := preserveUpstreamConditions(existing, downstreamStatus)
+	if existingStatus, found, err := unstructured.NestedFieldNoCopy(existing.UnstructuredContent(), "status"); err == nil && found && equality.Semantic.DeepEqual(existingStatus, newStatus) {
+		klog.V(5).Infof("Status of resource %q %s|%s/%s is up to date", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamName)
+		return nil
+	}
+	if err := unstructured.SetNestedField(newUpstream.UnstructuredContent(), newStatus, "status"); err != nil {
 		return err
 	}
 
@@ -184,3 +224,53 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 	klog.Infof("Updated status of resource %q %s|%s/%s from pcluster namespace %s", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
 	return nil
 }
+
+// isStatusWriter returns whether the syncer of the given sync target writes the status of the upstream resource
+// synced to the given sync targets, ordered by key. kcp chooses the writer among the ready sync targets. Until it
+// did, or if it chose a sync target the resource is not synced to anymore, the sync target with the lowest key
+// writes the status.
+func isStatusWriter(syncTargetKey string, upstream *unstructured.Unstructured, syncTargetKeys []string) bool {
+	if writer, found := upstream.GetAnnotations()[workloadv1alpha1.InternalStatusWriterAnnotationKey]; found {
+		for _, key := range syncTargetKeys {
+			if key == writer {
+				return writer == syncTargetKey
+			}
+		}
+	}
+	return len(syncTargetKeys) > 0 && syncTargetKeys[0] == syncTargetKey
+}
+
+// preserveUpstreamConditions returns the downstream status with the conditions that are only set upstream,
+// i.e. the SpecOverridesApplied condition of the spec syncer, copied over from the existing upstream object.
+func preserveUpstreamConditions(existing *unstructured.Unstructured, downstreamStatus interface{}) interface{} {
+	status, ok := downstreamStatus.(map[string]interface{})
+	if !ok {
+		return downstreamStatus
+	}
+	existingConditions, _, err := unstructured.NestedSlice(existing.UnstructuredContent(), "status", "conditions")
+	if err != nil || len(existingConditions) == 0 {
+		return downstreamStatus
+	}
+	var preserved []interface{}
+	for _, condition := range existingConditions {
+		if c, ok := condition.(map[string]interface{}); ok && c["type"] == string(workloadv1alpha1.SpecOverridesApplied) {
+			preserved = append(preserved, condition)
+		}
+	}
+	if len(preserved) == 0 {
+		return downstreamStatus
+	}
+
+	downstreamConditions, _, err := unstructured.NestedSlice(status, "conditions")
+	if err != nil {
+		return downstreamStatus
+	}
+	conditions := make([]interface{}, 0, len(downstreamConditions)+len(preserved))
+	for _, condition := range downstreamConditions {
+		if c, ok := condition.(map[string]interface{}); !ok || c["type"] != string(workloadv1alpha1.SpecOverridesApplied) {
+			conditions = append(conditions, condition)
+		}
+	}
+	status["conditions"] = append(conditions, preserved...)
+	return status
+}
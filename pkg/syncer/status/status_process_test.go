@@ -360,6 +360,351 @@ func TestSyncerProcess(t *testing.T) {
 					"status"),
 			},
 		},
+		"StatusSyncer upsert to existing resource, preserve upstream spec overrides condition": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas:   15,
+					Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}},
+				})),
+			toResources: []runtime.Object{
+				changeDeployment(
+					deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					}, nil, nil),
+					addDeploymentStatus(appsv1.DeploymentStatus{
+						Conditions: []appsv1.DeploymentCondition{{Type: "SpecOverridesApplied", Status: corev1.ConditionTrue}},
+					})),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, changeDeployment(
+						deployment("theDeployment", "test", "root:org:ws", map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						}, nil, nil),
+						addDeploymentStatus(appsv1.DeploymentStatus{
+							Replicas: 15,
+							Conditions: []appsv1.DeploymentCondition{
+								{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+								{Type: "SpecOverridesApplied", Status: corev1.ConditionTrue},
+							},
+						}))),
+					"status"),
+			},
+		},
+		"StatusSyncer upsert to existing resource synced to multiple synctargets, aggregate deployment status": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+				}, map[string]string{
+					"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+				}, nil),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+					}, map[string]string{
+						"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+						"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+					}, nil))),
+				updateDeploymentAction("test",
+					toUnstructured(t, changeDeployment(
+						deployment("theDeployment", "test", "root:org:ws", map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+							"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+						}, map[string]string{
+							"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+							"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+						}, nil),
+						addDeploymentStatus(appsv1.DeploymentStatus{
+							Replicas:      20,
+							ReadyReplicas: 5,
+						}))),
+					"status"),
+			},
+		},
+		"StatusSyncer upsert to existing resource synced to multiple synctargets, other synctarget has not reported yet": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+				}, nil, nil),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+					}, map[string]string{
+						"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+					}, nil))),
+				updateDeploymentAction("test",
+					toUnstructured(t, changeDeployment(
+						deployment("theDeployment", "test", "root:org:ws", map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+							"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+						}, map[string]string{
+							"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+						}, nil),
+						addDeploymentStatus(appsv1.DeploymentStatus{
+							Replicas: 15,
+						}))),
+					"status"),
+			},
+		},
+		"StatusSyncer upsert to existing resource synced to multiple synctargets, Primary aggregation strategy": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+				}, map[string]string{
+					"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+					"workload.kcp.dev/status-aggregation":                                         "Primary",
+				}, nil),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+					}, map[string]string{
+						"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+						"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+						"workload.kcp.dev/status-aggregation":                                         "Primary",
+					}, nil))),
+				updateDeploymentAction("test",
+					toUnstructured(t, changeDeployment(
+						deployment("theDeployment", "test", "root:org:ws", map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+							"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+						}, map[string]string{
+							"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+							"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+							"workload.kcp.dev/status-aggregation":                                         "Primary",
+						}, nil),
+						addDeploymentStatus(appsv1.DeploymentStatus{
+							Replicas: 15,
+						}))),
+					"status"),
+			},
+		},
+		"StatusSyncer upsert to existing resource synced to multiple synctargets, None aggregation strategy": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+				}, map[string]string{
+					"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+					"workload.kcp.dev/status-aggregation":                                         "None",
+				}, nil),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						"state.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": "Sync",
+					}, map[string]string{
+						"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+						"experimental.status.workload.kcp.dev/aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": `{"replicas":5,"readyReplicas":5}`,
+						"workload.kcp.dev/status-aggregation":                                         "None",
+					}, nil))),
+			},
+		},
+		"StatusSyncer upsert to existing resource synced to multiple synctargets, another synctarget writes the aggregated status": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					"state.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": "Sync",
+				}, map[string]string{
+					"experimental.status.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": `{"replicas":5,"readyReplicas":5}`,
+				}, nil),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						"state.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": "Sync",
+					}, map[string]string{
+						"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+						"experimental.status.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": `{"replicas":5,"readyReplicas":5}`,
+					}, nil))),
+			},
+		},
+		"StatusSyncer upsert to existing resource synced to multiple synctargets, kcp chose this synctarget to write the aggregated status": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"workspace":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+					"state.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": "Sync",
+				}, map[string]string{
+					"experimental.status.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": `{"replicas":5,"readyReplicas":5}`,
+					"internal.workload.kcp.dev/status-writer":                                     "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+				}, nil),
+			},
+			resourceToProcessName: "theDeployment",
+			syncTargetName:        "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updateDeploymentAction("test",
+					toUnstructured(t, deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+						"state.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": "Sync",
+					}, map[string]string{
+						"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+						"experimental.status.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": `{"replicas":5,"readyReplicas":5}`,
+						"internal.workload.kcp.dev/status-writer":                                     "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+					}, nil))),
+				updateDeploymentAction("test",
+					toUnstructured(t, changeDeployment(
+						deployment("theDeployment", "test", "root:org:ws", map[string]string{
+							"state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "Sync",
+							"state.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": "Sync",
+						}, map[string]string{
+							"experimental.status.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5": "{\"replicas\":15}",
+							"experimental.status.workload.kcp.dev/0aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeO": `{"replicas":5,"readyReplicas":5}`,
+							"internal.workload.kcp.dev/status-writer":                                     "2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5",
+						}, nil),
+						addDeploymentStatus(appsv1.DeploymentStatus{
+							Replicas:      20,
+							ReadyReplicas: 5,
+						}))),
+					"status"),
+			},
+		},
 		"StatusSyncer upsert to existing resource but owned by another synctarget, expect no update": {
 			upstreamLogicalCluster: "root:org:ws",
 			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
@@ -560,7 +905,7 @@ func TestSyncerProcess(t *testing.T) {
 			toClientResourceWatcherStarted := setupWatchReactor(tc.gvr.Resource, toClient)
 
 			fakeInformers := newFakeSyncerInformers(tc.gvr, toInformers, fromInformers)
-			controller, err := NewStatusSyncer(kcpLogicalCluster, tc.syncTargetName, syncTargetKey, tc.advancedSchedulingEnabled, toClusterClient, fromClient, toInformers, fromInformers, fakeInformers, tc.syncTargetUID)
+			controller, err := NewStatusSyncer(kcpLogicalCluster, tc.syncTargetName, syncTargetKey, tc.advancedSchedulingEnabled, toClusterClient, fromClient, toInformers, fromInformers, fakeInformers, tc.syncTargetUID, nil, nil, 0)
 			require.NoError(t, err)
 
 			toInformers.ForResource(tc.gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{})
@@ -734,4 +1079,10 @@ func (f *fakeSyncerInformers) InformerForResource(gvr schema.GroupVersionResourc
 		DownstreamInformer: f.downStreamInformer,
 	}, true
 }
+func (f *fakeSyncerInformers) ClusterScopedResourceAllowed(gr schema.GroupResource) bool {
+	return false
+}
+func (f *fakeSyncerInformers) ResourceHealth() []workloadv1alpha1.ResourceHealth {
+	return nil
+}
 func (f *fakeSyncerInformers) Start(ctx context.Context, numThreads int) {}
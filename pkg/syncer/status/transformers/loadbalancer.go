@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformers
+
+import (
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// LoadBalancerIngressRewriteFunc rewrites a load balancer ingress point of a downstream
+// Service or Ingress into the form reachable from outside of the physical cluster. It returns
+// false if the ingress point must not be synced upstream.
+type LoadBalancerIngressRewriteFunc func(ingress corev1.LoadBalancerIngress) (corev1.LoadBalancerIngress, bool)
+
+// ExternalLoadBalancerIngress is the LoadBalancerIngressRewriteFunc of the opt-in Service and
+// Ingress transformers. It drops the ingress points that only have a private, loopback or
+// link-local IP, i.e. which are not reachable from outside of the physical cluster.
+func ExternalLoadBalancerIngress(ingress corev1.LoadBalancerIngress) (corev1.LoadBalancerIngress, bool) {
+	if ingress.Hostname != "" {
+		return ingress, true
+	}
+	ip := net.ParseIP(ingress.IP)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return ingress, false
+	}
+	return ingress, true
+}
+
+type ServiceTransformer struct {
+	rewrite LoadBalancerIngressRewriteFunc
+}
+
+func (st *ServiceTransformer) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "services",
+	}
+}
+
+func NewServiceTransformer(rewrite LoadBalancerIngressRewriteFunc) *ServiceTransformer {
+	return &ServiceTransformer{
+		rewrite: rewrite,
+	}
+}
+
+// Transform rewrites the load balancer ingress points of the service status.
+func (st *ServiceTransformer) Transform(obj *unstructured.Unstructured) error {
+	return rewriteLoadBalancerIngresses(obj, st.rewrite)
+}
+
+type IngressTransformer struct {
+	rewrite LoadBalancerIngressRewriteFunc
+}
+
+func (it *IngressTransformer) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "networking.k8s.io",
+		Version:  "v1",
+		Resource: "ingresses",
+	}
+}
+
+func NewIngressTransformer(rewrite LoadBalancerIngressRewriteFunc) *IngressTransformer {
+	return &IngressTransformer{
+		rewrite: rewrite,
+	}
+}
+
+// Transform rewrites the load balancer ingress points of the ingress status.
+func (it *IngressTransformer) Transform(obj *unstructured.Unstructured) error {
+	return rewriteLoadBalancerIngresses(obj, it.rewrite)
+}
+
+func rewriteLoadBalancerIngresses(obj *unstructured.Unstructured, rewrite LoadBalancerIngressRewriteFunc) error {
+	unstructuredIngresses, found, err := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+	if err != nil || !found {
+		return err
+	}
+
+	rewritten := make([]interface{}, 0, len(unstructuredIngresses))
+	for _, unstructuredIngress := range unstructuredIngresses {
+		u, ok := unstructuredIngress.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var ingress corev1.LoadBalancerIngress
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, &ingress); err != nil {
+			return err
+		}
+		ingress, keep := rewrite(ingress)
+		if !keep {
+			continue
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&ingress)
+		if err != nil {
+			return err
+		}
+		rewritten = append(rewritten, u)
+	}
+
+	if len(rewritten) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "status", "loadBalancer", "ingress")
+		return nil
+	}
+	return unstructured.SetNestedSlice(obj.Object, rewritten, "status", "loadBalancer", "ingress")
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestServiceTransform(t *testing.T) {
+	for _, c := range []struct {
+		desc            string
+		rewrite         LoadBalancerIngressRewriteFunc
+		originalIngress []corev1.LoadBalancerIngress
+		expectedIngress []corev1.LoadBalancerIngress
+	}{{
+		desc:    "A service without load balancer ingress, should not be transformed",
+		rewrite: ExternalLoadBalancerIngress,
+	}, {
+		desc:    "Cluster-internal ingress points are dropped",
+		rewrite: ExternalLoadBalancerIngress,
+		originalIngress: []corev1.LoadBalancerIngress{
+			{IP: "10.0.0.1"},
+			{IP: "34.120.1.2"},
+			{IP: "127.0.0.1"},
+			{IP: "192.168.1.1", Hostname: "lb.example.com"},
+			{IP: "fd00::1"},
+		},
+		expectedIngress: []corev1.LoadBalancerIngress{
+			{IP: "34.120.1.2"},
+			{IP: "192.168.1.1", Hostname: "lb.example.com"},
+		},
+	}, {
+		desc:    "All ingress points dropped",
+		rewrite: ExternalLoadBalancerIngress,
+		originalIngress: []corev1.LoadBalancerIngress{
+			{IP: "172.18.0.3"},
+		},
+	}, {
+		desc: "Ingress points are rewritten",
+		rewrite: func(ingress corev1.LoadBalancerIngress) (corev1.LoadBalancerIngress, bool) {
+			return corev1.LoadBalancerIngress{Hostname: "external.example.com"}, true
+		},
+		originalIngress: []corev1.LoadBalancerIngress{
+			{IP: "10.0.0.1"},
+		},
+		expectedIngress: []corev1.LoadBalancerIngress{
+			{Hostname: "external.example.com"},
+		},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			service := &corev1.Service{
+				TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{Ingress: c.originalIngress},
+				},
+			}
+			unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(service)
+			require.NoError(t, err)
+			obj := &unstructured.Unstructured{Object: unstructuredObj}
+
+			err = NewServiceTransformer(c.rewrite).Transform(obj)
+			require.NoError(t, err)
+
+			var transformed corev1.Service
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &transformed)
+			require.NoError(t, err)
+			if !apiequality.Semantic.DeepEqual(c.expectedIngress, transformed.Status.LoadBalancer.Ingress) {
+				t.Errorf("expected ingress %v, got %v", c.expectedIngress, transformed.Status.LoadBalancer.Ingress)
+			}
+		})
+	}
+}
+
+func TestIngressTransform(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-ingress"},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{IP: "10.96.0.12"},
+				{Hostname: "ingress.example.com"},
+			}},
+		},
+	}
+	unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ingress)
+	require.NoError(t, err)
+	obj := &unstructured.Unstructured{Object: unstructuredObj}
+
+	err = NewIngressTransformer(ExternalLoadBalancerIngress).Transform(obj)
+	require.NoError(t, err)
+
+	var transformed networkingv1.Ingress
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &transformed)
+	require.NoError(t, err)
+	require.Equal(t, []corev1.LoadBalancerIngress{{Hostname: "ingress.example.com"}}, transformed.Status.LoadBalancer.Ingress)
+}
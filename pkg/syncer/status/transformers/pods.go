@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformers
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podInternalStatusFields are the fields of the pod status that only make sense
+// inside the physical cluster.
+var podInternalStatusFields = []string{"hostIP", "podIP", "podIPs", "nominatedNodeName"}
+
+type PodTransformer struct {
+}
+
+func (pt *PodTransformer) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "pods",
+	}
+}
+
+func NewPodTransformer() *PodTransformer {
+	return &PodTransformer{}
+}
+
+// Transform removes the node and cluster-internal addresses from the pod status.
+func (pt *PodTransformer) Transform(obj *unstructured.Unstructured) error {
+	for _, field := range podInternalStatusFields {
+		unstructured.RemoveNestedField(obj.Object, "status", field)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Transformer transforms the status of a downstream object before it is synced upstream,
+// the counterpart of the spec mutators in the other direction. The object passed to
+// Transform is a copy owned by the transformer.
+type Transformer interface {
+	GVR() schema.GroupVersionResource
+	Transform(downstreamObj *unstructured.Unstructured) error
+}
+
+// available returns the transformers which can be enabled in the syncer.
+func available() []Transformer {
+	return []Transformer{
+		NewPodTransformer(),
+		NewServiceTransformer(ExternalLoadBalancerIngress),
+		NewIngressTransformer(ExternalLoadBalancerIngress),
+	}
+}
+
+// Resources returns the resources there is a transformer for, e.g. ingresses.networking.k8s.io.
+func Resources() []string {
+	var resources []string
+	for _, t := range available() {
+		resources = append(resources, t.GVR().GroupResource().String())
+	}
+	return resources
+}
+
+// ForResources returns the transformers of the given resources. Transformers are opt-in, none is
+// applied unless its resource is given.
+func ForResources(resources []schema.GroupResource) ([]Transformer, error) {
+	var transformers []Transformer
+	for _, gr := range resources {
+		found := false
+		for _, t := range available() {
+			if t.GVR().GroupResource() == gr {
+				transformers = append(transformers, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("there is no status transformer for %q, known are: %s", gr, strings.Join(Resources(), ", "))
+		}
+	}
+	return transformers, nil
+}
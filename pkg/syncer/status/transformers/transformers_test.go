@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestForResources(t *testing.T) {
+	transformers, err := ForResources(nil)
+	require.NoError(t, err)
+	require.Empty(t, transformers, "transformers must be opt-in")
+
+	transformers, err = ForResources([]schema.GroupResource{{Resource: "pods"}, {Group: "networking.k8s.io", Resource: "ingresses"}})
+	require.NoError(t, err)
+	require.Len(t, transformers, 2)
+	require.IsType(t, &PodTransformer{}, transformers[0])
+	require.IsType(t, &IngressTransformer{}, transformers[1])
+
+	_, err = ForResources([]schema.GroupResource{{Group: "apps", Resource: "deployments"}})
+	require.Error(t, err)
+}
@@ -25,15 +25,20 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	kubernetesinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/version"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/cache"
+	componentbaseconfig "k8s.io/component-base/config"
 	"k8s.io/klog/v2"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
@@ -42,8 +47,11 @@ import (
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/syncer/namespace"
 	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/pkg/syncer/spec"
 	"github.com/kcp-dev/kcp/pkg/syncer/status"
+	"github.com/kcp-dev/kcp/pkg/syncer/status/transformers"
+	"github.com/kcp-dev/kcp/pkg/syncer/upsync"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
@@ -66,6 +74,30 @@ type SyncerConfig struct {
 	SyncTargetWorkspace logicalcluster.Name
 	SyncTargetName      string
 	SyncTargetUID       string
+
+	// ResourcesToUpsync are the qualified names of the namespaced resources mirrored from the namespaces of
+	// the sync target in the physical cluster to their upstream namespace. Nothing is upsynced if empty.
+	ResourcesToUpsync sets.String
+
+	// CapacityReportInterval is the minimal interval between two updates of the capacity of the
+	// physical cluster in the SyncTarget status. Capacity is not reported if zero.
+	CapacityReportInterval time.Duration
+
+	// StatusAggregation is the strategy to aggregate the statuses of the resources synced to multiple sync
+	// targets, per resource. Resources default to Aggregate. The strategy set on a resource takes precedence.
+	StatusAggregation map[schema.GroupResource]workloadv1alpha1.StatusAggregationStrategy
+
+	// StatusTransformers are the resources whose downstream status is transformed before it is synced
+	// upstream, e.g. to remove cluster-internal addresses. The status is synced as is by default.
+	StatusTransformers []schema.GroupResource
+
+	// WorkspaceConcurrency is the maximal number of workers of the spec and status syncers processing the
+	// resources of a workspace at the same time. There is no limit if zero.
+	WorkspaceConcurrency int
+
+	// LeaderElection configures the leader election between the replicas of the syncer, with a lock in
+	// the physical cluster. There is no leader election if nil or not enabled.
+	LeaderElection *componentbaseconfig.LeaderElectionConfiguration
 }
 
 func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, importPollInterval time.Duration) error {
@@ -154,12 +186,7 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	}
 
 	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(cfg.SyncTargetWorkspace, cfg.SyncTargetName)
-	upstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(upstreamDynamicClusterClient.Cluster(logicalcluster.Wildcard), resyncPeriod, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
-	})
-	downstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactoryWithOptions(downstreamDynamicClient, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
-	}, cache.WithResyncPeriod(resyncPeriod), cache.WithKeyFunction(keyfunctions.DeletionHandlingMetaNamespaceKeyFunc))
+	upstreamInformers, downstreamInformers := newSyncerInformerFactories(upstreamDynamicClusterClient, downstreamDynamicClient, syncTargetKey)
 
 	syncerInformers, err := resourcesync.NewController(
 		upstreamDynamicClusterClient,
@@ -188,14 +215,18 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		return err
 	}
 	specSyncer, err := spec.NewSpecSyncer(cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, upstreamURL, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncerInformers, syncTarget.GetUID())
+		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncerInformers, syncTarget.GetUID(), kcpInformerFactory.Workload().V1alpha1().SyncTargets().Lister(), cfg.WorkspaceConcurrency)
 	if err != nil {
 		return err
 	}
 
 	klog.Infof("Creating status syncer for SyncTarget %s|%s, resources %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, resources)
+	statusTransformers, err := transformers.ForResources(cfg.StatusTransformers)
+	if err != nil {
+		return err
+	}
 	statusSyncer, err := status.NewStatusSyncer(cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncerInformers, syncTarget.GetUID())
+		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncerInformers, syncTarget.GetUID(), cfg.StatusAggregation, statusTransformers, cfg.WorkspaceConcurrency)
 	if err != nil {
 		return err
 	}
@@ -210,45 +241,135 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		return err
 	}
 
+	var upsyncer *upsync.Controller
+	var upsyncInformers dynamicinformer.DynamicSharedInformerFactory
+	if cfg.ResourcesToUpsync.Len() > 0 {
+		mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(downstreamKubeClient.Discovery()))
+		upsyncGVRs, err := upsync.ResolveResources(mapper, cfg.ResourcesToUpsync.List())
+		if err != nil {
+			return err
+		}
+
+		klog.Infof("Creating upsyncer for SyncTarget %s|%s, resources %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, upsyncGVRs)
+		// the resources created downstream by third parties do not carry the labels of the syncer
+		upsyncInformers = dynamicinformer.NewFilteredDynamicSharedInformerFactoryWithOptions(downstreamDynamicClient, metav1.NamespaceAll, nil,
+			cache.WithResyncPeriod(resyncPeriod), cache.WithKeyFunction(keyfunctions.DeletionHandlingMetaNamespaceKeyFunc))
+		upsyncer, err = upsync.NewUpsyncer(cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, syncTarget.GetUID(),
+			upstreamDynamicClusterClient, downstreamInformers, upsyncInformers, upsyncGVRs, cfg.WorkspaceConcurrency)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The informers are started in every replica, so that standby replicas take over quickly when leader
+	// election is enabled. The resourcesync controller only starts informers and patches the SyncerAuthorized
+	// condition when it changes, so it runs in every replica too.
 	upstreamInformers.Start(ctx.Done())
 	downstreamInformers.Start(ctx.Done())
 	kcpInformerFactory.Start(ctx.Done())
+	if upsyncInformers != nil {
+		upsyncInformers.Start(ctx.Done())
+	}
+
+	var capacity *capacityReporter
+	var capacityInformersSynced func()
+	if cfg.CapacityReportInterval > 0 {
+		nodeInformers := kubernetesinformers.NewSharedInformerFactory(downstreamKubeClient, resyncPeriod)
+		podInformers := kubernetesinformers.NewSharedInformerFactoryWithOptions(downstreamKubeClient, resyncPeriod, kubernetesinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = capacityPodsFieldSelector
+		}))
+		capacity = &capacityReporter{
+			nodeLister: nodeInformers.Core().V1().Nodes().Lister(),
+			podLister:  podInformers.Core().V1().Pods().Lister(),
+			patch: func(ctx context.Context, patch []byte) error {
+				_, err := kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patch, metav1.PatchOptions{}, "status")
+				return err
+			},
+			syncTargetUID: string(syncTarget.GetUID()),
+		}
+		nodeInformers.Start(ctx.Done())
+		podInformers.Start(ctx.Done())
+		capacityInformersSynced = func() {
+			nodeInformers.WaitForCacheSync(ctx.Done())
+			podInformers.WaitForCacheSync(ctx.Done())
+		}
+	}
 
 	upstreamInformers.WaitForCacheSync(ctx.Done())
 	downstreamInformers.WaitForCacheSync(ctx.Done())
 	kcpInformerFactory.WaitForCacheSync(ctx.Done())
+	if upsyncInformers != nil {
+		upsyncInformers.WaitForCacheSync(ctx.Done())
+	}
 
-	go apiImporter.Start(ctx, importPollInterval)
 	go syncerInformers.Start(ctx, 1)
-	go specSyncer.Start(ctx, numSyncerThreads)
-	go statusSyncer.Start(ctx, numSyncerThreads)
-	go downstreamNamespaceController.Start(ctx, numSyncerThreads)
-	go upstreamNamespaceController.Start(ctx, numSyncerThreads)
-
-	if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
-		go startSyncerTunnel(ctx, upstreamConfig, downstreamConfig, cfg.SyncTargetWorkspace, cfg.SyncTargetName)
-	}
-
-	// Attempt to heartbeat every interval
-	go wait.UntilWithContext(ctx, func(ctx context.Context) {
-		var heartbeatTime time.Time
-
-		// TODO(marun) Figure out a strategy for backoff to avoid a thundering herd problem with lots of syncers
-		// Attempt to heartbeat every second until successful. Errors are logged instead of being returned so the
-		// poll error can be safely ignored.
-		_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
-			patchBytes := []byte(fmt.Sprintf(`[{"op":"test","path":"/metadata/uid","value":%q},{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, cfg.SyncTargetUID, time.Now().Format(time.RFC3339)))
-			syncTarget, err = kcpClusterClient.Cluster(cfg.SyncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
-			if err != nil {
-				logger.Error(err, "failed to set status.lastSyncerHeartbeatTime")
-				return false, nil //nolint:nilerr
-			}
-
-			heartbeatTime = syncTarget.Status.LastSyncerHeartbeatTime.Time
-			return true, nil
-		})
-		logger.V(5).Info("Heartbeat set", "heartbeatTime", heartbeatTime)
-	}, heartbeatInterval)
-
-	return nil
+
+	health := &healthReporter{
+		downstream: downstreamKubeClient.Discovery().RESTClient(),
+		resources:  syncerInformers.ResourceHealth,
+		controllers: []*shared.ControllerHealth{
+			specSyncer.Health(),
+			statusSyncer.Health(),
+			downstreamNamespaceController.Health(),
+			upstreamNamespaceController.Health(),
+		},
+	}
+	if upsyncer != nil {
+		health.controllers = append(health.controllers, upsyncer.Health())
+	}
+
+	// run starts everything writing upstream or downstream. With leader election, it only runs in the leader.
+	run := func(ctx context.Context) {
+		go apiImporter.Start(ctx, importPollInterval)
+		go specSyncer.Start(ctx, numSyncerThreads)
+		go statusSyncer.Start(ctx, numSyncerThreads)
+		go downstreamNamespaceController.Start(ctx, numSyncerThreads)
+		go upstreamNamespaceController.Start(ctx, numSyncerThreads)
+		if upsyncer != nil {
+			go upsyncer.Start(ctx, numSyncerThreads)
+		}
+
+		if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
+			go startSyncerTunnel(ctx, upstreamConfig, downstreamConfig, cfg.SyncTargetWorkspace, cfg.SyncTargetName)
+		}
+
+		if capacity != nil {
+			go func() {
+				capacityInformersSynced()
+				wait.UntilWithContext(ctx, capacity.report, cfg.CapacityReportInterval)
+			}()
+		}
+
+		// Attempt to heartbeat every interval
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			var heartbeatTime time.Time
+			syncerHealth := health.health(ctx)
+
+			// TODO(marun) Figure out a strategy for backoff to avoid a thundering herd problem with lots of syncers
+			// Attempt to heartbeat every second until successful. Errors are logged instead of being returned so the
+			// poll error can be safely ignored.
+			_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
+				patchBytes, err := heartbeatPatch(cfg.SyncTargetUID, time.Now(), kcpVersion, syncerHealth)
+				if err != nil {
+					logger.Error(err, "failed to create the heartbeat patch")
+					return false, nil //nolint:nilerr
+				}
+				syncTarget, err := kcpClusterClient.Cluster(cfg.SyncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
+				if err != nil {
+					logger.Error(err, "failed to set status.lastSyncerHeartbeatTime")
+					return false, nil //nolint:nilerr
+				}
+
+				heartbeatTime = syncTarget.Status.LastSyncerHeartbeatTime.Time
+				return true, nil
+			})
+			logger.V(5).Info("Heartbeat set", "heartbeatTime", heartbeatTime)
+		}, heartbeatInterval)
+	}
+
+	if cfg.LeaderElection == nil || !cfg.LeaderElection.LeaderElect {
+		run(ctx)
+		return nil
+	}
+	return runWhenLeading(ctx, cfg.LeaderElection, downstreamKubeClient, shared.GetSyncerID(syncTarget), run)
 }
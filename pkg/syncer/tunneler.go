@@ -102,14 +102,15 @@ func startTunneler(ctx context.Context, upstream, downstream *rest.Config, syncT
 
 	logger := klog.FromContext(ctx).WithValues("syncer-tunnel-url", dst)
 	logger.Info("connecting to destination URL")
-	l, err := tunneler.NewListener(clientUpstream, dst)
+	l, err := tunneler.NewListenerWithOptions(clientUpstream, dst, tunneler.ListenerOptions{Multiplexing: true})
 	if err != nil {
 		return err
 	}
 	defer l.Close()
 
-	// reverse proxy the request coming from the reverse connection to the p-cluster apiserver
-	server := &http.Server{Handler: proxy}
+	// reverse proxy the request coming from the reverse connection to the p-cluster apiserver. kcp multiplexes the
+	// requests over HTTP/2, except upgrades like exec that stay on HTTP/1.1.
+	server := &http.Server{Handler: tunneler.WithMultiplexing(proxy)}
 	defer server.Close()
 
 	logger.V(2).Info("serving on reverse connection")
@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/syncer/fairqueue"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+	"github.com/kcp-dev/kcp/third_party/keyfunctions"
+)
+
+const (
+	controllerName = "kcp-workload-syncer-upsync"
+)
+
+// Controller mirrors resources created downstream in the namespaces of the sync target, e.g. by controllers
+// or provisioners, to the upstream namespace they belong to. The upsynced resources are labelled with the
+// Upsync state of the sync target, and are read-only upstream.
+type Controller struct {
+	queue  workqueue.RateLimitingInterface
+	health *shared.ControllerHealth
+
+	upstreamClient            dynamic.ClusterInterface
+	downstreamNamespaceLister cache.GenericLister
+	upsyncInformers           dynamicinformer.DynamicSharedInformerFactory
+
+	syncTargetName      string
+	syncTargetWorkspace logicalcluster.Name
+	syncTargetUID       types.UID
+	syncTargetKey       string
+}
+
+// NewUpsyncer returns an upsync controller for the given resources. The upsync informers must not be filtered,
+// as resources created downstream by third parties do not carry the labels of the syncer.
+func NewUpsyncer(syncTargetWorkspace logicalcluster.Name, syncTargetName, syncTargetKey string, syncTargetUID types.UID,
+	upstreamClient dynamic.ClusterInterface, downstreamInformers, upsyncInformers dynamicinformer.DynamicSharedInformerFactory,
+	gvrs []schema.GroupVersionResource, workspaceConcurrency int) (*Controller, error) {
+
+	c := &Controller{
+		upstreamClient:            upstreamClient,
+		downstreamNamespaceLister: downstreamInformers.ForResource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Lister(),
+		upsyncInformers:           upsyncInformers,
+
+		syncTargetName:      syncTargetName,
+		syncTargetWorkspace: syncTargetWorkspace,
+		syncTargetUID:       syncTargetUID,
+		syncTargetKey:       syncTargetKey,
+	}
+	c.queue = fairqueue.New(controllerName, c.workspaceOfQueueKey, workspaceConcurrency)
+	c.health = shared.NewControllerHealth(controllerName, c.queue)
+
+	logger := logging.WithReconciler(klog.Background(), controllerName)
+
+	// resources deleted downstream after their namespace are not mapped to their upstream namespace anymore.
+	downstreamInformers.ForResource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueDeletedNamespace(gvrs, obj, logger)
+		},
+	})
+
+	for _, gvr := range gvrs {
+		gvr := gvr
+		logger.V(2).Info("Set up upsync informer", "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "syncTargetKey", syncTargetKey, "gvr", gvr.String())
+		upsyncInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.AddToQueue(gvr, obj, logger)
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				c.AddToQueue(gvr, obj, logger)
+			},
+			DeleteFunc: func(obj interface{}) {
+				c.AddToQueue(gvr, obj, logger)
+			},
+		})
+	}
+
+	return c, nil
+}
+
+type queueKey struct {
+	gvr schema.GroupVersionResource
+	key string // meta namespace key
+	// deletedNamespace is the locator of the deleted downstream namespace named by key, whose upsynced
+	// resources are to be deleted upstream.
+	deletedNamespace *shared.NamespaceLocator
+}
+
+// workspaceOfQueueKey returns the upstream logical cluster of the downstream namespace of the queued resource,
+// to share the workers fairly between the workspaces.
+func (c *Controller) workspaceOfQueueKey(item interface{}) string {
+	if deletedNamespace := item.(queueKey).deletedNamespace; deletedNamespace != nil {
+		return deletedNamespace.Workspace.String()
+	}
+	namespace, _, err := cache.SplitMetaNamespaceKey(item.(queueKey).key)
+	if err != nil || namespace == "" {
+		return ""
+	}
+	namespaceLocator, err := c.namespaceLocator(namespace)
+	if err != nil || namespaceLocator == nil {
+		return ""
+	}
+	return namespaceLocator.Workspace.String()
+}
+
+func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}, logger logr.Logger) {
+	key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if metaObj, err := meta.Accessor(obj); err == nil && metaObj.GetNamespace() == "" {
+		// only namespaced resources can be mapped to a workspace
+		return
+	}
+
+	logger.V(4).Info("queueing GVR", "gvr", gvr.String(), "key", key)
+	c.queue.Add(queueKey{gvr: gvr, key: key})
+}
+
+// enqueueDeletedNamespace queues the cleanup of the resources upsynced from the deleted downstream namespace,
+// if the namespace belongs to the sync target.
+func (c *Controller) enqueueDeletedNamespace(gvrs []schema.GroupVersionResource, obj interface{}, logger logr.Logger) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	nsMeta, err := meta.Accessor(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	namespaceLocator, err := c.namespaceLocatorOf(nsMeta)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if namespaceLocator == nil {
+		return
+	}
+
+	for _, gvr := range gvrs {
+		logger.V(4).Info("queueing GVR for the deleted namespace", "gvr", gvr.String(), "namespace", nsMeta.GetName())
+		c.queue.Add(queueKey{gvr: gvr, key: nsMeta.GetName(), deletedNamespace: namespaceLocator})
+	}
+}
+
+// Start starts N worker processes processing work items.
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting syncer workers")
+	defer logger.Info("Stopping syncer workers")
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+// startWorker processes work items until stopCh is closed.
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	qk := key.(queueKey)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), qk.key).WithValues("gvr", qk.gvr.String())
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	var err error
+	if qk.deletedNamespace != nil {
+		err = c.processDeletedNamespace(ctx, qk.gvr, qk.key, *qk.deletedNamespace)
+	} else {
+		err = c.process(ctx, qk.gvr, qk.key)
+	}
+	c.health.Observe(err)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+
+	return true
+}
+
+// Health returns the health of the controller, reported with the heartbeats of the syncer.
+func (c *Controller) Health() *shared.ControllerHealth {
+	return c.health
+}
+
+// namespaceLocator returns the locator of the given downstream namespace, or nil if the namespace does
+// not belong to the sync target.
+func (c *Controller) namespaceLocator(downstreamNamespace string) (*shared.NamespaceLocator, error) {
+	nsObj, err := c.downstreamNamespaceLister.Get(downstreamNamespace)
+	if err != nil {
+		return nil, err
+	}
+	nsMeta, ok := nsObj.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("namespace %q: expected a metav1.Object, got %T", downstreamNamespace, nsObj)
+	}
+	return c.namespaceLocatorOf(nsMeta)
+}
+
+// namespaceLocatorOf returns the locator of the given downstream namespace, or nil if the namespace does
+// not belong to the sync target.
+func (c *Controller) namespaceLocatorOf(nsMeta metav1.Object) (*shared.NamespaceLocator, error) {
+	namespaceLocator, exists, err := shared.LocatorFromAnnotations(nsMeta.GetAnnotations())
+	if err != nil {
+		return nil, fmt.Errorf("namespace %q: error decoding annotation: %w", nsMeta.GetName(), err)
+	}
+	if !exists || namespaceLocator == nil {
+		return nil, nil
+	}
+	if namespaceLocator.SyncTarget.UID != c.syncTargetUID || namespaceLocator.SyncTarget.Workspace != c.syncTargetWorkspace.String() {
+		return nil, nil
+	}
+	return namespaceLocator, nil
+}
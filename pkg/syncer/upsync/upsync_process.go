@@ -0,0 +1,267 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResource, key string) error {
+	logger := klog.FromContext(ctx)
+
+	downstreamNamespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Error(err, "Invalid key")
+		return nil
+	}
+	if downstreamNamespace == "" {
+		return nil
+	}
+
+	namespaceLocator, err := c.namespaceLocator(downstreamNamespace)
+	if apierrors.IsNotFound(err) {
+		// the upstream resources are deleted when the deletion of the namespace is processed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if namespaceLocator == nil {
+		logger.V(4).Info("namespace does not belong to the sync target, skipping")
+		return nil
+	}
+	upstreamClient := c.upstreamClient.Cluster(namespaceLocator.Workspace).Resource(gvr).Namespace(namespaceLocator.Namespace)
+	logger = logger.WithValues("upstreamWorkspace", namespaceLocator.Workspace, "upstreamNamespace", namespaceLocator.Namespace)
+	ctx = klog.NewContext(ctx, logger)
+
+	obj, exists, err := c.upsyncInformers.ForResource(gvr).Informer().GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return c.deleteUpstream(ctx, upstreamClient, name)
+	}
+	downstream, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("%s: expected an Unstructured, got %T", key, obj)
+	}
+	if downstream.GetDeletionTimestamp() != nil {
+		return c.deleteUpstream(ctx, upstreamClient, name)
+	}
+	if downstream.GetLabels()[workloadv1alpha1.InternalDownstreamClusterLabel] == c.syncTargetKey {
+		logger.V(4).Info("resource is synced from upstream, skipping")
+		return nil
+	}
+
+	upstream := toUpstream(downstream, namespaceLocator.Namespace, c.syncTargetKey)
+
+	existing, err := upstreamClient.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.V(2).Info("Creating upsynced resource upstream")
+		created, err := upstreamClient.Create(ctx, upstream, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		return c.updateUpstreamStatus(ctx, upstreamClient, upstream, created)
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.GetLabels()[c.stateLabel()] != string(workloadv1alpha1.ResourceStateUpsync) {
+		logger.Info("Upstream resource already exists and is not upsynced, skipping")
+		return nil
+	}
+	if equality.Semantic.DeepEqual(upstream.Object, withoutServerFields(existing).Object) {
+		return nil
+	}
+
+	logger.V(2).Info("Updating upsynced resource upstream")
+	upstream.SetResourceVersion(existing.GetResourceVersion())
+	updated, err := upstreamClient.Update(ctx, upstream, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	return c.updateUpstreamStatus(ctx, upstreamClient, upstream, updated)
+}
+
+// processDeletedNamespace deletes the resources upsynced from the deleted downstream namespace upstream, unless
+// the downstream namespace has been created again in the meantime.
+func (c *Controller) processDeletedNamespace(ctx context.Context, gvr schema.GroupVersionResource, downstreamNamespace string, namespaceLocator shared.NamespaceLocator) error {
+	logger := klog.FromContext(ctx).WithValues("upstreamWorkspace", namespaceLocator.Workspace, "upstreamNamespace", namespaceLocator.Namespace)
+	ctx = klog.NewContext(ctx, logger)
+
+	if _, err := c.downstreamNamespaceLister.Get(downstreamNamespace); err == nil {
+		logger.V(4).Info("namespace exists again, skipping")
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	upstreamClient := c.upstreamClient.Cluster(namespaceLocator.Workspace).Resource(gvr).Namespace(namespaceLocator.Namespace)
+	upsynced, err := upstreamClient.List(ctx, metav1.ListOptions{LabelSelector: c.stateLabel() + "=" + string(workloadv1alpha1.ResourceStateUpsync)})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := range upsynced.Items {
+		err := c.deleteUpstream(klog.NewContext(ctx, logger.WithValues("name", upsynced.Items[i].GetName())), upstreamClient, upsynced.Items[i].GetName())
+		if apierrors.IsForbidden(err) {
+			// the upstream namespace is not scheduled to the sync target anymore. kcp deletes the upsynced resources then.
+			logger.V(4).Info("upstream namespace is not scheduled to the sync target anymore, skipping")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateUpstreamStatus updates the status of the upstream resource if the resource has a status subresource
+// which ignored the status on creation or update.
+func (c *Controller) updateUpstreamStatus(ctx context.Context, upstreamClient dynamic.ResourceInterface, desired, current *unstructured.Unstructured) error {
+	status, found, err := unstructured.NestedFieldNoCopy(desired.Object, "status")
+	if err != nil || !found {
+		return err
+	}
+	if currentStatus, _, _ := unstructured.NestedFieldNoCopy(current.Object, "status"); equality.Semantic.DeepEqual(status, currentStatus) {
+		return nil
+	}
+
+	current = current.DeepCopy()
+	if err := unstructured.SetNestedField(current.Object, status, "status"); err != nil {
+		return err
+	}
+	_, err = upstreamClient.UpdateStatus(ctx, current, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+		// no status subresource
+		return nil
+	}
+	return err
+}
+
+func (c *Controller) deleteUpstream(ctx context.Context, upstreamClient dynamic.ResourceInterface, name string) error {
+	logger := klog.FromContext(ctx)
+
+	existing, err := upstreamClient.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.GetLabels()[c.stateLabel()] != string(workloadv1alpha1.ResourceStateUpsync) {
+		return nil
+	}
+
+	logger.V(2).Info("Deleting upsynced resource upstream")
+	uid := existing.GetUID()
+	err = upstreamClient.Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *Controller) stateLabel() string {
+	return workloadv1alpha1.ClusterResourceStateLabelPrefix + c.syncTargetKey
+}
+
+// toUpstream returns the upstream representation of the downstream resource: in the upstream namespace, without
+// the fields set by the downstream API server and controllers, and labelled as upsynced from the sync target.
+func toUpstream(downstream *unstructured.Unstructured, upstreamNamespace, syncTargetKey string) *unstructured.Unstructured {
+	upstream := withoutServerFields(downstream)
+	upstream.SetNamespace(upstreamNamespace)
+	// owners and finalizers are meaningful downstream only
+	upstream.SetOwnerReferences(nil)
+	upstream.SetFinalizers(nil)
+
+	labels := upstream.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTargetKey] = string(workloadv1alpha1.ResourceStateUpsync)
+	upstream.SetLabels(labels)
+
+	return upstream
+}
+
+// withoutServerFields returns a copy of the resource without the metadata managed by the API server.
+func withoutServerFields(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetDeletionTimestamp(nil)
+	obj.SetDeletionGracePeriodSeconds(nil)
+	obj.SetManagedFields(nil)
+	obj.SetSelfLink("")
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+// ResolveResources returns the versioned resources of the given qualified resource names, e.g. endpoints or
+// events.events.k8s.io, in their preferred version. Only namespaced resources can be upsynced.
+func ResolveResources(mapper meta.RESTMapper, resources []string) ([]schema.GroupVersionResource, error) {
+	gvrs := make([]schema.GroupVersionResource, 0, len(resources))
+	for _, resource := range resources {
+		gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve resource %q to upsync: %w", resource, err)
+		}
+		gvk, err := mapper.KindFor(gvr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve resource %q to upsync: %w", resource, err)
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve resource %q to upsync: %w", resource, err)
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			return nil, fmt.Errorf("resource %q cannot be upsynced: only namespaced resources are supported", resource)
+		}
+		gvrs = append(gvrs, gvr)
+	}
+	return gvrs, nil
+}
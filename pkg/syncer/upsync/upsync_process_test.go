@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upsync
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+var (
+	endpointsGVR  = corev1.SchemeGroupVersion.WithResource("endpoints")
+	namespacesGVR = corev1.SchemeGroupVersion.WithResource("namespaces")
+)
+
+type mockedDynamicCluster struct {
+	client *dynamicfake.FakeDynamicClient
+}
+
+func (mdc *mockedDynamicCluster) Cluster(name logicalcluster.Name) dynamic.Interface {
+	return mdc.client
+}
+
+func toUnstructured(t *testing.T, obj runtime.Object) *unstructured.Unstructured {
+	t.Helper()
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: raw}
+}
+
+func namespace(t *testing.T, name string, syncTargetUID types.UID) *unstructured.Unstructured {
+	t.Helper()
+	locator := shared.NewNamespaceLocator(logicalcluster.New("root:org:ws"), logicalcluster.New("root:org:ws"), syncTargetUID, "us-west1", "test")
+	raw, err := json.Marshal(locator)
+	require.NoError(t, err)
+	ns := toUnstructured(t, &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{shared.NamespaceLocatorAnnotation: string(raw)}},
+	})
+	return ns
+}
+
+func endpoints(t *testing.T, namespace string, labels map[string]string, ip string) *unstructured.Unstructured {
+	t.Helper()
+	return toUnstructured(t, &corev1.Endpoints{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-service",
+			Namespace: namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "Service", Name: "my-service", UID: "downstream-uid"},
+			},
+		},
+		Subsets: []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: ip}}}},
+	})
+}
+
+func TestUpsyncerProcess(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, "us-west1")
+	upsyncedLabels := map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: "Upsync"}
+	syncedLabels := map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: "Sync"}
+
+	tests := map[string]struct {
+		namespace         *unstructured.Unstructured
+		downstream        *unstructured.Unstructured
+		upstream          *unstructured.Unstructured
+		wantVerbs         []string
+		wantUpstreamLabel string
+	}{
+		"created downstream": {
+			namespace:         namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			downstream:        endpoints(t, "kcp-01c0zzvlqsi7n", nil, "10.0.0.1"),
+			wantVerbs:         []string{"get", "create"},
+			wantUpstreamLabel: "Upsync",
+		},
+		"unchanged": {
+			namespace:         namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			downstream:        endpoints(t, "kcp-01c0zzvlqsi7n", nil, "10.0.0.1"),
+			upstream:          withoutOwners(endpoints(t, "test", upsyncedLabels, "10.0.0.1")),
+			wantVerbs:         []string{"get"},
+			wantUpstreamLabel: "Upsync",
+		},
+		"changed downstream": {
+			namespace:         namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			downstream:        endpoints(t, "kcp-01c0zzvlqsi7n", nil, "10.0.0.2"),
+			upstream:          withoutOwners(endpoints(t, "test", upsyncedLabels, "10.0.0.1")),
+			wantVerbs:         []string{"get", "update"},
+			wantUpstreamLabel: "Upsync",
+		},
+		"upstream resource is not upsynced": {
+			namespace:         namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			downstream:        endpoints(t, "kcp-01c0zzvlqsi7n", nil, "10.0.0.2"),
+			upstream:          endpoints(t, "test", syncedLabels, "10.0.0.1"),
+			wantVerbs:         []string{"get"},
+			wantUpstreamLabel: "Sync",
+		},
+		"deleted downstream": {
+			namespace: namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			upstream:  withoutOwners(endpoints(t, "test", upsyncedLabels, "10.0.0.1")),
+			wantVerbs: []string{"get", "delete"},
+		},
+		"namespace of another sync target": {
+			namespace:  namespace(t, "kcp-01c0zzvlqsi7n", "anotherSyncTargetUID"),
+			downstream: endpoints(t, "kcp-01c0zzvlqsi7n", nil, "10.0.0.1"),
+		},
+		"synced from upstream": {
+			namespace:  namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			downstream: endpoints(t, "kcp-01c0zzvlqsi7n", map[string]string{workloadv1alpha1.InternalDownstreamClusterLabel: syncTargetKey}, "10.0.0.1"),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+
+			var upstreamObjects []runtime.Object
+			if tc.upstream != nil {
+				upstreamObjects = append(upstreamObjects, tc.upstream)
+			}
+			upstreamClient := dynamicfake.NewSimpleDynamicClient(scheme, upstreamObjects...)
+			downstreamClient := dynamicfake.NewSimpleDynamicClient(scheme)
+
+			downstreamInformers := dynamicinformer.NewDynamicSharedInformerFactory(downstreamClient, 0)
+			upsyncInformers := dynamicinformer.NewDynamicSharedInformerFactory(downstreamClient, 0)
+
+			controller, err := NewUpsyncer(syncTargetWorkspace, "us-west1", syncTargetKey, "syncTargetUID",
+				&mockedDynamicCluster{client: upstreamClient}, downstreamInformers, upsyncInformers, []schema.GroupVersionResource{endpointsGVR}, 0)
+			require.NoError(t, err)
+
+			require.NoError(t, downstreamInformers.ForResource(namespacesGVR).Informer().GetIndexer().Add(tc.namespace))
+			if tc.downstream != nil {
+				require.NoError(t, upsyncInformers.ForResource(endpointsGVR).Informer().GetIndexer().Add(tc.downstream))
+			}
+
+			err = controller.process(context.Background(), endpointsGVR, "kcp-01c0zzvlqsi7n/my-service")
+			require.NoError(t, err)
+
+			var verbs []string
+			for _, action := range upstreamClient.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			require.Equal(t, tc.wantVerbs, verbs)
+
+			upstream, err := upstreamClient.Tracker().Get(endpointsGVR, "test", "my-service")
+			if tc.wantUpstreamLabel == "" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			upstreamMeta, err := meta.Accessor(upstream)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantUpstreamLabel, upstreamMeta.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTargetKey])
+			if tc.wantUpstreamLabel == "Upsync" {
+				require.Empty(t, upstreamMeta.GetOwnerReferences(), "downstream owner references must not be upsynced")
+				for _, action := range upstreamClient.Actions() {
+					if action, ok := action.(clienttesting.CreateAction); ok {
+						require.Equal(t, "test", action.GetNamespace())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUpsyncerProcessDeletedNamespace(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, "us-west1")
+	upsyncedLabels := map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: "Upsync"}
+	locator := shared.NewNamespaceLocator(syncTargetWorkspace, syncTargetWorkspace, "syncTargetUID", "us-west1", "test")
+
+	tests := map[string]struct {
+		namespace   *unstructured.Unstructured
+		upstream    *unstructured.Unstructured
+		wantDeleted bool
+	}{
+		"upsynced resource is deleted": {
+			upstream:    withoutOwners(endpoints(t, "test", upsyncedLabels, "10.0.0.1")),
+			wantDeleted: true,
+		},
+		"resource not upsynced is kept": {
+			upstream: endpoints(t, "test", map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: "Sync"}, "10.0.0.1"),
+		},
+		"namespace created again": {
+			namespace: namespace(t, "kcp-01c0zzvlqsi7n", "syncTargetUID"),
+			upstream:  withoutOwners(endpoints(t, "test", upsyncedLabels, "10.0.0.1")),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+
+			upstreamClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.upstream)
+			downstreamClient := dynamicfake.NewSimpleDynamicClient(scheme)
+
+			downstreamInformers := dynamicinformer.NewDynamicSharedInformerFactory(downstreamClient, 0)
+			upsyncInformers := dynamicinformer.NewDynamicSharedInformerFactory(downstreamClient, 0)
+
+			controller, err := NewUpsyncer(syncTargetWorkspace, "us-west1", syncTargetKey, "syncTargetUID",
+				&mockedDynamicCluster{client: upstreamClient}, downstreamInformers, upsyncInformers, []schema.GroupVersionResource{endpointsGVR}, 0)
+			require.NoError(t, err)
+
+			if tc.namespace != nil {
+				require.NoError(t, downstreamInformers.ForResource(namespacesGVR).Informer().GetIndexer().Add(tc.namespace))
+			}
+
+			err = controller.processDeletedNamespace(context.Background(), endpointsGVR, "kcp-01c0zzvlqsi7n", locator)
+			require.NoError(t, err)
+
+			_, err = upstreamClient.Tracker().Get(endpointsGVR, "test", "my-service")
+			if tc.wantDeleted {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func withoutOwners(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj.SetOwnerReferences(nil)
+	return obj
+}
+
+func TestResolveResources(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.AddSpecific(corev1.SchemeGroupVersion.WithKind("Endpoints"), endpointsGVR, corev1.SchemeGroupVersion.WithResource("endpoint"), meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "events.k8s.io", Version: "v1", Kind: "Event"}, meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("PersistentVolume"), meta.RESTScopeRoot)
+
+	gvrs, err := ResolveResources(mapper, []string{"endpoints", "events.events.k8s.io"})
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupVersionResource{endpointsGVR, {Group: "events.k8s.io", Version: "v1", Resource: "events"}}, gvrs)
+
+	_, err = ResolveResources(mapper, []string{"persistentvolumes"})
+	require.ErrorContains(t, err, "only namespaced resources are supported")
+
+	_, err = ResolveResources(mapper, []string{"unknowns"})
+	require.Error(t, err)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clusters"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// tunnelSubresource is the SyncTarget subresource the tunnel commands are authorized against, i.e.
+// the verbs "connect" and "proxy" on "synctargets/tunnel" in the workspace of the SyncTarget.
+const tunnelSubresource = "tunnel"
+
+var syncTargetsResource = schema.GroupResource{Group: workloadv1alpha1.SchemeGroupVersion.Group, Resource: "synctargets"}
+
+// TunnelAuthorizer decides whether a user may run a tunnel command for a SyncTarget. It returns a
+// forbidden error if the user is not allowed to.
+type TunnelAuthorizer interface {
+	// AuthorizeConnect checks that the user may register reverse connections for the SyncTarget.
+	AuthorizeConnect(ctx context.Context, user user.Info, syncTargetWorkspace logicalcluster.Name, syncTargetName string) error
+	// AuthorizeProxy checks that the user may send requests through the tunnel of the SyncTarget.
+	AuthorizeProxy(ctx context.Context, user user.Info, syncTargetWorkspace logicalcluster.Name, syncTargetName string) error
+}
+
+// NewTunnelAuthorizer returns a TunnelAuthorizer checking the tunnel commands with a SubjectAccessReview in the
+// workspace of the SyncTarget. On top, only the service account of the syncer of the SyncTarget may connect,
+// i.e. a user with the permission to connect cannot register a tunnel for another SyncTarget.
+func NewTunnelAuthorizer(syncTargetLister workloadlisters.SyncTargetLister, kubeClusterClient kubernetesclient.ClusterInterface) TunnelAuthorizer {
+	return &tunnelAuthorizer{
+		syncTargetLister:  syncTargetLister,
+		kubeClusterClient: kubeClusterClient,
+		newAuthorizer:     delegated.NewDelegatedAuthorizer,
+	}
+}
+
+type tunnelAuthorizer struct {
+	syncTargetLister  workloadlisters.SyncTargetLister
+	kubeClusterClient kubernetesclient.ClusterInterface
+	newAuthorizer     delegated.DelegatedAuthorizerFactory
+}
+
+func (a *tunnelAuthorizer) AuthorizeConnect(ctx context.Context, user user.Info, syncTargetWorkspace logicalcluster.Name, syncTargetName string) error {
+	if err := a.authorize(ctx, user, cmdTunnelConnect, syncTargetWorkspace, syncTargetName); err != nil {
+		return err
+	}
+
+	syncTarget, err := a.syncTargetLister.Get(clusters.ToClusterAwareKey(syncTargetWorkspace, syncTargetName))
+	if err != nil {
+		return err
+	}
+	namespace, name, err := serviceaccount.SplitUsername(user.GetName())
+	if err != nil {
+		return apierrors.NewForbidden(syncTargetsResource, syncTargetName, fmt.Errorf("user %q is not a service account", user.GetName()))
+	}
+	if name != shared.GetSyncerID(syncTarget) {
+		return apierrors.NewForbidden(syncTargetsResource, syncTargetName, fmt.Errorf("service account %s/%s is not the one of the syncer of SyncTarget %s|%s", namespace, name, syncTargetWorkspace, syncTargetName))
+	}
+	if clusterNames := user.GetExtra()[serviceaccount.ClusterNameKey]; len(clusterNames) != 1 || clusterNames[0] != syncTargetWorkspace.String() {
+		return apierrors.NewForbidden(syncTargetsResource, syncTargetName, fmt.Errorf("service account %s/%s is not from workspace %s", namespace, name, syncTargetWorkspace))
+	}
+	return nil
+}
+
+func (a *tunnelAuthorizer) AuthorizeProxy(ctx context.Context, user user.Info, syncTargetWorkspace logicalcluster.Name, syncTargetName string) error {
+	return a.authorize(ctx, user, cmdTunnelProxy, syncTargetWorkspace, syncTargetName)
+}
+
+func (a *tunnelAuthorizer) authorize(ctx context.Context, user user.Info, verb string, syncTargetWorkspace logicalcluster.Name, syncTargetName string) error {
+	authz, err := a.newAuthorizer(syncTargetWorkspace, a.kubeClusterClient)
+	if err != nil {
+		return err
+	}
+	decision, reason, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+		User:            user,
+		Verb:            verb,
+		Name:            syncTargetName,
+		APIGroup:        workloadv1alpha1.SchemeGroupVersion.Group,
+		APIVersion:      workloadv1alpha1.SchemeGroupVersion.Version,
+		Resource:        "synctargets",
+		Subresource:     tunnelSubresource,
+		ResourceRequest: true,
+	})
+	if err != nil {
+		return err
+	}
+	if decision != authorizer.DecisionAllow {
+		return apierrors.NewForbidden(syncTargetsResource, syncTargetName, fmt.Errorf("user %q cannot %s the tunnel of SyncTarget %s|%s: %s", user.GetName(), verb, syncTargetWorkspace, syncTargetName, reason))
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestTunnelAuthorizer(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:compute")
+	target := syncTarget(syncTargetWorkspace, "us-west1")
+	syncerUser := func(name, workspace string) user.Info {
+		return &user.DefaultInfo{
+			Name:  serviceaccount.MakeUsername("default", name),
+			Extra: map[string][]string{serviceaccount.ClusterNameKey: {workspace}},
+		}
+	}
+
+	tests := map[string]struct {
+		user       user.Info
+		connect    bool
+		allowed    bool
+		wantVerb   string
+		wantErr    bool
+		wantForbid bool
+	}{
+		"syncer connects": {
+			user:     syncerUser(shared.GetSyncerID(target), syncTargetWorkspace.String()),
+			connect:  true,
+			allowed:  true,
+			wantVerb: "connect",
+		},
+		"syncer without permission connects": {
+			user:       syncerUser(shared.GetSyncerID(target), syncTargetWorkspace.String()),
+			connect:    true,
+			wantVerb:   "connect",
+			wantErr:    true,
+			wantForbid: true,
+		},
+		"user with permission connects": {
+			user:       &user.DefaultInfo{Name: "admin"},
+			connect:    true,
+			allowed:    true,
+			wantVerb:   "connect",
+			wantErr:    true,
+			wantForbid: true,
+		},
+		"syncer of another SyncTarget connects": {
+			user:       syncerUser(shared.GetSyncerID(syncTarget(syncTargetWorkspace, "us-east1")), syncTargetWorkspace.String()),
+			connect:    true,
+			allowed:    true,
+			wantVerb:   "connect",
+			wantErr:    true,
+			wantForbid: true,
+		},
+		"service account of the same name in another workspace connects": {
+			user:       syncerUser(shared.GetSyncerID(target), "root:org:other"),
+			connect:    true,
+			allowed:    true,
+			wantVerb:   "connect",
+			wantErr:    true,
+			wantForbid: true,
+		},
+		"user with permission proxies": {
+			user:     &user.DefaultInfo{Name: "admin"},
+			allowed:  true,
+			wantVerb: "proxy",
+		},
+		"user without permission proxies": {
+			user:       &user.DefaultInfo{Name: "admin"},
+			wantVerb:   "proxy",
+			wantErr:    true,
+			wantForbid: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(func(obj interface{}) (string, error) {
+				syncTarget := obj.(*workloadv1alpha1.SyncTarget)
+				return clusters.ToClusterAwareKey(logicalcluster.From(syncTarget), syncTarget.Name), nil
+			}, cache.Indexers{})
+			require.NoError(t, indexer.Add(target))
+
+			var got authorizer.Attributes
+			a := &tunnelAuthorizer{
+				syncTargetLister: workloadlisters.NewSyncTargetLister(indexer),
+				newAuthorizer: func(clusterName logicalcluster.Name, _ kubernetesclient.ClusterInterface) (authorizer.Authorizer, error) {
+					require.Equal(t, syncTargetWorkspace, clusterName)
+					return authorizer.AuthorizerFunc(func(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+						got = attrs
+						if tc.allowed {
+							return authorizer.DecisionAllow, "", nil
+						}
+						return authorizer.DecisionNoOpinion, "", nil
+					}), nil
+				},
+			}
+
+			var err error
+			if tc.connect {
+				err = a.AuthorizeConnect(context.Background(), tc.user, syncTargetWorkspace, target.Name)
+			} else {
+				err = a.AuthorizeProxy(context.Background(), tc.user, syncTargetWorkspace, target.Name)
+			}
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantForbid, apierrors.IsForbidden(err))
+
+			require.NotNil(t, got)
+			require.Equal(t, tc.wantVerb, got.GetVerb())
+			require.Equal(t, workloadv1alpha1.SchemeGroupVersion.Group, got.GetAPIGroup())
+			require.Equal(t, "synctargets", got.GetResource())
+			require.Equal(t, "tunnel", got.GetSubresource())
+			require.Equal(t, target.Name, got.GetName())
+		})
+	}
+}
+
+func TestSyncerTunnelAuthorization(t *testing.T) {
+	tests := map[string]struct {
+		user       user.Info
+		authorizer TunnelAuthorizer
+		path       string
+		wantStatus int
+	}{
+		"unauthenticated": {
+			authorizer: allowAllTunnelAuthorizer{},
+			path:       "/ws/apis/workload.kcp.dev/v1alpha1/synctargets/d001/proxy/",
+			wantStatus: http.StatusUnauthorized,
+		},
+		"proxy forbidden": {
+			user:       &user.DefaultInfo{Name: "user"},
+			authorizer: denyAllTunnelAuthorizer{},
+			path:       "/ws/apis/workload.kcp.dev/v1alpha1/synctargets/d001/proxy/",
+			wantStatus: http.StatusForbidden,
+		},
+		"connect forbidden": {
+			user:       &user.DefaultInfo{Name: "user"},
+			authorizer: denyAllTunnelAuthorizer{},
+			path:       "/ws/apis/workload.kcp.dev/v1alpha1/synctargets/d001/connect",
+			wantStatus: http.StatusForbidden,
+		},
+		"proxy allowed to a syncer not connected": {
+			user:       &user.DefaultInfo{Name: "user"},
+			authorizer: allowAllTunnelAuthorizer{},
+			path:       "/ws/apis/workload.kcp.dev/v1alpha1/synctargets/d001/proxy/",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var handler http.Handler = NewTunneler(tc.authorizer, NewOptions(), nil).WithSyncerTunnel(http.NotFoundHandler())
+			if tc.user != nil {
+				handler = withUser(handler, tc.user)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, defaultTunnelPathPrefix+tc.path, nil))
+			require.Equal(t, tc.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+// withUser authenticates every request as the given user.
+func withUser(handler http.Handler, u user.Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(request.WithUser(r.Context(), u)))
+	}
+}
+
+type allowAllTunnelAuthorizer struct{}
+
+func (allowAllTunnelAuthorizer) AuthorizeConnect(context.Context, user.Info, logicalcluster.Name, string) error {
+	return nil
+}
+
+func (allowAllTunnelAuthorizer) AuthorizeProxy(context.Context, user.Info, logicalcluster.Name, string) error {
+	return nil
+}
+
+type denyAllTunnelAuthorizer struct{}
+
+func (denyAllTunnelAuthorizer) AuthorizeConnect(_ context.Context, _ user.Info, _ logicalcluster.Name, name string) error {
+	return apierrors.NewForbidden(syncTargetsResource, name, nil)
+}
+
+func (denyAllTunnelAuthorizer) AuthorizeProxy(_ context.Context, _ user.Info, _ logicalcluster.Name, name string) error {
+	return apierrors.NewForbidden(syncTargetsResource, name, nil)
+}
@@ -41,6 +41,12 @@ type Dialer struct {
 	pickupFailed chan error
 	donec        chan struct{}
 	closeOnce    sync.Once
+
+	// workspace and syncTarget identify the tunnel in the metrics.
+	workspace  string
+	syncTarget string
+	// transport sends requests through the reverse connections of the Dialer.
+	transport *tunnelTransport
 }
 
 // NewDialer returns the side of the connection which will initiate
@@ -145,25 +151,36 @@ func (d *Dialer) close() {
 // Dial creates a new connection back to the Listener.
 func (d *Dialer) Dial(ctx context.Context, network string, address string) (net.Conn, error) {
 	now := time.Now()
-	defer klog.V(5).Infof("dial to %s took %v", address, time.Since(now))
+	c, reason, err := d.dial(ctx)
+	klog.V(5).Infof("dial to %s took %v", address, time.Since(now))
+	if err != nil {
+		dialFailures.WithLabelValues(d.workspace, d.syncTarget, reason).Inc()
+		return nil, err
+	}
+	dialDuration.WithLabelValues(d.workspace, d.syncTarget).Observe(time.Since(now).Seconds())
+	return newMeteredConn(c, d.workspace, d.syncTarget), nil
+}
+
+// dial returns a new connection, or the error and the reason of the failure for the metrics.
+func (d *Dialer) dial(ctx context.Context) (net.Conn, string, error) {
 	// First, tell serve that we want a connection:
 	select {
 	case d.connReady <- true:
 	case <-d.donec:
-		return nil, errors.New("tunneler.Dialer closed")
+		return nil, dialFailureClosed, errors.New("tunneler.Dialer closed")
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, dialFailureCanceled, ctx.Err()
 	}
 
 	// Then pick it up:
 	select {
 	case c := <-d.incomingConn:
-		return c, nil
+		return c, "", nil
 	case err := <-d.pickupFailed:
-		return nil, err
+		return nil, dialFailurePickupFailed, err
 	case <-d.donec:
-		return nil, errors.New("tunneler.Dialer closed")
+		return nil, dialFailureClosed, errors.New("tunneler.Dialer closed")
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, dialFailureCanceled, ctx.Err()
 	}
 }
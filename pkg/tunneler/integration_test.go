@@ -19,6 +19,7 @@ package tunneler
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
@@ -26,6 +27,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
 )
 
 func setup(t *testing.T) (*http.Client, string, func()) {
@@ -38,7 +41,7 @@ func setup(t *testing.T) (*http.Client, string, func()) {
 
 	// public server
 	mux := http.NewServeMux()
-	apiHandler := WithSyncerTunnel(mux)
+	apiHandler := withUser(NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), nil).WithSyncerTunnel(mux), &user.DefaultInfo{Name: "syncer"})
 	publicServer := httptest.NewUnstartedServer(apiHandler)
 	publicServer.EnableHTTP2 = true
 	publicServer.StartTLS()
@@ -135,7 +138,7 @@ func Test_integration_listener_reconnect(t *testing.T) {
 
 	// public server
 	mux := http.NewServeMux()
-	apiHandler := WithSyncerTunnel(mux)
+	apiHandler := withUser(NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), nil).WithSyncerTunnel(mux), &user.DefaultInfo{Name: "syncer"})
 	publicServer := httptest.NewUnstartedServer(apiHandler)
 	publicServer.EnableHTTP2 = true
 	publicServer.StartTLS()
@@ -218,3 +221,89 @@ func Test_integration_listener_reconnect(t *testing.T) {
 		t.Errorf("Expected %s received %s", "Hello world", bodyString)
 	}
 }
+
+func Test_integration_multiplexing(t *testing.T) {
+	// public server
+	mux := http.NewServeMux()
+	apiHandler := withUser(NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), nil).WithSyncerTunnel(mux), &user.DefaultInfo{Name: "syncer"})
+	publicServer := httptest.NewUnstartedServer(apiHandler)
+	publicServer.EnableHTTP2 = true
+	publicServer.StartTLS()
+	defer publicServer.Close()
+
+	// private server
+	dstUrl, err := SyncerTunnelURL(publicServer.URL, "ws", "d001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewListenerWithOptions(publicServer.Client(), dstUrl, ListenerOptions{Multiplexing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &countingListener{Listener: l}
+	server := &http.Server{Handler: WithMultiplexing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))}
+	//nolint:errcheck
+	go server.Serve(cl)
+	defer server.Close()
+
+	// wait for the reverse connection to be established
+	time.Sleep(1 * time.Second)
+
+	client := publicServer.Client()
+	uri := dstUrl + "/" + cmdTunnelProxy + "/"
+	get := func() {
+		resp, err := client.Get(uri)
+		if err != nil {
+			t.Errorf("Request Failed: %s", err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Errorf("Reading body failed: %s", err)
+		}
+		if string(body) != "HTTP/2.0" {
+			t.Errorf("Expected %s received %s", "HTTP/2.0", string(body))
+		}
+	}
+
+	get()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get()
+		}()
+	}
+	wg.Wait()
+
+	if accepted := cl.accepted(); accepted != 1 {
+		t.Errorf("Expected all the requests over 1 reverse connection, got %d", accepted)
+	}
+}
+
+// countingListener counts the accepted connections.
+type countingListener struct {
+	net.Listener
+	mu sync.Mutex
+	n  int
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.n++
+		l.mu.Unlock()
+	}
+	return c, err
+}
+
+func (l *countingListener) accepted() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
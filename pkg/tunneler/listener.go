@@ -44,22 +44,37 @@ var _ net.Listener = (*Listener)(nil)
 type Listener struct {
 	url    string
 	client *http.Client
+	opts   ListenerOptions
 
 	sc     net.Conn // control plane connection
 	connc  chan net.Conn
 	donec  chan struct{}
 	writec chan<- []byte
 
-	mu      sync.Mutex // guards below, closing connc, and writing to rw
+	mu      sync.Mutex // guards below, closing donec, and writing to rw
 	readErr error
 	closed  bool
 }
 
+// ListenerOptions configure the protocols served on the connections of a Listener.
+type ListenerOptions struct {
+	// Multiplexing announces that the connections are served with HTTP/2 without TLS next to HTTP/1.1, i.e.
+	// the server handler is wrapped with WithMultiplexing. kcp then sends concurrent requests over a single
+	// reverse connection.
+	Multiplexing bool
+}
+
 // NewListener returns a new Listener, it dials to the Dialer
 // creating "reverse connection" that are accepted by this Listener.
 // - client: http client, required for TLS
 // - url: a URL to the base of the reverse handler on the Dialer
 func NewListener(client *http.Client, url string) (*Listener, error) {
+	return NewListenerWithOptions(client, url, ListenerOptions{})
+}
+
+// NewListenerWithOptions returns a new Listener like NewListener, serving the
+// protocols of the given options on its connections.
+func NewListenerWithOptions(client *http.Client, url string, opts ListenerOptions) (*Listener, error) {
 	err := configureHTTP2Transport(client)
 	if err != nil {
 		return nil, err
@@ -68,6 +83,7 @@ func NewListener(client *http.Client, url string) (*Listener, error) {
 	ln := &Listener{
 		url:    url,
 		client: client,
+		opts:   opts,
 		connc:  make(chan net.Conn, 4), // arbitrary
 		donec:  make(chan struct{}),
 	}
@@ -157,6 +173,9 @@ func (ln *Listener) dial() (net.Conn, error) {
 		klog.V(5).Infof("Can not create request %v", err)
 		return nil, err
 	}
+	if ln.opts.Multiplexing {
+		req.Header.Set(tunnelProtocolsHeader, protocolH2C)
+	}
 
 	klog.V(5).Infof("Listener creating connection to %s", connect)
 	res, err := ln.client.Do(req)
@@ -184,22 +203,20 @@ func (ln *Listener) grabConn() {
 
 	// send the connection to the listener
 	select {
+	case ln.connc <- c:
 	case <-ln.donec:
-		return
-	default:
-		select {
-		case ln.connc <- c:
-		case <-ln.donec:
-			return
-		}
+		c.Close()
 	}
 
 }
 
 // Accept blocks and returns a new connection, or an error.
 func (ln *Listener) Accept() (net.Conn, error) {
-	c, ok := <-ln.connc
-	if !ok {
+	select {
+	case c := <-ln.connc:
+		klog.V(5).Infof("Accept connection")
+		return c, nil
+	case <-ln.donec:
 		ln.mu.Lock()
 		err, closed := ln.readErr, ln.closed
 		ln.mu.Unlock()
@@ -208,8 +225,6 @@ func (ln *Listener) Accept() (net.Conn, error) {
 		}
 		return nil, ErrListenerClosed
 	}
-	klog.V(5).Infof("Accept connection")
-	return c, nil
 }
 
 // ErrListenerClosed is returned by Accept after Close has been called.
@@ -224,7 +239,7 @@ func (ln *Listener) Close() error {
 		return nil
 	}
 	ln.closed = true
-	close(ln.connc)
+	// connc is never closed, grabConn might still be sending to it
 	close(ln.donec)
 	ln.sc.Close()
 	return nil
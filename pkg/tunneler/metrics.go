@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"net"
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "kcp"
+	metricsSubsystem = "syncer_tunnel"
+
+	directionToSyncer   = "to_syncer"
+	directionFromSyncer = "from_syncer"
+
+	dialFailureClosed       = "closed"
+	dialFailureCanceled     = "canceled"
+	dialFailurePickupFailed = "pickup_failed"
+)
+
+var (
+	tunnelLabels = []string{"workspace", "synctarget"}
+
+	openTunnels = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "open",
+		Help:           "Number of connected syncer tunnels per SyncTarget.",
+		StabilityLevel: metrics.ALPHA,
+	}, tunnelLabels)
+
+	openConnections = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "connections",
+		Help:           "Number of open reverse connections per SyncTarget.",
+		StabilityLevel: metrics.ALPHA,
+	}, tunnelLabels)
+
+	transferredBytes = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "bytes_total",
+		Help:           "Number of bytes sent through the reverse connections per SyncTarget and direction.",
+		StabilityLevel: metrics.ALPHA,
+	}, append(tunnelLabels, "direction"))
+
+	dialDuration = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "dial_duration_seconds",
+		Help:           "Time to get a reverse connection from the syncer per SyncTarget.",
+		Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+		StabilityLevel: metrics.ALPHA,
+	}, tunnelLabels)
+
+	dialFailures = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "dial_failures_total",
+		Help:           "Number of failures to get a reverse connection from the syncer per SyncTarget and reason.",
+		StabilityLevel: metrics.ALPHA,
+	}, append(tunnelLabels, "reason"))
+
+	inflightRequests = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "inflight_requests",
+		Help:           "Number of requests in flight through the tunnel per SyncTarget.",
+		StabilityLevel: metrics.ALPHA,
+	}, tunnelLabels)
+
+	throttledRequests = metrics.NewCounterVec(&metrics.CounterOpts{
+		Namespace:      metricsNamespace,
+		Subsystem:      metricsSubsystem,
+		Name:           "throttled_requests_total",
+		Help:           "Number of requests rejected or given up while waiting for a free slot of the tunnel per SyncTarget.",
+		StabilityLevel: metrics.ALPHA,
+	}, tunnelLabels)
+
+	registerMetricsOnce sync.Once
+)
+
+// registerMetrics registers the syncer tunnel metrics with the global registry of the server.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(openTunnels)
+		legacyregistry.MustRegister(openConnections)
+		legacyregistry.MustRegister(transferredBytes)
+		legacyregistry.MustRegister(dialDuration)
+		legacyregistry.MustRegister(dialFailures)
+		legacyregistry.MustRegister(inflightRequests)
+		legacyregistry.MustRegister(throttledRequests)
+	})
+}
+
+// meteredConn counts the bytes read and written through a reverse connection, and the open connections.
+type meteredConn struct {
+	net.Conn
+	workspace, syncTarget string
+	closeOnce             sync.Once
+}
+
+func newMeteredConn(conn net.Conn, workspace, syncTarget string) net.Conn {
+	openConnections.WithLabelValues(workspace, syncTarget).Inc()
+	return &meteredConn{Conn: conn, workspace: workspace, syncTarget: syncTarget}
+}
+
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	transferredBytes.WithLabelValues(c.workspace, c.syncTarget, directionFromSyncer).Add(float64(n))
+	return n, err
+}
+
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	transferredBytes.WithLabelValues(c.workspace, c.syncTarget, directionToSyncer).Add(float64(n))
+	return n, err
+}
+
+func (c *meteredConn) Close() error {
+	c.closeOnce.Do(func() {
+		openConnections.WithLabelValues(c.workspace, c.syncTarget).Dec()
+	})
+	return c.Conn.Close()
+}
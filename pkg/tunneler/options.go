@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options configure the reverse connections of the syncer tunnels on the kcp side.
+type Options struct {
+	// MaxIdleConnsPerTunnel is the number of idle HTTP/1.1 reverse connections kept for reuse per SyncTarget.
+	// Multiplexed tunnels mostly use a single HTTP/2 connection, and HTTP/1.1 for upgrades only.
+	MaxIdleConnsPerTunnel int
+	// IdleConnTimeout is the time an idle reverse connection is kept before it is closed.
+	IdleConnTimeout time.Duration
+	// MaxConcurrentRequestsPerTunnel limits the requests in flight through the tunnel of one SyncTarget, including
+	// long-running ones like logs and exec. Further requests wait for a slot for at most MaxRequestWaitPerTunnel.
+	// Zero means no limit.
+	MaxConcurrentRequestsPerTunnel int
+	// MaxRequestWaitPerTunnel is the maximal time a request waits for a free slot of a tunnel. Requests which do
+	// not get a slot in time are answered with 429 Too Many Requests and a Retry-After header.
+	MaxRequestWaitPerTunnel time.Duration
+	// CloseDelay is the time a reverse connection waits after its last write before it is closed, to flush the
+	// data in flight.
+	CloseDelay time.Duration
+}
+
+// NewOptions returns the default Options.
+func NewOptions() *Options {
+	return &Options{
+		MaxIdleConnsPerTunnel:          10,
+		IdleConnTimeout:                90 * time.Second,
+		MaxConcurrentRequestsPerTunnel: 100,
+		MaxRequestWaitPerTunnel:        30 * time.Second,
+		CloseDelay:                     500 * time.Millisecond,
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.MaxIdleConnsPerTunnel, "syncer-tunnel-max-idle-connections", o.MaxIdleConnsPerTunnel, "Number of idle reverse connections kept for reuse per syncer tunnel.")
+	fs.DurationVar(&o.IdleConnTimeout, "syncer-tunnel-idle-connection-timeout", o.IdleConnTimeout, "Time an idle reverse connection of a syncer tunnel is kept before it is closed.")
+	fs.IntVar(&o.MaxConcurrentRequestsPerTunnel, "syncer-tunnel-max-concurrent-requests", o.MaxConcurrentRequestsPerTunnel, "Maximum number of requests in flight through one syncer tunnel, including logs, exec and port-forward streams. Further requests wait. 0 means no limit.")
+	fs.DurationVar(&o.MaxRequestWaitPerTunnel, "syncer-tunnel-max-request-wait", o.MaxRequestWaitPerTunnel, "Maximum time a request waits for a free slot of a syncer tunnel before it is rejected with 429 Too Many Requests.")
+	fs.DurationVar(&o.CloseDelay, "syncer-tunnel-close-delay", o.CloseDelay, "Time a reverse connection of a syncer tunnel waits after its last write before it is closed.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if o.MaxIdleConnsPerTunnel < 0 {
+		errs = append(errs, fmt.Errorf("--syncer-tunnel-max-idle-connections must not be negative"))
+	}
+	if o.IdleConnTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--syncer-tunnel-idle-connection-timeout must not be negative"))
+	}
+	if o.MaxConcurrentRequestsPerTunnel < 0 {
+		errs = append(errs, fmt.Errorf("--syncer-tunnel-max-concurrent-requests must not be negative"))
+	}
+	if o.MaxRequestWaitPerTunnel < 0 {
+		errs = append(errs, fmt.Errorf("--syncer-tunnel-max-request-wait must not be negative"))
+	}
+	if o.CloseDelay < 0 {
+		errs = append(errs, fmt.Errorf("--syncer-tunnel-close-delay must not be negative"))
+	}
+
+	return errs
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+var (
+	errorScheme = runtime.NewScheme()
+	errorCodecs = serializer.NewCodecFactory(errorScheme)
+
+	// tunneledPodSubresources are the pod subresources served by the physical cluster the pod runs on.
+	tunneledPodSubresources = sets.NewString("log", "exec", "attach", "portforward")
+)
+
+func init() {
+	errorScheme.AddUnversionedTypes(metav1.Unversioned,
+		&metav1.Status{},
+	)
+}
+
+// podTarget is the pod of a workspace in the physical cluster of a SyncTarget.
+type podTarget struct {
+	syncTarget *workloadv1alpha1.SyncTarget
+	// transport and url reach the physical cluster, either through the tunnel connected to this
+	// shard, or through the shard the tunnel is connected to.
+	transport           http.RoundTripper
+	url                 *url.URL
+	downstreamNamespace string
+}
+
+// WithPodSubresourceProxy serves the log, exec, attach and portforward subresources of pods in workspaces
+// by proxying them through the syncer tunnel to the physical cluster the pod runs on. The SyncTargets are
+// found through the state labels of the upstream namespace, and the downstream namespace through its
+// namespace locator. If the namespace is synced to multiple SyncTargets, the pod is looked up in each of
+// them. Requests for namespaces that are not synced fall through to apiHandler.
+//
+// It must run after authorization, i.e. the user must be allowed to access the subresource in the workspace.
+// The request is sent to the physical cluster with the credentials of the syncer. If the tunnel is connected
+// to another shard, the request is forwarded to that shard, see Sharding. SyncTargets in workspaces of
+// other shards are looked up on those shards, in the location workspaces selected by the Placements of the
+// workspace (placementIndexer is indexed by logical cluster).
+func (t *Tunneler) WithPodSubresourceProxy(apiHandler http.Handler, namespaceLister corev1listers.NamespaceLister, syncTargetIndexer, placementIndexer cache.Indexer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestInfo, ok := request.RequestInfoFrom(r.Context())
+		if !ok || !requestInfo.IsResourceRequest || requestInfo.APIGroup != "" || requestInfo.Resource != "pods" ||
+			!tunneledPodSubresources.Has(requestInfo.Subresource) || requestInfo.Namespace == "" || requestInfo.Name == "" {
+			apiHandler.ServeHTTP(w, r)
+			return
+		}
+		cluster := request.ClusterFrom(r.Context())
+		if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+			apiHandler.ServeHTTP(w, r)
+			return
+		}
+
+		ns, err := namespaceLister.Get(clusters.ToClusterAwareKey(cluster.Name, requestInfo.Namespace))
+		if apierrors.IsNotFound(err) {
+			apiHandler.ServeHTTP(w, r)
+			return
+		} else if err != nil {
+			responsewriters.InternalError(w, r, err)
+			return
+		}
+		syncTargetKeys := shared.SyncTargetKeys(ns.Labels)
+		if len(syncTargetKeys) == 0 {
+			apiHandler.ServeHTTP(w, r)
+			return
+		}
+
+		gv := schema.GroupVersion{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion}
+		var targets []podTarget
+		for _, syncTargetKey := range syncTargetKeys {
+			target, err := t.podTargetFor(r.Context(), syncTargetIndexer, placementIndexer, cluster.Name, requestInfo.Namespace, syncTargetKey)
+			if err != nil {
+				responsewriters.InternalError(w, r, err)
+				return
+			}
+			if target != nil {
+				targets = append(targets, *target)
+			}
+		}
+		if len(targets) == 0 {
+			err := apierrors.NewServiceUnavailable(fmt.Sprintf("no syncer tunnel is connected for namespace %q", requestInfo.Namespace))
+			responsewriters.ErrorNegotiated(err, errorCodecs, gv, w, r)
+			return
+		}
+
+		target := targets[0]
+		if len(targets) > 1 {
+			found := false
+			for _, candidate := range targets {
+				exists, err := downstreamPodExists(r.Context(), candidate, requestInfo.Name)
+				if err != nil {
+					klog.V(4).InfoS("failed to look up pod through syncer tunnel", "syncTarget", candidate.syncTarget.Name, "namespace", candidate.downstreamNamespace, "pod", requestInfo.Name, "err", err)
+					continue
+				}
+				if exists {
+					target, found = candidate, true
+					break
+				}
+			}
+			if !found {
+				err := apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, requestInfo.Name)
+				responsewriters.ErrorNegotiated(err, errorCodecs, gv, w, r)
+				return
+			}
+		}
+
+		downstreamPath := path.Join("/api", requestInfo.APIVersion, "namespaces", target.downstreamNamespace, "pods", requestInfo.Name, requestInfo.Subresource)
+		klog.V(4).InfoS("proxying pod subresource through syncer tunnel", "cluster", cluster.Name, "namespace", requestInfo.Namespace,
+			"pod", requestInfo.Name, "subresource", requestInfo.Subresource, "syncTarget", target.syncTarget.Name, "downstreamPath", downstreamPath)
+
+		proxy := httputil.NewSingleHostReverseProxy(target.url)
+		proxy.Transport = target.transport
+		director := proxy.Director
+		proxy.Director = func(req *http.Request) {
+			director(req)
+			req.URL.Path = target.url.Path + downstreamPath
+			req.URL.RawPath = ""
+			// the physical cluster is accessed with the credentials of the syncer, never with the ones of the user.
+			stripCredentials(req.Header)
+		}
+		proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+			err = apierrors.NewServiceUnavailable(fmt.Sprintf("failed to proxy through the syncer tunnel of SyncTarget %q: %v", target.syncTarget.Name, err))
+			responsewriters.ErrorNegotiated(err, errorCodecs, gv, w, req)
+		}
+		proxy.ServeHTTP(w, r)
+	}
+}
+
+// podTargetFor returns the downstream namespace of the upstream namespace on the SyncTarget with the given key,
+// or nil if the SyncTarget does not exist or its syncer is connected neither to this nor to another shard.
+func (t *Tunneler) podTargetFor(ctx context.Context, syncTargetIndexer, placementIndexer cache.Indexer, clusterName logicalcluster.Name, namespace, syncTargetKey string) (*podTarget, error) {
+	syncTarget, err := t.syncTargetFor(ctx, syncTargetIndexer, placementIndexer, clusterName, syncTargetKey)
+	if err != nil || syncTarget == nil {
+		return nil, err
+	}
+	syncTargetWorkspace := logicalcluster.From(syncTarget)
+	target := &podTarget{syncTarget: syncTarget}
+	if d := t.pool.getDialer(syncTargetWorkspace.String(), syncTarget.Name); d != nil && !isClosedChan(d.Done()) {
+		target.transport = d.transport
+		target.url = &url.URL{Scheme: "http", Host: syncTarget.Name}
+	} else {
+		peerURL, err := t.sharding.peerURLFor(syncTarget)
+		if err != nil || peerURL == nil {
+			return nil, err
+		}
+		tunnelURL, err := SyncerTunnelURL(peerURL.String(), syncTargetWorkspace.String(), syncTarget.Name)
+		if err != nil {
+			return nil, err
+		}
+		if target.url, err = url.Parse(tunnelURL + "/" + cmdTunnelProxy); err != nil {
+			return nil, err
+		}
+		target.transport = &forwardingRoundTripper{delegate: t.sharding.PeerTransport, shardName: t.sharding.ShardName}
+	}
+
+	locator := shared.NewNamespaceLocator(clusterName, syncTargetWorkspace, syncTarget.GetUID(), syncTarget.Name, namespace)
+	downstreamNamespace, err := shared.PhysicalClusterNamespaceName(locator)
+	if err != nil {
+		return nil, err
+	}
+	target.downstreamNamespace = downstreamNamespace
+	return target, nil
+}
+
+// syncTargetFor returns the SyncTarget with the given key, or nil if it does not exist. SyncTargets that are
+// not on this shard are looked up on the other shards, in the location workspaces of the Placements of the
+// workspace.
+func (t *Tunneler) syncTargetFor(ctx context.Context, syncTargetIndexer, placementIndexer cache.Indexer, clusterName logicalcluster.Name, syncTargetKey string) (*workloadv1alpha1.SyncTarget, error) {
+	objs, err := syncTargetIndexer.ByIndex(indexers.SyncTargetsBySyncTargetKey, syncTargetKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 1 {
+		syncTarget, ok := objs[0].(*workloadv1alpha1.SyncTarget)
+		if !ok {
+			return nil, fmt.Errorf("expected a SyncTarget, got %T", objs[0])
+		}
+		return syncTarget, nil
+	}
+	if len(objs) > 1 || t.sharding == nil {
+		return nil, nil
+	}
+
+	placements, err := indexers.ByIndex[*schedulingv1alpha1.Placement](placementIndexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	locationWorkspaces := sets.NewString()
+	for _, placement := range placements {
+		if placement.Status.SelectedLocation != nil {
+			locationWorkspaces.Insert(placement.Status.SelectedLocation.Path)
+		}
+	}
+	syncTargetWorkspaces := make([]logicalcluster.Name, 0, locationWorkspaces.Len())
+	for _, locationWorkspace := range locationWorkspaces.List() {
+		syncTargetWorkspaces = append(syncTargetWorkspaces, logicalcluster.New(locationWorkspace))
+	}
+	return t.sharding.remoteSyncTarget(ctx, syncTargetWorkspaces, syncTargetKey)
+}
+
+// downstreamPodExists checks through the syncer tunnel whether the pod exists in the physical cluster.
+func downstreamPodExists(ctx context.Context, target podTarget, name string) (bool, error) {
+	u := *target.url
+	u.Path += path.Join("/api/v1/namespaces", target.downstreamNamespace, "pods", name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	client := &http.Client{Transport: target.transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+}
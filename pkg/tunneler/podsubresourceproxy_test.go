@@ -0,0 +1,299 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestPodSubresourceProxy(t *testing.T) {
+	workspace := logicalcluster.New("root:org:ws")
+	syncTargetWorkspace := logicalcluster.New("root:org:compute")
+	connected := syncTarget(syncTargetWorkspace, "connected")
+	disconnected := syncTarget(syncTargetWorkspace, "disconnected")
+	downstreamNamespace, err := shared.PhysicalClusterNamespaceName(shared.NewNamespaceLocator(workspace, syncTargetWorkspace, connected.UID, connected.Name, "test"))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		path            string
+		syncTargets     []*workloadv1alpha1.SyncTarget
+		wantStatus      int
+		wantBody        string
+		wantBackendPath string
+	}{
+		"logs of a synced pod": {
+			path:            "/api/v1/namespaces/test/pods/mypod/log?container=busybox",
+			syncTargets:     []*workloadv1alpha1.SyncTarget{connected},
+			wantStatus:      http.StatusOK,
+			wantBody:        "logs",
+			wantBackendPath: "/api/v1/namespaces/" + downstreamNamespace + "/pods/mypod/log?container=busybox",
+		},
+		"namespace not synced": {
+			path:       "/api/v1/namespaces/test/pods/mypod/log",
+			wantStatus: http.StatusTeapot,
+		},
+		"not a tunneled subresource": {
+			path:        "/api/v1/namespaces/test/pods/mypod/status",
+			syncTargets: []*workloadv1alpha1.SyncTarget{connected},
+			wantStatus:  http.StatusTeapot,
+		},
+		"syncer not connected": {
+			path:        "/api/v1/namespaces/test/pods/mypod/exec",
+			syncTargets: []*workloadv1alpha1.SyncTarget{disconnected},
+			wantStatus:  http.StatusServiceUnavailable,
+		},
+		"pod looked up on all sync targets with a connected syncer": {
+			path:            "/api/v1/namespaces/test/pods/mypod/log",
+			syncTargets:     []*workloadv1alpha1.SyncTarget{connected, disconnected},
+			wantStatus:      http.StatusOK,
+			wantBody:        "logs",
+			wantBackendPath: "/api/v1/namespaces/" + downstreamNamespace + "/pods/mypod/log",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			namespaceIndexer := cache.NewIndexer(func(obj interface{}) (string, error) {
+				ns := obj.(*corev1.Namespace)
+				return clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name), nil
+			}, cache.Indexers{})
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: workspace.String()},
+				Labels:      map[string]string{},
+			}}
+			syncTargetIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexers.SyncTargetsBySyncTargetKey: indexers.IndexSyncTargetsBySyncTargetKey})
+			for _, syncTarget := range tc.syncTargets {
+				ns.Labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTarget.Name)] = string(workloadv1alpha1.ResourceStateSync)
+				require.NoError(t, syncTargetIndexer.Add(syncTarget))
+			}
+			require.NoError(t, namespaceIndexer.Add(ns))
+
+			backendRequests := make(chan *http.Request, 10)
+			backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				backendRequests <- r
+				if r.URL.Path == "/api/v1/namespaces/"+downstreamNamespace+"/pods/mypod" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				fmt.Fprint(w, "logs")
+			})
+			client, stop := setupPodSubresourceProxy(t, workspace, syncTargetWorkspace, connected.Name, backend, corev1listers.NewNamespaceLister(namespaceIndexer), syncTargetIndexer)
+			defer stop()
+
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer user-token")
+			resp, err := client(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantStatus, resp.StatusCode, string(body))
+			if tc.wantBody != "" {
+				require.Equal(t, tc.wantBody, string(body))
+			}
+			if tc.wantBackendPath != "" {
+				var last *http.Request
+				for len(backendRequests) > 0 {
+					last = <-backendRequests
+				}
+				require.NotNil(t, last)
+				require.Equal(t, tc.wantBackendPath, last.URL.RequestURI())
+				require.Empty(t, last.Header.Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestPodSubresourceProxySyncTargetOnOtherShard(t *testing.T) {
+	workspace := logicalcluster.New("root:org:ws")
+	syncTargetWorkspace := logicalcluster.New("root:org:compute")
+	target := syncTarget(syncTargetWorkspace, "us-west1")
+	target.Annotations[workloadv1alpha1.InternalTunnelShardAnnotationKey] = "shard-a"
+	downstreamNamespace, err := shared.PhysicalClusterNamespaceName(shared.NewNamespaceLocator(workspace, syncTargetWorkspace, target.UID, target.Name, "test"))
+	require.NoError(t, err)
+
+	backendRequests := make(chan *http.Request, 10)
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendRequests <- r
+		fmt.Fprint(w, "logs")
+	})
+
+	// the workspace of the SyncTarget and its syncer tunnel are on shard A
+	listPath := "/clusters/" + syncTargetWorkspace.String() + "/apis/workload.kcp.dev/v1alpha1/synctargets"
+	tunnelHandler := NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), nil).WithSyncerTunnel(http.NotFoundHandler())
+	shardA := httptest.NewUnstartedServer(withUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == listPath {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&workloadv1alpha1.SyncTargetList{Items: []workloadv1alpha1.SyncTarget{*target}}) //nolint:errcheck
+			return
+		}
+		tunnelHandler.ServeHTTP(w, r)
+	}), &user.DefaultInfo{Name: "shard-admin"}))
+	shardA.EnableHTTP2 = true
+	shardA.StartTLS()
+	defer shardA.Close()
+
+	dstURL, err := SyncerTunnelURL(shardA.URL, syncTargetWorkspace.String(), target.Name)
+	require.NoError(t, err)
+	l, err := NewListener(shardA.Client(), dstURL)
+	require.NoError(t, err)
+	defer l.Close()
+	server := &http.Server{Handler: backend}
+	defer server.Close()
+	go server.Serve(l) //nolint:errcheck
+
+	// shard B only holds the workspace, which places its namespace on the location workspace of the SyncTarget
+	namespaceIndexer := cache.NewIndexer(func(obj interface{}) (string, error) {
+		ns := obj.(*corev1.Namespace)
+		return clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name), nil
+	}, cache.Indexers{})
+	require.NoError(t, namespaceIndexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Annotations: map[string]string{logicalcluster.AnnotationKey: workspace.String()},
+		Labels: map[string]string{
+			workloadv1alpha1.ClusterResourceStateLabelPrefix + workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, target.Name): string(workloadv1alpha1.ResourceStateSync),
+		},
+	}}))
+	placementIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexers.ByLogicalCluster: indexers.IndexByLogicalCluster})
+	require.NoError(t, placementIndexer.Add(&schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: workspace.String()},
+		},
+		Status: schedulingv1alpha1.PlacementStatus{
+			SelectedLocation: &schedulingv1alpha1.LocationReference{Path: syncTargetWorkspace.String(), LocationName: "default"},
+		},
+	}))
+	sharding := &Sharding{
+		ShardName:        "shard-b",
+		SyncTargetLister: workloadlisters.NewSyncTargetLister(syncTargetIndexer(t)),
+		ShardLister:      tenancylisters.NewClusterWorkspaceShardLister(shardIndexer(t, clusterWorkspaceShard("shard-a", shardA.URL))),
+		PeerTransport:    shardA.Client().Transport,
+	}
+	emptySyncTargetIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexers.SyncTargetsBySyncTargetKey: indexers.IndexSyncTargetsBySyncTargetKey})
+	handler := genericapifilters.WithRequestInfo(
+		NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), sharding).WithPodSubresourceProxy(http.NotFoundHandler(), corev1listers.NewNamespaceLister(namespaceIndexer), emptySyncTargetIndexer, placementIndexer),
+		&request.RequestInfoFactory{APIPrefixes: sets.NewString("api", "apis"), GrouplessAPIPrefixes: sets.NewString("api")},
+	)
+	shardB := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(request.WithCluster(r.Context(), request.Cluster{Name: workspace})))
+	}))
+	defer shardB.Close()
+
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodGet, shardB.URL+"/api/v1/namespaces/test/pods/mypod/log", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer user-token")
+		resp, err := shardB.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode == http.StatusOK && string(body) == "logs"
+	}, 10*time.Second, 100*time.Millisecond, "request was not proxied through shard A")
+
+	got := <-backendRequests
+	require.Equal(t, "/api/v1/namespaces/"+downstreamNamespace+"/pods/mypod/log", got.URL.Path)
+	require.Empty(t, got.Header.Get("Authorization"), "user credentials must not be forwarded")
+}
+
+// setupPodSubresourceProxy starts a kcp server with a pod subresource proxy, and a syncer connected to it
+// serving backend as its physical cluster.
+func setupPodSubresourceProxy(t *testing.T, workspace, syncTargetWorkspace logicalcluster.Name, syncTargetName string, backend http.Handler,
+	namespaceLister corev1listers.NamespaceLister, syncTargetIndexer cache.Indexer) (func(*http.Request) (*http.Response, error), func()) {
+	t.Helper()
+
+	tunneler := NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), nil)
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	placementIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexers.ByLogicalCluster: indexers.IndexByLogicalCluster})
+	var handler http.Handler = tunneler.WithPodSubresourceProxy(fallback, namespaceLister, syncTargetIndexer, placementIndexer)
+	handler = genericapifilters.WithRequestInfo(handler, &request.RequestInfoFactory{APIPrefixes: sets.NewString("api", "apis"), GrouplessAPIPrefixes: sets.NewString("api")})
+	withCluster := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(request.WithCluster(r.Context(), request.Cluster{Name: workspace})))
+	})
+
+	publicServer := httptest.NewUnstartedServer(withUser(tunneler.WithSyncerTunnel(withCluster), &user.DefaultInfo{Name: "syncer"}))
+	publicServer.EnableHTTP2 = true
+	publicServer.StartTLS()
+
+	dstURL, err := SyncerTunnelURL(publicServer.URL, syncTargetWorkspace.String(), syncTargetName)
+	require.NoError(t, err)
+	l, err := NewListener(publicServer.Client(), dstURL)
+	require.NoError(t, err)
+	server := &http.Server{Handler: backend}
+	//nolint:errcheck
+	go server.Serve(l)
+
+	// wait for the reverse connection to be established
+	time.Sleep(1 * time.Second)
+
+	client := func(req *http.Request) (*http.Response, error) {
+		u, err := req.URL.Parse(publicServer.URL + req.URL.RequestURI())
+		if err != nil {
+			return nil, err
+		}
+		req.URL = u
+		return publicServer.Client().Do(req)
+	}
+	stop := func() {
+		l.Close()
+		server.Close()
+		publicServer.Close()
+	}
+	return client, stop
+}
+
+func syncTarget(workspace logicalcluster.Name, name string) *workloadv1alpha1.SyncTarget {
+	return &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			UID:         types.UID("uid-" + name),
+			Annotations: map[string]string{logicalcluster.AnnotationKey: workspace.String()},
+		},
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+)
+
+const (
+	// forwardedHeader is set on the tunnel requests forwarded by another shard, with the name of that shard.
+	// Forwarded requests are never forwarded again.
+	forwardedHeader = "X-Kcp-Syncer-Tunnel-Forwarded"
+
+	unpublishTimeout = 10 * time.Second
+
+	// remoteSyncTargetTTL is how long a SyncTarget found on another shard is used before it is looked up again.
+	remoteSyncTargetTTL = 30 * time.Second
+)
+
+// Sharding lets the Tunneler serve the tunnels of syncers connected to another shard. The name of the shard
+// the tunnel of a SyncTarget is connected to is recorded on the SyncTarget with the
+// workloadv1alpha1.InternalTunnelShardAnnotationKey annotation, and the proxy requests for a tunnel not
+// connected to this shard are forwarded to the base URL of the ClusterWorkspaceShard of that name. Requests
+// are never forwarded to a URL that is not the one of a known shard.
+//
+// The tunnel requests must reach a shard holding the SyncTarget, i.e. the one of its workspace. The
+// kcp-front-proxy routes them by the workspace in the path.
+type Sharding struct {
+	// ShardName is the name of the ClusterWorkspaceShard of this shard.
+	ShardName string
+	// SyncTargetLister looks up the shard the tunnel of a SyncTarget is connected to.
+	SyncTargetLister workloadlisters.SyncTargetLister
+	// ShardLister resolves the base URL of the shard the tunnel of a SyncTarget is connected to.
+	ShardLister tenancylisters.ClusterWorkspaceShardLister
+	// KcpClusterClient records the shard on the SyncTargets of the tunnels connected to this shard.
+	KcpClusterClient kcpclient.ClusterInterface
+	// PeerTransport authenticates the forwarded requests against the other shards. If nil,
+	// requests are not forwarded.
+	PeerTransport http.RoundTripper
+
+	remoteSyncTargetsLock sync.Mutex
+	remoteSyncTargets     map[string]remoteSyncTarget
+}
+
+// remoteSyncTarget is a SyncTarget found on another shard.
+type remoteSyncTarget struct {
+	syncTarget *workloadv1alpha1.SyncTarget
+	expires    time.Time
+}
+
+// publish records this shard on the SyncTarget as the one its tunnel is connected to. It retries
+// until the context is done.
+func (s *Sharding) publish(ctx context.Context, syncTargetWorkspace logicalcluster.Name, syncTargetName string) {
+	err := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+		return ctx.Err() == nil && !apierrors.IsNotFound(err)
+	}, func() error {
+		syncTarget, err := s.SyncTargetLister.Get(clusters.ToClusterAwareKey(syncTargetWorkspace, syncTargetName))
+		if err != nil {
+			return err
+		}
+		if syncTarget.Annotations[workloadv1alpha1.InternalTunnelShardAnnotationKey] == s.ShardName {
+			return nil
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					workloadv1alpha1.InternalTunnelShardAnnotationKey: s.ShardName,
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = s.KcpClusterClient.Cluster(syncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		klog.V(2).InfoS("failed to record the shard of the syncer tunnel", "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "shardName", s.ShardName, "err", err)
+	}
+}
+
+// unpublish removes this shard from the SyncTarget, unless the tunnel has connected to another shard
+// in the meantime.
+func (s *Sharding) unpublish(ctx context.Context, syncTargetWorkspace logicalcluster.Name, syncTargetName string) {
+	path := "/metadata/annotations/" + strings.ReplaceAll(workloadv1alpha1.InternalTunnelShardAnnotationKey, "/", "~1")
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": path, "value": s.ShardName},
+		{"op": "remove", "path": path},
+	})
+	if err != nil {
+		klog.Errorf("failed to create the patch of SyncTarget %s|%s: %v", syncTargetWorkspace, syncTargetName, err)
+		return
+	}
+	_, err = s.KcpClusterClient.Cluster(syncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.JSONPatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		// the test operation fails when the tunnel is connected to another shard, or the annotation is gone.
+		klog.V(4).InfoS("did not remove the shard of the syncer tunnel", "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "err", err)
+	}
+}
+
+// peerURL returns the base URL of the other shard the tunnel of the SyncTarget is connected to, or
+// nil if the tunnel is not connected to another shard, or the requests cannot be forwarded.
+func (s *Sharding) peerURL(syncTargetWorkspace logicalcluster.Name, syncTargetName string) (*url.URL, error) {
+	if s == nil || s.PeerTransport == nil {
+		return nil, nil
+	}
+	syncTarget, err := s.SyncTargetLister.Get(clusters.ToClusterAwareKey(syncTargetWorkspace, syncTargetName))
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return s.peerURLFor(syncTarget)
+}
+
+// peerURLFor is like peerURL for a SyncTarget that has been looked up already.
+func (s *Sharding) peerURLFor(syncTarget *workloadv1alpha1.SyncTarget) (*url.URL, error) {
+	if s == nil || s.PeerTransport == nil {
+		return nil, nil
+	}
+	syncTargetWorkspace := logicalcluster.From(syncTarget)
+	shardName := syncTarget.Annotations[workloadv1alpha1.InternalTunnelShardAnnotationKey]
+	if shardName == "" || shardName == s.ShardName {
+		return nil, nil
+	}
+	shard, err := s.ShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, shardName))
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("unknown shard %q of the tunnel of SyncTarget %s|%s", shardName, syncTargetWorkspace, syncTarget.Name)
+	} else if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(shard.Spec.BaseURL)
+	if err != nil || shard.Spec.BaseURL == "" {
+		return nil, fmt.Errorf("invalid base URL %q of shard %q of the tunnel of SyncTarget %s|%s: %v", shard.Spec.BaseURL, shardName, syncTargetWorkspace, syncTarget.Name, err)
+	}
+	return u, nil
+}
+
+// remoteSyncTarget looks up the SyncTarget with the given key in the given workspaces on the other shards,
+// for SyncTargets whose workspace is not served by this shard. It returns nil if no other shard holds it, or
+// the requests cannot be forwarded. The SyncTargets found are remembered for remoteSyncTargetTTL.
+func (s *Sharding) remoteSyncTarget(ctx context.Context, syncTargetWorkspaces []logicalcluster.Name, syncTargetKey string) (*workloadv1alpha1.SyncTarget, error) {
+	if s == nil || s.PeerTransport == nil || len(syncTargetWorkspaces) == 0 {
+		return nil, nil
+	}
+
+	s.remoteSyncTargetsLock.Lock()
+	cached, found := s.remoteSyncTargets[syncTargetKey]
+	s.remoteSyncTargetsLock.Unlock()
+	if found && time.Now().Before(cached.expires) {
+		return cached.syncTarget, nil
+	}
+
+	shards, err := s.ShardLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, shard := range shards {
+		if shard.Name == s.ShardName || shard.Spec.BaseURL == "" {
+			continue
+		}
+		client, err := kcpclient.NewClusterForConfig(&rest.Config{Host: shard.Spec.BaseURL, Transport: s.PeerTransport})
+		if err != nil {
+			return nil, err
+		}
+		for _, syncTargetWorkspace := range syncTargetWorkspaces {
+			syncTargets, err := client.Cluster(syncTargetWorkspace).WorkloadV1alpha1().SyncTargets().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				// the workspace is not served by that shard.
+				klog.V(6).InfoS("failed to list SyncTargets on shard", "shardName", shard.Name, "syncTargetWorkspace", syncTargetWorkspace, "err", err)
+				continue
+			}
+			for i := range syncTargets.Items {
+				syncTarget := &syncTargets.Items[i]
+				if workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTarget.Name) != syncTargetKey {
+					continue
+				}
+				if syncTarget.Annotations == nil {
+					syncTarget.Annotations = map[string]string{}
+				}
+				syncTarget.Annotations[logicalcluster.AnnotationKey] = syncTargetWorkspace.String()
+
+				s.remoteSyncTargetsLock.Lock()
+				if s.remoteSyncTargets == nil {
+					s.remoteSyncTargets = map[string]remoteSyncTarget{}
+				}
+				s.remoteSyncTargets[syncTargetKey] = remoteSyncTarget{syncTarget: syncTarget, expires: time.Now().Add(remoteSyncTargetTTL)}
+				s.remoteSyncTargetsLock.Unlock()
+				return syncTarget, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// newPeerProxy returns a reverse proxy forwarding requests to the tunnel proxy path of the SyncTarget on the
+// shard with the given URL, with the credentials of the shard.
+func (s *Sharding) newPeerProxy(peerURL *url.URL, syncTargetWorkspace logicalcluster.Name, syncTargetName string, proxiedPath string) (*httputil.ReverseProxy, error) {
+	tunnelURL, err := SyncerTunnelURL(peerURL.String(), syncTargetWorkspace.String(), syncTargetName)
+	if err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(tunnelURL + "/" + cmdTunnelProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = s.PeerTransport
+	proxy.FlushInterval = -1
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		req.URL.Path = proxiedPath
+		req.URL.RawPath = ""
+		director(req)
+		// the user has been authorized by this shard, the other shard is accessed with the credentials of the shard.
+		stripCredentials(req.Header)
+		req.Header.Set(forwardedHeader, s.ShardName)
+	}
+	return proxy, nil
+}
+
+// forward forwards a proxy request to the shard the tunnel of the SyncTarget is connected to. It returns false,
+// without writing a response, if the tunnel is not connected to another shard.
+func (t *Tunneler) forward(w http.ResponseWriter, r *http.Request, syncTargetWorkspace logicalcluster.Name, syncTargetName, proxiedPath string) bool {
+	if t.sharding == nil || r.Header.Get(forwardedHeader) != "" {
+		return false
+	}
+	peerURL, err := t.sharding.peerURL(syncTargetWorkspace, syncTargetName)
+	if err != nil {
+		responsewriters.InternalError(w, r, err)
+		return true
+	}
+	if peerURL == nil {
+		return false
+	}
+	proxy, err := t.sharding.newPeerProxy(peerURL, syncTargetWorkspace, syncTargetName, proxiedPath)
+	if err != nil {
+		responsewriters.InternalError(w, r, err)
+		return true
+	}
+	klog.V(4).InfoS("forwarding syncer tunnel request to shard", "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "shardURL", peerURL)
+	proxy.ServeHTTP(w, r)
+	return true
+}
+
+// forwardingRoundTripper marks the requests as forwarded by this shard.
+type forwardingRoundTripper struct {
+	delegate  http.RoundTripper
+	shardName string
+}
+
+func (rt *forwardingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(forwardedHeader, rt.shardName)
+	return rt.delegate.RoundTrip(req)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+)
+
+func TestShardingForwardsToPeerShard(t *testing.T) {
+	workspace := logicalcluster.New("root:ws")
+
+	backendRequests := make(chan *http.Request, 10)
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendRequests <- r
+		w.Write([]byte("Hello world")) //nolint:errcheck
+	}))
+	defer backend.Close()
+
+	// the syncer is connected to shard A
+	shardA := httptest.NewUnstartedServer(withUser(NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), nil).WithSyncerTunnel(http.NotFoundHandler()), &user.DefaultInfo{Name: "shard-admin"}))
+	shardA.EnableHTTP2 = true
+	shardA.StartTLS()
+	defer shardA.Close()
+
+	dstURL, err := SyncerTunnelURL(shardA.URL, workspace.String(), "us-west1")
+	require.NoError(t, err)
+	l, err := NewListener(shardA.Client(), dstURL)
+	require.NoError(t, err)
+	defer l.Close()
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	proxy := httputil.NewSingleHostReverseProxy(backendURL)
+	proxy.Transport = backend.Client().Transport
+	server := &http.Server{Handler: proxy}
+	defer server.Close()
+	go server.Serve(l) //nolint:errcheck
+
+	// shard B finds shard A on the SyncTarget, and its URL on the ClusterWorkspaceShard
+	target := syncTarget(workspace, "us-west1")
+	target.Annotations[workloadv1alpha1.InternalTunnelShardAnnotationKey] = "shard-a"
+	unknown := syncTarget(workspace, "us-east1")
+	unknown.Annotations[workloadv1alpha1.InternalTunnelShardAnnotationKey] = "shard-c"
+	sharding := &Sharding{
+		ShardName:        "shard-b",
+		SyncTargetLister: workloadlisters.NewSyncTargetLister(syncTargetIndexer(t, target, unknown)),
+		ShardLister:      tenancylisters.NewClusterWorkspaceShardLister(shardIndexer(t, clusterWorkspaceShard("shard-a", shardA.URL))),
+		PeerTransport:    shardA.Client().Transport,
+	}
+	shardB := httptest.NewTLSServer(withUser(NewTunneler(allowAllTunnelAuthorizer{}, NewOptions(), sharding).WithSyncerTunnel(http.NotFoundHandler()), &user.DefaultInfo{Name: "user"}))
+	defer shardB.Close()
+
+	proxyURL, err := SyncerTunnelURL(shardB.URL, workspace.String(), "us-west1")
+	require.NoError(t, err)
+	proxyURL += "/" + cmdTunnelProxy + "/api/v1/namespaces"
+
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodGet, proxyURL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer user-token")
+		resp, err := shardB.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode == http.StatusOK && string(body) == "Hello world"
+	}, 10*time.Second, 100*time.Millisecond, "request was not forwarded to shard A")
+
+	got := <-backendRequests
+	require.Equal(t, "/api/v1/namespaces", got.URL.Path)
+	require.Empty(t, got.Header.Get("Authorization"), "user credentials must not be forwarded")
+
+	t.Run("forwarded requests are not forwarded again", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, proxyURL, nil)
+		require.NoError(t, err)
+		req.Header.Set(forwardedHeader, "shard-c")
+		resp, err := shardB.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+
+	t.Run("requests are not forwarded to unknown shards", func(t *testing.T) {
+		unknownURL, err := SyncerTunnelURL(shardB.URL, workspace.String(), "us-east1")
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, unknownURL+"/"+cmdTunnelProxy+"/api/v1/namespaces", nil)
+		require.NoError(t, err)
+		resp, err := shardB.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
+func TestShardingPublish(t *testing.T) {
+	workspace := logicalcluster.New("root:ws")
+	target := syncTarget(workspace, "us-west1")
+	client := kcpfake.NewSimpleClientset(target)
+	indexer := syncTargetIndexer(t, target)
+	newSharding := func(shardName string) *Sharding {
+		return &Sharding{
+			ShardName:        shardName,
+			SyncTargetLister: workloadlisters.NewSyncTargetLister(indexer),
+			KcpClusterClient: fakeClusterClient{client},
+		}
+	}
+	shardAnnotation := func() string {
+		got, err := client.WorkloadV1alpha1().SyncTargets().Get(context.Background(), target.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return got.Annotations[workloadv1alpha1.InternalTunnelShardAnnotationKey]
+	}
+
+	newSharding("shard-a").publish(context.Background(), workspace, target.Name)
+	require.Equal(t, "shard-a", shardAnnotation())
+
+	newSharding("shard-b").unpublish(context.Background(), workspace, target.Name)
+	require.Equal(t, "shard-a", shardAnnotation(), "another shard must not remove the tunnel of shard A")
+
+	newSharding("shard-a").unpublish(context.Background(), workspace, target.Name)
+	require.Empty(t, shardAnnotation())
+}
+
+func syncTargetIndexer(t *testing.T, syncTargets ...*workloadv1alpha1.SyncTarget) cache.Indexer {
+	t.Helper()
+	indexer := cache.NewIndexer(func(obj interface{}) (string, error) {
+		syncTarget := obj.(*workloadv1alpha1.SyncTarget)
+		return clusters.ToClusterAwareKey(logicalcluster.From(syncTarget), syncTarget.Name), nil
+	}, cache.Indexers{})
+	for _, syncTarget := range syncTargets {
+		require.NoError(t, indexer.Add(syncTarget))
+	}
+	return indexer
+}
+
+func clusterWorkspaceShard(name, baseURL string) *tenancyv1alpha1.ClusterWorkspaceShard {
+	return &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{logicalcluster.AnnotationKey: tenancyv1alpha1.RootCluster.String()},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: baseURL},
+	}
+}
+
+func shardIndexer(t *testing.T, shards ...*tenancyv1alpha1.ClusterWorkspaceShard) cache.Indexer {
+	t.Helper()
+	indexer := cache.NewIndexer(func(obj interface{}) (string, error) {
+		shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+		return clusters.ToClusterAwareKey(logicalcluster.From(shard), shard.Name), nil
+	}, cache.Indexers{})
+	for _, shard := range shards {
+		require.NoError(t, indexer.Add(shard))
+	}
+	return indexer
+}
+
+type fakeClusterClient struct {
+	kcpclient.Interface
+}
+
+func (c fakeClusterClient) Cluster(logicalcluster.Name) kcpclient.Interface {
+	return c.Interface
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// tunnelProtocolsHeader is set by the Listener on the connect requests to announce the protocols it serves
+	// on the reverse connections on top of HTTP/1.1.
+	tunnelProtocolsHeader = "X-Kcp-Syncer-Tunnel-Protocols"
+	// protocolH2C is HTTP/2 without TLS, with prior knowledge.
+	protocolH2C = "h2c"
+
+	// multiplexedDialTimeout bounds the time to get the reverse connection of a multiplexed tunnel, which is not
+	// bound to the context of a single request.
+	multiplexedDialTimeout = 30 * time.Second
+
+	// throttledRetryAfterSeconds is the Retry-After of requests rejected because the tunnel has no free slot.
+	throttledRetryAfterSeconds = 1
+)
+
+// WithMultiplexing serves HTTP/2 without TLS next to HTTP/1.1 on the reverse connections, so that kcp can send
+// concurrent requests over a single reverse connection. The Listener must be created with Multiplexing enabled
+// for kcp to use it.
+func WithMultiplexing(handler http.Handler) http.Handler {
+	return h2c.NewHandler(handler, &http2.Server{})
+}
+
+// tunnelTransport sends requests through the reverse connections of a Dialer. Requests are multiplexed over
+// HTTP/2 if the Listener supports it, except for upgrades like exec, attach and port-forward that need HTTP/1.1.
+// Idle HTTP/1.1 connections are kept for reuse. The requests in flight are limited, holding a slot until the
+// response body is closed.
+type tunnelTransport struct {
+	http1 *http.Transport
+	http2 *http2.Transport
+
+	// slots has a buffer of the maximum concurrent requests, or is nil if not limited.
+	slots chan struct{}
+	// maxWait is the maximal time a request waits for a free slot.
+	maxWait time.Duration
+
+	workspace, syncTarget string
+}
+
+var _ http.RoundTripper = &tunnelTransport{}
+
+func newTunnelTransport(d *Dialer, multiplexing bool, opts *Options) *tunnelTransport {
+	t := &tunnelTransport{
+		http1: &http.Transport{
+			Proxy:               nil,    // no proxies
+			DialContext:         d.Dial, // use a reverse connection
+			ForceAttemptHTTP2:   false,  // this is a tunneled connection
+			MaxIdleConnsPerHost: opts.MaxIdleConnsPerTunnel,
+			IdleConnTimeout:     opts.IdleConnTimeout,
+		},
+		workspace:  d.workspace,
+		syncTarget: d.syncTarget,
+	}
+	if opts.MaxIdleConnsPerTunnel == 0 {
+		// one connection per request
+		t.http1.DisableKeepAlives = true
+	}
+	if multiplexing {
+		t.http2 = &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), multiplexedDialTimeout)
+				defer cancel()
+				return d.Dial(ctx, network, addr)
+			},
+			ReadIdleTimeout: 30 * time.Second,
+			PingTimeout:     15 * time.Second,
+		}
+	}
+	if opts.MaxConcurrentRequestsPerTunnel > 0 {
+		t.slots = make(chan struct{}, opts.MaxConcurrentRequestsPerTunnel)
+		t.maxWait = opts.MaxRequestWaitPerTunnel
+	}
+	return t
+}
+
+func (t *tunnelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.slots != nil {
+		timer := time.NewTimer(t.maxWait)
+		select {
+		case t.slots <- struct{}{}:
+			timer.Stop()
+		case <-timer.C:
+			throttledRequests.WithLabelValues(t.workspace, t.syncTarget).Inc()
+			return tooManyRequests(req), nil
+		case <-req.Context().Done():
+			timer.Stop()
+			throttledRequests.WithLabelValues(t.workspace, t.syncTarget).Inc()
+			return nil, fmt.Errorf("syncer tunnel: too many concurrent requests: %w", req.Context().Err())
+		}
+	}
+	inflightRequests.WithLabelValues(t.workspace, t.syncTarget).Inc()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			inflightRequests.WithLabelValues(t.workspace, t.syncTarget).Dec()
+			if t.slots != nil {
+				<-t.slots
+			}
+		})
+	}
+
+	var rt http.RoundTripper = t.http1
+	if t.http2 != nil && !httpguts.HeaderValuesContainsToken(req.Header["Connection"], "Upgrade") {
+		rt = t.http2
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if rwc, ok := resp.Body.(io.ReadWriteCloser); ok {
+		// upgraded connections are read and written by the caller, e.g. the reverse proxy.
+		resp.Body = &releasingReadWriteCloser{ReadWriteCloser: rwc, release: release}
+	} else {
+		resp.Body = &releasingReadCloser{ReadCloser: resp.Body, release: release}
+	}
+	return resp, nil
+}
+
+// tooManyRequests returns the response to a request which did not get a free slot of the tunnel in time.
+func tooManyRequests(req *http.Request) *http.Response {
+	status := apierrors.NewTooManyRequests("too many concurrent requests through the syncer tunnel, please try again later", throttledRetryAfterSeconds).Status()
+	status.APIVersion, status.Kind = "v1", "Status"
+	body, _ := json.Marshal(status)
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests)),
+		StatusCode: http.StatusTooManyRequests,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+			"Retry-After":  []string{strconv.Itoa(throttledRetryAfterSeconds)},
+		},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// CloseIdleConnections closes the idle reverse connections, e.g. when the tunnel is closed.
+func (t *tunnelTransport) CloseIdleConnections() {
+	t.http1.CloseIdleConnections()
+	if t.http2 != nil {
+		t.http2.CloseIdleConnections()
+	}
+}
+
+// releasingReadCloser frees the slot of a request when its response body is closed.
+type releasingReadCloser struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingReadCloser) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// releasingReadWriteCloser frees the slot of an upgraded request when its connection is closed.
+type releasingReadWriteCloser struct {
+	io.ReadWriteCloser
+	release func()
+}
+
+func (b *releasingReadWriteCloser) Close() error {
+	defer b.release()
+	return b.ReadWriteCloser.Close()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunneler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunnelTransportBackpressure(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck
+	}))
+	defer backend.Close()
+	u, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+
+	transport := &tunnelTransport{
+		http1:   &http.Transport{},
+		slots:   make(chan struct{}, 1),
+		maxWait: time.Minute,
+	}
+	defer transport.CloseIdleConnections()
+
+	first, err := transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: u, Header: http.Header{}})
+	require.NoError(t, err)
+
+	t.Log("A second request waits for the slot of the first one until its context is done")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = transport.RoundTrip((&http.Request{Method: http.MethodGet, URL: u, Header: http.Header{}}).WithContext(ctx))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	t.Log("Closing the response body of the first request frees its slot")
+	body, err := ioutil.ReadAll(first.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(body))
+	require.NoError(t, first.Body.Close())
+
+	second, err := transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: u, Header: http.Header{}})
+	require.NoError(t, err)
+	require.NoError(t, second.Body.Close())
+	require.Len(t, transport.slots, 0)
+}
+
+func TestTunnelTransportMaxWait(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck
+	}))
+	defer backend.Close()
+	u, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+
+	transport := &tunnelTransport{
+		http1:   &http.Transport{},
+		slots:   make(chan struct{}, 1),
+		maxWait: 100 * time.Millisecond,
+	}
+	defer transport.CloseIdleConnections()
+
+	first, err := transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: u, Header: http.Header{}})
+	require.NoError(t, err)
+	defer first.Body.Close()
+
+	t.Log("A second request without deadline is rejected once it waited for the maximal time")
+	resp, err := transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: u, Header: http.Header{}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Contains(t, string(body), `"reason":"TooManyRequests"`)
+	require.Len(t, transport.slots, 1, "rejected requests must not take a slot")
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package tunneler
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -25,10 +26,14 @@ import (
 	"net/url"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/aojea/rwconn"
+	"github.com/kcp-dev/logicalcluster/v2"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/klog/v2"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
@@ -38,6 +43,9 @@ const (
 	defaultTunnelPathPrefix = "/services/syncer-tunnels/clusters"
 	cmdTunnelConnect        = "connect"
 	cmdTunnelProxy          = "proxy"
+
+	auditAnnotationSyncTarget = "tunnel.workload.kcp.dev/synctarget"
+	auditAnnotationCommand    = "tunnel.workload.kcp.dev/command"
 )
 
 type controlMsg struct {
@@ -56,12 +64,15 @@ type key struct {
 type tunnelPool struct {
 	mu   sync.Mutex
 	pool map[key]*Dialer
+
+	opts *Options
 }
 
 // NewtunnelPool returns a tunnelPool
-func newTunnelPool() *tunnelPool {
+func newTunnelPool(opts *Options) *tunnelPool {
 	return &tunnelPool{
 		pool: map[key]*Dialer{},
+		opts: opts,
 	}
 }
 
@@ -74,8 +85,9 @@ func (rp *tunnelPool) getDialer(cluster, syncer string) *Dialer {
 }
 
 // createDialer creates a reverse dialer with id
-// it's a noop if a dialer already exists
-func (rp *tunnelPool) createDialer(cluster, syncer string, conn net.Conn) *Dialer {
+// it's a noop if a dialer already exists. The dialer is removed
+// from the pool when it is closed.
+func (rp *tunnelPool) createDialer(cluster, syncer string, conn net.Conn, multiplexing bool) *Dialer {
 	rp.mu.Lock()
 	defer rp.mu.Unlock()
 	id := key{cluster, syncer}
@@ -83,9 +95,23 @@ func (rp *tunnelPool) createDialer(cluster, syncer string, conn net.Conn) *Diale
 		return d
 	}
 	d := NewDialer(conn)
+	d.workspace, d.syncTarget = cluster, syncer
+	d.transport = newTunnelTransport(d, multiplexing, rp.opts)
 	rp.pool[id] = d
-	return d
 
+	openTunnels.WithLabelValues(cluster, syncer).Inc()
+	go func() {
+		<-d.Done()
+		openTunnels.WithLabelValues(cluster, syncer).Dec()
+		d.transport.CloseIdleConnections()
+
+		rp.mu.Lock()
+		defer rp.mu.Unlock()
+		if rp.pool[id] == d {
+			delete(rp.pool, id)
+		}
+	}()
+	return d
 }
 
 // deleteDialer delete the reverse dialer for the id
@@ -109,15 +135,46 @@ func SyncerTunnelURL(host, ws, target string) (string, error) {
 	return host + defaultTunnelPathPrefix + "/" + ws + "/apis/" + workloadv1alpha1.SchemeGroupVersion.String() + "/synctargets/" + target, nil
 }
 
-// HTTP Handler that handles reverse connections and reverse proxy requests using 2 different paths:
+// IsSyncerTunnelPath returns true if the path is served by the syncer tunnels.
+func IsSyncerTunnelPath(path string) bool {
+	return strings.HasPrefix(path, defaultTunnelPathPrefix)
+}
+
+// Tunneler holds the reverse connections established by the syncers, and serves the handlers
+// using them. Handlers of the same Tunneler share the reverse connections.
+type Tunneler struct {
+	pool       *tunnelPool
+	authorizer TunnelAuthorizer
+	opts       *Options
+	sharding   *Sharding
+}
+
+// NewTunneler returns a Tunneler without reverse connections, authorizing the tunnel commands
+// with the given authorizer. If sharding is not nil, the tunnels connected to this shard are
+// recorded on their SyncTargets, and the requests for tunnels connected to other shards are
+// forwarded to them.
+func NewTunneler(authorizer TunnelAuthorizer, opts *Options, sharding *Sharding) *Tunneler {
+	registerMetrics()
+	return &Tunneler{
+		pool:       newTunnelPool(opts),
+		authorizer: authorizer,
+		opts:       opts,
+		sharding:   sharding,
+	}
+}
+
+// WithSyncerTunnel is an HTTP Handler that handles reverse connections and reverse proxy requests using 2 different paths:
 //
 // https://host/services/syncer-tunnels/clusters/<ws>/apis/workload.kcp.dev/v1alpha1/synctargets/<name>/connect establish reverse connections and queue them so it can be consumed by the dialer
 // https://host/services/syncer-tunnels/clusters/<ws>/apis/workload.kcp.dev/v1alpha1/synctargets/<name>/proxy/{path} proxies the {path} through the reverse connection identified by the cluster and syncer name
-func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
-	pool := newTunnelPool()
+//
+// The user must be authenticated, and is authorized for the "connect" or "proxy" verb on the "synctargets/tunnel"
+// subresource of the SyncTarget, see NewTunnelAuthorizer. The SyncTarget and the command are added as audit annotations.
+func (t *Tunneler) WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
+	pool := t.pool
 	return func(w http.ResponseWriter, r *http.Request) {
 		// fall through, syncer tunnels URL start by /services/tunnels
-		if !strings.HasPrefix(r.URL.Path, defaultTunnelPathPrefix) {
+		if !IsSyncerTunnelPath(r.URL.Path) {
 			apiHandler.ServeHTTP(w, r)
 			return
 		}
@@ -143,6 +200,10 @@ func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
 		syncerName := path[5]
 		command := path[6]
 
+		if !t.authorize(w, r, logicalcluster.New(clusterName), syncerName, command) {
+			return
+		}
+
 		klog.V(5).InfoS("tunneler connection received", "command", command, "clusterName", clusterName, "syncerName", syncerName)
 		switch command {
 		case cmdTunnelConnect:
@@ -161,14 +222,17 @@ func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
 			// first connection to register the dialer and start the control loop
 			fw := &flushWriter{w: w, f: flusher}
 			doneCh := make(chan struct{})
-			conn := rwconn.NewConn(r.Body, fw, rwconn.SetWriteDelay(500*time.Millisecond), rwconn.SetCloseHook(func() {
+			conn := rwconn.NewConn(r.Body, fw, rwconn.SetWriteDelay(t.opts.CloseDelay), rwconn.SetCloseHook(func() {
 				// exit the handler
 				close(doneCh)
 			}))
 			if d == nil || isClosedChan(d.Done()) {
 				// start clean
 				pool.deleteDialer(clusterName, syncerName)
-				pool.createDialer(clusterName, syncerName, conn)
+				d = pool.createDialer(clusterName, syncerName, conn, r.Header.Get(tunnelProtocolsHeader) == protocolH2C)
+				if t.sharding != nil {
+					go t.sharding.publish(r.Context(), logicalcluster.New(clusterName), syncerName)
+				}
 				// start control loop
 				select {
 				case <-r.Context().Done():
@@ -176,6 +240,11 @@ func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
 				case <-doneCh:
 				}
 				klog.V(5).Infof("stopped tunnel %s-%s control connection ", clusterName, syncerName)
+				if current := pool.getDialer(clusterName, syncerName); t.sharding != nil && (current == nil || current == d || isClosedChan(current.Done())) {
+					ctx, cancel := context.WithTimeout(context.Background(), unpublishTimeout)
+					defer cancel()
+					t.sharding.unpublish(ctx, logicalcluster.New(clusterName), syncerName)
+				}
 				return
 			}
 			// create a reverse connection
@@ -200,30 +269,27 @@ func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
 				http.Error(w, "wrong url", http.StatusInternalServerError)
 				return
 			}
+			// strip the non-proxied path
+			proxypath := "/"
+			if len(path) > 7 {
+				proxypath += strings.Join(path[7:], "/")
+			}
 			d := pool.getDialer(clusterName, syncerName)
 			if d == nil || isClosedChan(d.Done()) {
+				if t.forward(w, r, logicalcluster.New(clusterName), syncerName, proxypath) {
+					return
+				}
 				http.Error(w, "syncer tunnels: syncer not connected", http.StatusInternalServerError)
 				return
 			}
 			proxy := httputil.NewSingleHostReverseProxy(target)
 			director := proxy.Director
-			proxy.Transport = &http.Transport{
-				Proxy:               nil,    // no proxies
-				DialContext:         d.Dial, // use a reverse connection
-				ForceAttemptHTTP2:   false,  // this is a tunneled connection
-				DisableKeepAlives:   true,   // one connection per reverse connection
-				MaxIdleConnsPerHost: -1,
-			}
+			proxy.Transport = d.transport
 			// only proxy the proxied path and don't forward the authentication header
 			proxy.Director = func(req *http.Request) {
-				// strip the non-proxied path
-				proxypath := "/"
-				if len(path) > 7 {
-					proxypath += strings.Join(path[7:], "/")
-				}
 				req.URL.Path = proxypath
-				// TODO: strip authorization header?????
-				req.Header.Del("Authorization")
+				// the user has been authenticated already, never forward its credentials to the physical cluster.
+				stripCredentials(req.Header)
 				director(req)
 			}
 			proxy.ServeHTTP(w, r)
@@ -235,6 +301,53 @@ func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
 	}
 }
 
+// authorize checks that the authenticated user may run the command for the SyncTarget, and annotates the audit
+// event of the request. It writes the error response and returns false if not.
+func (t *Tunneler) authorize(w http.ResponseWriter, r *http.Request, syncTargetWorkspace logicalcluster.Name, syncTargetName, command string) bool {
+	ctx := r.Context()
+	gv := workloadv1alpha1.SchemeGroupVersion
+
+	u, ok := request.UserFrom(ctx)
+	if !ok {
+		responsewriters.ErrorNegotiated(apierrors.NewUnauthorized("syncer tunnels: no authenticated user"), errorCodecs, gv, w, r)
+		return false
+	}
+
+	var err error
+	switch command {
+	case cmdTunnelConnect:
+		err = t.authorizer.AuthorizeConnect(ctx, u, syncTargetWorkspace, syncTargetName)
+	case cmdTunnelProxy:
+		err = t.authorizer.AuthorizeProxy(ctx, u, syncTargetWorkspace, syncTargetName)
+	default:
+		http.Error(w, "syncer tunnels: unsupported command", http.StatusInternalServerError)
+		return false
+	}
+	if err != nil {
+		klog.V(2).InfoS("syncer tunnel command denied", "command", command, "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "user", u.GetName(), "err", err)
+		if _, ok := err.(apierrors.APIStatus); !ok {
+			err = apierrors.NewInternalError(err)
+		}
+		responsewriters.ErrorNegotiated(err, errorCodecs, gv, w, r)
+		return false
+	}
+
+	kaudit.AddAuditAnnotation(ctx, auditAnnotationSyncTarget, workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName))
+	kaudit.AddAuditAnnotation(ctx, auditAnnotationCommand, command)
+	return true
+}
+
+// stripCredentials removes the credentials and the impersonation headers of the user from the header of a request
+// sent through a tunnel. The physical cluster is accessed with the credentials of the syncer only.
+func stripCredentials(header http.Header) {
+	header.Del("Authorization")
+	for name := range header {
+		if strings.HasPrefix(name, "Impersonate-") {
+			header.Del(name)
+		}
+	}
+}
+
 // flushWriter
 type flushWriter struct {
 	w io.Writer
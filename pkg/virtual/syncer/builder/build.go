@@ -25,6 +25,8 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
@@ -175,13 +177,17 @@ func BuildVirtualWorkspace(
 				wildcardKcpInformers.Apis().V1alpha1().APIExports(),
 				func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, apiExportIdentityHash string) (apidefinition.APIDefinition, error) {
 					syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName)
-					requirements, selectable := labels.SelectorFromSet(map[string]string{
-						workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: string(workloadv1alpha1.ResourceStateSync),
-					}).Requirements()
-					if !selectable {
-						return nil, fmt.Errorf("unable to create a selector from the provided labels")
+					// the syncer sees the resources synced to its sync target, and the ones it upsyncs from it
+					requirement, err := labels.NewRequirement(workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTargetKey, selection.In,
+						[]string{string(workloadv1alpha1.ResourceStateSync), string(workloadv1alpha1.ResourceStateUpsync)})
+					if err != nil {
+						return nil, fmt.Errorf("unable to create a selector from the provided labels: %w", err)
+					}
+					withLabelSelector := forwardingregistry.WithStaticLabelSelector(labels.Requirements{*requirement})
+					withUpsyncedMutations := withUpsyncedMutations(syncTargetKey, kubeClusterClient)
+					storageWrapper := func(resource schema.GroupResource, storage *forwardingregistry.StoreFuncs) *forwardingregistry.StoreFuncs {
+						return withUpsyncedMutations(resource, withLabelSelector(resource, storage))
 					}
-					storageWrapper := forwardingregistry.WithStaticLabelSelector(requirements)
 
 					ctx, cancelFn := context.WithCancel(context.Background())
 					storageBuilder := NewStorageBuilder(ctx, dynamicClusterClient, apiExportIdentityHash, storageWrapper)
@@ -109,6 +109,9 @@ func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterfa
 			registry.ListerFunc
 			registry.UpdaterFunc
 			registry.WatcherFunc
+			// creations and deletions are restricted to upsynced resources by the storage wrapper
+			registry.CreaterFunc
+			registry.GracefulDeleterFunc
 
 			registry.TableConvertorFunc
 			registry.CategoriesProviderFunc
@@ -118,10 +121,12 @@ func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterfa
 			ListFactoryFunc: storage.ListFactoryFunc,
 			DestroyerFunc:   storage.DestroyerFunc,
 
-			GetterFunc:  storage.GetterFunc,
-			ListerFunc:  storage.ListerFunc,
-			UpdaterFunc: storage.UpdaterFunc,
-			WatcherFunc: storage.WatcherFunc,
+			GetterFunc:          storage.GetterFunc,
+			ListerFunc:          storage.ListerFunc,
+			UpdaterFunc:         storage.UpdaterFunc,
+			WatcherFunc:         storage.WatcherFunc,
+			CreaterFunc:         storage.CreaterFunc,
+			GracefulDeleterFunc: storage.GracefulDeleterFunc,
 
 			TableConvertorFunc:      storage.TableConvertorFunc,
 			CategoriesProviderFunc:  storage.CategoriesProviderFunc,
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+// withUpsyncedMutations restricts the creations and deletions to the resources upsynced from the sync target
+// with the given key, in namespaces scheduled to the sync target. The syncer only updates the resources synced
+// to the sync target.
+func withUpsyncedMutations(syncTargetKey string, kubeClusterClient kubernetesclient.ClusterInterface) forwardingregistry.StorageWrapper {
+	stateLabel := workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey
+	isUpsynced := func(obj runtime.Object) (bool, error) {
+		metaObj, ok := obj.(metav1.Object)
+		if !ok {
+			return false, fmt.Errorf("expected a metav1.Object, got %T", obj)
+		}
+		return metaObj.GetLabels()[stateLabel] == string(workloadv1alpha1.ResourceStateUpsync), nil
+	}
+	// isNamespaceScheduled returns true if the namespace of the request carries the state label of the sync target.
+	isNamespaceScheduled := func(ctx context.Context) (bool, error) {
+		cluster := genericapirequest.ClusterFrom(ctx)
+		namespace := genericapirequest.NamespaceValue(ctx)
+		if cluster == nil || cluster.Wildcard || namespace == "" {
+			return false, nil
+		}
+		ns, err := kubeClusterClient.Cluster(cluster.Name).CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		_, found := ns.Labels[stateLabel]
+		return found, nil
+	}
+
+	return func(resource schema.GroupResource, storage *forwardingregistry.StoreFuncs) *forwardingregistry.StoreFuncs {
+		delegateCreater := storage.CreaterFunc
+		storage.CreaterFunc = func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+			upsynced, err := isUpsynced(obj)
+			if err != nil {
+				return nil, err
+			}
+			if !upsynced {
+				return nil, errors.NewForbidden(resource, "", fmt.Errorf("only resources with the %s=%s label can be created", stateLabel, workloadv1alpha1.ResourceStateUpsync))
+			}
+			scheduled, err := isNamespaceScheduled(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !scheduled {
+				return nil, errors.NewForbidden(resource, "", fmt.Errorf("resources can only be created in namespaces with the %s label", stateLabel))
+			}
+			return delegateCreater.Create(ctx, obj, createValidation, options)
+		}
+
+		delegateGetter := storage.GetterFunc
+		delegateDeleter := storage.GracefulDeleterFunc
+		storage.GracefulDeleterFunc = func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+			obj, err := delegateGetter.Get(ctx, name, &metav1.GetOptions{})
+			if err != nil {
+				return nil, false, err
+			}
+			upsynced, err := isUpsynced(obj)
+			if err != nil {
+				return nil, false, err
+			}
+			if !upsynced {
+				return nil, false, errors.NewForbidden(resource, name, fmt.Errorf("only resources with the %s=%s label can be deleted", stateLabel, workloadv1alpha1.ResourceStateUpsync))
+			}
+			scheduled, err := isNamespaceScheduled(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			if !scheduled {
+				return nil, false, errors.NewForbidden(resource, name, fmt.Errorf("resources can only be deleted in namespaces with the %s label", stateLabel))
+			}
+			return delegateDeleter.Delete(ctx, name, deleteValidation, options)
+		}
+
+		return storage
+	}
+}
@@ -34,6 +34,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/projection"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	workspacecache "github.com/kcp-dev/kcp/pkg/virtual/workspaces/cache"
 )
@@ -126,6 +127,10 @@ func NewUserWorkspaceWatcher(user user.Info, lclusterName logicalcluster.Name, c
 			if matches, err := predicate.Matches(workspace); err != nil || !matches {
 				return
 			}
+			// deleted workspaces disappear when their content is retained
+			if helper.IsRetained(workspace) {
+				e.Type = watch.Deleted
+			}
 		}
 
 		select {
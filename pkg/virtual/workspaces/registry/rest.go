@@ -44,6 +44,7 @@ import (
 	clusterworkspaceadmission "github.com/kcp-dev/kcp/pkg/admission/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/projection"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
@@ -201,11 +202,18 @@ func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (run
 
 	workspaceList := &tenancyv1beta1.WorkspaceList{
 		ListMeta: clusterWorkspaceList.ListMeta,
-		Items:    make([]tenancyv1beta1.Workspace, len(clusterWorkspaceList.Items)),
+		Items:    make([]tenancyv1beta1.Workspace, 0, len(clusterWorkspaceList.Items)),
 	}
 
-	for i, cws := range clusterWorkspaceList.Items {
-		projection.ProjectClusterWorkspaceToWorkspace(&cws, &workspaceList.Items[i])
+	for i := range clusterWorkspaceList.Items {
+		cws := &clusterWorkspaceList.Items[i]
+		// deleted workspaces are hidden while their content is retained
+		if helper.IsRetained(cws) {
+			continue
+		}
+		var ws tenancyv1beta1.Workspace
+		projection.ProjectClusterWorkspaceToWorkspace(cws, &ws)
+		workspaceList.Items = append(workspaceList.Items, ws)
 	}
 
 	return workspaceList, nil
@@ -258,6 +266,9 @@ func (s *REST) Get(ctx context.Context, name string, options *metav1.GetOptions)
 	if err != nil {
 		return nil, err
 	}
+	if helper.IsRetained(cws) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
 
 	var ws tenancyv1beta1.Workspace
 	projection.ProjectClusterWorkspaceToWorkspace(cws, &ws)
@@ -436,6 +447,10 @@ func (w withProjection) ResultChan() <-chan watch.Event {
 				ws := &tenancyv1beta1.Workspace{}
 				projection.ProjectClusterWorkspaceToWorkspace(cws, ws)
 				ev.Object = ws
+				// deleted workspaces disappear when their content is retained
+				if helper.IsRetained(cws) {
+					ev.Type = watch.Deleted
+				}
 			}
 			w.ch <- ev
 		}
@@ -22,6 +22,7 @@ import (
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/assert"
@@ -47,6 +48,8 @@ import (
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
 	workspaceauth "github.com/kcp-dev/kcp/pkg/virtual/workspaces/authorization"
@@ -288,6 +291,64 @@ func TestListWorkspacesWithGroupPermission(t *testing.T) {
 	applyTest(t, test)
 }
 
+func TestListAndGetRetainedWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	retained := tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: "root:orgName",
+			},
+			Name:              "bar",
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers:        []string{"tenancy.kcp.dev/workspace-finalizer"},
+		},
+	}
+	conditions.MarkFalse(&retained, tenancyv1alpha1.WorkspaceContentDeleted, tenancyv1alpha1.WorkspaceContentDeletedReasonRetained, conditionsv1alpha1.ConditionSeverityInfo, "")
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			orgName: logicalcluster.New("root:orgName"),
+			reviewer: workspaceauth.NewReviewer(&mockSubjectLocator{
+				subjects: map[string]map[string][]rbacv1.Subject{
+					"list/tenancy.kcp.dev/v1alpha1/workspaces": {
+						"": rbacGroups("test-group"),
+					},
+					"get/tenancy.kcp.dev/v1alpha1/workspaces": {
+						"bar": rbacGroups("test-group"),
+					},
+				},
+			}),
+			rootReviewer: workspaceauth.NewReviewer(nil),
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "root:orgName",
+						},
+						Name: "foo",
+					},
+				},
+				retained,
+			},
+		},
+		apply: func(t *testing.T, storage *REST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.List(ctx, nil)
+			require.NoError(t, err)
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "the retained workspace should be hidden")
+			assert.Equal(t, "foo", workspaces.Items[0].Name)
+
+			_, err = storage.Get(ctx, "bar", nil)
+			require.True(t, errors.IsNotFound(err), "expected NotFound for the retained workspace, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
+
 func TestListWorkspacesWithUserPermission(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
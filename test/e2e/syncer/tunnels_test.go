@@ -188,8 +188,10 @@ func TestSyncerTunnel(t *testing.T) {
 	// KCP will forwards all requests to the downstream cluster
 	// kubectl --server=https://{host}/clusters/{cluster}/services/tunnels/syncer-proxy/{syncer-name} get pods -A
 	t.Logf("Get logs through KCP from downstream deployment")
-	proxiedConfig := rest.CopyConfig(syncerFixture.SyncerConfig.UpstreamConfig)
-	u, err := tunneler.SyncerTunnelURL(syncerFixture.SyncerConfig.UpstreamConfig.Host, wsClusterName.String(), syncerFixture.SyncerConfig.SyncTargetName)
+	// The syncer itself may only connect to its tunnel, proxying requires the "proxy" verb on synctargets/tunnel,
+	// which the workspace admin has.
+	proxiedConfig := rest.CopyConfig(upstreamConfig)
+	u, err := tunneler.SyncerTunnelURL(upstreamConfig.Host, wsClusterName.String(), syncerFixture.SyncerConfig.SyncTargetName)
 	require.NoError(t, err, "failed to parse upstream Host for syncer")
 	// The base URL is the one obtained SyncerTunnelURL + the command, in this case cmdTunnelProxy = "proxy"
 	// That will send all the kubectl requests directly through the tunnel